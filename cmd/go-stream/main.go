@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/donkomura/go-stream/input"
+	"github.com/donkomura/go-stream/stream"
 )
 
 func main() {
@@ -23,11 +26,11 @@ func main() {
 		panic(err)
 	}
 
-	source := NewFileLineStream([]string{file1, file2})
-	appleCount := Stream(
+	source := input.NewFileLineStream([]string{file1, file2})
+	appleCount := stream.Stream(
 		source.Seq,
-		Filter(func(v string) bool { return v == "apple" },
-			End(Count[string]()),
+		stream.Filter(func(v string) bool { return v == "apple" },
+			stream.End(stream.Count[string]()),
 		),
 	)
 	if err := source.Err(); err != nil {
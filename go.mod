@@ -1,3 +1,3 @@
-module go-stream
+module github.com/donkomura/go-stream
 
 go 1.25
@@ -0,0 +1,186 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errAccessLogFormat = errors.New("malformed access log line")
+
+// accessLogTimeLayout is the timestamp layout of Common/Combined log lines.
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogEntry is one request from an Apache/Nginx access log in the Common
+// or Combined Log Format. Fields logged as "-" are left empty (or zero).
+type AccessLogEntry struct {
+	RemoteAddr string
+	Ident      string
+	User       string
+	Time       time.Time
+	// Request is the raw request line; Method, Path and Protocol are split
+	// from it when it has the usual three parts.
+	Request   string
+	Method    string
+	Path      string
+	Protocol  string
+	Status    int
+	Bytes     int64
+	Referer   string
+	UserAgent string
+}
+
+// ParseAccessLogLine parses a Common or Combined Log Format line, such as
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/4.08"
+//
+// Referer and user agent are optional, so Common lines parse too. Quoted
+// fields may contain backslash-escaped quotes, as Nginx writes them.
+func ParseAccessLogLine(line string) (AccessLogEntry, error) {
+	var entry AccessLogEntry
+	s := accessLogScanner{rest: line}
+
+	entry.RemoteAddr = s.field()
+	entry.Ident = dashEmpty(s.field())
+	entry.User = dashEmpty(s.field())
+	stamp, ok := s.bracketed()
+	if !ok {
+		return entry, fmt.Errorf("%w: timestamp", errAccessLogFormat)
+	}
+	t, err := time.Parse(accessLogTimeLayout, stamp)
+	if err != nil {
+		return entry, fmt.Errorf("%w: timestamp: %v", errAccessLogFormat, err)
+	}
+	entry.Time = t
+
+	if entry.Request, ok = s.quoted(); !ok {
+		return entry, fmt.Errorf("%w: request", errAccessLogFormat)
+	}
+	if parts := strings.Fields(entry.Request); len(parts) == 3 {
+		entry.Method, entry.Path, entry.Protocol = parts[0], parts[1], parts[2]
+	}
+
+	if entry.Status, err = strconv.Atoi(s.field()); err != nil {
+		return entry, fmt.Errorf("%w: status", errAccessLogFormat)
+	}
+	if size := s.field(); size != "-" {
+		if entry.Bytes, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return entry, fmt.Errorf("%w: bytes", errAccessLogFormat)
+		}
+	}
+
+	if s.done() {
+		return entry, nil
+	}
+	referer, ok := s.quoted()
+	if !ok {
+		return entry, fmt.Errorf("%w: referer", errAccessLogFormat)
+	}
+	userAgent, ok := s.quoted()
+	if !ok {
+		return entry, fmt.Errorf("%w: user agent", errAccessLogFormat)
+	}
+	entry.Referer, entry.UserAgent = dashEmpty(referer), dashEmpty(userAgent)
+	return entry, nil
+}
+
+func dashEmpty(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+// accessLogScanner walks the space-separated fields of an access log line.
+type accessLogScanner struct {
+	rest string
+}
+
+func (s *accessLogScanner) done() bool {
+	s.rest = strings.TrimLeft(s.rest, " ")
+	return s.rest == ""
+}
+
+func (s *accessLogScanner) field() string {
+	s.rest = strings.TrimLeft(s.rest, " ")
+	value, rest, _ := strings.Cut(s.rest, " ")
+	s.rest = rest
+	return value
+}
+
+func (s *accessLogScanner) bracketed() (string, bool) {
+	s.rest = strings.TrimLeft(s.rest, " ")
+	if !strings.HasPrefix(s.rest, "[") {
+		return "", false
+	}
+	value, rest, ok := strings.Cut(s.rest[1:], "]")
+	s.rest = rest
+	return value, ok
+}
+
+func (s *accessLogScanner) quoted() (string, bool) {
+	s.rest = strings.TrimLeft(s.rest, " ")
+	if !strings.HasPrefix(s.rest, `"`) {
+		return "", false
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s.rest); i++ {
+		switch c := s.rest[i]; c {
+		case '\\':
+			if i+1 < len(s.rest) {
+				i++
+				sb.WriteByte(s.rest[i])
+			}
+		case '"':
+			s.rest = s.rest[i+1:]
+			return sb.String(), true
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// AccessLogParser parses Apache/Nginx access logs into AccessLogEntry
+// records. Blank lines are skipped. A malformed line stops parsing with an
+// error naming the line, unless SkipInvalid is set.
+type AccessLogParser struct {
+	SkipInvalid bool
+}
+
+func (p AccessLogParser) Parse(_ string, r io.Reader, yield func(AccessLogEntry) bool) error {
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) > 0 {
+			lineNo++
+			if trimmed := strings.TrimSpace(line); trimmed != "" {
+				entry, err := ParseAccessLogLine(trimmed)
+				if err != nil && !p.SkipInvalid {
+					return fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				if err == nil && !yield(entry) {
+					return nil
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// NewFileAccessLogStream provides access log input by composing
+// FileStream -> AccessLogParser -> transform pipeline.
+func NewFileAccessLogStream(paths []string) Input[AccessLogEntry] {
+	return ParseFiles[AccessLogEntry](NewFileStream(paths), AccessLogParser{})
+}
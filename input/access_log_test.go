@@ -0,0 +1,113 @@
+package input
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestParseAccessLogLine(t *testing.T) {
+	pdt := time.FixedZone("", -7*60*60)
+	tests := []struct {
+		name string
+		line string
+		want AccessLogEntry
+	}{
+		{
+			name: "combined",
+			line: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"`,
+			want: AccessLogEntry{
+				RemoteAddr: "127.0.0.1", User: "frank",
+				Time:    time.Date(2000, 10, 10, 13, 55, 36, 0, pdt),
+				Request: "GET /apache_pb.gif HTTP/1.0", Method: "GET", Path: "/apache_pb.gif", Protocol: "HTTP/1.0",
+				Status: 200, Bytes: 2326,
+				Referer: "http://www.example.com/start.html", UserAgent: "Mozilla/4.08 [en] (Win98; I ;Nav)",
+			},
+		},
+		{
+			name: "common with dashes",
+			line: `::1 - - [10/Oct/2000:13:55:36 -0700] "-" 400 -`,
+			want: AccessLogEntry{
+				RemoteAddr: "::1",
+				Time:       time.Date(2000, 10, 10, 13, 55, 36, 0, pdt),
+				Request:    "-", Status: 400,
+			},
+		},
+		{
+			name: "escaped quotes",
+			line: `10.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /q?x=\"y\" HTTP/1.1" 304 0 "-" "curl \"7\""`,
+			want: AccessLogEntry{
+				RemoteAddr: "10.0.0.1",
+				Time:       time.Date(2000, 10, 10, 13, 55, 36, 0, pdt),
+				Request:    `GET /q?x="y" HTTP/1.1`, Method: "GET", Path: `/q?x="y"`, Protocol: "HTTP/1.1",
+				Status: 304, UserAgent: `curl "7"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAccessLogLine(tt.line)
+			if err != nil {
+				t.Fatalf("ParseAccessLogLine() error = %v", err)
+			}
+			if !got.Time.Equal(tt.want.Time) {
+				t.Fatalf("Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time = tt.want.Time
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseAccessLogLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, line := range []string{
+		`127.0.0.1 - - 10/Oct/2000 "GET / HTTP/1.0" 200 1`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0 200 1`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" OK 1`,
+	} {
+		if _, err := ParseAccessLogLine(line); !errors.Is(err, errAccessLogFormat) {
+			t.Fatalf("ParseAccessLogLine(%q) error = %v, want errAccessLogFormat", line, err)
+		}
+	}
+}
+
+func TestNewFileAccessLogStream(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "access.log")
+	writeTextFile(t, file, strings.Join([]string{
+		`1.1.1.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a HTTP/1.1" 200 10 "-" "ua"`,
+		``,
+		`garbage`,
+		`2.2.2.2 - - [10/Oct/2000:13:55:37 -0700] "POST /b HTTP/1.1" 500 20`,
+	}, "\n"))
+
+	source := NewFileAccessLogStream([]string{file})
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[AccessLogEntry]()))
+	if len(got) != 1 || got[0].Path != "/a" {
+		t.Fatalf("Stream() = %+v, want the first entry only", got)
+	}
+	if err := source.Err(); !errors.Is(err, errAccessLogFormat) || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("Err() = %v, want malformed line 3", err)
+	}
+
+	lenient := ParseFiles[AccessLogEntry](NewFileStream([]string{file}), AccessLogParser{SkipInvalid: true})
+	paths := stream.Stream(
+		lenient.Seq,
+		stream.Filter(func(e AccessLogEntry) bool { return e.Status >= 200 },
+			stream.Map(func(e AccessLogEntry) string { return e.Path },
+				stream.End(stream.Collect[string]()),
+			),
+		),
+	)
+	if want := []string{"/a", "/b"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Stream() = %v, want %v", paths, want)
+	}
+	if err := lenient.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
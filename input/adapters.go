@@ -0,0 +1,58 @@
+package input
+
+import (
+	"bufio"
+	"database/sql"
+)
+
+// FromScanner adapts a bufio.Scanner the caller already holds into an Input
+// of its tokens, lines unless another split function was set. A scanner can
+// only be read once, so the Input yields nothing on later runs. Err reports
+// sc.Err().
+func FromScanner(sc *bufio.Scanner) Input[string] {
+	return Input[string]{
+		Seq: func(yield func(string) bool) {
+			for sc.Scan() {
+				if !yield(sc.Text()) {
+					return
+				}
+			}
+		},
+		Err: sc.Err,
+	}
+}
+
+// FromRows adapts the result of a database query into an Input, converting
+// each row with scan. rows is closed once the run ends, including when the
+// pipeline stops early, so like a scanner it can be read only once. Err
+// reports the first error from scan, iteration or Close.
+func FromRows[T any](rows *sql.Rows, scan func(*sql.Rows) (T, error)) Input[T] {
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var runErr error
+		defer func() {
+			setFirstErr(&runErr, rows.Close())
+			state.Set(runErr)
+		}()
+
+		for rows.Next() {
+			v, err := scan(rows)
+			if err != nil {
+				runErr = err
+				return
+			}
+			if !yield(v) {
+				return
+			}
+		}
+		runErr = rows.Err()
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
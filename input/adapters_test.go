@@ -0,0 +1,144 @@
+package input
+
+import (
+	"bufio"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestFromScanner(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("alpha beta\ngamma\n"))
+	sc.Split(bufio.ScanWords)
+
+	source := FromScanner(sc)
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+	if !slices.Equal(got, []string{"alpha", "beta", "gamma"}) {
+		t.Fatalf("FromScanner()=%v, expected [alpha beta gamma]", got)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() returned error: %v", err)
+	}
+
+	failing := FromScanner(bufio.NewScanner(io.MultiReader(strings.NewReader("ok\n"), errReader{})))
+	stream.Stream(failing.Seq, stream.End(stream.Count[string]()))
+	if !errors.Is(failing.Err(), errRowsFailed) {
+		t.Fatalf("Err() = %v, expected errRowsFailed", failing.Err())
+	}
+}
+
+func TestFromRows(t *testing.T) {
+	db, err := sql.Open("go-stream-test", "")
+	if err != nil {
+		t.Fatalf("sql.Open() returned error: %v", err)
+	}
+	defer db.Close()
+
+	type user struct {
+		ID   int64
+		Name string
+	}
+	scanUser := func(rows *sql.Rows) (user, error) {
+		var u user
+		err := rows.Scan(&u.ID, &u.Name)
+		return u, err
+	}
+
+	rows, err := db.Query("users")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	source := FromRows(rows, scanUser)
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[user]()))
+	if !slices.Equal(got, []user{{1, "ann"}, {2, "bob"}, {3, "cy"}}) {
+		t.Fatalf("FromRows()=%v, expected three users", got)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() returned error: %v", err)
+	}
+
+	rows, _ = db.Query("users")
+	source = FromRows(rows, scanUser)
+	if first := stream.Stream(source.Seq, stream.Take(1, stream.End(stream.Collect[user]()))); len(first) != 1 {
+		t.Fatalf("FromRows() with Take(1)=%v, expected one user", first)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows.Err() = %v after early stop", err)
+	}
+	if rows.Next() {
+		t.Fatalf("rows still open after the run stopped early")
+	}
+
+	rows, _ = db.Query("broken")
+	source = FromRows(rows, scanUser)
+	if n := stream.Stream(source.Seq, stream.End(stream.Count[user]())); n != 1 {
+		t.Fatalf("FromRows() counted %d rows before the failure, expected 1", n)
+	}
+	if !errors.Is(source.Err(), errRowsFailed) {
+		t.Fatalf("Err() = %v, expected errRowsFailed", source.Err())
+	}
+}
+
+var errRowsFailed = errors.New("rows failed")
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errRowsFailed }
+
+// testDriver serves fixed result sets: query "users" returns three rows and
+// "broken" fails after the first.
+type testDriver struct{}
+
+func init() {
+	sql.Register("go-stream-test", testDriver{})
+}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) { return testStmt{query: query}, nil }
+func (testConn) Close() error                              { return nil }
+func (testConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type testStmt struct {
+	query string
+}
+
+func (testStmt) Close() error  { return nil }
+func (testStmt) NumInput() int { return 0 }
+func (testStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s testStmt) Query([]driver.Value) (driver.Rows, error) {
+	values := [][]driver.Value{{int64(1), "ann"}, {int64(2), "bob"}, {int64(3), "cy"}}
+	return &testRows{values: values, failAfter: map[string]int{"broken": 1}[s.query]}, nil
+}
+
+type testRows struct {
+	values    [][]driver.Value
+	next      int
+	failAfter int
+}
+
+func (r *testRows) Columns() []string { return []string{"id", "name"} }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.failAfter > 0 && r.next == r.failAfter {
+		return errRowsFailed
+	}
+	if r.next == len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,180 @@
+package input
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var errArchiveEntryExpired = errors.New("archive entry opened after the stream moved past it")
+
+// archiveEntryInput is a FileInput for one regular file inside an archive.
+type archiveEntryInput struct {
+	path string
+	open func() (io.ReadCloser, error)
+}
+
+func (f archiveEntryInput) Path() string {
+	return f.path
+}
+
+func (f archiveEntryInput) Open() (io.ReadCloser, error) {
+	return f.open()
+}
+
+// NewArchiveStream opens each tar or zip archive from archives and yields its
+// regular files as FileInputs, in archive order, without extracting them.
+// Compressed tarballs (.tar.gz, .tgz, .tar.bz2, ...) are handled through the
+// usual decompression, and compressed entries are decompressed on Open.
+// Entry paths have the form "<archive path>!<entry name>".
+//
+// An entry can only be opened while it is the current element of the stream,
+// which is how ParseFiles consumes it; opening it later fails. Zip archives
+// from sources other than local files are read into memory, because zip
+// needs random access.
+func NewArchiveStream(archives FileStream) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for archive := range archives.Seq {
+			stopped, err := yieldArchiveEntries(archive, yield)
+			if err != nil {
+				setFirstErr(&runErr, err)
+				return
+			}
+			if stopped {
+				return
+			}
+		}
+		if sourceErr := archives.Err(); sourceErr != nil {
+			setFirstErr(&runErr, sourceErr)
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// NewFileArchiveStream yields the entries of the archives at paths.
+func NewFileArchiveStream(paths []string) FileStream {
+	return NewArchiveStream(NewFileStream(paths))
+}
+
+func yieldArchiveEntries(archive FileInput, yield func(FileInput) bool) (stopped bool, err error) {
+	if local, ok := archive.(localFileInput); ok && strings.EqualFold(filepath.Ext(local.path), ".zip") {
+		return yieldLocalZipEntries(local.path, yield)
+	}
+
+	rc, err := archive.Open()
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", archive.Path(), err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); closeErr != nil {
+			setFirstErr(&err, fmt.Errorf("close %s: %w", archive.Path(), closeErr))
+		}
+	}()
+
+	reader := bufio.NewReader(rc)
+	if magic, _ := reader.Peek(4); bytes.Equal(magic, []byte("PK\x03\x04")) || bytes.Equal(magic, []byte("PK\x05\x06")) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return false, fmt.Errorf("read %s: %w", archive.Path(), err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return false, fmt.Errorf("read %s: %w", archive.Path(), err)
+		}
+		return yieldZipEntries(archive.Path(), zr, yield), nil
+	}
+	return yieldTarEntries(archive.Path(), tar.NewReader(reader), yield)
+}
+
+func yieldLocalZipEntries(path string, yield func(FileInput) bool) (bool, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", path, err)
+	}
+	stopped := yieldZipEntries(path, &zr.Reader, yield)
+	if err := zr.Close(); err != nil {
+		return stopped, fmt.Errorf("close %s: %w", path, err)
+	}
+	return stopped, nil
+}
+
+func yieldZipEntries(archivePath string, zr *zip.Reader, yield func(FileInput) bool) (stopped bool) {
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		current := true
+		entry := archiveEntryInput{
+			path: archivePath + "!" + file.Name,
+			open: func() (io.ReadCloser, error) {
+				if !current {
+					return nil, errArchiveEntryExpired
+				}
+				rc, err := file.Open()
+				if err != nil {
+					return nil, err
+				}
+				return openDecompressed(file.Name, rc)
+			},
+		}
+		ok := yield(entry)
+		current = false
+		if !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func yieldTarEntries(archivePath string, tr *tar.Reader, yield func(FileInput) bool) (bool, error) {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("read %s: %w", archivePath, err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		current, opened := true, false
+		name := header.Name
+		entry := archiveEntryInput{
+			path: archivePath + "!" + name,
+			open: func() (io.ReadCloser, error) {
+				// The tar stream can only be read forward, once per entry.
+				if !current || opened {
+					return nil, errArchiveEntryExpired
+				}
+				opened = true
+				return openDecompressed(name, io.NopCloser(tr))
+			},
+		}
+		ok := yield(entry)
+		current = false
+		if !ok {
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package input
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func tarGzBytes(t *testing.T, entries map[string]string, order ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "logs/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatalf("write tar dir: %v", err)
+	}
+	for _, name := range order {
+		content := entries[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, entries map[string]string, order ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry: %v", err)
+		}
+		if _, err := w.Write([]byte(entries[name])); err != nil {
+			t.Fatalf("write zip entry: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewFileArchiveStream(t *testing.T) {
+	entries := map[string]string{
+		"logs/a.log":    "a1\na2\n",
+		"logs/b.log.gz": string(gzipBytes(t, "b1\n")),
+	}
+
+	t.Run("parses entries of tarballs and zips", func(t *testing.T) {
+		dir := t.TempDir()
+		tarball := filepath.Join(dir, "bundle.tar.gz")
+		archive := filepath.Join(dir, "bundle.zip")
+		writeTextFile(t, tarball, string(tarGzBytes(t, entries, "logs/a.log", "logs/b.log.gz")))
+		writeTextFile(t, archive, string(zipBytes(t, entries, "logs/b.log.gz", "logs/a.log")))
+
+		files := NewFileArchiveStream([]string{tarball, archive})
+		source := ParseFiles[string](files, LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "a2", "b1", "b1", "a1", "a2"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+
+		var paths []string
+		for file := range files.Seq {
+			paths = append(paths, file.Path())
+		}
+		wantPaths := []string{
+			tarball + "!logs/a.log", tarball + "!logs/b.log.gz",
+			archive + "!logs/b.log.gz", archive + "!logs/a.log",
+		}
+		if !reflect.DeepEqual(paths, wantPaths) {
+			t.Fatalf("paths = %v, want %v", paths, wantPaths)
+		}
+	})
+
+	t.Run("reads zips from non-local sources", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"bundle.bin": {Data: zipBytes(t, entries, "logs/a.log")},
+		}
+		source := ParseFiles[string](NewArchiveStream(NewFSFileStream(fsys, []string{"bundle.bin"})), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		if want := []string{"a1", "a2"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("entries expire once the stream advances", func(t *testing.T) {
+		dir := t.TempDir()
+		tarball := filepath.Join(dir, "bundle.tgz")
+		writeTextFile(t, tarball, string(tarGzBytes(t, entries, "logs/a.log", "logs/b.log.gz")))
+
+		var kept []FileInput
+		for file := range NewFileArchiveStream([]string{tarball}).Seq {
+			kept = append(kept, file)
+		}
+		if len(kept) != 2 {
+			t.Fatalf("entries = %d, want 2", len(kept))
+		}
+		if _, err := kept[0].Open(); !errors.Is(err, errArchiveEntryExpired) {
+			t.Fatalf("Open() = %v, want errArchiveEntryExpired", err)
+		}
+	})
+
+	t.Run("corrupt archives report an error", func(t *testing.T) {
+		dir := t.TempDir()
+		bad := filepath.Join(dir, "bad.tar")
+		writeTextFile(t, bad, "definitely not a tarball, but long enough to hold a header block")
+
+		source := ParseFiles[string](NewFileArchiveStream([]string{bad}), LineParser{})
+		stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if err := source.Err(); err == nil {
+			t.Fatalf("Err() = nil, want read error")
+		}
+	})
+}
@@ -0,0 +1,527 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var (
+	errAvroMagic            = errors.New("not an Avro object container file")
+	errUnsupportedAvroCodec = errors.New("unsupported Avro codec")
+	errAvroSync             = errors.New("Avro sync marker mismatch")
+	errAvroLength           = errors.New("invalid Avro length")
+)
+
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// maxAvroBlockSize bounds a block, before and after decompression, and any
+// length read from a file, so a corrupt length cannot exhaust memory.
+const maxAvroBlockSize = 64 << 20
+
+// AvroParser parses Avro object container files (OCF) using the writer schema
+// embedded in each file's header. Blocks compressed with the "null" and
+// "deflate" codecs are supported. Blocks over 64 MiB, compressed or not,
+// fail the file.
+//
+// Records decode to map[string]any, arrays to []any, maps to map[string]any,
+// enums to their symbol string, bytes and fixed to []byte, int and long to
+// int32 and int64, float and double to float32 and float64, and unions to the
+// value of the selected branch. Logical types decode as their underlying type.
+//
+// When T is neither any nor map[string]any, each record is assigned to T:
+// struct fields match record fields by their `avro:"name"` tag or field name,
+// and numeric values convert to any numeric field type that holds them.
+type AvroParser[T any] struct{}
+
+func (AvroParser[T]) Parse(_ string, r io.Reader, yield func(T) bool) error {
+	reader := bufio.NewReader(r)
+	header, err := readAvroHeader(reader)
+	if err != nil {
+		return err
+	}
+
+	sync := make([]byte, len(header.sync))
+	var block int
+	for {
+		count, err := binary.ReadVarint(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("block %d: %w", block, err)
+		}
+		size, err := binary.ReadVarint(reader)
+		if err != nil {
+			return fmt.Errorf("block %d: %w", block, noEOF(err))
+		}
+		if count < 0 || size < 0 || size > maxAvroBlockSize {
+			return fmt.Errorf("block %d: %w", block, errAvroLength)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return fmt.Errorf("block %d: %w", block, noEOF(err))
+		}
+		if header.codec == "deflate" {
+			if data, err = io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(data)), maxAvroBlockSize+1)); err != nil {
+				return fmt.Errorf("block %d: deflate: %w", block, err)
+			}
+			if len(data) > maxAvroBlockSize {
+				return fmt.Errorf("block %d: %w", block, errAvroLength)
+			}
+		}
+
+		objects := bytes.NewReader(data)
+		for i := int64(0); i < count; i++ {
+			v, err := decodeAvro(objects, header.schema)
+			if err != nil {
+				return fmt.Errorf("block %d record %d: %w", block, i, err)
+			}
+			out, err := avroAs[T](v)
+			if err != nil {
+				return fmt.Errorf("block %d record %d: %w", block, i, err)
+			}
+			if !yield(out) {
+				return nil
+			}
+		}
+
+		if _, err := io.ReadFull(reader, sync); err != nil {
+			return fmt.Errorf("block %d: %w", block, noEOF(err))
+		}
+		if !bytes.Equal(sync, header.sync) {
+			return fmt.Errorf("block %d: %w", block, errAvroSync)
+		}
+		block++
+	}
+}
+
+// NewFileAvroStream provides Avro container file input decoded into T by
+// composing FileStream -> AvroParser -> transform pipeline.
+func NewFileAvroStream[T any](paths []string) Input[T] {
+	return ParseFiles[T](NewFileStream(paths), AvroParser[T]{})
+}
+
+type avroHeader struct {
+	schema *avroSchema
+	codec  string
+	sync   []byte
+}
+
+func readAvroHeader(r *bufio.Reader) (avroHeader, error) {
+	var header avroHeader
+	magic := make([]byte, len(avroMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, avroMagic) {
+		return header, errAvroMagic
+	}
+
+	meta, err := decodeAvro(r, &avroSchema{typ: "map", values: &avroSchema{typ: "bytes"}})
+	if err != nil {
+		return header, fmt.Errorf("header: %w", noEOF(err))
+	}
+	metadata := meta.(map[string]any)
+
+	rawSchema, _ := metadata["avro.schema"].([]byte)
+	if header.schema, err = parseAvroSchema(rawSchema); err != nil {
+		return header, fmt.Errorf("header: schema: %w", err)
+	}
+	header.codec = "null"
+	if codec, ok := metadata["avro.codec"].([]byte); ok && len(codec) > 0 {
+		header.codec = string(codec)
+	}
+	if header.codec != "null" && header.codec != "deflate" {
+		return header, fmt.Errorf("header: %w %q", errUnsupportedAvroCodec, header.codec)
+	}
+
+	header.sync = make([]byte, 16)
+	if _, err := io.ReadFull(r, header.sync); err != nil {
+		return header, fmt.Errorf("header: %w", noEOF(err))
+	}
+	return header, nil
+}
+
+// avroSchema is a resolved Avro schema node.
+type avroSchema struct {
+	typ      string
+	fields   []avroField
+	symbols  []string
+	items    *avroSchema
+	values   *avroSchema
+	branches []*avroSchema
+	size     int
+}
+
+type avroField struct {
+	name   string
+	schema *avroSchema
+}
+
+func parseAvroSchema(raw []byte) (*avroSchema, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return resolveAvroSchema(v, "", map[string]*avroSchema{})
+}
+
+// resolveAvroSchema converts decoded schema JSON into avroSchema nodes. Named
+// types are registered by full and short name so later references resolve.
+func resolveAvroSchema(v any, namespace string, named map[string]*avroSchema) (*avroSchema, error) {
+	switch s := v.(type) {
+	case string:
+		switch s {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroSchema{typ: s}, nil
+		}
+		if schema, ok := named[s]; ok {
+			return schema, nil
+		}
+		if schema, ok := named[namespace+"."+s]; ok {
+			return schema, nil
+		}
+		return nil, fmt.Errorf("unknown type %q", s)
+
+	case []any:
+		union := &avroSchema{typ: "union"}
+		for _, branch := range s {
+			schema, err := resolveAvroSchema(branch, namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			union.branches = append(union.branches, schema)
+		}
+		return union, nil
+
+	case map[string]any:
+		typ, _ := s["type"].(string)
+		schema := &avroSchema{typ: typ}
+		switch typ {
+		case "record", "error", "enum", "fixed":
+			name, _ := s["name"].(string)
+			if ns, ok := s["namespace"].(string); ok {
+				namespace = ns
+			}
+			if i := strings.LastIndexByte(name, '.'); i >= 0 {
+				namespace = name[:i]
+			}
+			if name == "" {
+				return nil, fmt.Errorf("%s without name", typ)
+			}
+			short := name[strings.LastIndexByte(name, '.')+1:]
+			named[short] = schema
+			if namespace != "" {
+				named[namespace+"."+short] = schema
+			}
+		}
+
+		switch typ {
+		case "record", "error":
+			schema.typ = "record"
+			fields, _ := s["fields"].([]any)
+			for _, f := range fields {
+				field, _ := f.(map[string]any)
+				name, _ := field["name"].(string)
+				fieldSchema, err := resolveAvroSchema(field["type"], namespace, named)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", name, err)
+				}
+				schema.fields = append(schema.fields, avroField{name: name, schema: fieldSchema})
+			}
+		case "enum":
+			symbols, _ := s["symbols"].([]any)
+			for _, symbol := range symbols {
+				name, _ := symbol.(string)
+				schema.symbols = append(schema.symbols, name)
+			}
+		case "fixed":
+			size, ok := s["size"].(float64)
+			if !ok || size < 0 || size > maxAvroBlockSize || size != math.Trunc(size) {
+				return nil, fmt.Errorf("fixed %s: %w: size %v", s["name"], errAvroLength, s["size"])
+			}
+			schema.size = int(size)
+		case "array":
+			items, err := resolveAvroSchema(s["items"], namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			schema.items = items
+		case "map":
+			values, err := resolveAvroSchema(s["values"], namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			schema.values = values
+		default:
+			// Primitive types written as {"type": "long", "logicalType": ...}.
+			return resolveAvroSchema(typ, namespace, named)
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("invalid schema %v", v)
+}
+
+// avroReader is what the binary decoder reads from.
+type avroReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func decodeAvro(r avroReader, schema *avroSchema) (any, error) {
+	switch schema.typ {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := r.ReadByte()
+		return b != 0, err
+	case "int":
+		n, err := binary.ReadVarint(r)
+		if err == nil && (n < math.MinInt32 || n > math.MaxInt32) {
+			err = fmt.Errorf("int %d out of range", n)
+		}
+		return int32(n), err
+	case "long":
+		return binary.ReadVarint(r)
+	case "float":
+		var buf [4]byte
+		_, err := io.ReadFull(r, buf[:])
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf[:])), err
+	case "double":
+		var buf [8]byte
+		_, err := io.ReadFull(r, buf[:])
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf[:])), err
+	case "bytes":
+		return readAvroBytes(r)
+	case "string":
+		b, err := readAvroBytes(r)
+		return string(b), err
+	case "fixed":
+		b := make([]byte, schema.size)
+		_, err := io.ReadFull(r, b)
+		return b, err
+
+	case "record":
+		record := make(map[string]any, len(schema.fields))
+		for _, field := range schema.fields {
+			v, err := decodeAvro(r, field.schema)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+			record[field.name] = v
+		}
+		return record, nil
+
+	case "enum":
+		i, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(schema.symbols)) {
+			return nil, fmt.Errorf("enum index %d out of range", i)
+		}
+		return schema.symbols[i], nil
+
+	case "union":
+		i, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= int64(len(schema.branches)) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return decodeAvro(r, schema.branches[i])
+
+	case "array":
+		items := []any{}
+		err := readAvroBlocks(r, func() error {
+			v, err := decodeAvro(r, schema.items)
+			items = append(items, v)
+			return err
+		})
+		return items, err
+
+	case "map":
+		values := map[string]any{}
+		err := readAvroBlocks(r, func() error {
+			key, err := readAvroBytes(r)
+			if err != nil {
+				return err
+			}
+			v, err := decodeAvro(r, schema.values)
+			values[string(key)] = v
+			return err
+		})
+		return values, err
+	}
+	return nil, fmt.Errorf("unsupported type %q", schema.typ)
+}
+
+// readAvroBlocks reads the blocked encoding shared by arrays and maps.
+func readAvroBlocks(r avroReader, item func() error) error {
+	for {
+		count, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// A negative count is followed by the block size in bytes.
+			count = -count
+			if _, err := binary.ReadVarint(r); err != nil {
+				return err
+			}
+		}
+		if count < 0 || count > maxAvroBlockSize {
+			return errAvroLength
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readAvroBytes(r avroReader) ([]byte, error) {
+	n, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > maxAvroBlockSize {
+		return nil, errAvroLength
+	}
+	if sized, ok := r.(interface{ Len() int }); ok {
+		if n > int64(sized.Len()) {
+			return nil, errAvroLength
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	}
+	// The header is read from the file itself, so grow with the data that
+	// actually arrives rather than trusting n up front.
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// avroAs converts a decoded value to T.
+func avroAs[T any](v any) (T, error) {
+	if out, ok := v.(T); ok {
+		return out, nil
+	}
+	var out T
+	err := assignAvro(reflect.ValueOf(&out).Elem(), v)
+	return out, err
+}
+
+func assignAvro(dst reflect.Value, v any) error {
+	if v == nil {
+		dst.SetZero()
+		return nil
+	}
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		ptr := reflect.New(dst.Type().Elem())
+		if err := assignAvro(ptr.Elem(), v); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+
+	case reflect.Struct:
+		record, ok := v.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, field := range reflect.VisibleFields(dst.Type()) {
+			if !field.IsExported() || field.Anonymous || promotedThroughUnexportedPointer(dst.Type(), field.Index) {
+				continue
+			}
+			name := field.Tag.Get("avro")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			value, ok := record[name]
+			if !ok {
+				continue
+			}
+			if err := assignAvro(fieldByIndexAlloc(dst, field.Index), value); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		values, ok := v.(map[string]any)
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			break
+		}
+		out := reflect.MakeMapWithSize(dst.Type(), len(values))
+		for key, value := range values {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := assignAvro(elem, value); err != nil {
+				return fmt.Errorf("key %s: %w", key, err)
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(out)
+		return nil
+
+	case reflect.Slice:
+		items, ok := v.([]any)
+		if !ok {
+			break
+		}
+		out := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assignAvro(out.Index(i), item); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		dst.Set(out)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if src.CanInt() && !dst.OverflowInt(src.Int()) {
+			dst.SetInt(src.Int())
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if src.CanInt() {
+			dst.SetFloat(float64(src.Int()))
+			return nil
+		}
+		if src.CanFloat() {
+			dst.SetFloat(src.Float())
+			return nil
+		}
+	}
+
+	if src.Type().ConvertibleTo(dst.Type()) && src.Kind() == dst.Kind() {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot assign %T to %s", v, dst.Type())
+}
@@ -0,0 +1,235 @@
+package input
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+const avroEventSchema = `{
+	"type": "record", "name": "Event", "namespace": "test",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": "string"},
+		{"name": "score", "type": ["null", "double"]},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["CLICK", "VIEW"]}},
+		{"name": "attrs", "type": {"type": "map", "values": "int"}},
+		{"name": "parent", "type": ["null", "Event"]}
+	]
+}`
+
+type avroEvent struct {
+	ID     int64          `avro:"id"`
+	Name   string         `avro:"name"`
+	Score  *float64       `avro:"score"`
+	Tags   []string       `avro:"tags"`
+	Kind   string         `avro:"kind"`
+	Attrs  map[string]int `avro:"attrs"`
+	Parent *avroEvent     `avro:"parent"`
+}
+
+func appendAvroString(b []byte, s string) []byte {
+	b = binary.AppendVarint(b, int64(len(s)))
+	return append(b, s...)
+}
+
+// encodeAvroEvent encodes an Event with no parent.
+func encodeAvroEvent(id int64, name string, score *float64, tags []string, kind int64, attrs map[string]int64) []byte {
+	b := binary.AppendVarint(nil, id)
+	b = appendAvroString(b, name)
+	if score == nil {
+		b = binary.AppendVarint(b, 0)
+	} else {
+		b = binary.AppendVarint(b, 1)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(*score))
+	}
+	if len(tags) > 0 {
+		b = binary.AppendVarint(b, int64(len(tags)))
+		for _, tag := range tags {
+			b = appendAvroString(b, tag)
+		}
+	}
+	b = binary.AppendVarint(b, 0)
+	b = binary.AppendVarint(b, kind)
+	if len(attrs) > 0 {
+		// Negative block count followed by a byte size, as writers may emit.
+		var block []byte
+		for key, value := range attrs {
+			block = appendAvroString(block, key)
+			block = binary.AppendVarint(block, value)
+		}
+		b = binary.AppendVarint(b, -int64(len(attrs)))
+		b = binary.AppendVarint(b, int64(len(block)))
+		b = append(b, block...)
+	}
+	b = binary.AppendVarint(b, 0)
+	return binary.AppendVarint(b, 0)
+}
+
+// avroContainer builds an OCF file with one block per entry in blocks.
+func avroContainer(codec string, sync []byte, blocks ...[][]byte) []byte {
+	b := append([]byte(nil), avroMagic...)
+	b = binary.AppendVarint(b, 2)
+	b = appendAvroString(b, "avro.schema")
+	b = appendAvroString(b, avroEventSchema)
+	b = appendAvroString(b, "avro.codec")
+	b = appendAvroString(b, codec)
+	b = binary.AppendVarint(b, 0)
+	b = append(b, sync...)
+
+	for _, records := range blocks {
+		data := bytes.Join(records, nil)
+		if codec == "deflate" {
+			var buf bytes.Buffer
+			w, _ := flate.NewWriter(&buf, flate.BestCompression)
+			_, _ = w.Write(data)
+			_ = w.Close()
+			data = buf.Bytes()
+		}
+		b = binary.AppendVarint(b, int64(len(records)))
+		b = binary.AppendVarint(b, int64(len(data)))
+		b = append(b, data...)
+		b = append(b, sync...)
+	}
+	return b
+}
+
+func TestNewFileAvroStream(t *testing.T) {
+	sync := []byte("0123456789abcdef")
+	score := 0.5
+	first := encodeAvroEvent(1, "signup", &score, []string{"web", "eu"}, 0, map[string]int64{"n": 3})
+	second := encodeAvroEvent(2, "login", nil, nil, 1, nil)
+	third := encodeAvroEvent(3, "logout", nil, []string{"app"}, 1, nil)
+
+	t.Run("decodes records into structs across codecs", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.avro")
+		fileB := filepath.Join(dir, "b.avro")
+		writeTextFile(t, fileA, string(avroContainer("null", sync, [][]byte{first, second})))
+		writeTextFile(t, fileB, string(avroContainer("deflate", sync, [][]byte{third}, [][]byte{})))
+
+		source := NewFileAvroStream[avroEvent]([]string{fileA, fileB})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[avroEvent]()))
+
+		want := []avroEvent{
+			{ID: 1, Name: "signup", Score: &score, Tags: []string{"web", "eu"}, Kind: "CLICK", Attrs: map[string]int{"n": 3}},
+			{ID: 2, Name: "login", Tags: []string{}, Kind: "VIEW", Attrs: map[string]int{}},
+			{ID: 3, Name: "logout", Tags: []string{"app"}, Kind: "VIEW", Attrs: map[string]int{}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %+v, want %+v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("allocates embedded struct pointers", func(t *testing.T) {
+		type AvroBase struct {
+			ID int64 `avro:"id"`
+		}
+		type embedded struct {
+			*AvroBase
+			Name string `avro:"name"`
+		}
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.avro")
+		writeTextFile(t, file, string(avroContainer("null", sync, [][]byte{second})))
+
+		source := NewFileAvroStream[embedded]([]string{file})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[embedded]()))
+		want := []embedded{{AvroBase: &AvroBase{ID: 2}, Name: "login"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %+v, want %+v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("decodes records into maps", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.avro")
+		writeTextFile(t, file, string(avroContainer("null", sync, [][]byte{second})))
+
+		source := NewFileAvroStream[map[string]any]([]string{file})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]any]()))
+
+		want := []map[string]any{{
+			"id": int64(2), "name": "login", "score": nil, "tags": []any{},
+			"kind": "VIEW", "attrs": map[string]any{}, "parent": nil,
+		}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports corrupt files", func(t *testing.T) {
+		dir := t.TempDir()
+		badSync := filepath.Join(dir, "sync.avro")
+		content := avroContainer("null", sync, [][]byte{first})
+		content[len(content)-1] ^= 0xff
+		writeTextFile(t, badSync, string(content))
+
+		source := NewFileAvroStream[avroEvent]([]string{badSync})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[avroEvent]()))
+		if len(got) != 1 {
+			t.Fatalf("Stream() returned %d records, want 1", len(got))
+		}
+		if err := source.Err(); !errors.Is(err, errAvroSync) {
+			t.Fatalf("Err() = %v, want errAvroSync", err)
+		}
+
+		notAvro := filepath.Join(dir, "plain.avro")
+		writeTextFile(t, notAvro, "hello")
+		source = NewFileAvroStream[avroEvent]([]string{notAvro})
+		stream.Stream(source.Seq, stream.End(stream.Collect[avroEvent]()))
+		if err := source.Err(); !errors.Is(err, errAvroMagic) {
+			t.Fatalf("Err() = %v, want errAvroMagic", err)
+		}
+
+		snappy := filepath.Join(dir, "snappy.avro")
+		writeTextFile(t, snappy, string(avroContainer("snappy", sync)))
+		source = NewFileAvroStream[avroEvent]([]string{snappy})
+		stream.Stream(source.Seq, stream.End(stream.Collect[avroEvent]()))
+		if err := source.Err(); !errors.Is(err, errUnsupportedAvroCodec) {
+			t.Fatalf("Err() = %v, want errUnsupportedAvroCodec", err)
+		}
+	})
+
+	t.Run("rejects oversized lengths", func(t *testing.T) {
+		dir := t.TempDir()
+		header := avroContainer("null", sync)
+		hugeBlock := binary.AppendVarint(binary.AppendVarint(bytes.Clone(header), 1), 1<<40)
+		// A metadata map whose only value claims far more bytes than follow.
+		hugeValue := binary.AppendVarint(append([]byte(nil), avroMagic...), 1)
+		hugeValue = appendAvroString(hugeValue, "avro.schema")
+		hugeValue = binary.AppendVarint(hugeValue, 1<<40)
+		badFixed := binary.AppendVarint(append([]byte(nil), avroMagic...), 1)
+		badFixed = appendAvroString(badFixed, "avro.schema")
+		badFixed = appendAvroString(badFixed, `{"type": "fixed", "name": "Hash", "size": -1}`)
+		badFixed = binary.AppendVarint(badFixed, 0)
+		badFixed = append(badFixed, sync...)
+
+		for name, content := range map[string][]byte{"block": hugeBlock, "header value": hugeValue, "fixed size": badFixed} {
+			path := filepath.Join(dir, "bad.avro")
+			writeTextFile(t, path, string(content))
+			source := NewFileAvroStream[map[string]any]([]string{path})
+			stream.Stream(source.Seq, stream.End(stream.Collect[map[string]any]()))
+			if err := source.Err(); !errors.Is(err, errAvroLength) {
+				t.Fatalf("%s: Err() = %v, want errAvroLength", name, err)
+			}
+		}
+	})
+}
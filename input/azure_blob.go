@@ -0,0 +1,189 @@
+//go:build objectstore
+
+package input
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// azureStorageVersion is the Blob service REST API version requested.
+const azureStorageVersion = "2021-08-06"
+
+// AzureBlobConfig describes how to reach Azure Blob Storage.
+type AzureBlobConfig struct {
+	// Account is the storage account name.
+	Account string
+	// Endpoint defaults to https://<account>.blob.core.windows.net. For
+	// Azurite, use e.g. http://127.0.0.1:10000/devstoreaccount1.
+	Endpoint string
+	// AccountKey is the base64 account key used for Shared Key signing.
+	AccountKey string
+	// SASToken is appended to every request when AccountKey is empty.
+	SASToken string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// AzureBlobConfigFromEnv reads AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_KEY and
+// AZURE_STORAGE_SAS_TOKEN.
+func AzureBlobConfigFromEnv() AzureBlobConfig {
+	return AzureBlobConfig{
+		Account:    os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		AccountKey: os.Getenv("AZURE_STORAGE_KEY"),
+		SASToken:   os.Getenv("AZURE_STORAGE_SAS_TOKEN"),
+	}
+}
+
+// azureBlobInput is a FileInput for one blob.
+type azureBlobInput struct {
+	cfg       AzureBlobConfig
+	container string
+	name      string
+}
+
+func (f azureBlobInput) Path() string {
+	return "azblob://" + f.cfg.Account + "/" + f.container + "/" + f.name
+}
+
+// Open streams the blob content; compressed blobs are decompressed as for
+// local files.
+func (f azureBlobInput) Open() (io.ReadCloser, error) {
+	resp, err := f.cfg.get(f.container, f.name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.name, resp.Body)
+}
+
+// NewAzureBlobStream lists blobs under prefix lazily, one page at a time, and
+// yields each blob as a FileInput in the lexical order the service lists them.
+// It is only built with the objectstore build tag.
+func NewAzureBlobStream(cfg AzureBlobConfig, container, prefix string) FileStream {
+	return newPagedFileStream(func(marker string) ([]FileInput, string, error) {
+		query := url.Values{}
+		query.Set("restype", "container")
+		query.Set("comp", "list")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		resp, err := cfg.get(container, "", query)
+		if err != nil {
+			return nil, "", fmt.Errorf("list azblob://%s/%s/%s: %w", cfg.Account, container, prefix, err)
+		}
+		defer resp.Body.Close()
+
+		var page struct {
+			Blobs []struct {
+				Name string `xml:"Name"`
+			} `xml:"Blobs>Blob"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+			return nil, "", fmt.Errorf("list azblob://%s/%s/%s: %w", cfg.Account, container, prefix, err)
+		}
+
+		inputs := make([]FileInput, 0, len(page.Blobs))
+		for _, blob := range page.Blobs {
+			inputs = append(inputs, azureBlobInput{cfg: cfg, container: container, name: blob.Name})
+		}
+		return inputs, page.NextMarker, nil
+	})
+}
+
+func (cfg AzureBlobConfig) get(container, name string, query url.Values) (*http.Response, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + cfg.Account + ".blob.core.windows.net"
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.Path += "/" + container
+	u.RawPath = u.EscapedPath()
+	if name != "" {
+		u.Path += "/" + name
+		u.RawPath += "/" + uriEncodePath(name)
+	}
+	u.RawQuery = query.Encode()
+	if cfg.AccountKey == "" && cfg.SASToken != "" {
+		sas := strings.TrimPrefix(cfg.SASToken, "?")
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += sas
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Ms-Version", azureStorageVersion)
+	if cfg.AccountKey != "" {
+		if err := cfg.sign(req, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+	return doObjectRequest(cfg.HTTPClient, req)
+}
+
+// sign adds a Shared Key Authorization header to a body-less request.
+func (cfg AzureBlobConfig) sign(req *http.Request, now time.Time) error {
+	key, err := base64.StdEncoding.DecodeString(cfg.AccountKey)
+	if err != nil {
+		return fmt.Errorf("decode account key: %w", err)
+	}
+	req.Header.Set("X-Ms-Date", now.Format(http.TimeFormat))
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+
+	var sb strings.Builder
+	sb.WriteString(req.Method + "\n")
+	// Content-Encoding, Content-Language, Content-Length, Content-MD5,
+	// Content-Type, Date, If-Modified-Since, If-Match, If-None-Match,
+	// If-Unmodified-Since and Range are all empty for these requests.
+	sb.WriteString(strings.Repeat("\n", 11))
+	for _, name := range msHeaders {
+		sb.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+
+	sb.WriteString("/" + cfg.Account + req.URL.EscapedPath())
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		sb.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(sb.String()))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	req.Header.Set("Authorization", "SharedKey "+cfg.Account+":"+signature)
+	return nil
+}
@@ -0,0 +1,216 @@
+package input
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errNoChecksum           = errors.New("no checksum in manifest")
+	errUnknownChecksumAlgo  = errors.New("unknown checksum algorithm")
+	errChecksumManifestLine = errors.New("malformed checksum manifest line")
+)
+
+// ChecksumAlgorithm names a digest used to verify file content.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	// ChecksumCRC32 is the IEEE CRC-32, written as 8 hex digits.
+	ChecksumCRC32 ChecksumAlgorithm = "crc32"
+)
+
+func (a ChecksumAlgorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumCRC32:
+		return crc32.NewIEEE(), nil
+	}
+	return nil, fmt.Errorf("%w %q", errUnknownChecksumAlgo, string(a))
+}
+
+// ChecksumError reports a file whose content does not match its expected
+// checksum.
+type ChecksumError struct {
+	Path      string
+	Algorithm ChecksumAlgorithm
+	Want      string
+	Got       string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for %s: want %s, got %s", e.Algorithm, e.Path, e.Want, e.Got)
+}
+
+// NewChecksumFileInput wraps a FileInput so its content is hashed while it is
+// parsed and compared with want, a hex digest, once it has been read; a
+// mismatch fails the run with a *ChecksumError. If the parser stops before
+// the end of the file, the remainder is read on Close to finish the check.
+//
+// Local files are hashed as stored on disk, before decompression, matching
+// the output of tools like sha256sum. Other inputs are hashed as returned by
+// their Open.
+func NewChecksumFileInput(file FileInput, algo ChecksumAlgorithm, want string) FileInput {
+	return checksumInput{FileInput: file, algo: algo, want: strings.ToLower(want)}
+}
+
+// NewChecksumFileStream verifies every file of files against manifest, which
+// maps paths to hex digests, e.g. as read by ParseChecksumManifest. Files are
+// looked up by path and then by base name; a file missing from the manifest
+// fails the run.
+func NewChecksumFileStream(files FileStream, algo ChecksumAlgorithm, manifest map[string]string) FileStream {
+	return FileStream{
+		Seq: func(yield func(FileInput) bool) {
+			for file := range files.Seq {
+				want, ok := manifest[file.Path()]
+				if !ok {
+					want, ok = manifest[filepath.Base(file.Path())]
+				}
+				var wrapped FileInput = missingChecksumInput{file}
+				if ok {
+					wrapped = NewChecksumFileInput(file, algo, want)
+				}
+				if !yield(wrapped) {
+					return
+				}
+			}
+		},
+		Err: files.Err,
+	}
+}
+
+// ParseChecksumManifest reads checksums in the format written by sha256sum
+// and similar tools: one "<hex digest>  <path>" per line, where a '*' before
+// the path marks binary mode. Blank lines and lines starting with '#' are
+// skipped.
+func ParseChecksumManifest(r io.Reader) (map[string]string, error) {
+	manifest := make(map[string]string)
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		line = trimLineEnding(line)
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+			sum, path, ok := strings.Cut(line, " ")
+			path = strings.TrimPrefix(strings.TrimPrefix(path, " "), "*")
+			if _, err := hex.DecodeString(sum); !ok || err != nil || sum == "" || path == "" {
+				return nil, fmt.Errorf("line %d: %w", lineNo, errChecksumManifestLine)
+			}
+			manifest[path] = strings.ToLower(sum)
+		}
+		if readErr == io.EOF {
+			return manifest, nil
+		}
+	}
+}
+
+type missingChecksumInput struct {
+	FileInput
+}
+
+func (f missingChecksumInput) Open() (io.ReadCloser, error) {
+	return nil, errNoChecksum
+}
+
+type checksumInput struct {
+	FileInput
+	algo ChecksumAlgorithm
+	want string
+}
+
+func (f checksumInput) Open() (io.ReadCloser, error) {
+	h, err := f.algo.newHash()
+	if err != nil {
+		return nil, err
+	}
+
+	local, isLocal := f.FileInput.(localFileInput)
+	var rc io.ReadCloser
+	if isLocal {
+		rc, err = os.Open(local.path)
+	} else {
+		rc, err = f.FileInput.Open()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := &checksumReader{src: rc, hash: h, input: f}
+	if isLocal {
+		return openDecompressed(local.path, verifier)
+	}
+	return verifier, nil
+}
+
+// checksumReader hashes src as it is read and checks the digest at EOF.
+type checksumReader struct {
+	src   io.ReadCloser
+	hash  hash.Hash
+	input checksumInput
+	// done is set once the digest has been checked.
+	done bool
+	err  error
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, r.finalErr()
+	}
+	n, err := r.src.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		r.verify()
+		if r.err != nil {
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// Close reads any unread content to complete the check, then closes src. A
+// mismatch is reported again even if Read returned it, as a buffering layer
+// in between may have held it back from a consumer that stopped early.
+func (r *checksumReader) Close() error {
+	var drainErr error
+	if !r.done {
+		_, drainErr = io.Copy(r.hash, r.src)
+		if drainErr == nil {
+			r.verify()
+		}
+	}
+	closeErr := r.src.Close()
+	if drainErr != nil {
+		return drainErr
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return closeErr
+}
+
+func (r *checksumReader) verify() {
+	r.done = true
+	got := hex.EncodeToString(r.hash.Sum(nil))
+	if got != r.input.want {
+		r.err = &ChecksumError{Path: r.input.Path(), Algorithm: r.input.algo, Want: r.input.want, Got: got}
+	}
+}
+
+func (r *checksumReader) finalErr() error {
+	if r.err != nil {
+		return r.err
+	}
+	return io.EOF
+}
@@ -0,0 +1,126 @@
+package input
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewChecksumFileStream(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "a.log")
+	writeTextFile(t, plainPath, "one\ntwo\n")
+	gzPath := filepath.Join(dir, "b.log.gz")
+	gzContent := gzipBytes(t, "three\n")
+	if err := os.WriteFile(gzPath, gzContent, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	manifestText := "# release manifest\n" +
+		sha256Hex([]byte("one\ntwo\n")) + "  a.log\n" +
+		strings.ToUpper(sha256Hex(gzContent)) + " *" + gzPath + "\n"
+	manifest, err := ParseChecksumManifest(strings.NewReader(manifestText))
+	if err != nil {
+		t.Fatalf("ParseChecksumManifest() error = %v", err)
+	}
+
+	run := func(algo ChecksumAlgorithm, manifest map[string]string, paths ...string) ([]string, error) {
+		source := ParseFiles[string](NewChecksumFileStream(NewFileStream(paths), algo, manifest), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		return got, source.Err()
+	}
+
+	t.Run("matching files parse normally", func(t *testing.T) {
+		got, err := run(ChecksumSHA256, manifest, plainPath, gzPath)
+		if err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+
+		h := crc32.NewIEEE()
+		h.Write([]byte("one\ntwo\n"))
+		crc := map[string]string{"a.log": hex.EncodeToString(h.Sum(nil))}
+		if _, err := run(ChecksumCRC32, crc, plainPath); err != nil {
+			t.Fatalf("crc32: Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("mismatch fails the run", func(t *testing.T) {
+		bad := map[string]string{"a.log": sha256Hex([]byte("tampered"))}
+		_, err := run(ChecksumSHA256, bad, plainPath)
+		var sumErr *ChecksumError
+		if !errors.As(err, &sumErr) {
+			t.Fatalf("Err() = %v, want *ChecksumError", err)
+		}
+		if sumErr.Got != sha256Hex([]byte("one\ntwo\n")) || sumErr.Path != plainPath {
+			t.Fatalf("ChecksumError = %+v", sumErr)
+		}
+	})
+
+	t.Run("early stop still verifies the whole file", func(t *testing.T) {
+		bad := map[string]string{"a.log": sha256Hex([]byte("one\n"))}
+		source := ParseFiles[string](NewChecksumFileStream(NewFileStream([]string{plainPath}), ChecksumSHA256, bad), LineParser{})
+		got := stream.Stream(source.Seq, stream.Take[string](1, stream.End(stream.Collect[string]())))
+		if !reflect.DeepEqual(got, []string{"one"}) {
+			t.Fatalf("Stream() = %v, want [one]", got)
+		}
+		var sumErr *ChecksumError
+		if err := source.Err(); !errors.As(err, &sumErr) {
+			t.Fatalf("Err() = %v, want *ChecksumError", err)
+		}
+	})
+
+	t.Run("mismatch held back by a buffered reader is reported by Close", func(t *testing.T) {
+		file := NewChecksumFileInput(localFileInput{path: plainPath}, ChecksumSHA256, sha256Hex([]byte("tampered")))
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		// A sniffer peeking past the end of the small file receives the
+		// mismatch and drops it, as openDecompressed does, before the
+		// consumer reads one line and stops.
+		br := bufio.NewReader(rc)
+		_, _ = br.Peek(16)
+		line, err := br.ReadString('\n')
+		if line != "one\n" || err != nil {
+			t.Fatalf("ReadString() = %q, %v, want %q, nil", line, err, "one\n")
+		}
+		var sumErr *ChecksumError
+		if err := rc.Close(); !errors.As(err, &sumErr) {
+			t.Fatalf("Close() = %v, want *ChecksumError", err)
+		}
+	})
+
+	t.Run("files missing from the manifest fail the run", func(t *testing.T) {
+		other := filepath.Join(dir, "c.log")
+		writeTextFile(t, other, "x\n")
+		if _, err := run(ChecksumSHA256, manifest, other); !errors.Is(err, errNoChecksum) {
+			t.Fatalf("Err() = %v, want %v", err, errNoChecksum)
+		}
+	})
+}
+
+func TestParseChecksumManifest(t *testing.T) {
+	if _, err := ParseChecksumManifest(strings.NewReader("abc\n")); !errors.Is(err, errChecksumManifestLine) {
+		t.Fatalf("ParseChecksumManifest() error = %v, want %v", err, errChecksumManifestLine)
+	}
+	if _, err := ParseChecksumManifest(strings.NewReader("zz  file\n")); !errors.Is(err, errChecksumManifestLine) {
+		t.Fatalf("ParseChecksumManifest() error = %v, want %v", err, errChecksumManifestLine)
+	}
+}
@@ -0,0 +1,196 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var errNoDecompressor = errors.New("no decompressor registered")
+
+// Decompressor wraps r with a reader that yields the decompressed bytes.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+type compressionFormat struct {
+	name  string
+	ext   string
+	magic []byte
+	// sniff, when set, replaces the plain magic prefix check.
+	sniff func(header []byte) bool
+	open  Decompressor
+}
+
+func (f compressionFormat) matches(header []byte) bool {
+	if f.sniff != nil {
+		return f.sniff(header)
+	}
+	return bytes.HasPrefix(header, f.magic)
+}
+
+// sniffHeaderLen is how many leading bytes detectCompression needs.
+const sniffHeaderLen = 10
+
+// bzip2BlockMagic starts the first block of a bzip2 stream, right after the
+// "BZh" signature and the block size level.
+var bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+
+// sniffBzip2 requires the full stream header, so text that merely starts
+// with "BZh" is not mistaken for bzip2.
+func sniffBzip2(header []byte) bool {
+	return len(header) >= 10 && bytes.HasPrefix(header, []byte("BZh")) &&
+		header[3] >= '1' && header[3] <= '9' && bytes.Equal(header[4:10], bzip2BlockMagic)
+}
+
+// plainExtensions are extensions of uncompressed files; detectCompression
+// trusts them and does not sniff their content.
+var plainExtensions = map[string]bool{
+	".txt": true, ".log": true, ".csv": true, ".tsv": true, ".json": true,
+	".jsonl": true, ".ndjson": true, ".yaml": true, ".yml": true, ".xml": true,
+	".html": true, ".md": true,
+}
+
+var (
+	compressionMu      sync.RWMutex
+	compressionFormats = []compressionFormat{
+		{
+			name:  "gzip",
+			ext:   ".gz",
+			magic: []byte{0x1f, 0x8b, 0x08},
+			open: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			name:  "bzip2",
+			ext:   ".bz2",
+			sniff: sniffBzip2,
+			open: func(r io.Reader) (io.ReadCloser, error) {
+				return io.NopCloser(bzip2.NewReader(r)), nil
+			},
+		},
+		{
+			// zstd is only detected: there is no built-in decoder, so opening
+			// a zstd file fails with errNoDecompressor until one is
+			// registered with RegisterDecompressor("zstd", ...).
+			name:  "zstd",
+			ext:   ".zst",
+			magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		},
+	}
+)
+
+// RegisterDecompressor installs the decompressor used for a named format
+// ("gzip", "bzip2" or "zstd"), replacing any built-in one. zstd has no
+// built-in decoder and must be registered before zstd files can be read.
+func RegisterDecompressor(name string, open Decompressor) error {
+	compressionMu.Lock()
+	defer compressionMu.Unlock()
+
+	for i := range compressionFormats {
+		if compressionFormats[i].name == name {
+			// Copy on write so readers holding the old slice are unaffected.
+			formats := slices.Clone(compressionFormats)
+			formats[i].open = open
+			compressionFormats = formats
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown compression format %q", name)
+}
+
+// NewDecompressingFileInput wraps a FileInput so Open returns decompressed
+// content when the file is gzip or bzip2 compressed. zstd files are
+// recognized but fail to open unless a decoder was installed with
+// RegisterDecompressor.
+//
+// The format is taken from the path extension; only files with no
+// extension, or one that is neither a compression nor a common text
+// extension, are sniffed by their leading magic bytes. Uncompressed files
+// are returned unchanged.
+func NewDecompressingFileInput(file FileInput) FileInput {
+	return decompressingInput{FileInput: file}
+}
+
+type decompressingInput struct {
+	FileInput
+}
+
+func (f decompressingInput) Open() (io.ReadCloser, error) {
+	rc, err := f.FileInput.Open()
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.Path(), rc)
+}
+
+// openDecompressed detects the compression format of rc and wraps it.
+// Closing the returned reader also closes rc.
+func openDecompressed(path string, rc io.ReadCloser) (io.ReadCloser, error) {
+	reader := bufio.NewReader(rc)
+	header, _ := reader.Peek(sniffHeaderLen)
+	format := detectCompression(path, header)
+
+	if format == nil {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, rc}, nil
+	}
+	if format.open == nil {
+		_ = rc.Close()
+		return nil, fmt.Errorf("%s: %w", format.name, errNoDecompressor)
+	}
+
+	decompressed, err := format.open(reader)
+	if err != nil {
+		_ = rc.Close()
+		return nil, fmt.Errorf("%s: %w", format.name, err)
+	}
+	return &decompressingReadCloser{ReadCloser: decompressed, source: rc}, nil
+}
+
+// detectCompression returns the format named by the path extension, or nil
+// for a known uncompressed extension. Only files with no extension or an
+// unknown one are sniffed for a format whose magic bytes start header.
+func detectCompression(path string, header []byte) *compressionFormat {
+	compressionMu.RLock()
+	formats := compressionFormats
+	compressionMu.RUnlock()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := range formats {
+		if formats[i].ext == ext {
+			return &formats[i]
+		}
+	}
+	if plainExtensions[ext] {
+		return nil
+	}
+	for i := range formats {
+		if formats[i].matches(header) {
+			return &formats[i]
+		}
+	}
+	return nil
+}
+
+type decompressingReadCloser struct {
+	io.ReadCloser
+	source io.Closer
+}
+
+func (r *decompressingReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	if sourceErr := r.source.Close(); err == nil {
+		err = sourceErr
+	}
+	return err
+}
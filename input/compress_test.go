@@ -0,0 +1,153 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// bzip2 -9 of "apple\nbanana\n"
+var bzip2Fixture = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x5a, 0xf1,
+	0xa9, 0x28, 0x00, 0x00, 0x02, 0xc1, 0x80, 0x00, 0x10, 0x32, 0x05, 0x40,
+	0x00, 0x20, 0x00, 0x21, 0xa7, 0xa8, 0xc4, 0x21, 0x80, 0x3a, 0x26, 0xd4,
+	0xb5, 0x07, 0x8b, 0xb9, 0x22, 0x9c, 0x28, 0x48, 0x2d, 0x78, 0xd4, 0x94,
+	0x00,
+}
+
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewFileLineStreamDecompression(t *testing.T) {
+	t.Run("reads gzip, bzip2 and plain files together", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.log.gz")
+		fileB := filepath.Join(dir, "b.log.bz2")
+		fileC := filepath.Join(dir, "c.log")
+
+		writeTextFile(t, fileA, string(gzipBytes(t, "a1\na2\n")))
+		writeTextFile(t, fileB, string(bzip2Fixture))
+		writeTextFile(t, fileC, "c1\n")
+
+		source := NewFileLineStream([]string{fileA, fileB, fileC})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "a2", "apple", "banana", "c1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("sniffs magic bytes without an extension", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "rotated.log.1")
+		writeTextFile(t, fileA, string(gzipBytes(t, "x\ny\n")))
+
+		source := NewFileLineStream([]string{fileA})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"x", "y"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("does not sniff plain text extensions or bare BZh text", func(t *testing.T) {
+		dir := t.TempDir()
+		gzipped := filepath.Join(dir, "looks-compressed.log")
+		writeTextFile(t, gzipped, "\x1f\x8b\x08 text\n")
+		names := filepath.Join(dir, "names")
+		writeTextFile(t, names, "BZhang\nLi\n")
+
+		source := NewFileLineStream([]string{gzipped, names})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"\x1f\x8b\x08 text", "BZhang", "Li"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("sniffs bzip2 by its full header", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "archive.1")
+		writeTextFile(t, fileA, string(bzip2Fixture))
+
+		source := NewFileLineStream([]string{fileA})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if want := []string{"apple", "banana"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("reports corrupt gzip", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "broken.gz")
+		writeTextFile(t, fileA, "not gzip at all")
+
+		source := NewFileLineStream([]string{fileA})
+		_ = stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+func TestRegisterDecompressor(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.log.zst")
+	writeTextFile(t, fileA, "\x28\xb5\x2f\xfdz1\nz2\n")
+
+	source := NewFileLineStream([]string{fileA})
+	_ = stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+	if err := source.Err(); !errors.Is(err, errNoDecompressor) {
+		t.Fatalf("Err() = %v, want errNoDecompressor", err)
+	}
+
+	// A stand-in decoder that strips the frame magic.
+	fake := func(r io.Reader) (io.ReadCloser, error) {
+		if _, err := io.CopyN(io.Discard, r, 4); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	}
+	if err := RegisterDecompressor("zstd", fake); err != nil {
+		t.Fatalf("RegisterDecompressor() returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = RegisterDecompressor("zstd", nil) })
+
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+	want := []string{"z1", "z2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stream() = %v, want %v", got, want)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+
+	if err := RegisterDecompressor("lz4", fake); err == nil {
+		t.Fatal("RegisterDecompressor(lz4) = nil, want error")
+	}
+}
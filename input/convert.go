@@ -0,0 +1,123 @@
+package input
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Conversions tracks the failures of the conversion stages sharing it:
+// Convert, ToInt, ToFloat, ToTime and the Parse* stages for string streams.
+// By default the first failure ends the stream; with skipInvalid, records
+// that fail are dropped and the stream goes on. Either way Err returns the
+// first failure, so like Input.Err, check it after the run.
+type Conversions struct {
+	mu          sync.Mutex
+	skipInvalid bool
+	failed      int
+	err         error
+}
+
+// NewConversions returns a Conversions that stops at the first failure, or
+// with skipInvalid, skips failing records.
+func NewConversions(skipInvalid bool) *Conversions {
+	return &Conversions{skipInvalid: skipInvalid}
+}
+
+// Err returns the first conversion failure, or nil.
+func (c *Conversions) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Failed returns the number of records that failed to convert.
+func (c *Conversions) Failed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failed
+}
+
+// fail records err and reports whether the stream should go on.
+func (c *Conversions) fail(err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failed++
+	if c.err == nil {
+		c.err = err
+	}
+	return c.skipInvalid
+}
+
+// Convert passes fn(v) on for every element, reporting failures to c.
+func Convert[F, A, B any](c *Conversions, fn func(A) (B, error), cont func(iter.Seq[B]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(B) bool) {
+			for v := range seq {
+				converted, err := fn(v)
+				if err != nil {
+					if !c.fail(err) {
+						return
+					}
+					continue
+				}
+				if !yield(converted) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// ToInt passes on the named column of every row as an int. Failures are
+// *ColumnError values locating the row.
+func ToInt[F any](c *Conversions, column string, cont func(iter.Seq[int]) F) func(iter.Seq[Row]) F {
+	return Convert(c, func(r Row) (int, error) { return r.Int(column) }, cont)
+}
+
+// ToFloat passes on the named column of every row as a float64.
+func ToFloat[F any](c *Conversions, column string, cont func(iter.Seq[float64]) F) func(iter.Seq[Row]) F {
+	return Convert(c, func(r Row) (float64, error) { return r.Float(column) }, cont)
+}
+
+// ToTime passes on the named column of every row parsed with layout, or
+// time.RFC3339 when layout is empty.
+func ToTime[F any](c *Conversions, column, layout string, cont func(iter.Seq[time.Time]) F) func(iter.Seq[Row]) F {
+	return Convert(c, func(r Row) (time.Time, error) { return r.Time(column, layout) }, cont)
+}
+
+// ParseInt is ToInt for a stream of strings, such as lines. Failures name
+// the element's position in the stream.
+func ParseInt[F any](c *Conversions, cont func(iter.Seq[int]) F) func(iter.Seq[string]) F {
+	return convertStrings(c, strconv.Atoi, cont)
+}
+
+// ParseFloat is ToFloat for a stream of strings.
+func ParseFloat[F any](c *Conversions, cont func(iter.Seq[float64]) F) func(iter.Seq[string]) F {
+	return convertStrings(c, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }, cont)
+}
+
+// ParseTime is ToTime for a stream of strings.
+func ParseTime[F any](c *Conversions, layout string, cont func(iter.Seq[time.Time]) F) func(iter.Seq[string]) F {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return convertStrings(c, func(s string) (time.Time, error) { return time.Parse(layout, s) }, cont)
+}
+
+func convertStrings[F, B any](c *Conversions, parse func(string) (B, error), cont func(iter.Seq[B]) F) func(iter.Seq[string]) F {
+	return func(seq iter.Seq[string]) F {
+		element := 0
+		numbered := func(s string) (B, error) {
+			element++
+			v, err := parse(s)
+			if err != nil {
+				return v, fmt.Errorf("element %d: %w", element, err)
+			}
+			return v, nil
+		}
+		return Convert(c, numbered, cont)(seq)
+	}
+}
@@ -0,0 +1,76 @@
+package input
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestToIntStopsAtFirstFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "latency.csv")
+	writeTextFile(t, path, "path,latency_ms\n/a,12\n/b,n/a\n/c,7\n")
+
+	source := NewFileCSVRowStream([]string{path})
+	conv := NewConversions(false)
+	got := stream.Stream(source.Seq, ToInt(conv, "latency_ms", stream.End(stream.Collect[int]())))
+	if !slices.Equal(got, []int{12}) {
+		t.Fatalf("ToInt()=%v, expected [12]", got)
+	}
+	var columnErr *ColumnError
+	if !errors.As(conv.Err(), &columnErr) || columnErr.Line != 3 || columnErr.Path != path {
+		t.Fatalf("Err() = %v, expected *ColumnError at %s:3", conv.Err(), path)
+	}
+	if conv.Failed() != 1 {
+		t.Fatalf("Failed()=%d, expected 1", conv.Failed())
+	}
+}
+
+func TestConversionsSkipInvalid(t *testing.T) {
+	rows := []Row{
+		NewRow([]string{"v", "at"}, []string{"1.5", "2024-05-01"}),
+		NewRow([]string{"v", "at"}, []string{"x", "bad"}),
+		NewRow([]string{"v", "at"}, []string{"2", "2024-05-02"}),
+	}
+
+	conv := NewConversions(true)
+	floats := stream.Stream(slices.Values(rows), ToFloat(conv, "v", stream.End(stream.Collect[float64]())))
+	if !slices.Equal(floats, []float64{1.5, 2}) {
+		t.Fatalf("ToFloat()=%v, expected [1.5 2]", floats)
+	}
+	times := stream.Stream(slices.Values(rows), ToTime(conv, "at", time.DateOnly, stream.End(stream.Collect[time.Time]())))
+	if len(times) != 2 || times[1].Day() != 2 {
+		t.Fatalf("ToTime()=%v, expected two dates", times)
+	}
+	if conv.Failed() != 2 || !errors.Is(conv.Err(), strconv.ErrSyntax) {
+		t.Fatalf("Failed()=%d, Err() = %v, expected 2 failures starting with a syntax error", conv.Failed(), conv.Err())
+	}
+}
+
+func TestParseStringStages(t *testing.T) {
+	lines := slices.Values([]string{"3", "4", "five", "6"})
+
+	conv := NewConversions(true)
+	sum := stream.Stream(lines, ParseInt(conv, stream.End(stream.Reduce(0, func(acc, v int) int { return acc + v }))))
+	if sum != 13 {
+		t.Fatalf("sum=%d, expected 13", sum)
+	}
+	if err := conv.Err(); err == nil || err.Error() != `element 3: strconv.Atoi: parsing "five": invalid syntax` {
+		t.Fatalf("Err() = %v, expected the failure at element 3", err)
+	}
+
+	conv = NewConversions(false)
+	floats := stream.Stream(slices.Values([]string{"0.5", "1e3"}), ParseFloat(conv, stream.End(stream.Collect[float64]())))
+	if !slices.Equal(floats, []float64{0.5, 1000}) || conv.Err() != nil {
+		t.Fatalf("ParseFloat()=%v, %v, expected [0.5 1000]", floats, conv.Err())
+	}
+	times := stream.Stream(slices.Values([]string{"2024-05-01T10:00:00Z"}), ParseTime(conv, "", stream.End(stream.Collect[time.Time]())))
+	if len(times) != 1 || times[0].Hour() != 10 || conv.Err() != nil {
+		t.Fatalf("ParseTime()=%v, %v, expected one RFC 3339 time", times, conv.Err())
+	}
+}
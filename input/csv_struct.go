@@ -0,0 +1,308 @@
+package input
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errCSVStructType       = errors.New("CSVStructParser type must be a struct")
+	errUnsupportedCSVField = errors.New("unsupported field type")
+)
+
+// CSVFieldError reports a CSV value that could not be converted into a
+// struct field.
+type CSVFieldError struct {
+	Line   int
+	Column string
+	Field  string
+	Value  string
+	Err    error
+}
+
+func (e *CSVFieldError) Error() string {
+	return fmt.Sprintf("line %d, column %q (field %s): cannot convert %q: %v", e.Line, e.Column, e.Field, e.Value, e.Err)
+}
+
+func (e *CSVFieldError) Unwrap() error {
+	return e.Err
+}
+
+// CSVStructParser parses CSV files with a header row into values of the struct
+// type T. Columns map to exported fields by their `csv:"name"` tag, or by
+// field name when untagged; `csv:"-"` skips a field. Columns without a field,
+// and fields without a column, are ignored.
+//
+// Supported field types are strings, ints, uints, floats, bools,
+// time.Duration, time.Time, encoding.TextUnmarshaler implementations, and
+// pointers to these (empty values leave pointers nil). time.Time uses the tag
+// option `layout=...`, falling back to TimeLayout and then time.RFC3339.
+//
+// A record with conversion errors stops parsing with all of that record's
+// field errors joined; each is a *CSVFieldError.
+type CSVStructParser[T any] struct {
+	CSVParser
+	TimeLayout string
+}
+
+type csvFieldPlan struct {
+	column int
+	name   string
+	index  []int
+	layout string
+}
+
+func (p CSVStructParser[T]) Parse(_ string, r io.Reader, yield func(T) bool) error {
+	reader := p.newReader(r)
+	header, err := readCSVHeader(reader)
+	if err != nil || header == nil {
+		return err
+	}
+	plan, err := p.plan(header)
+	if err != nil {
+		return err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var v T
+		target := reflect.ValueOf(&v).Elem()
+		var fieldErrs []error
+		for _, field := range plan {
+			if field.column >= len(record) {
+				continue
+			}
+			value := record[field.column]
+			if err := setCSVField(fieldByIndexAlloc(target, field.index), value, field.layout); err != nil {
+				line, _ := reader.FieldPos(field.column)
+				fieldErrs = append(fieldErrs, &CSVFieldError{
+					Line:   line,
+					Column: header[field.column],
+					Field:  field.name,
+					Value:  value,
+					Err:    err,
+				})
+			}
+		}
+		if len(fieldErrs) > 0 {
+			return errors.Join(fieldErrs...)
+		}
+		if !yield(v) {
+			return nil
+		}
+	}
+}
+
+// plan matches header columns to fields of T.
+func (p CSVStructParser[T]) plan(header []string) ([]csvFieldPlan, error) {
+	fields, err := csvStructFields(reflect.TypeFor[T](), p.TimeLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	var plan []csvFieldPlan
+	for _, field := range fields {
+		column, ok := columns[field.column]
+		if !ok {
+			continue
+		}
+		if !supportedCSVFieldType(field.typ) {
+			return nil, fmt.Errorf("field %s: %w %s", field.plan.name, errUnsupportedCSVField, field.typ)
+		}
+		field.plan.column = column
+		plan = append(plan, field.plan)
+	}
+	return plan, nil
+}
+
+type csvStructField struct {
+	column string
+	typ    reflect.Type
+	plan   csvFieldPlan
+}
+
+// csvStructFields lists the fields of the struct type typ that map to CSV
+// columns, in field order. Times without a layout option use layout, or
+// time.RFC3339 when it is empty.
+func csvStructFields(typ reflect.Type, layout string) ([]csvStructField, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", errCSVStructType, typ)
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	var fields []csvStructField
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous || promotedThroughUnexportedPointer(typ, field.Index) {
+			continue
+		}
+		tag := field.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fieldLayout := layout
+		for _, option := range strings.Split(options, ",") {
+			if value, ok := strings.CutPrefix(option, "layout="); ok {
+				fieldLayout = value
+			}
+		}
+		fields = append(fields, csvStructField{
+			column: name,
+			typ:    field.Type,
+			plan:   csvFieldPlan{name: field.Name, index: field.Index, layout: fieldLayout},
+		})
+	}
+	return fields, nil
+}
+
+// promotedThroughUnexportedPointer reports whether the field at index is
+// promoted through an embedded pointer to an unexported type, which
+// reflection can neither allocate nor set.
+func promotedThroughUnexportedPointer(typ reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		field := typ.Field(i)
+		typ = field.Type
+		if typ.Kind() == reflect.Pointer {
+			if !field.IsExported() {
+				return true
+			}
+			typ = typ.Elem()
+		}
+	}
+	return false
+}
+
+// fieldByIndexAlloc is v.FieldByIndex for setting a field: nil embedded
+// struct pointers on the way are allocated instead of panicking.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+func supportedCSVFieldType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == timeType || reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setCSVField converts value into dst, which has a supported type.
+func setCSVField(dst reflect.Value, value, layout string) error {
+	if dst.Kind() == reflect.Pointer {
+		if value == "" {
+			return nil
+		}
+		ptr := reflect.New(dst.Type().Elem())
+		if err := setCSVField(ptr.Elem(), value, layout); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	}
+
+	switch dst.Type() {
+	case timeType:
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		dst.SetInt(int64(d))
+		return nil
+	}
+	if u, ok := dst.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	if dst.Kind() == reflect.String {
+		dst.SetString(value)
+		return nil
+	}
+
+	value = strings.TrimSpace(value)
+	switch dst.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	}
+	return nil
+}
+
+// NewFileCSVStructStream provides CSV input decoded into T by composing
+// FileStream -> CSVStructParser -> transform pipeline.
+func NewFileCSVStructStream[T any](paths []string) Input[T] {
+	return ParseFiles[T](NewFileStream(paths), CSVStructParser[T]{})
+}
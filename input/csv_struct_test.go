@@ -0,0 +1,179 @@
+package input
+
+import (
+	"errors"
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+type csvOrder struct {
+	ID       int           `csv:"id"`
+	Customer string        `csv:"customer"`
+	Amount   float64       `csv:"amount"`
+	Paid     bool          `csv:"paid"`
+	Placed   time.Time     `csv:"placed,layout=2006-01-02"`
+	Timeout  time.Duration `csv:"timeout"`
+	Discount *float64      `csv:"discount"`
+	Client   netip.Addr    `csv:"client"`
+	Note     string        `csv:"-"`
+	Region   string
+}
+
+// CSVBase and csvHidden are embedded through pointers by csvEmbedded.
+type CSVBase struct {
+	ID int `csv:"id"`
+}
+
+type csvHidden struct {
+	Secret string `csv:"secret"`
+}
+
+type csvEmbedded struct {
+	*CSVBase
+	*csvHidden
+	Name string `csv:"name"`
+}
+
+func TestNewFileCSVStructStream(t *testing.T) {
+	t.Run("decodes typed fields by tag", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "orders.csv")
+		writeTextFile(t, file, strings.Join([]string{
+			"region,id,customer,amount,paid,placed,timeout,discount,client,Note,extra",
+			"eu,1,alice,12.5,true,2024-03-01,1m30s,0.1,10.0.0.1,ignored,x",
+			"us, 2 ,bob,7,false,2024-03-02,5s,,::1,ignored,y",
+		}, "\n")+"\n")
+
+		source := NewFileCSVStructStream[csvOrder]([]string{file})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[csvOrder]()))
+
+		discount := 0.1
+		want := []csvOrder{
+			{
+				ID: 1, Customer: "alice", Amount: 12.5, Paid: true,
+				Placed:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				Timeout:  90 * time.Second,
+				Discount: &discount,
+				Client:   netip.MustParseAddr("10.0.0.1"),
+			},
+			{
+				ID: 2, Customer: "bob", Amount: 7,
+				Placed:  time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+				Timeout: 5 * time.Second,
+				Client:  netip.MustParseAddr("::1"),
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %+v, want %+v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("allocates embedded struct pointers", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "embedded.csv")
+		writeTextFile(t, file, "id,name,secret\n7,ann,x\n")
+
+		source := NewFileCSVStructStream[csvEmbedded]([]string{file})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[csvEmbedded]()))
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		// Fields promoted through an unexported embedded pointer cannot be
+		// set and are skipped.
+		want := []csvEmbedded{{CSVBase: &CSVBase{ID: 7}, Name: "ann"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("untagged fields match by name", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "regions.csv")
+		writeTextFile(t, file, "Region,id\nap,3\n")
+
+		source := NewFileCSVStructStream[csvOrder]([]string{file})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[csvOrder]()))
+
+		want := []csvOrder{{ID: 3, Region: "ap"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("reports every field error of a record", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "bad.csv")
+		writeTextFile(t, file, "id,amount,paid\n1,2.5,true\nx,2.5,maybe\n4,1,true\n")
+
+		source := NewFileCSVStructStream[csvOrder]([]string{file})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[csvOrder]()))
+
+		if want := []csvOrder{{ID: 1, Amount: 2.5, Paid: true}}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %+v, want %+v", got, want)
+		}
+
+		err := source.Err()
+		if err == nil {
+			t.Fatalf("Err() = nil, want conversion error")
+		}
+		var fieldErr *CSVFieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("Err() = %v, want *CSVFieldError", err)
+		}
+		if fieldErr.Line != 3 || fieldErr.Column != "id" || fieldErr.Field != "ID" || fieldErr.Value != "x" {
+			t.Fatalf("CSVFieldError = %+v, want line 3 column id", fieldErr)
+		}
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Fatalf("Err() = %v, want strconv.ErrSyntax", err)
+		}
+		if !strings.Contains(err.Error(), `column "paid"`) {
+			t.Fatalf("Err() = %v, want paid column reported", err)
+		}
+	})
+
+	t.Run("TimeLayout applies to untagged layouts", func(t *testing.T) {
+		type event struct {
+			At time.Time `csv:"at"`
+		}
+		dir := t.TempDir()
+		file := filepath.Join(dir, "events.csv")
+		writeTextFile(t, file, "at\n01/02/2024 15:04\n")
+
+		parser := CSVStructParser[event]{TimeLayout: "01/02/2006 15:04"}
+		source := ParseFiles[event](NewFileStream([]string{file}), parser)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[event]()))
+
+		want := []event{{At: time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("unsupported field type is an error", func(t *testing.T) {
+		type row struct {
+			Tags []string `csv:"tags"`
+		}
+		dir := t.TempDir()
+		file := filepath.Join(dir, "tags.csv")
+		writeTextFile(t, file, "tags\na\n")
+
+		source := NewFileCSVStructStream[row]([]string{file})
+		stream.Stream(source.Seq, stream.End(stream.Collect[row]()))
+		if err := source.Err(); !errors.Is(err, errUnsupportedCSVField) {
+			t.Fatalf("Err() = %v, want errUnsupportedCSVField", err)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package input
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DirStreamOptions filters the files produced by NewDirStream.
+// Zero values disable the corresponding filter.
+type DirStreamOptions struct {
+	// Include keeps only files whose base name matches one of these
+	// filepath.Match patterns.
+	Include []string
+	// Exclude drops files whose base name matches one of these patterns.
+	Exclude []string
+	// ExcludeDirs skips directories whose base name matches one of these
+	// patterns, without descending into them.
+	ExcludeDirs []string
+	// Extensions keeps only files with one of these extensions (".log").
+	// Matching is case-insensitive.
+	Extensions []string
+	// MinSize and MaxSize bound the file size in bytes. MaxSize 0 means no limit.
+	MinSize int64
+	MaxSize int64
+	// ModifiedAfter and ModifiedBefore bound the modification time.
+	// ModifiedAfter is inclusive, ModifiedBefore is exclusive.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+}
+
+// NewDirStream creates a lazy file stream by walking the tree under root in
+// lexical order. Only regular files that pass opts are yielded.
+// Directories are read as the stream is consumed, and the first walk error
+// stops the stream.
+func NewDirStream(root string, opts DirStreamOptions) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		if err := opts.validate(); err != nil {
+			setFirstErr(&runErr, err)
+			return
+		}
+
+		walkErr := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != root && matchAny(opts.ExcludeDirs, entry.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+
+			keep, err := opts.keep(entry)
+			if err != nil {
+				return err
+			}
+			if keep && !yield(localFileInput{path: path}) {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if walkErr != nil {
+			setFirstErr(&runErr, fmt.Errorf("walk %s: %w", root, walkErr))
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+func (o DirStreamOptions) validate() error {
+	for _, patterns := range [][]string{o.Include, o.Exclude, o.ExcludeDirs} {
+		for _, pattern := range patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (o DirStreamOptions) keep(entry fs.DirEntry) (bool, error) {
+	name := entry.Name()
+	if len(o.Include) > 0 && !matchAny(o.Include, name) {
+		return false, nil
+	}
+	if matchAny(o.Exclude, name) {
+		return false, nil
+	}
+	if len(o.Extensions) > 0 {
+		ext := filepath.Ext(name)
+		if !slices.ContainsFunc(o.Extensions, func(want string) bool { return strings.EqualFold(want, ext) }) {
+			return false, nil
+		}
+	}
+
+	if o.MinSize == 0 && o.MaxSize == 0 && o.ModifiedAfter.IsZero() && o.ModifiedBefore.IsZero() {
+		return true, nil
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() < o.MinSize {
+		return false, nil
+	}
+	if o.MaxSize > 0 && info.Size() > o.MaxSize {
+		return false, nil
+	}
+	if !o.ModifiedAfter.IsZero() && info.ModTime().Before(o.ModifiedAfter) {
+		return false, nil
+	}
+	if !o.ModifiedBefore.IsZero() && !info.ModTime().Before(o.ModifiedBefore) {
+		return false, nil
+	}
+	return true, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func dirStreamPaths(t *testing.T, root string, opts DirStreamOptions) []string {
+	t.Helper()
+	files := NewDirStream(root, opts)
+	got := stream.Stream(
+		files.Seq,
+		stream.Map(func(f FileInput) string {
+			rel, err := filepath.Rel(root, f.Path())
+			if err != nil {
+				t.Fatalf("Rel(%s): %v", f.Path(), err)
+			}
+			return filepath.ToSlash(rel)
+		},
+			stream.End(stream.Collect[string]()),
+		),
+	)
+	if err := files.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	return got
+}
+
+func TestNewDirStream(t *testing.T) {
+	root := globFixture(t)
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir .git: %v", err)
+	}
+	writeTextFile(t, filepath.Join(root, ".git", "HEAD.log"), "ref\n")
+	writeTextFile(t, filepath.Join(root, "big.log"), strings.Repeat("x", 1000))
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "a.log"), old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		opts DirStreamOptions
+		want []string
+	}{
+		{
+			name: "no filters walks everything",
+			opts: DirStreamOptions{},
+			want: []string{".git/HEAD.log", "a.log", "b.txt", "big.log", "other/e.log", "sub/c.log", "sub/deep/d.log"},
+		},
+		{
+			name: "extension and excluded dirs",
+			opts: DirStreamOptions{Extensions: []string{".LOG"}, ExcludeDirs: []string{".git", "deep"}},
+			want: []string{"a.log", "big.log", "other/e.log", "sub/c.log"},
+		},
+		{
+			name: "include and exclude name patterns",
+			opts: DirStreamOptions{Include: []string{"*.log"}, Exclude: []string{"big*", "HEAD*"}},
+			want: []string{"a.log", "other/e.log", "sub/c.log", "sub/deep/d.log"},
+		},
+		{
+			name: "size bounds",
+			opts: DirStreamOptions{MinSize: 100},
+			want: []string{"big.log"},
+		},
+		{
+			name: "modified time window",
+			opts: DirStreamOptions{
+				Extensions:     []string{".log"},
+				ExcludeDirs:    []string{".git"},
+				ModifiedAfter:  time.Now().Add(-72 * time.Hour),
+				ModifiedBefore: time.Now().Add(-24 * time.Hour),
+			},
+			want: []string{"a.log"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := dirStreamPaths(t, root, tc.opts)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("NewDirStream() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("stops walking when consumer stops", func(t *testing.T) {
+		files := NewDirStream(root, DirStreamOptions{})
+		first := stream.Stream(files.Seq, stream.End(stream.First[FileInput]()))
+		if !first.OK {
+			t.Fatal("First() OK = false, want true")
+		}
+		if err := files.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports missing root", func(t *testing.T) {
+		files := NewDirStream(filepath.Join(root, "missing"), DirStreamOptions{})
+		_ = stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+
+	t.Run("reports bad pattern", func(t *testing.T) {
+		files := NewDirStream(root, DirStreamOptions{Include: []string{"["}})
+		_ = stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
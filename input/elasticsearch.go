@@ -0,0 +1,323 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var errBulkAction = errors.New("unsupported bulk action")
+
+// Defaults for BulkOptions.
+const (
+	defaultBulkBatchSize  = 500
+	defaultBulkBatchBytes = 5 << 20
+)
+
+// BulkAction describes how one document is indexed by WriteBulk.
+type BulkAction struct {
+	// Op is "index" (the default), which replaces a document with the same
+	// ID, or "create", which fails if it already exists.
+	Op string
+	// Index is the target index. Defaults to BulkOptions.Index.
+	Index string
+	// ID is the document ID. Empty lets the cluster assign one.
+	ID string
+}
+
+// BulkOptions configures WriteBulk.
+type BulkOptions struct {
+	// Client sends the requests. Nil uses http.DefaultClient.
+	Client *http.Client
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+	// Index is the target index of actions that do not name one.
+	Index string
+	// BatchSize is the number of documents per _bulk request. Defaults to
+	// 500.
+	BatchSize int
+	// MaxBatchBytes flushes a batch early once its body reaches this size.
+	// Defaults to 5MiB.
+	MaxBatchBytes int
+	// MaxRetries bounds the retries of a request after transport errors,
+	// 429 and 5xx responses, and separately of documents rejected with 429.
+	// Defaults to 3; negative disables retries.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, doubled for each
+	// further one up to MaxBackoff. A Retry-After header takes precedence.
+	// They default to 500ms and 30s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// BulkItemError reports one document the cluster did not index.
+type BulkItemError struct {
+	// Position is the index of the document in the stream.
+	Position int
+	Index    string
+	ID       string
+	Status   int
+	// Type and Reason are taken from the item's error object.
+	Type   string
+	Reason string
+}
+
+// BulkError lists the documents that failed while the rest of the stream
+// was indexed.
+type BulkError struct {
+	Items []BulkItemError
+}
+
+func (e *BulkError) Error() string {
+	first := e.Items[0]
+	msg := fmt.Sprintf("bulk: %d documents failed; first at position %d: status %d", len(e.Items), first.Position, first.Status)
+	if first.Type != "" {
+		msg += ": " + first.Type
+	}
+	if first.Reason != "" {
+		msg += ": " + first.Reason
+	}
+	return msg
+}
+
+// WriteBulk indexes each element as a JSON document into Elasticsearch or
+// OpenSearch, batching them into _bulk requests sent to endpoint (the
+// cluster URL, e.g. "http://localhost:9200"). action chooses the index and
+// ID of each document; nil indexes every document into opts.Index with a
+// generated ID.
+//
+// Failed requests are retried with backoff as set by opts, and documents
+// rejected with 429 are resent likewise. Other rejected documents do not stop
+// the stream: they are returned together as a *BulkError once it ends. A
+// request that still fails, a marshal error or cancellation of ctx stops
+// consumption and is returned.
+func WriteBulk[T any](ctx context.Context, endpoint string, action func(T) BulkAction, opts BulkOptions) func(iter.Seq[T]) error {
+	return func(seq iter.Seq[T]) error {
+		client := newBulkClient(ctx, endpoint, opts)
+		batchSize := opts.BatchSize
+		if batchSize <= 0 {
+			batchSize = defaultBulkBatchSize
+		}
+		maxBytes := opts.MaxBatchBytes
+		if maxBytes <= 0 {
+			maxBytes = defaultBulkBatchBytes
+		}
+
+		var batch []bulkItem
+		var batchBytes int
+		position := 0
+		for v := range seq {
+			var a BulkAction
+			if action != nil {
+				a = action(v)
+			}
+			item, err := newBulkItem(position, a, v, opts.Index)
+			if err != nil {
+				return err
+			}
+			position++
+			batch = append(batch, item)
+			batchBytes += len(item.body)
+			if len(batch) >= batchSize || batchBytes >= maxBytes {
+				if err := client.flush(batch); err != nil {
+					return err
+				}
+				batch, batchBytes = batch[:0], 0
+			}
+		}
+		if len(batch) > 0 {
+			if err := client.flush(batch); err != nil {
+				return err
+			}
+		}
+		if len(client.failed) > 0 {
+			return &BulkError{Items: client.failed}
+		}
+		return nil
+	}
+}
+
+// bulkItem is one document with its encoded action and source lines.
+type bulkItem struct {
+	position int
+	index    string
+	id       string
+	body     []byte
+}
+
+func newBulkItem[T any](position int, a BulkAction, doc T, defaultIndex string) (bulkItem, error) {
+	op := a.Op
+	if op == "" {
+		op = "index"
+	}
+	if op != "index" && op != "create" {
+		return bulkItem{}, fmt.Errorf("document %d: %w %q", position, errBulkAction, op)
+	}
+	index := a.Index
+	if index == "" {
+		index = defaultIndex
+	}
+
+	meta := struct {
+		Index string `json:"_index,omitempty"`
+		ID    string `json:"_id,omitempty"`
+	}{index, a.ID}
+	line, err := json.Marshal(map[string]any{op: meta})
+	if err != nil {
+		return bulkItem{}, err
+	}
+	source, err := json.Marshal(doc)
+	if err != nil {
+		return bulkItem{}, fmt.Errorf("document %d: %w", position, err)
+	}
+	body := make([]byte, 0, len(line)+len(source)+2)
+	body = append(append(append(append(body, line...), '\n'), source...), '\n')
+	return bulkItem{position: position, index: index, id: a.ID, body: body}, nil
+}
+
+// bulkResponse is the part of a _bulk response WriteBulk reads.
+type bulkResponse struct {
+	Errors bool                          `json:"errors"`
+	Items  []map[string]bulkItemResponse `json:"items"`
+}
+
+type bulkItemResponse struct {
+	Index  string `json:"_index"`
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+type bulkClient struct {
+	ctx     context.Context
+	url     string
+	client  *http.Client
+	header  http.Header
+	backoff retryBackoff
+	failed  []BulkItemError
+}
+
+func newBulkClient(ctx context.Context, endpoint string, opts BulkOptions) *bulkClient {
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &bulkClient{
+		ctx:     ctx,
+		url:     strings.TrimSuffix(endpoint, "/") + "/_bulk",
+		client:  client,
+		header:  opts.Header,
+		backoff: newRetryBackoff(opts.MaxRetries, opts.InitialBackoff, opts.MaxBackoff),
+	}
+}
+
+// flush indexes items, resending those rejected with 429 and recording the
+// other failures.
+func (c *bulkClient) flush(items []bulkItem) error {
+	pending := items
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(pending)
+		if err != nil {
+			return err
+		}
+		if len(resp.Items) != len(pending) {
+			return fmt.Errorf("bulk: response has %d items for %d documents", len(resp.Items), len(pending))
+		}
+
+		var retry []bulkItem
+		for i, result := range resp.Items {
+			item := pending[i]
+			for _, r := range result {
+				switch {
+				case r.Status >= 200 && r.Status <= 299:
+				case r.Status == http.StatusTooManyRequests && attempt < c.backoff.retries:
+					retry = append(retry, item)
+				default:
+					failure := BulkItemError{Position: item.position, Index: item.index, ID: item.id, Status: r.Status}
+					if r.Index != "" {
+						failure.Index = r.Index
+					}
+					if r.ID != "" {
+						failure.ID = r.ID
+					}
+					if r.Error != nil {
+						failure.Type, failure.Reason = r.Error.Type, r.Error.Reason
+					}
+					c.failed = append(c.failed, failure)
+				}
+			}
+		}
+		if len(retry) == 0 {
+			return nil
+		}
+		if !sleepUntil(c.ctx, time.Now().Add(c.backoff.delay(attempt))) {
+			return c.ctx.Err()
+		}
+		pending = retry
+	}
+}
+
+// send posts one _bulk request, retrying transient failures.
+func (c *bulkClient) send(items []bulkItem) (*bulkResponse, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		body.Write(item.body)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, c.url, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range c.header {
+			req.Header[name] = append(req.Header[name], values...)
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+
+		resp, err := c.client.Do(req)
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if c.ctx.Err() != nil {
+				return nil, c.ctx.Err()
+			}
+		case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+			var decoded bulkResponse
+			err := json.NewDecoder(resp.Body).Decode(&decoded)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("bulk: decode response: %w", err)
+			}
+			return &decoded, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			delay = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+		default:
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("bulk: unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		}
+
+		if attempt >= c.backoff.retries {
+			return nil, fmt.Errorf("bulk: after %d attempts: %w", attempt+1, err)
+		}
+		if delay <= 0 {
+			delay = c.backoff.delay(attempt)
+		}
+		if !sleepUntil(c.ctx, time.Now().Add(delay)) {
+			return nil, c.ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+type bulkTestDoc struct {
+	ID  string `json:"id"`
+	Msg string `json:"msg"`
+}
+
+// fakeBulkServer accepts _bulk requests, storing indexed documents by ID.
+// Documents with ID "busy" are rejected with 429 the first time and
+// documents with ID prefix "bad" with 400.
+type fakeBulkServer struct {
+	mu       sync.Mutex
+	requests int
+	docs     map[string]bulkTestDoc
+	indices  map[string]string
+	busySeen bool
+	failNext int
+}
+
+func (s *fakeBulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if s.failNext > 0 {
+		s.failNext--
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var items []map[string]any
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+			http.Error(w, "malformed", http.StatusBadRequest)
+			return
+		}
+		var doc bulkTestDoc
+		_ = json.Unmarshal(scanner.Bytes(), &doc)
+		for op, meta := range action {
+			result := map[string]any{"_index": meta.Index, "_id": meta.ID, "status": 201}
+			switch {
+			case meta.ID == "busy" && !s.busySeen:
+				s.busySeen = true
+				result["status"] = 429
+				result["error"] = map[string]string{"type": "es_rejected_execution_exception"}
+			case len(meta.ID) >= 3 && meta.ID[:3] == "bad":
+				result["status"] = 400
+				result["error"] = map[string]string{"type": "mapper_parsing_exception", "reason": "failed to parse"}
+			default:
+				s.docs[meta.ID] = doc
+				s.indices[meta.ID] = meta.Index
+			}
+			items = append(items, map[string]any{op: result})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": true, "items": items})
+}
+
+func newFakeBulkServer(t *testing.T) (*fakeBulkServer, string) {
+	fake := &fakeBulkServer{docs: make(map[string]bulkTestDoc), indices: make(map[string]string)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, srv.URL
+}
+
+func TestWriteBulk(t *testing.T) {
+	ctx := context.Background()
+	byID := func(d bulkTestDoc) BulkAction { return BulkAction{ID: d.ID} }
+	opts := BulkOptions{Index: "logs", BatchSize: 2, InitialBackoff: time.Millisecond}
+
+	t.Run("batches documents and retries rejections", func(t *testing.T) {
+		fake, url := newFakeBulkServer(t)
+		fake.failNext = 1
+		docs := []bulkTestDoc{{"a", "1"}, {"busy", "2"}, {"b", "3"}, {"c", "4"}, {"d", "5"}}
+		if err := stream.Stream(slices.Values(docs), stream.End(WriteBulk(ctx, url, byID, opts))); err != nil {
+			t.Fatalf("WriteBulk() error = %v, want nil", err)
+		}
+		for _, d := range docs {
+			if fake.docs[d.ID] != d || fake.indices[d.ID] != "logs" {
+				t.Fatalf("document %s = %+v in %q, want %+v in logs", d.ID, fake.docs[d.ID], fake.indices[d.ID], d)
+			}
+		}
+		// Three batches, one 503 and one resend of the 429 document.
+		if fake.requests != 5 {
+			t.Fatalf("requests = %d, want 5", fake.requests)
+		}
+	})
+
+	t.Run("reports failed documents after the stream", func(t *testing.T) {
+		fake, url := newFakeBulkServer(t)
+		docs := []bulkTestDoc{{"a", "1"}, {"bad1", "2"}, {"b", "3"}, {"bad2", "4"}}
+		action := func(d bulkTestDoc) BulkAction { return BulkAction{Op: "create", Index: "events", ID: d.ID} }
+		err := stream.Stream(slices.Values(docs), stream.End(WriteBulk(ctx, url, action, opts)))
+		var bulkErr *BulkError
+		if !errors.As(err, &bulkErr) {
+			t.Fatalf("WriteBulk() error = %v, want *BulkError", err)
+		}
+		want := []BulkItemError{
+			{Position: 1, Index: "events", ID: "bad1", Status: 400, Type: "mapper_parsing_exception", Reason: "failed to parse"},
+			{Position: 3, Index: "events", ID: "bad2", Status: 400, Type: "mapper_parsing_exception", Reason: "failed to parse"},
+		}
+		if !reflect.DeepEqual(bulkErr.Items, want) {
+			t.Fatalf("Items = %+v, want %+v", bulkErr.Items, want)
+		}
+		if len(fake.docs) != 2 {
+			t.Fatalf("indexed %d documents, want 2", len(fake.docs))
+		}
+	})
+
+	t.Run("request failures stop the stream", func(t *testing.T) {
+		fake, url := newFakeBulkServer(t)
+		fake.failNext = 10
+		noRetry := opts
+		noRetry.MaxRetries = -1
+		err := stream.Stream(slices.Values([]bulkTestDoc{{"a", "1"}}), stream.End(WriteBulk(ctx, url, byID, noRetry)))
+		if err == nil {
+			t.Fatal("WriteBulk() error = nil, want an error")
+		}
+		if fake.requests != 1 {
+			t.Fatalf("requests = %d, want 1", fake.requests)
+		}
+
+		bad := func(bulkTestDoc) BulkAction { return BulkAction{Op: "delete"} }
+		err = stream.Stream(slices.Values([]bulkTestDoc{{"a", "1"}}), stream.End(WriteBulk(ctx, url, bad, opts)))
+		if !errors.Is(err, errBulkAction) {
+			t.Fatalf("WriteBulk() error = %v, want %v", err, errBulkAction)
+		}
+	})
+}
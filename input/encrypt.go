@@ -0,0 +1,339 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	errEncryptedMagic     = errors.New("not an encrypted stream")
+	errEncryptedChunk     = errors.New("invalid encrypted chunk size")
+	errDecryptFailed      = errors.New("decryption failed: wrong key or corrupted data")
+	errEncryptedTruncated = errors.New("encrypted stream truncated")
+	errEncryptWriterDone  = errors.New("write to closed encrypting writer")
+)
+
+// encryptedMagic starts every stream written by NewEncryptingWriter. The
+// format is private to this package; see the layout comment below.
+var encryptedMagic = []byte("GSENC\x01")
+
+const (
+	// DefaultEncryptChunkSize is the plaintext size of each sealed chunk.
+	DefaultEncryptChunkSize = 64 << 10
+	maxEncryptChunkSize     = 16 << 20
+	encryptNoncePrefixSize  = 7
+)
+
+// KeyProvider returns the AES key (16, 24 or 32 bytes) for a key ID recorded
+// in an encrypted file's header, for example by looking it up in a KMS or
+// keyring. Rotating keys only requires new files to name a new ID.
+type KeyProvider func(keyID string) ([]byte, error)
+
+// StaticKey returns a KeyProvider that answers every key ID with key.
+func StaticKey(key []byte) KeyProvider {
+	return func(string) ([]byte, error) {
+		return key, nil
+	}
+}
+
+// NewDecryptingFileInput wraps a FileInput whose content was written by
+// NewEncryptingWriter so Open returns the plaintext, decrypted chunk by chunk
+// while it is parsed; nothing is written to disk.
+//
+// The format is this package's own chunked AES-GCM layout, not age, OpenSSL
+// or any other standard container: files encrypted by other tools are
+// rejected, and files written by NewEncryptingWriter can only be read here. A trailing ".enc" is
+// ignored when detecting compression of the plaintext, so "app.log.gz.enc"
+// is decrypted and then decompressed.
+//
+// Wrong keys, tampering and truncation surface as read errors, so they fail
+// the run through Err.
+func NewDecryptingFileInput(file FileInput, keys KeyProvider) FileInput {
+	return decryptingInput{FileInput: file, keys: keys}
+}
+
+// NewDecryptingFileStream applies NewDecryptingFileInput to every file of
+// files.
+func NewDecryptingFileStream(files FileStream, keys KeyProvider) FileStream {
+	return FileStream{
+		Seq: func(yield func(FileInput) bool) {
+			for file := range files.Seq {
+				if !yield(NewDecryptingFileInput(file, keys)) {
+					return
+				}
+			}
+		},
+		Err: files.Err,
+	}
+}
+
+type decryptingInput struct {
+	FileInput
+	keys KeyProvider
+}
+
+func (f decryptingInput) Open() (io.ReadCloser, error) {
+	rc, err := f.FileInput.Open()
+	if err != nil {
+		return nil, err
+	}
+	dr, err := newDecryptReader(rc, f.keys)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return openDecompressed(strings.TrimSuffix(f.Path(), ".enc"), struct {
+		io.Reader
+		io.Closer
+	}{dr, rc})
+}
+
+// The encrypted format is private to this package: a header followed by
+// AES-GCM sealed chunks, each the ciphertext and its 16-byte tag. The header
+// is
+//
+//	magic "GSENC\x01" | key ID length (1) | key ID | chunk size (4, big endian) | nonce prefix (7)
+//
+// Every chunk but the last holds exactly chunk size bytes of plaintext. The
+// 12-byte nonce of chunk i is the prefix, i as a 4-byte big-endian counter and
+// a byte that is 1 for the last chunk only, so reordering, dropping or
+// truncating chunks fails authentication. The header is the additional data
+// of every chunk.
+
+func encryptionAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// decryptReader opens the sealed chunks of an encrypted stream in order.
+type decryptReader struct {
+	src       *bufio.Reader
+	aead      cipher.AEAD
+	header    []byte
+	prefix    []byte
+	chunkSize int
+	counter   uint32
+	chunk     []byte
+	plain     []byte
+	done      bool
+	err       error
+}
+
+func newDecryptReader(r io.Reader, keys KeyProvider) (*decryptReader, error) {
+	src := bufio.NewReader(r)
+	var header bytes.Buffer
+	read := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(src, b); err != nil {
+			return nil, fmt.Errorf("%w: %w", errEncryptedMagic, noEOF(err))
+		}
+		header.Write(b)
+		return b, nil
+	}
+
+	magic, err := read(len(encryptedMagic))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, encryptedMagic) {
+		return nil, errEncryptedMagic
+	}
+	idLen, err := read(1)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := read(int(idLen[0]))
+	if err != nil {
+		return nil, err
+	}
+	sizeBytes, err := read(4)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := int(binary.BigEndian.Uint32(sizeBytes))
+	if chunkSize <= 0 || chunkSize > maxEncryptChunkSize {
+		return nil, fmt.Errorf("%w: %d", errEncryptedChunk, chunkSize)
+	}
+	prefix, err := read(encryptNoncePrefixSize)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keys(string(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", keyID, err)
+	}
+	aead, err := encryptionAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", keyID, err)
+	}
+	return &decryptReader{
+		src:       src,
+		aead:      aead,
+		header:    header.Bytes(),
+		prefix:    prefix,
+		chunkSize: chunkSize,
+		chunk:     make([]byte, chunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// next opens the following chunk. A chunk is the last one when it is short
+// or nothing follows it.
+func (r *decryptReader) next() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	last := false
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, peekErr := r.src.Peek(1); peekErr == io.EOF {
+			last = true
+		}
+	}
+	if n < r.aead.Overhead() {
+		return errEncryptedTruncated
+	}
+
+	plain, err := r.aead.Open(nil, chunkNonce(r.prefix, r.counter, last), r.chunk[:n], r.header)
+	if err != nil {
+		// A stream cut at a chunk boundary ends in a valid non-final chunk.
+		if _, midErr := r.aead.Open(nil, chunkNonce(r.prefix, r.counter, false), r.chunk[:n], r.header); last && midErr == nil {
+			return errEncryptedTruncated
+		}
+		return fmt.Errorf("chunk %d: %w", r.counter, errDecryptFailed)
+	}
+	r.counter++
+	r.plain = plain
+	r.done = last
+	return nil
+}
+
+// NewEncryptingWriter returns a writer that encrypts everything written to it
+// into w in the private format read by NewDecryptingFileInput, sealing chunks of
+// DefaultEncryptChunkSize bytes with key (16, 24 or 32 bytes for AES-128,
+// -192 or -256). keyID, at most 255 bytes, is stored in the header for the
+// reader's KeyProvider. Close must be called to write the final chunk; it
+// does not close w.
+func NewEncryptingWriter(w io.Writer, keyID string, key []byte) (io.WriteCloser, error) {
+	if len(keyID) > 255 {
+		return nil, fmt.Errorf("key ID longer than 255 bytes")
+	}
+	aead, err := encryptionAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, encryptNoncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{}, encryptedMagic...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint32(header, DefaultEncryptChunkSize)
+	header = append(header, prefix...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		dst:    w,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		buf:    make([]byte, 0, DefaultEncryptChunkSize),
+	}, nil
+}
+
+type encryptWriter struct {
+	dst     io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	sealed  []byte
+	closed  bool
+	err     error
+}
+
+// Write buffers p, sealing a full chunk only once more data follows it, as the
+// last chunk is sealed differently.
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errEncryptWriterDone
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == cap(w.buf) {
+			if w.err = w.seal(false); w.err != nil {
+				return written, w.err
+			}
+		}
+		n := min(len(p), cap(w.buf)-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the buffered data as the last chunk.
+func (w *encryptWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	return w.seal(true)
+}
+
+func (w *encryptWriter) seal(last bool) error {
+	w.sealed = w.aead.Seal(w.sealed[:0], chunkNonce(w.prefix, w.counter, last), w.buf, w.header)
+	w.counter++
+	w.buf = w.buf[:0]
+	_, err := w.dst.Write(w.sealed)
+	return err
+}
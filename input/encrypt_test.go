@@ -0,0 +1,128 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func encryptBytes(t *testing.T, keyID string, key, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewEncryptingWriter(&buf, keyID, key)
+	if err != nil {
+		t.Fatalf("NewEncryptingWriter: %v", err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewDecryptingFileInput(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	keys := func(id string) ([]byte, error) {
+		if id != "logs-2024" {
+			return nil, fmt.Errorf("unknown key")
+		}
+		return key, nil
+	}
+	decryptLines := func(paths ...string) ([]string, error) {
+		source := ParseFiles[string](NewDecryptingFileStream(NewFileStream(paths), keys), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		return got, source.Err()
+	}
+
+	t.Run("round trips across chunk boundaries", func(t *testing.T) {
+		// Sizes around the chunk size exercise full and empty final chunks.
+		for _, size := range []int{0, 5, DefaultEncryptChunkSize - 3, 2 * DefaultEncryptChunkSize, 2*DefaultEncryptChunkSize + 10} {
+			var plain strings.Builder
+			var want []string
+			for i := 0; plain.Len() < size; i++ {
+				line := fmt.Sprintf("line %06d", i)
+				plain.WriteString(line + "\n")
+				want = append(want, line)
+			}
+			path := filepath.Join(dir, fmt.Sprintf("round%d.log.enc", size))
+			writeTextFile(t, path, string(encryptBytes(t, "logs-2024", key, []byte(plain.String()))))
+
+			got, err := decryptLines(path)
+			if err != nil {
+				t.Fatalf("size %d: Err() = %v, want nil", size, err)
+			}
+			if want == nil {
+				want = []string{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("size %d: Stream() returned %d lines, want %d", size, len(got), len(want))
+			}
+		}
+	})
+
+	t.Run("decompresses plaintext after decrypting", func(t *testing.T) {
+		var gz bytes.Buffer
+		zw := gzip.NewWriter(&gz)
+		_, _ = zw.Write([]byte("a\nb\n"))
+		_ = zw.Close()
+		path := filepath.Join(dir, "app.log.gz.enc")
+		writeTextFile(t, path, string(encryptBytes(t, "logs-2024", key, gz.Bytes())))
+
+		got, err := decryptLines(path)
+		if err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("rejects wrong keys, tampering and truncation", func(t *testing.T) {
+		plain := bytes.Repeat([]byte("0123456789abcdef\n"), DefaultEncryptChunkSize/8)
+		sealed := encryptBytes(t, "logs-2024", key, plain)
+		headerSize := len(encryptedMagic) + 1 + len("logs-2024") + 4 + encryptNoncePrefixSize
+		chunk := DefaultEncryptChunkSize + 16
+
+		tampered := bytes.Clone(sealed)
+		tampered[len(tampered)-20] ^= 1
+		otherKey := encryptBytes(t, "logs-2024", bytes.Repeat([]byte{8}, 32), plain)
+
+		tests := []struct {
+			name    string
+			content []byte
+			want    error
+		}{
+			{"tampered", tampered, errDecryptFailed},
+			{"wrong key", otherKey, errDecryptFailed},
+			{"cut at chunk boundary", sealed[:headerSize+chunk], errEncryptedTruncated},
+			{"cut inside chunk", sealed[:headerSize+chunk+100], errDecryptFailed},
+			{"plaintext", []byte("hello\n"), errEncryptedMagic},
+		}
+		for _, tt := range tests {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_")+".enc")
+			if err := os.WriteFile(path, tt.content, 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if _, err := decryptLines(path); !errors.Is(err, tt.want) {
+				t.Fatalf("%s: Err() = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+
+		path := filepath.Join(dir, "unknown.enc")
+		writeTextFile(t, path, string(encryptBytes(t, "other", key, plain)))
+		if _, err := decryptLines(path); err == nil || !strings.Contains(err.Error(), `key "other"`) {
+			t.Fatalf("Err() = %v, want unknown key error", err)
+		}
+	})
+}
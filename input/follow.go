@@ -0,0 +1,201 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// defaultFollowPollInterval is how often a followed file is checked for new
+// data when FollowOptions.PollInterval is not set.
+const defaultFollowPollInterval = 250 * time.Millisecond
+
+// FollowOptions configures NewFollowLineStream.
+type FollowOptions struct {
+	// PollInterval is the wait between checks once the end of the file has
+	// been reached. Defaults to 250ms.
+	PollInterval time.Duration
+	// StartAtEnd skips the existing content and yields only appended lines.
+	StartAtEnd bool
+
+	// atEOF, when set, runs each time the end of the file is reached, before
+	// it is checked for rotation. Tests use it to change the file there.
+	atEOF func()
+}
+
+// NewFollowLineStream reads path line by line like "tail -F": after reaching
+// the end of the file it keeps polling for appended data and yields lines
+// until ctx is cancelled. A partially written final line is held back until
+// its newline arrives.
+//
+// Truncation (the file shrinking below the read offset) restarts reading from
+// the beginning. Rotation (path now naming a different file) finishes the old
+// file, reading it to its end once more for lines written just before the
+// switch, and continues with the new one from its start.
+// Cancellation ends the stream without an error.
+func NewFollowLineStream(ctx context.Context, path string, opts FollowOptions) FileLineStream {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultFollowPollInterval
+	}
+	var state runErrState
+
+	seq := func(yield func(string) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		f := &followedFile{path: path}
+		if err := f.open(opts.StartAtEnd); err != nil {
+			setFirstErr(&runErr, err)
+			return
+		}
+		defer f.close()
+
+		var pending []byte
+		for ctx.Err() == nil {
+			chunk, readErr := f.reader.ReadBytes('\n')
+			f.offset += int64(len(chunk))
+			pending = append(pending, chunk...)
+			if readErr == nil {
+				line := trimLineEnding(string(pending))
+				pending = pending[:0]
+				if !yield(line) {
+					return
+				}
+				continue
+			}
+			if readErr != io.EOF {
+				setFirstErr(&runErr, fmt.Errorf("read %s: %w", path, readErr))
+				return
+			}
+
+			if opts.atEOF != nil {
+				opts.atEOF()
+			}
+			change, err := f.checkRotation()
+			if err != nil {
+				setFirstErr(&runErr, err)
+				return
+			}
+			switch change {
+			case fileRotating:
+				continue
+			case fileRotated:
+				// The old file is complete, so its unterminated last line is final.
+				if len(pending) > 0 {
+					line := trimLineEnding(string(pending))
+					pending = pending[:0]
+					if !yield(line) {
+						return
+					}
+				}
+				continue
+			case fileTruncated:
+				pending = pending[:0]
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+	}
+
+	return FileLineStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// followedFile tracks the file currently being followed.
+type followedFile struct {
+	path   string
+	file   *os.File
+	info   os.FileInfo
+	reader *bufio.Reader
+	offset int64
+	// draining is set once rotation has been seen and the old file is being
+	// read to its end one last time.
+	draining bool
+}
+
+func (f *followedFile) open(atEnd bool) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat %s: %w", f.path, err)
+	}
+
+	f.offset = 0
+	if atEnd {
+		if f.offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("seek %s: %w", f.path, err)
+		}
+	}
+	f.close()
+	f.file = file
+	f.info = info
+	f.reader = bufio.NewReader(file)
+	return nil
+}
+
+func (f *followedFile) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+type fileChange int
+
+const (
+	fileUnchanged fileChange = iota
+	fileRotating
+	fileRotated
+	fileTruncated
+)
+
+// checkRotation reopens or rewinds the file when it was rotated or truncated.
+// A path that is temporarily missing mid-rotation is waited for. On rotation
+// it first reports fileRotating, so the caller reads what was appended to the
+// old file since its last EOF, and reopens the path at the next EOF.
+func (f *followedFile) checkRotation() (fileChange, error) {
+	current, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		return fileUnchanged, nil
+	}
+	if err != nil {
+		return fileUnchanged, fmt.Errorf("stat %s: %w", f.path, err)
+	}
+
+	if !os.SameFile(f.info, current) {
+		if !f.draining {
+			f.draining = true
+			return fileRotating, nil
+		}
+		f.draining = false
+		return fileRotated, f.open(false)
+	}
+	if current.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return fileUnchanged, fmt.Errorf("seek %s: %w", f.path, err)
+		}
+		f.offset = 0
+		f.reader.Reset(f.file)
+		return fileTruncated, nil
+	}
+	return fileUnchanged, nil
+}
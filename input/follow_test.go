@@ -0,0 +1,187 @@
+package input
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// followLines runs a follow stream in the background and returns a channel
+// of the lines it yields.
+func followLines(source FileLineStream) <-chan string {
+	lines := make(chan string, 100)
+	go func() {
+		defer close(lines)
+		for line := range source.Seq {
+			lines <- line
+		}
+	}()
+	return lines
+}
+
+func expectLines(t *testing.T, lines <-chan string, want ...string) {
+	t.Helper()
+	got := []string{}
+	timeout := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended after %v, want %v", got, want)
+			}
+			got = append(got, line)
+		case <-timeout:
+			t.Fatalf("timed out after %v, want %v", got, want)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("lines = %v, want %v", got, want)
+	}
+}
+
+func appendTextFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("append %s: %v", path, err)
+	}
+}
+
+func TestNewFollowLineStream(t *testing.T) {
+	opts := FollowOptions{PollInterval: 5 * time.Millisecond}
+
+	t.Run("yields existing and appended lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		writeTextFile(t, path, "a1\na2\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		source := NewFollowLineStream(ctx, path, opts)
+		lines := followLines(source)
+
+		expectLines(t, lines, "a1", "a2")
+		appendTextFile(t, path, "a3\npart")
+		expectLines(t, lines, "a3")
+		appendTextFile(t, path, "ial\n")
+		expectLines(t, lines, "partial")
+
+		cancel()
+		if _, ok := <-lines; ok {
+			t.Fatal("stream yielded after cancel")
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("starts at end when requested", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		writeTextFile(t, path, "old\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := followLines(NewFollowLineStream(ctx, path, FollowOptions{PollInterval: 5 * time.Millisecond, StartAtEnd: true}))
+
+		time.Sleep(100 * time.Millisecond)
+		appendTextFile(t, path, "new\n")
+		expectLines(t, lines, "new")
+	})
+
+	t.Run("restarts after truncation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		writeTextFile(t, path, "before-1\nbefore-2\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := followLines(NewFollowLineStream(ctx, path, opts))
+
+		expectLines(t, lines, "before-1", "before-2")
+		writeTextFile(t, path, "after\n")
+		expectLines(t, lines, "after")
+	})
+
+	t.Run("follows rotation to the new file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		writeTextFile(t, path, "r1\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := followLines(NewFollowLineStream(ctx, path, opts))
+
+		expectLines(t, lines, "r1")
+		appendTextFile(t, path, "r2\n")
+		if err := os.Rename(path, filepath.Join(dir, "app.log.1")); err != nil {
+			t.Fatalf("rename: %v", err)
+		}
+		writeTextFile(t, path, "n1\nn2\n")
+		expectLines(t, lines, "r2", "n1", "n2")
+	})
+
+	t.Run("drains lines appended to the old file before rotation", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		writeTextFile(t, path, "r1\npar")
+
+		// At the first EOF the writer finishes the old file and rotates it,
+		// so the stream sees the rotation after data it has not read yet.
+		var rotateErr error
+		rotated := false
+		rotate := func() {
+			if rotated {
+				return
+			}
+			rotated = true
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				rotateErr = err
+				return
+			}
+			_, rotateErr = f.WriteString("tial\nr2\n")
+			_ = f.Close()
+			if rotateErr == nil {
+				rotateErr = os.Rename(path, filepath.Join(dir, "app.log.1"))
+			}
+			if rotateErr == nil {
+				rotateErr = os.WriteFile(path, []byte("n1\n"), 0o644)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := followLines(NewFollowLineStream(ctx, path, FollowOptions{PollInterval: 5 * time.Millisecond, atEOF: rotate}))
+
+		expectLines(t, lines, "r1", "partial", "r2", "n1")
+		if rotateErr != nil {
+			t.Fatalf("rotate: %v", rotateErr)
+		}
+	})
+
+	t.Run("reports missing file", func(t *testing.T) {
+		source := NewFollowLineStream(context.Background(), filepath.Join(t.TempDir(), "missing.log"), opts)
+		_ = stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+
+	t.Run("consumer can stop the stream", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		writeTextFile(t, path, "x\ny\nz\n")
+
+		source := NewFollowLineStream(context.Background(), path, opts)
+		got := stream.Stream(source.Seq, stream.Take(2, stream.End(stream.Collect[string]())))
+		if !reflect.DeepEqual(got, []string{"x", "y"}) {
+			t.Fatalf("Stream() = %v, want [x y]", got)
+		}
+	})
+}
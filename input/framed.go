@@ -0,0 +1,142 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	errNoFrameFuncs     = errors.New("FramedParser requires Split and Decode")
+	errLengthPrefixSize = errors.New("length prefix must be 1, 2, 4 or 8 bytes")
+	errFrameSize        = errors.New("frame size must be positive")
+	errEmptyDelimiter   = errors.New("frame delimiter must not be empty")
+)
+
+// defaultMaxFrameSize bounds a single frame when FramedParser.MaxFrameSize is
+// not set.
+const defaultMaxFrameSize = 1 << 20
+
+// FramedParser parses binary files made of frames. Split cuts the byte stream
+// into frames, in the manner of bufio.Scanner, and Decode turns each frame
+// into a record. The frame slice is only valid during Decode, so decoders that
+// keep it must copy it.
+//
+// LengthPrefixFrames, DelimitedFrames and FixedSizeFrames cover common
+// layouts; any bufio.SplitFunc works.
+type FramedParser[T any] struct {
+	Split  bufio.SplitFunc
+	Decode func(frame []byte) (T, error)
+	// MaxFrameSize is the largest frame accepted, in bytes. Defaults to 1 MiB.
+	MaxFrameSize int
+}
+
+func (p FramedParser[T]) Parse(_ string, r io.Reader, yield func(T) bool) error {
+	if p.Split == nil || p.Decode == nil {
+		return errNoFrameFuncs
+	}
+	maxSize := p.MaxFrameSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxFrameSize
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(maxSize, 64*1024)), maxSize)
+	scanner.Split(p.Split)
+	frame := 0
+	for scanner.Scan() {
+		v, err := p.Decode(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", frame, err)
+		}
+		if !yield(v) {
+			return nil
+		}
+		frame++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("frame %d: %w", frame, err)
+	}
+	return nil
+}
+
+// NewFileFramedStream provides framed binary input decoded into T by composing
+// FileStream -> FramedParser -> transform pipeline.
+func NewFileFramedStream[T any](paths []string, split bufio.SplitFunc, decode func(frame []byte) (T, error)) Input[T] {
+	return ParseFiles[T](NewFileStream(paths), FramedParser[T]{Split: split, Decode: decode})
+}
+
+// LengthPrefixFrames splits frames that start with an unsigned length of
+// prefixSize bytes (1, 2, 4 or 8) in the given byte order. Frames exclude the
+// prefix. Data ending inside a frame is reported as io.ErrUnexpectedEOF.
+func LengthPrefixFrames(prefixSize int, order binary.ByteOrder) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if prefixSize != 1 && prefixSize != 2 && prefixSize != 4 && prefixSize != 8 {
+			return 0, nil, errLengthPrefixSize
+		}
+		if len(data) < prefixSize {
+			return 0, nil, incompleteFrame(data, atEOF)
+		}
+
+		var n uint64
+		switch prefixSize {
+		case 1:
+			n = uint64(data[0])
+		case 2:
+			n = uint64(order.Uint16(data))
+		case 4:
+			n = uint64(order.Uint32(data))
+		case 8:
+			n = order.Uint64(data)
+		}
+		if n > uint64(len(data)-prefixSize) {
+			// Frames larger than MaxFrameSize end in bufio.ErrTooLong.
+			return 0, nil, incompleteFrame(data, atEOF)
+		}
+		end := prefixSize + int(n)
+		return end, data[prefixSize:end], nil
+	}
+}
+
+// DelimitedFrames splits frames terminated by delim, which is not part of the
+// frame. A final frame without a trailing delimiter is returned as is. An
+// empty delim fails the file.
+func DelimitedFrames(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(delim) == 0 {
+			return 0, nil, errEmptyDelimiter
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// FixedSizeFrames splits the stream into frames of exactly size bytes. A short
+// final frame is reported as io.ErrUnexpectedEOF.
+func FixedSizeFrames(size int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if size <= 0 {
+			return 0, nil, errFrameSize
+		}
+		if len(data) >= size {
+			return size, data[:size], nil
+		}
+		return 0, nil, incompleteFrame(data, atEOF)
+	}
+}
+
+// incompleteFrame asks for more data, or reports truncation at EOF.
+func incompleteFrame(data []byte, atEOF bool) error {
+	if atEOF && len(data) > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
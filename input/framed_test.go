@@ -0,0 +1,115 @@
+package input
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewFileFramedStream(t *testing.T) {
+	decodeString := func(frame []byte) (string, error) { return string(frame), nil }
+
+	t.Run("length-prefixed frames across files", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.bin")
+		fileB := filepath.Join(dir, "b.bin")
+
+		var content []byte
+		for _, frame := range []string{"alpha", "", "beta\nwith newline"} {
+			content = binary.BigEndian.AppendUint16(content, uint16(len(frame)))
+			content = append(content, frame...)
+		}
+		writeTextFile(t, fileA, string(content))
+		writeTextFile(t, fileB, "\x00\x05gamma")
+
+		source := NewFileFramedStream([]string{fileA, fileB}, LengthPrefixFrames(2, binary.BigEndian), decodeString)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"alpha", "", "beta\nwith newline", "gamma"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("delimited and fixed-size frames", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.bin")
+		writeTextFile(t, file, "one\xff\xfetwo\xff\xfethree")
+
+		source := NewFileFramedStream([]string{file}, DelimitedFrames([]byte{0xff, 0xfe}), decodeString)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+
+		type pair struct{ A, B byte }
+		writeTextFile(t, file, "abcdef")
+		pairs := NewFileFramedStream([]string{file}, FixedSizeFrames(2), func(frame []byte) (pair, error) {
+			return pair{frame[0], frame[1]}, nil
+		})
+		gotPairs := stream.Stream(pairs.Seq, stream.End(stream.Collect[pair]()))
+		if want := []pair{{'a', 'b'}, {'c', 'd'}, {'e', 'f'}}; !reflect.DeepEqual(gotPairs, want) {
+			t.Fatalf("Stream() = %v, want %v", gotPairs, want)
+		}
+		if err := pairs.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+
+		empty := NewFileFramedStream([]string{file}, DelimitedFrames(nil), decodeString)
+		stream.Stream(empty.Seq, stream.End(stream.Collect[string]()))
+		if err := empty.Err(); !errors.Is(err, errEmptyDelimiter) {
+			t.Fatalf("Err() = %v, want %v", err, errEmptyDelimiter)
+		}
+	})
+
+	t.Run("truncated frame is an error", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.bin")
+		writeTextFile(t, file, "\x03abc\x05ab")
+
+		source := NewFileFramedStream([]string{file}, LengthPrefixFrames(1, nil), decodeString)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if want := []string{"abc"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+		if err := source.Err(); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("Err() = %v, want io.ErrUnexpectedEOF", err)
+		}
+	})
+
+	t.Run("decode errors and oversized frames", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.bin")
+		writeTextFile(t, file, "ok;bad;ok")
+
+		source := NewFileFramedStream([]string{file}, DelimitedFrames([]byte(";")), func(frame []byte) (string, error) {
+			if string(frame) == "bad" {
+				return "", fmt.Errorf("bad frame")
+			}
+			return string(frame), nil
+		})
+		stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if err := source.Err(); err == nil || !strings.Contains(err.Error(), "frame 1: bad frame") {
+			t.Fatalf("Err() = %v, want frame 1 decode error", err)
+		}
+
+		writeTextFile(t, file, "\x00\x00\x10\x00"+strings.Repeat("x", 4096))
+		parser := FramedParser[string]{Split: LengthPrefixFrames(4, binary.BigEndian), Decode: decodeString, MaxFrameSize: 1024}
+		limited := ParseFiles[string](NewFileStream([]string{file}), parser)
+		stream.Stream(limited.Seq, stream.End(stream.Collect[string]()))
+		if err := limited.Err(); !errors.Is(err, bufio.ErrTooLong) {
+			t.Fatalf("Err() = %v, want bufio.ErrTooLong", err)
+		}
+	})
+}
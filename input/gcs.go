@@ -0,0 +1,119 @@
+//go:build objectstore
+
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// GCSConfig describes how to reach Google Cloud Storage through its JSON API.
+type GCSConfig struct {
+	// Endpoint defaults to https://storage.googleapis.com. Set it to point at
+	// an emulator such as fake-gcs-server.
+	Endpoint string
+	// AccessToken is sent as an OAuth2 bearer token when set. Alternatively,
+	// pass an HTTPClient that authenticates requests itself.
+	AccessToken string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// GCSConfigFromEnv reads STORAGE_EMULATOR_HOST and GOOGLE_OAUTH_ACCESS_TOKEN.
+func GCSConfigFromEnv() GCSConfig {
+	cfg := GCSConfig{AccessToken: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")}
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		cfg.Endpoint = host
+	}
+	return cfg
+}
+
+// gcsInput is a FileInput for one Cloud Storage object.
+type gcsInput struct {
+	cfg    GCSConfig
+	bucket string
+	name   string
+}
+
+func (f gcsInput) Path() string {
+	return "gs://" + f.bucket + "/" + f.name
+}
+
+// Open streams the object media; compressed objects are decompressed as for
+// local files.
+func (f gcsInput) Open() (io.ReadCloser, error) {
+	resp, err := f.cfg.get("/storage/v1/b/"+url.PathEscape(f.bucket)+"/o/"+url.PathEscape(f.name), url.Values{"alt": {"media"}})
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.name, resp.Body)
+}
+
+// NewGCSStream lists objects under prefix lazily, one page at a time, and
+// yields each object as a FileInput in the lexical order GCS lists them.
+// "Directory" placeholder objects ending in "/" are skipped. It is only
+// built with the objectstore build tag.
+func NewGCSStream(cfg GCSConfig, bucket, prefix string) FileStream {
+	return newPagedFileStream(func(token string) ([]FileInput, string, error) {
+		query := url.Values{}
+		query.Set("prefix", prefix)
+		query.Set("fields", "items(name),nextPageToken")
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+
+		resp, err := cfg.get("/storage/v1/b/"+url.PathEscape(bucket)+"/o", query)
+		if err != nil {
+			return nil, "", fmt.Errorf("list gs://%s/%s: %w", bucket, prefix, err)
+		}
+		defer resp.Body.Close()
+
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			return nil, "", fmt.Errorf("list gs://%s/%s: %w", bucket, prefix, err)
+		}
+
+		inputs := make([]FileInput, 0, len(page.Items))
+		for _, obj := range page.Items {
+			if strings.HasSuffix(obj.Name, "/") {
+				continue
+			}
+			inputs = append(inputs, gcsInput{cfg: cfg, bucket: bucket, name: obj.Name})
+		}
+		return inputs, page.NextPageToken, nil
+	})
+}
+
+func (cfg GCSConfig) get(escapedPath string, query url.Values) (*http.Response, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/") + escapedPath)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.AccessToken)
+	}
+	return doObjectRequest(cfg.HTTPClient, req)
+}
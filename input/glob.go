@@ -0,0 +1,118 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewGlobFileStream creates a lazy file stream from shell glob patterns.
+// Besides the filepath.Match syntax, a "**" path segment matches zero or more
+// directories, and a trailing "**" matches every file below that point.
+// Directories are expanded only while the stream is consumed. Matches are
+// yielded per pattern in lexical walk order, each file at most once.
+// Patterns matching nothing are not an error.
+func NewGlobFileStream(patterns ...string) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		seen := map[string]struct{}{}
+		emit := func(path string) bool {
+			if _, ok := seen[path]; ok {
+				return true
+			}
+			seen[path] = struct{}{}
+			return yield(localFileInput{path: path})
+		}
+
+		for _, pattern := range patterns {
+			base, segments := splitGlobPattern(pattern)
+			for _, segment := range segments {
+				if _, err := filepath.Match(segment, ""); err != nil {
+					setFirstErr(&runErr, fmt.Errorf("glob %s: %w", pattern, err))
+					return
+				}
+			}
+			if !globWalk(base, segments, emit) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// splitGlobPattern separates the root a pattern is relative to from its
+// path segments.
+func splitGlobPattern(pattern string) (string, []string) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	volume := filepath.VolumeName(pattern)
+	pattern = pattern[len(volume):]
+	base := volume + "."
+	if strings.HasPrefix(pattern, "/") {
+		base = volume + "/"
+		pattern = strings.TrimLeft(pattern, "/")
+	}
+
+	segments := strings.Split(pattern, "/")
+	if segments[len(segments)-1] == "**" {
+		segments = append(segments, "*")
+	}
+	return base, segments
+}
+
+// globWalk yields regular files below dir that match segments.
+// It returns false once yield asks to stop.
+// Unreadable directories are skipped, as filepath.Glob does.
+func globWalk(dir string, segments []string, yield func(string) bool) bool {
+	if len(segments) == 0 {
+		if info, err := os.Stat(dir); err == nil && info.Mode().IsRegular() {
+			return yield(dir)
+		}
+		return true
+	}
+
+	segment, rest := segments[0], segments[1:]
+	if segment == "**" {
+		if !globWalk(dir, rest, yield) {
+			return false
+		}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if entry.IsDir() && !globWalk(filepath.Join(dir, entry.Name()), segments, yield) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !hasGlobMeta(segment) {
+		return globWalk(filepath.Join(dir, segment), rest, yield)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if matched, _ := filepath.Match(segment, entry.Name()); !matched {
+			continue
+		}
+		if !globWalk(filepath.Join(dir, entry.Name()), rest, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasGlobMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
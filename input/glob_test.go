@@ -0,0 +1,109 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func globFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, sub := range []string{"sub/deep", "other"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", sub, err)
+		}
+	}
+	writeTextFile(t, filepath.Join(dir, "a.log"), "a\n")
+	writeTextFile(t, filepath.Join(dir, "b.txt"), "b\n")
+	writeTextFile(t, filepath.Join(dir, "sub", "c.log"), "c\n")
+	writeTextFile(t, filepath.Join(dir, "sub", "deep", "d.log"), "d\n")
+	writeTextFile(t, filepath.Join(dir, "other", "e.log"), "e\n")
+	return dir
+}
+
+func globPaths(t *testing.T, dir string, patterns ...string) []string {
+	t.Helper()
+	files := NewGlobFileStream(patterns...)
+	got := stream.Stream(
+		files.Seq,
+		stream.Map(func(f FileInput) string {
+			rel, err := filepath.Rel(dir, f.Path())
+			if err != nil {
+				t.Fatalf("Rel(%s): %v", f.Path(), err)
+			}
+			return filepath.ToSlash(rel)
+		},
+			stream.End(stream.Collect[string]()),
+		),
+	)
+	if err := files.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	return got
+}
+
+func TestNewGlobFileStream(t *testing.T) {
+	dir := globFixture(t)
+
+	cases := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "single-level wildcard",
+			patterns: []string{filepath.Join(dir, "*.log")},
+			want:     []string{"a.log"},
+		},
+		{
+			name:     "double star matches any depth",
+			patterns: []string{filepath.Join(dir, "**", "*.log")},
+			want:     []string{"a.log", "other/e.log", "sub/c.log", "sub/deep/d.log"},
+		},
+		{
+			name:     "trailing double star matches all files below",
+			patterns: []string{filepath.Join(dir, "sub", "**")},
+			want:     []string{"sub/c.log", "sub/deep/d.log"},
+		},
+		{
+			name:     "patterns keep order and skip duplicates",
+			patterns: []string{filepath.Join(dir, "*.txt"), filepath.Join(dir, "**", "*")},
+			want:     []string{"b.txt", "a.log", "other/e.log", "sub/c.log", "sub/deep/d.log"},
+		},
+		{
+			name:     "no match is empty",
+			patterns: []string{filepath.Join(dir, "*.csv")},
+			want:     []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := globPaths(t, dir, tc.patterns...)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("NewGlobFileStream() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("works with parsing", func(t *testing.T) {
+		source := ParseFiles[string](NewGlobFileStream(filepath.Join(dir, "sub", "**", "*.log")), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		want := []string{"c", "d"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("reports bad pattern", func(t *testing.T) {
+		files := NewGlobFileStream(filepath.Join(dir, "["))
+		_ = stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
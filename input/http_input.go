@@ -0,0 +1,80 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// httpInput is a FileInput backed by an HTTP(S) GET request.
+type httpInput struct {
+	url    string
+	client *http.Client
+}
+
+func (f httpInput) Path() string {
+	return f.url
+}
+
+// Open issues the GET request and returns the response body. Non-2xx
+// responses are reported as errors. Compressed bodies are decompressed
+// as for local files.
+func (f httpInput) Open() (io.ReadCloser, error) {
+	resp, err := f.client.Get(f.url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	u, err := url.Parse(f.url)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	return openDecompressed(u.Path, resp.Body)
+}
+
+// NewURLStream creates a lazy stream of HTTP(S) inputs in URL order.
+// Each body is fetched only when it is parsed, so it composes with ParseFiles
+// like local files. A nil client uses http.DefaultClient; set its Timeout to
+// bound each request. Status and transport errors surface through Err.
+func NewURLStream(urls []string, client *http.Client) FileStream {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for _, rawURL := range urls {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				setFirstErr(&runErr, fmt.Errorf("parse url %s: %w", rawURL, err))
+				return
+			}
+			if u.Scheme != "http" && u.Scheme != "https" {
+				setFirstErr(&runErr, fmt.Errorf("parse url %s: unsupported scheme %q", rawURL, u.Scheme))
+				return
+			}
+
+			if !yield(httpInput{url: rawURL, client: client}) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package input
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewURLStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.csv":
+			_, _ = w.Write([]byte("apple,2\nbanana,1\n"))
+		case "/b.csv":
+			_, _ = w.Write([]byte("orange,3\n"))
+		case "/c.csv.gz":
+			_, _ = w.Write(gzipBytes(t, "grape,4\n"))
+		case "/slow.csv":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte("late,0\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("parses remote CSV bodies in order", func(t *testing.T) {
+		source := ParseFiles[[]string](
+			NewURLStream([]string{server.URL + "/a.csv", server.URL + "/b.csv", server.URL + "/c.csv.gz"}, nil),
+			CSVParser{},
+		)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{{"apple", "2"}, {"banana", "1"}, {"orange", "3"}, {"grape", "4"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports non-2xx status", func(t *testing.T) {
+		source := ParseFiles[string](
+			NewURLStream([]string{server.URL + "/a.csv", server.URL + "/missing.csv"}, nil),
+			LineParser{},
+		)
+		got := stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+
+		if got != 2 {
+			t.Fatalf("Count() = %d, want 2", got)
+		}
+		err := source.Err()
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Fatalf("Err() = %v, want 404 status error", err)
+		}
+	})
+
+	t.Run("reports client timeout", func(t *testing.T) {
+		client := &http.Client{Timeout: 20 * time.Millisecond}
+		source := ParseFiles[string](NewURLStream([]string{server.URL + "/slow.csv"}, client), LineParser{})
+		_ = stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want timeout error")
+		}
+	})
+
+	t.Run("rejects unsupported scheme", func(t *testing.T) {
+		files := NewURLStream([]string{"ftp://example.com/a.csv"}, nil)
+		_ = stream.Stream(files.Seq, stream.End(stream.Count[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
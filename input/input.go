@@ -0,0 +1,632 @@
+// Package input provides the sources, parsers and sinks that feed and drain
+// stream pipelines.
+//
+// Local files compressed with gzip or bzip2 are decompressed transparently.
+// zstd files are recognized, but the package ships no zstd decoder: reading
+// one fails until a decoder is installed with RegisterDecompressor.
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"strings"
+	"sync"
+)
+
+type runErrState struct {
+	mu   sync.RWMutex
+	last error
+}
+
+func (s *runErrState) Set(err error) {
+	s.mu.Lock()
+	s.last = err
+	s.mu.Unlock()
+}
+
+func (s *runErrState) Get() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+func setFirstErr(dst *error, err error) {
+	if err != nil && *dst == nil {
+		*dst = err
+	}
+}
+
+// noEOF reports a clean EOF in the middle of a structure as truncation.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Input provides a lazy sequence with per-run error reporting.
+type Input[T any] struct {
+	Seq iter.Seq[T]
+	Err func() error
+}
+
+// FileStream provides a lazy file reference sequence.
+type FileStream = Input[FileInput]
+
+// FileLineStream keeps backward-compatible naming for line-oriented input.
+type FileLineStream = Input[string]
+
+// FileCSVStream provides CSV record input where each record is []string.
+type FileCSVStream = Input[[]string]
+
+// FileInput is the interface passed from file stream to parsing.
+// It abstracts how a file is opened, so parsing can focus on decoding logic.
+type FileInput interface {
+	Path() string
+	Open() (io.ReadCloser, error)
+}
+
+type localFileInput struct {
+	path string
+}
+
+func (f localFileInput) Path() string {
+	return f.path
+}
+
+// Open returns the file content, transparently decompressing gzip and bzip2
+// files (see NewDecompressingFileInput).
+func (f localFileInput) Open() (io.ReadCloser, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.path, file)
+}
+
+// FileParser abstracts parser implementations for any file format.
+type FileParser[T any] interface {
+	Parse(path string, r io.Reader, yield func(T) bool) error
+}
+
+func trimLineEnding(line string) string {
+	trimmed := strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(trimmed, "\r")
+}
+
+// NewFileStream creates a lazy file reference stream in path order.
+// It validates each file exists before yielding it.
+func NewFileStream(paths []string) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				setFirstErr(&runErr, fmt.Errorf("stat %s: %w", path, err))
+				return
+			}
+
+			if !yield(localFileInput{path: path}) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+type fsFileInput struct {
+	fsys fs.FS
+	path string
+}
+
+func (f fsFileInput) Path() string {
+	return f.path
+}
+
+func (f fsFileInput) Open() (io.ReadCloser, error) {
+	file, err := f.fsys.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.path, file)
+}
+
+// NewFSFileStream creates a lazy file reference stream over an fs.FS, such as
+// embed.FS, a zip archive or fstest.MapFS, in path order.
+// Paths use fs.FS syntax (slash-separated, unrooted). It validates each file
+// exists before yielding it.
+func NewFSFileStream(fsys fs.FS, paths []string) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for _, path := range paths {
+			if _, err := fs.Stat(fsys, path); err != nil {
+				setFirstErr(&runErr, fmt.Errorf("stat %s: %w", path, err))
+				return
+			}
+
+			if !yield(fsFileInput{fsys: fsys, path: path}) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// ParseFiles creates a parsed input stream by connecting a FileStream and a FileParser.
+// This is the boundary between file streaming and format parsing.
+func ParseFiles[T any](files FileStream, parser FileParser[T]) Input[T] {
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for file := range files.Seq {
+			consumerStopped, err := parseFileWith[T](file, parser, yield)
+			setFirstErr(&runErr, err)
+			if consumerStopped {
+				return
+			}
+			if runErr != nil {
+				return
+			}
+		}
+		if sourceErr := files.Err(); sourceErr != nil {
+			setFirstErr(&runErr, sourceErr)
+		}
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// minReadBufferSize is bufio's default size. bufio.NewReader wraps a smaller
+// *bufio.Reader in a new buffer of this size instead of reusing it.
+const minReadBufferSize = 4096
+
+// readBufferSize returns the size of r when it is a *bufio.Reader, such as
+// the one parseFileBuffered hands to parsers, and bufio's default otherwise.
+func readBufferSize(r io.Reader) int {
+	if br, ok := r.(*bufio.Reader); ok {
+		return max(br.Size(), minReadBufferSize)
+	}
+	return minReadBufferSize
+}
+
+func parseFileWith[T any](file FileInput, parser FileParser[T], yield func(T) bool) (consumerStopped bool, err error) {
+	return parseFileBuffered(file, parser, 0, yield)
+}
+
+// parseFileBuffered is parseFileWith handing the parser a bufio.Reader of
+// bufferSize bytes, at least minReadBufferSize, when bufferSize > 0. Parsers
+// that wrap their input with bufio.NewReader then use it instead of a
+// default-sized buffer.
+func parseFileBuffered[T any](file FileInput, parser FileParser[T], bufferSize int, yield func(T) bool) (consumerStopped bool, err error) {
+	reader, openErr := file.Open()
+	if openErr != nil {
+		return false, fmt.Errorf("open %s: %w", file.Path(), openErr)
+	}
+	var src io.Reader = reader
+	if bufferSize > 0 {
+		src = bufio.NewReaderSize(reader, max(bufferSize, minReadBufferSize))
+	}
+
+	stopped := false
+	parseErr := parser.Parse(file.Path(), src, func(v T) bool {
+		if !yield(v) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if parseErr != nil {
+		setFirstErr(&err, fmt.Errorf("parse %s: %w", file.Path(), parseErr))
+	}
+	if closeErr := reader.Close(); closeErr != nil {
+		setFirstErr(&err, fmt.Errorf("close %s: %w", file.Path(), closeErr))
+	}
+
+	return stopped, err
+}
+
+// LineParser parses text files into line records.
+type LineParser struct {
+	// Encoding decodes files that are not UTF-8, for example DecodeBOM for
+	// files that may be UTF-16 with a byte order mark. Nil reads UTF-8.
+	Encoding TextDecoder
+}
+
+func (p LineParser) Parse(_ string, r io.Reader, yield func(string) bool) error {
+	size := readBufferSize(r)
+	if p.Encoding != nil {
+		r = p.Encoding(r)
+	}
+	reader := bufio.NewReaderSize(r, size)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) > 0 {
+			if !yield(trimLineEnding(line)) {
+				return nil
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// CSVParser parses CSV files and yields each record as []string.
+type CSVParser struct {
+	Comma            rune
+	Comment          rune
+	TrimLeadingSpace bool
+	FieldsPerRecord  int
+	LazyQuotes       bool
+	// SniffDelimiter picks the delimiter per file from its first line among
+	// comma, tab, semicolon and pipe. Comma applies when none of them occurs.
+	SniffDelimiter bool
+
+	// ReuseRecord yields the same slice for every record, overwritten by the
+	// next one, saving an allocation per record. Consumers must copy what
+	// they keep. CSVHeaderParser, CSVRowParser and CSVStructParser ignore it
+	// as they build new values anyway.
+	ReuseRecord bool
+
+	// Columns keeps only the named columns, in this order, so unused fields
+	// are never copied out of the reader. Names are looked up in the first
+	// record of each file, which CSVParser then yields projected as well;
+	// a name missing from the header fails the file.
+	Columns []string
+	// ColumnIndexes keeps only the fields at these zero-based positions, in
+	// this order. Columns takes precedence when both are set.
+	//
+	// With either projection, a field missing from a short record is "".
+	// CSVStructParser ignores both, as it only converts mapped columns.
+	ColumnIndexes []int
+}
+
+// sniffedDelimiters are the candidates for CSVParser.SniffDelimiter; earlier
+// entries win ties.
+var sniffedDelimiters = []rune{',', '\t', ';', '|'}
+
+// sniffLineLimit bounds how much of the first line is inspected when sniffing.
+const sniffLineLimit = 64 * 1024
+
+func (p CSVParser) newReader(r io.Reader) *csv.Reader {
+	comma := p.Comma
+	if p.SniffDelimiter {
+		br := bufio.NewReaderSize(r, sniffLineLimit)
+		// A read error here is returned again by the first csv read.
+		head, _ := br.Peek(sniffLineLimit)
+		if d := sniffDelimiter(head); d != 0 {
+			comma = d
+		}
+		r = br
+	}
+
+	reader := csv.NewReader(r)
+	if comma != 0 {
+		reader.Comma = comma
+	}
+	if p.Comment != 0 {
+		reader.Comment = p.Comment
+	}
+	reader.TrimLeadingSpace = p.TrimLeadingSpace
+	reader.FieldsPerRecord = p.FieldsPerRecord
+	reader.LazyQuotes = p.LazyQuotes
+	// Projected records are copied field by field, so the reader's own
+	// record can be reused.
+	reader.ReuseRecord = p.ReuseRecord || p.projected()
+	return reader
+}
+
+func (p CSVParser) projected() bool {
+	return len(p.Columns) > 0 || len(p.ColumnIndexes) > 0
+}
+
+// projection resolves Columns against header, or returns ColumnIndexes.
+func (p CSVParser) projection(header []string) ([]int, error) {
+	if len(p.Columns) == 0 {
+		for _, column := range p.ColumnIndexes {
+			if column < 0 {
+				return nil, fmt.Errorf("%w %d", errNegativeColumn, column)
+			}
+		}
+		return p.ColumnIndexes, nil
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	indexes := make([]int, len(p.Columns))
+	for i, name := range p.Columns {
+		column, ok := columns[name]
+		if !ok {
+			return nil, fmt.Errorf("%w %q in header", errMissingColumn, name)
+		}
+		indexes[i] = column
+	}
+	return indexes, nil
+}
+
+// projectRecord copies the fields at indexes out of record.
+func projectRecord(record []string, indexes []int) []string {
+	return projectRecordInto(nil, record, indexes)
+}
+
+// projectRecordInto is projectRecord reusing dst when it is large enough.
+func projectRecordInto(dst, record []string, indexes []int) []string {
+	if cap(dst) < len(indexes) {
+		dst = make([]string, len(indexes))
+	}
+	dst = dst[:len(indexes)]
+	for i, column := range indexes {
+		dst[i] = ""
+		if column < len(record) {
+			dst[i] = record[column]
+		}
+	}
+	return dst
+}
+
+func (p CSVParser) Parse(_ string, r io.Reader, yield func([]string) bool) error {
+	reader := p.newReader(r)
+	var indexes []int
+	var projected []string
+	if p.projected() {
+		var header []string
+		var err error
+		if len(p.Columns) > 0 {
+			header, err = readCSVHeader(reader)
+			if err != nil || header == nil {
+				return err
+			}
+		}
+		if indexes, err = p.projection(header); err != nil {
+			return err
+		}
+		if header != nil && !yield(projectRecord(header, indexes)) {
+			return nil
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case indexes != nil && p.ReuseRecord:
+			projected = projectRecordInto(projected, record, indexes)
+			record = projected
+		case indexes != nil:
+			record = projectRecord(record, indexes)
+		case !p.ReuseRecord:
+			record = append([]string(nil), record...)
+		}
+		if !yield(record) {
+			return nil
+		}
+	}
+}
+
+// sniffDelimiter returns the candidate delimiter occurring most often outside
+// quotes in the first line of head, or 0 when none occurs.
+func sniffDelimiter(head []byte) rune {
+	counts := make(map[rune]int, len(sniffedDelimiters))
+	quoted := false
+	for _, c := range string(head) {
+		if c == '"' {
+			quoted = !quoted
+		} else if !quoted && (c == '\n' || c == '\r') {
+			break
+		} else if !quoted {
+			counts[c]++
+		}
+	}
+
+	var best rune
+	bestCount := 0
+	for _, d := range sniffedDelimiters {
+		if counts[d] > bestCount {
+			best, bestCount = d, counts[d]
+		}
+	}
+	return best
+}
+
+// CSVHeaderParser parses CSV files whose first record is a header and yields
+// each following record as a map from column name to value, so columns can be
+// referenced by name regardless of their order in each file.
+// The header is read per file. With FieldsPerRecord < 0, columns missing from
+// a short record are absent from its map and extra fields are ignored.
+type CSVHeaderParser struct {
+	CSVParser
+}
+
+func (p CSVHeaderParser) Parse(_ string, r io.Reader, yield func(map[string]string) bool) error {
+	reader := p.newReader(r)
+	header, err := readCSVHeader(reader)
+	if err != nil || header == nil {
+		return err
+	}
+	var indexes []int
+	if p.projected() {
+		if indexes, err = p.projection(header); err != nil {
+			return err
+		}
+		header = projectRecord(header, indexes)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if indexes != nil {
+			record = projectRecord(record, indexes)
+		}
+
+		row := make(map[string]string, len(header))
+		for i, value := range record[:min(len(record), len(header))] {
+			row[header[i]] = value
+		}
+		if !yield(row) {
+			return nil
+		}
+	}
+}
+
+// readCSVHeader reads the header record, stripping a UTF-8 byte order mark
+// and rejecting duplicate column names. It returns nil for an empty file.
+func readCSVHeader(reader *csv.Reader) ([]string, error) {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header = append([]string(nil), header...)
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	if err := checkHeaderNames(header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// checkHeaderNames rejects headers that name a column twice.
+func checkHeaderNames(header []string) error {
+	seen := make(map[string]struct{}, len(header))
+	for _, name := range header {
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("duplicate column %q in header", name)
+		}
+		seen[name] = struct{}{}
+	}
+	return nil
+}
+
+// TSVParser parses tab-separated files, splitting each line on tabs. TSV has
+// no quoting, so quotes are ordinary characters wherever they appear, unlike
+// CSVParser with a tab Comma. Empty lines are skipped and records may have
+// different numbers of fields.
+type TSVParser struct{}
+
+func (TSVParser) Parse(path string, r io.Reader, yield func([]string) bool) error {
+	return LineParser{}.Parse(path, r, func(line string) bool {
+		if line == "" {
+			return true
+		}
+		return yield(strings.Split(line, "\t"))
+	})
+}
+
+// JSONLinesParser parses JSON-lines files, decoding each line into T.
+// Blank lines are skipped.
+type JSONLinesParser[T any] struct{}
+
+func (JSONLinesParser[T]) Parse(_ string, r io.Reader, yield func(T) bool) error {
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+			if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+				var v T
+				if err := json.Unmarshal(trimmed, &v); err != nil {
+					return fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				if !yield(v) {
+					return nil
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// NewFileLineStream keeps the old line-oriented API and now composes
+// FileStream -> LineParser -> transform pipeline.
+func NewFileLineStream(paths []string) FileLineStream {
+	return ParseFiles[string](NewFileStream(paths), LineParser{})
+}
+
+// NewFileCSVStream provides CSV input by composing
+// FileStream -> CSVParser -> transform pipeline.
+func NewFileCSVStream(paths []string) FileCSVStream {
+	return ParseFiles[[]string](NewFileStream(paths), CSVParser{})
+}
+
+// NewFileTSVStream provides tab-separated input by composing
+// FileStream -> TSVParser -> transform pipeline.
+func NewFileTSVStream(paths []string) FileCSVStream {
+	return ParseFiles[[]string](NewFileStream(paths), TSVParser{})
+}
+
+// NewFileJSONLStream provides JSON-lines input decoded into T by composing
+// FileStream -> JSONLinesParser -> transform pipeline.
+func NewFileJSONLStream[T any](paths []string) Input[T] {
+	return ParseFiles[T](NewFileStream(paths), JSONLinesParser[T]{})
+}
+
+// NewFileCSVHeaderStream provides CSV input keyed by header names by composing
+// FileStream -> CSVHeaderParser -> transform pipeline.
+func NewFileCSVHeaderStream(paths []string) Input[map[string]string] {
+	return ParseFiles[map[string]string](NewFileStream(paths), CSVHeaderParser{})
+}
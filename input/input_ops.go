@@ -0,0 +1,60 @@
+package input
+
+// Filter returns an Input passing only the elements for which fn reports
+// true. Err still reports the underlying source's error.
+func (in Input[T]) Filter(fn func(T) bool) Input[T] {
+	return Input[T]{
+		Seq: func(yield func(T) bool) {
+			for v := range in.Seq {
+				if fn(v) && !yield(v) {
+					return
+				}
+			}
+		},
+		Err: in.Err,
+	}
+}
+
+// Map returns an Input with fn applied to every element. Methods cannot
+// change the element type; use MapInput for that.
+func (in Input[T]) Map(fn func(T) T) Input[T] {
+	return MapInput(in, fn)
+}
+
+// Take returns an Input that stops after the first n elements, without
+// reading the source further.
+func (in Input[T]) Take(n int) Input[T] {
+	return Input[T]{
+		Seq: func(yield func(T) bool) {
+			if n <= 0 {
+				return
+			}
+			count := 0
+			for v := range in.Seq {
+				if !yield(v) {
+					return
+				}
+				count++
+				if count >= n {
+					return
+				}
+			}
+		},
+		Err: in.Err,
+	}
+}
+
+// MapInput returns an Input of fn applied to every element of in, keeping
+// in's Err.
+func MapInput[T, U any](in Input[T], fn func(T) U) Input[U] {
+	return Input[U]{
+		Seq: func(yield func(U) bool) {
+			for v := range in.Seq {
+				if !yield(fn(v)) {
+					return
+				}
+			}
+		},
+		Err: in.Err,
+	}
+}
@@ -0,0 +1,60 @@
+package input
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInputMethodsKeepSourceErr(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	writeTextFile(t, path, "  apple \n\n banana\n   \ncherry\n")
+
+	lines := NewFileLineStream([]string{path, filepath.Join(dir, "missing.txt")}).
+		Map(strings.TrimSpace).
+		Filter(func(s string) bool { return s != "" })
+
+	got := slices.Collect(lines.Seq)
+	if !slices.Equal(got, []string{"apple", "banana", "cherry"}) {
+		t.Fatalf("Seq=%v, expected [apple banana cherry]", got)
+	}
+	if err := lines.Err(); err == nil {
+		t.Fatalf("Err() returned nil, expected missing file error")
+	}
+
+	lengths := MapInput(lines, func(s string) int { return len(s) })
+	if got := slices.Collect(lengths.Seq); !slices.Equal(got, []int{5, 6, 6}) {
+		t.Fatalf("MapInput() Seq=%v, expected [5 6 6]", got)
+	}
+	if err := lengths.Err(); err == nil {
+		t.Fatalf("MapInput() Err() returned nil, expected missing file error")
+	}
+}
+
+func TestInputTakeStopsSource(t *testing.T) {
+	pulled := 0
+	in := Input[int]{
+		Seq: func(yield func(int) bool) {
+			for i := range 10 {
+				pulled++
+				if !yield(i) {
+					return
+				}
+			}
+		},
+		Err: func() error { return nil },
+	}
+
+	got := slices.Collect(in.Filter(func(v int) bool { return v%2 == 1 }).Take(2).Seq)
+	if !slices.Equal(got, []int{1, 3}) {
+		t.Fatalf("Take(2)=%v, expected [1 3]", got)
+	}
+	if pulled != 4 {
+		t.Fatalf("pulled %d elements, expected 4", pulled)
+	}
+	if got := slices.Collect(in.Take(0).Seq); len(got) != 0 {
+		t.Fatalf("Take(0)=%v, expected empty", got)
+	}
+}
@@ -0,0 +1,486 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var errCompressedSplit = errors.New("compressed files cannot be split into byte ranges")
+
+// parallelBatchSize is the number of records a ParseFilesParallel worker
+// hands to the consumer at once.
+const parallelBatchSize = 256
+
+// fileRangeInput is a FileInput covering bytes [start, end) of a local file.
+type fileRangeInput struct {
+	path  string
+	start int64
+	end   int64
+}
+
+func (f fileRangeInput) Path() string {
+	return f.path
+}
+
+func (f fileRangeInput) Open() (io.ReadCloser, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, f.start, f.end-f.start), file}, nil
+}
+
+// NewFileRangeStream splits one line-oriented file into up to n byte ranges
+// and yields each range as a FileInput. Range boundaries are moved forward to
+// the byte after the next newline, so no line is split across ranges.
+// Fewer than n ranges are produced when lines are longer than a range.
+// Ranges are raw bytes, so compressed files are rejected rather than
+// decompressed as NewFileStream would.
+func NewFileRangeStream(path string, n int) FileStream {
+	n = max(n, 1)
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		bounds, err := lineAlignedBounds(path, n)
+		if err != nil {
+			setFirstErr(&runErr, err)
+			return
+		}
+		for i := 0; i+1 < len(bounds); i++ {
+			if !yield(fileRangeInput{path: path, start: bounds[i], end: bounds[i+1]}) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// lineAlignedBounds returns ascending offsets starting at 0 and ending at
+// the file size, each one placed right after a newline.
+func lineAlignedBounds(path string, n int) ([]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := checkSplittable(file, path); err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	size := info.Size()
+
+	bounds := []int64{0}
+	for i := 1; i < n; i++ {
+		nominal := size * int64(i) / int64(n)
+		if nominal <= bounds[len(bounds)-1] {
+			continue
+		}
+		next, err := nextLineStart(file, nominal, size)
+		if err != nil {
+			return nil, fmt.Errorf("split %s: %w", path, err)
+		}
+		if next >= size {
+			break
+		}
+		if next > bounds[len(bounds)-1] {
+			bounds = append(bounds, next)
+		}
+	}
+	if size > 0 {
+		bounds = append(bounds, size)
+	}
+	return bounds, nil
+}
+
+// checkSplittable fails for files NewFileStream would decompress, whose byte
+// ranges are not lines or records.
+func checkSplittable(file *os.File, path string) error {
+	header := make([]byte, sniffHeaderLen)
+	n, _ := file.ReadAt(header, 0)
+	if format := detectCompression(path, header[:n]); format != nil {
+		return fmt.Errorf("split %s: %w (%s)", path, errCompressedSplit, format.name)
+	}
+	return nil
+}
+
+// nextLineStart returns the first offset >= offset that begins a line.
+func nextLineStart(r io.ReaderAt, offset, size int64) (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+	pos := offset - 1
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		pos++
+		if b == '\n' {
+			return pos, nil
+		}
+	}
+}
+
+// ParseFilesParallel is like ParseFiles, but parses up to workers files at a
+// time. Records from one file keep their relative order, while records from
+// different files are interleaved in completion order.
+// The first error stops all workers and is reported by Err.
+func ParseFilesParallel[T any](files FileStream, parser FileParser[T], workers int) Input[T] {
+	workers = max(workers, 1)
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var (
+			mu       sync.Mutex
+			runErr   error
+			panicVal any
+		)
+		fail := func(err error) {
+			mu.Lock()
+			setFirstErr(&runErr, err)
+			mu.Unlock()
+		}
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		stop := make(chan struct{})
+		var stopOnce sync.Once
+		halt := func() {
+			stopOnce.Do(func() { close(stop) })
+		}
+
+		fileCh := make(chan FileInput)
+		batches := make(chan []T, workers)
+
+		dispatched := make(chan struct{})
+		go func() {
+			defer close(dispatched)
+			defer close(fileCh)
+			for file := range files.Seq {
+				select {
+				case fileCh <- file:
+				case <-stop:
+					return
+				}
+			}
+			if sourceErr := files.Err(); sourceErr != nil {
+				fail(sourceErr)
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						mu.Lock()
+						if panicVal == nil {
+							panicVal = r
+						}
+						mu.Unlock()
+						halt()
+					}
+				}()
+
+				for file := range fileCh {
+					batch := make([]T, 0, parallelBatchSize)
+					flush := func() bool {
+						if len(batch) == 0 {
+							return true
+						}
+						select {
+						case batches <- batch:
+							batch = make([]T, 0, parallelBatchSize)
+							return true
+						case <-stop:
+							return false
+						}
+					}
+
+					_, err := parseFileWith[T](file, parser, func(v T) bool {
+						batch = append(batch, v)
+						if len(batch) < parallelBatchSize {
+							return true
+						}
+						return flush()
+					})
+					if err != nil {
+						fail(err)
+						halt()
+						return
+					}
+					if !flush() {
+						return
+					}
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(batches)
+		}()
+
+		defer func() {
+			halt()
+			for range batches {
+			}
+			<-dispatched
+			if panicVal != nil {
+				panic(panicVal)
+			}
+		}()
+
+		for batch := range batches {
+			for _, v := range batch {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// NewParallelFileLineStream reads one large line-oriented file with up to
+// workers goroutines, each parsing its own newline-aligned byte range.
+// Lines within a range keep file order; ranges are interleaved.
+func NewParallelFileLineStream(path string, workers int) FileLineStream {
+	return ParseFilesParallel[string](NewFileRangeStream(path, workers), LineParser{}, workers)
+}
+
+// csvRangeInput is a fileRangeInput that replays the CSV header before its
+// range, so header-aware parsers can parse any range on its own.
+type csvRangeInput struct {
+	fileRangeInput
+	header []byte
+}
+
+func (f csvRangeInput) Open() (io.ReadCloser, error) {
+	rc, err := f.fileRangeInput.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(f.header), rc), rc}, nil
+}
+
+// NewCSVRangeStream splits one CSV file into up to n byte ranges that start
+// at record boundaries, so quoted fields containing newlines are never split.
+// Boundaries are found by tracking quote parity, which is computed for all
+// ranges concurrently; files using CSVParser.LazyQuotes with stray quotes
+// cannot be split reliably.
+//
+// With header set, the first record is kept out of the ranges and replayed at
+// the start of each one, so CSVHeaderParser and CSVStructParser can parse
+// every range independently. Line numbers in parse errors are relative to
+// the range. As with NewFileRangeStream, compressed files are rejected.
+func NewCSVRangeStream(path string, n int, header bool) FileStream {
+	n = max(n, 1)
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		headerBytes, bounds, err := csvAlignedBounds(path, n, header)
+		if err != nil {
+			setFirstErr(&runErr, err)
+			return
+		}
+		for i := 0; i+1 < len(bounds); i++ {
+			input := csvRangeInput{
+				fileRangeInput: fileRangeInput{path: path, start: bounds[i], end: bounds[i+1]},
+				header:         headerBytes,
+			}
+			if !yield(input) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// csvAlignedBounds returns the header record (when header is set) and
+// ascending range offsets from the end of the header to the file size, each
+// one at the start of a record.
+func csvAlignedBounds(path string, n int, header bool) ([]byte, []int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := checkSplittable(file, path); err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	size := info.Size()
+
+	var start int64
+	var headerBytes []byte
+	if header {
+		if start, err = recordEnd(file, 0, size, false); err != nil {
+			return nil, nil, fmt.Errorf("split %s: %w", path, err)
+		}
+		headerBytes = make([]byte, start)
+		if _, err := file.ReadAt(headerBytes, 0); err != nil {
+			return nil, nil, fmt.Errorf("split %s: %w", path, err)
+		}
+	}
+
+	// Count quotes between nominal boundaries in parallel; a boundary is
+	// inside a quoted field when an odd number of quotes precedes it.
+	nominal := make([]int64, n+1)
+	for i := range nominal {
+		nominal[i] = start + (size-start)*int64(i)/int64(n)
+	}
+	counts := make([]int64, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n - 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counts[i], errs[i] = countQuotes(file, nominal[i], nominal[i+1])
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, fmt.Errorf("split %s: %w", path, err)
+	}
+
+	bounds := []int64{start}
+	var quotes int64
+	for i := 1; i < n; i++ {
+		quotes += counts[i-1]
+		if nominal[i] <= bounds[len(bounds)-1] {
+			continue
+		}
+		next, err := nextRecordStart(file, nominal[i], size, quotes%2 == 1)
+		if err != nil {
+			return nil, nil, fmt.Errorf("split %s: %w", path, err)
+		}
+		if next >= size {
+			break
+		}
+		if next > bounds[len(bounds)-1] {
+			bounds = append(bounds, next)
+		}
+	}
+	if size > start {
+		bounds = append(bounds, size)
+	}
+	return headerBytes, bounds, nil
+}
+
+// countQuotes counts the '"' bytes in [from, to).
+func countQuotes(r io.ReaderAt, from, to int64) (int64, error) {
+	buf := make([]byte, 1<<20)
+	var count int64
+	for pos := from; pos < to; {
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), to-pos)], pos)
+		count += int64(bytes.Count(buf[:n], []byte{'"'}))
+		pos += int64(n)
+		if err != nil && (err != io.EOF || pos < to) {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// nextRecordStart returns the first offset >= offset that begins a record,
+// given whether offset is inside a quoted field.
+func nextRecordStart(r io.ReaderAt, offset, size int64, inQuotes bool) (int64, error) {
+	var prev [1]byte
+	if _, err := r.ReadAt(prev[:], offset-1); err != nil {
+		return 0, err
+	}
+	if prev[0] == '\n' && !inQuotes {
+		return offset, nil
+	}
+	return recordEnd(r, offset, size, inQuotes)
+}
+
+// recordEnd returns the offset after the first newline outside quotes at or
+// after offset, or size if there is none.
+func recordEnd(r io.ReaderAt, offset, size int64, inQuotes bool) (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(r, offset, size-offset))
+	pos := offset
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		pos++
+		switch {
+		case b == '"':
+			inQuotes = !inQuotes
+		case b == '\n' && !inQuotes:
+			return pos, nil
+		}
+	}
+}
+
+// NewParallelFileCSVStream parses one large CSV file with up to workers
+// goroutines, each reading its own record-aligned byte range (see
+// NewCSVRangeStream). Records within a range keep file order; ranges are
+// interleaved.
+func NewParallelFileCSVStream(path string, workers int) FileCSVStream {
+	return ParseFilesParallel[[]string](NewCSVRangeStream(path, workers, false), CSVParser{}, workers)
+}
+
+// NewParallelFileCSVHeaderStream is NewParallelFileCSVStream for files with a
+// header row, yielding records as maps like NewFileCSVHeaderStream.
+func NewParallelFileCSVHeaderStream(path string, workers int) Input[map[string]string] {
+	return ParseFilesParallel[map[string]string](NewCSVRangeStream(path, workers, true), CSVHeaderParser{}, workers)
+}
@@ -0,0 +1,262 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewFileRangeStream(t *testing.T) {
+	t.Run("ranges cover the file on line boundaries", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "big.log")
+		var content strings.Builder
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(&content, "line-%03d-%s\n", i, strings.Repeat("x", i%7))
+		}
+		writeTextFile(t, path, content.String())
+
+		files := NewFileRangeStream(path, 4)
+		ranges := stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := files.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if len(ranges) != 4 {
+			t.Fatalf("len(ranges) = %d, want 4", len(ranges))
+		}
+
+		source := ParseFiles[string](files, LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		want := strings.Split(strings.TrimSuffix(content.String(), "\n"), "\n")
+		if !slices.Equal(got, want) {
+			t.Fatalf("Stream() lines = %d, want %d in original order", len(got), len(want))
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("long lines produce fewer ranges", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "long.log")
+		writeTextFile(t, path, strings.Repeat("a", 100)+"\nb\n")
+
+		files := NewFileRangeStream(path, 8)
+		ranges := stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if len(ranges) != 2 {
+			t.Fatalf("len(ranges) = %d, want 2", len(ranges))
+		}
+	})
+
+	t.Run("rejects compressed files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log.gz")
+		writeTextFile(t, path, string(gzipBytes(t, "a\nb\n")))
+
+		files := NewFileRangeStream(path, 2)
+		_ = stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := files.Err(); !errors.Is(err, errCompressedSplit) {
+			t.Fatalf("Err() = %v, want %v", err, errCompressedSplit)
+		}
+		csvFiles := NewCSVRangeStream(path, 2, true)
+		_ = stream.Stream(csvFiles.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := csvFiles.Err(); !errors.Is(err, errCompressedSplit) {
+			t.Fatalf("NewCSVRangeStream Err() = %v, want %v", err, errCompressedSplit)
+		}
+	})
+
+	t.Run("reports missing file", func(t *testing.T) {
+		files := NewFileRangeStream(filepath.Join(t.TempDir(), "missing.log"), 2)
+		_ = stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+func TestNewParallelFileLineStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.log")
+	var content strings.Builder
+	want := []string{}
+	for i := 0; i < 5000; i++ {
+		line := fmt.Sprintf("event-%05d", i)
+		want = append(want, line)
+		content.WriteString(line + "\n")
+	}
+	writeTextFile(t, path, content.String())
+
+	source := NewParallelFileLineStream(path, 4)
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("Stream() returned %d lines, want the %d input lines", len(got), len(want))
+	}
+
+	first := stream.Stream(source.Seq, stream.Take(10, stream.End(stream.Count[string]())))
+	if first != 10 {
+		t.Fatalf("Count() = %d, want 10", first)
+	}
+}
+
+func TestParseFilesParallel(t *testing.T) {
+	t.Run("reads all files", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := []string{}
+		for i := 0; i < 6; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("%d.txt", i))
+			writeTextFile(t, path, fmt.Sprintf("%d-a\n%d-b\n", i, i))
+			paths = append(paths, path)
+		}
+
+		source := ParseFilesParallel[string](NewFileStream(paths), LineParser{}, 3)
+		got := stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+		if got != 12 {
+			t.Fatalf("Count() = %d, want 12", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "ok.csv")
+		fileB := filepath.Join(dir, "broken.csv")
+		writeTextFile(t, fileA, "a,1\n")
+		writeTextFile(t, fileB, "\"unclosed,2\n")
+
+		source := ParseFilesParallel[[]string](NewFileStream([]string{fileA, fileB}), CSVParser{}, 2)
+		_ = stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+
+	t.Run("reports source errors", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.txt")
+		writeTextFile(t, fileA, "a1\n")
+
+		source := ParseFilesParallel[string](NewFileStream([]string{fileA, filepath.Join(dir, "missing.txt")}), LineParser{}, 2)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if !slices.Equal(got, []string{"a1"}) {
+			t.Fatalf("Stream() = %v, want [a1]", got)
+		}
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+// quotedCSV builds a CSV file whose notes column contains quoted newlines,
+// escaped quotes and commas.
+func quotedCSV(rows int) (string, [][]string) {
+	var content strings.Builder
+	content.WriteString("id,note\n")
+	var records [][]string
+	for i := 0; i < rows; i++ {
+		note := fmt.Sprintf("row %d", i)
+		if i%3 == 0 {
+			note = fmt.Sprintf("multi\nline \"%d\",\n%s", i, strings.Repeat("y", i%11))
+		}
+		id := fmt.Sprintf("%04d", i)
+		records = append(records, []string{id, note})
+		fmt.Fprintf(&content, "%s,\"%s\"\n", id, strings.ReplaceAll(note, `"`, `""`))
+	}
+	return content.String(), records
+}
+
+func TestNewCSVRangeStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.csv")
+	content, records := quotedCSV(500)
+	writeTextFile(t, path, content)
+
+	t.Run("ranges start on record boundaries", func(t *testing.T) {
+		files := NewCSVRangeStream(path, 7, false)
+		ranges := stream.Stream(files.Seq, stream.End(stream.Collect[FileInput]()))
+		if len(ranges) != 7 {
+			t.Fatalf("len(ranges) = %d, want 7", len(ranges))
+		}
+
+		source := ParseFiles[[]string](files, CSVParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		want := append([][]string{{"id", "note"}}, records...)
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Fatalf("Stream() records = %d, want %d in original order", len(got), len(want))
+		}
+	})
+
+	t.Run("header is replayed for every range", func(t *testing.T) {
+		source := ParseFiles[map[string]string](NewCSVRangeStream(path, 5, true), CSVHeaderParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if len(got) != len(records) {
+			t.Fatalf("Stream() records = %d, want %d", len(got), len(records))
+		}
+		for i, rec := range got {
+			if rec["id"] != records[i][0] || rec["note"] != records[i][1] {
+				t.Fatalf("record %d = %v, want %v", i, rec, records[i])
+			}
+		}
+	})
+
+	t.Run("header only file has no ranges", func(t *testing.T) {
+		headerOnly := filepath.Join(dir, "header.csv")
+		writeTextFile(t, headerOnly, "id,note\n")
+		files := NewCSVRangeStream(headerOnly, 3, true)
+		if n := stream.Stream(files.Seq, stream.End(stream.Count[FileInput]())); n != 0 {
+			t.Fatalf("ranges = %d, want 0", n)
+		}
+		if err := files.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+}
+
+func TestNewParallelFileCSVStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.csv")
+	content, records := quotedCSV(3000)
+	writeTextFile(t, path, content)
+
+	source := NewParallelFileCSVHeaderStream(path, 4)
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	notes := make(map[string]string, len(got))
+	for _, rec := range got {
+		notes[rec["id"]] = rec["note"]
+	}
+	if len(got) != len(records) || len(notes) != len(records) {
+		t.Fatalf("Stream() records = %d (%d distinct), want %d", len(got), len(notes), len(records))
+	}
+	for _, rec := range records {
+		if notes[rec[0]] != rec[1] {
+			t.Fatalf("record %s note = %q, want %q", rec[0], notes[rec[0]], rec[1])
+		}
+	}
+
+	rows := NewParallelFileCSVStream(path, 4)
+	if n := stream.Stream(rows.Seq, stream.End(stream.Count[[]string]())); n != len(records)+1 {
+		t.Fatalf("Stream() = %d records, want %d", n, len(records)+1)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
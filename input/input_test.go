@@ -0,0 +1,529 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewFileLineStream(t *testing.T) {
+	t.Run("reads multiple files sequentially", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.txt")
+		fileB := filepath.Join(dir, "b.txt")
+
+		writeTextFile(t, fileA, "a1\na2\n")
+		writeTextFile(t, fileB, "b1\nb2\n")
+
+		source := NewFileLineStream([]string{fileA, fileB})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "a2", "b1", "b2"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("works with existing filters and aggregate", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "events-1.log")
+		fileB := filepath.Join(dir, "events-2.log")
+
+		writeTextFile(t, fileA, "apple\nbanana\napple\n")
+		writeTextFile(t, fileB, "orange\nbanana\napple\n")
+
+		source := NewFileLineStream([]string{fileA, fileB})
+		count := stream.Stream(
+			source.Seq,
+			stream.Filter(func(v string) bool { return v == "apple" },
+				stream.End(stream.Count[string]()),
+			),
+		)
+
+		if count != 3 {
+			t.Fatalf("apple count = %d, want 3", count)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("stops with error when file does not exist", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.txt")
+		missing := filepath.Join(dir, "missing.txt")
+
+		writeTextFile(t, fileA, "a1\n")
+
+		source := NewFileLineStream([]string{fileA, missing})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+
+	t.Run("resets Err per iteration run", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.txt")
+		missing := filepath.Join(dir, "missing.txt")
+		writeTextFile(t, fileA, "a1\na2\n")
+
+		source := NewFileLineStream([]string{fileA, missing})
+
+		_ = stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("first run Err() = nil, want non-nil")
+		}
+
+		first := stream.Stream(source.Seq, stream.End(stream.First[string]()))
+		if !first.OK || first.Value != "a1" {
+			t.Fatalf("First() = (%q, %v), want (\"a1\", true)", first.Value, first.OK)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("second run Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reads final line without trailing newline", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.txt")
+		writeTextFile(t, fileA, "a1\na2")
+
+		source := NewFileLineStream([]string{fileA})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		want := []string{"a1", "a2"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+}
+
+func writeTextFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestNewFileCSVStream(t *testing.T) {
+	t.Run("reads CSV records across multiple files", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.csv")
+		fileB := filepath.Join(dir, "b.csv")
+
+		writeTextFile(t, fileA, "apple,2\nbanana,1\n")
+		writeTextFile(t, fileB, "orange,3\n")
+
+		source := NewFileCSVStream([]string{fileA, fileB})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{
+			{"apple", "2"},
+			{"banana", "1"},
+			{"orange", "3"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("works with existing transform pipeline", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "events-1.csv")
+		fileB := filepath.Join(dir, "events-2.csv")
+
+		writeTextFile(t, fileA, "apple,ok\nbanana,ok\n")
+		writeTextFile(t, fileB, "apple,ng\napple,ok\n")
+
+		source := NewFileCSVStream([]string{fileA, fileB})
+		count := stream.Stream(
+			source.Seq,
+			stream.Filter(func(row []string) bool { return len(row) > 0 && row[0] == "apple" },
+				stream.End(stream.Count[[]string]()),
+			),
+		)
+
+		if count != 3 {
+			t.Fatalf("apple count = %d, want 3", count)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("returns parse error with malformed CSV", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "ok.csv")
+		fileB := filepath.Join(dir, "broken.csv")
+
+		writeTextFile(t, fileA, "a,1\n")
+		writeTextFile(t, fileB, "\"unclosed,2\n")
+
+		source := NewFileCSVStream([]string{fileA, fileB})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{{"a", "1"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+type splitParser struct {
+	sep string
+}
+
+func (p splitParser) Parse(_ string, r io.Reader, yield func([]string) bool) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !yield(strings.Split(line, p.sep)) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func TestParseFilesWithCustomParser(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.pipe")
+	fileB := filepath.Join(dir, "b.pipe")
+
+	writeTextFile(t, fileA, "k1|v1\nk2|v2\n")
+	writeTextFile(t, fileB, "k3|v3\n")
+
+	source := ParseFiles[[]string](NewFileStream([]string{fileA, fileB}), splitParser{sep: "|"})
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+	want := [][]string{
+		{"k1", "v1"},
+		{"k2", "v2"},
+		{"k3", "v3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stream() = %v, want %v", got, want)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
+
+type jsonlEvent struct {
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+func TestNewFileJSONLStream(t *testing.T) {
+	t.Run("decodes each line into a struct", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.jsonl")
+		fileB := filepath.Join(dir, "b.jsonl")
+
+		writeTextFile(t, fileA, "{\"level\":\"info\",\"msg\":\"start\"}\n\n{\"level\":\"error\",\"msg\":\"disk\"}\n")
+		writeTextFile(t, fileB, "{\"level\":\"error\",\"msg\":\"net\"}")
+
+		source := NewFileJSONLStream[jsonlEvent]([]string{fileA, fileB})
+		got := stream.Stream(
+			source.Seq,
+			stream.Filter(func(e jsonlEvent) bool { return e.Level == "error" },
+				stream.Map(func(e jsonlEvent) string { return e.Msg },
+					stream.End(stream.Collect[string]()),
+				),
+			),
+		)
+
+		want := []string{"disk", "net"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("returns decode error with line number", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "broken.jsonl")
+		writeTextFile(t, fileA, "{\"level\":\"info\"}\n{not json}\n")
+
+		source := NewFileJSONLStream[jsonlEvent]([]string{fileA})
+		got := stream.Stream(source.Seq, stream.End(stream.Count[jsonlEvent]()))
+
+		if got != 1 {
+			t.Fatalf("Count() = %d, want 1", got)
+		}
+		err := source.Err()
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("Err() = %v, want error mentioning line 2", err)
+		}
+	})
+}
+
+func TestNewFSFileStream(t *testing.T) {
+	fsys := fstest.MapFS{
+		"logs/a.log":    {Data: []byte("a1\na2\n")},
+		"logs/b.log.gz": {Data: gzipBytes(t, "b1\n")},
+		"data/c.csv":    {Data: []byte("x,1\ny,2\n")},
+	}
+
+	t.Run("streams files from an fs.FS", func(t *testing.T) {
+		source := ParseFiles[string](NewFSFileStream(fsys, []string{"logs/a.log", "logs/b.log.gz"}), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "a2", "b1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("works with any parser", func(t *testing.T) {
+		source := ParseFiles[[]string](NewFSFileStream(fsys, []string{"data/c.csv"}), CSVParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{{"x", "1"}, {"y", "2"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("stops with error when file does not exist", func(t *testing.T) {
+		source := ParseFiles[string](NewFSFileStream(fsys, []string{"logs/a.log", "logs/missing.log"}), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "a2"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+func TestNewFileCSVHeaderStream(t *testing.T) {
+	t.Run("maps columns by name across differing orders", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.csv")
+		fileB := filepath.Join(dir, "b.csv")
+
+		writeTextFile(t, fileA, "\ufeffstatus,latency_ms\n200,12\n500,80\n")
+		writeTextFile(t, fileB, "latency_ms,status\n7,200\n")
+
+		source := NewFileCSVHeaderStream([]string{fileA, fileB})
+		got := stream.Stream(
+			source.Seq,
+			stream.Filter(func(row map[string]string) bool { return row["status"] == "200" },
+				stream.Map(func(row map[string]string) string { return row["latency_ms"] },
+					stream.End(stream.Collect[string]()),
+				),
+			),
+		)
+
+		want := []string{"12", "7"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("header-only and empty files yield nothing", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "header.csv")
+		fileB := filepath.Join(dir, "empty.csv")
+		writeTextFile(t, fileA, "a,b\n")
+		writeTextFile(t, fileB, "")
+
+		source := NewFileCSVHeaderStream([]string{fileA, fileB})
+		if got := stream.Stream(source.Seq, stream.End(stream.Count[map[string]string]())); got != 0 {
+			t.Fatalf("Count() = %d, want 0", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("short records with variable field counts", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.csv")
+		writeTextFile(t, fileA, "a,b,c\n1,2\n3,4,5,6\n")
+
+		parser := CSVHeaderParser{CSVParser{FieldsPerRecord: -1}}
+		source := ParseFiles[map[string]string](NewFileStream([]string{fileA}), parser)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+
+		want := []map[string]string{
+			{"a": "1", "b": "2"},
+			{"a": "3", "b": "4", "c": "5"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("rejects duplicate header names", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "dup.csv")
+		writeTextFile(t, fileA, "a,a\n1,2\n")
+
+		source := NewFileCSVHeaderStream([]string{fileA})
+		_ = stream.Stream(source.Seq, stream.End(stream.Count[map[string]string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+func TestNewFileTSVStream(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.tsv")
+	writeTextFile(t, file, "apple\t2,5\nbanana\t1\n\n5\" screen\t3\r\n\"quoted\" name\t\"4\"\n")
+
+	source := NewFileTSVStream([]string{file})
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+	want := [][]string{{"apple", "2,5"}, {"banana", "1"}, {`5" screen`, "3"}, {`"quoted" name`, `"4"`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stream() = %v, want %v", got, want)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+}
+
+func TestCSVParserColumnProjection(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wide.csv")
+	writeTextFile(t, path, "id,name,status,latency_ms,extra\n1,a,200,12,x\n2,b,500,340\n")
+
+	t.Run("named columns on plain records", func(t *testing.T) {
+		source := ParseFiles[[]string](NewFileStream([]string{path}), CSVParser{Columns: []string{"latency_ms", "id"}, FieldsPerRecord: -1})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+		want := [][]string{{"latency_ms", "id"}, {"12", "1"}, {"340", "2"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("indexed columns", func(t *testing.T) {
+		source := ParseFiles[[]string](NewFileStream([]string{path}), CSVParser{ColumnIndexes: []int{2, 4}, FieldsPerRecord: -1})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+		want := [][]string{{"status", "extra"}, {"200", "x"}, {"500", ""}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("header maps and rows", func(t *testing.T) {
+		parser := CSVParser{Columns: []string{"status", "id"}, FieldsPerRecord: -1}
+		maps := ParseFiles[map[string]string](NewFileStream([]string{path}), CSVHeaderParser{parser})
+		got := stream.Stream(maps.Seq, stream.End(stream.Collect[map[string]string]()))
+		want := []map[string]string{{"status": "200", "id": "1"}, {"status": "500", "id": "2"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("CSVHeaderParser = %v, want %v", got, want)
+		}
+
+		rows := ParseFiles[Row](NewFileStream([]string{path}), CSVRowParser{parser})
+		collected := stream.Stream(rows.Seq, stream.End(stream.Collect[Row]()))
+		if len(collected) != 2 || !reflect.DeepEqual(collected[1].Values(), []string{"500", "2"}) {
+			t.Fatalf("CSVRowParser = %v, want projected rows", collected)
+		}
+		if _, ok := collected[0].Lookup("name"); ok {
+			t.Fatalf("Lookup(name) found a column that was not projected")
+		}
+	})
+
+	t.Run("missing column fails the file", func(t *testing.T) {
+		source := ParseFiles[[]string](NewFileStream([]string{path}), CSVParser{Columns: []string{"nope"}})
+		got := stream.Stream(source.Seq, stream.End(stream.Count[[]string]()))
+		if got != 0 || !errors.Is(source.Err(), errMissingColumn) {
+			t.Fatalf("got %d records, Err() = %v, want 0 and errMissingColumn", got, source.Err())
+		}
+
+		source = ParseFiles[[]string](NewFileStream([]string{path}), CSVParser{ColumnIndexes: []int{-1}})
+		stream.Stream(source.Seq, stream.End(stream.Count[[]string]()))
+		if !errors.Is(source.Err(), errNegativeColumn) {
+			t.Fatalf("Err() = %v, want errNegativeColumn", source.Err())
+		}
+	})
+}
+
+func TestCSVParserSniffDelimiter(t *testing.T) {
+	t.Run("sniffs the delimiter per file", func(t *testing.T) {
+		dir := t.TempDir()
+		files := map[string]string{
+			"comma.csv": "a,b\n1,2\n",
+			"tab.tsv":   "a\tb\n3\t4\n",
+			"semi.csv":  "\"x;y\";b\n5;6\n",
+			"pipe.txt":  "a|b|c\n7|8|9,0\n",
+		}
+		var paths []string
+		for _, name := range []string{"comma.csv", "tab.tsv", "semi.csv", "pipe.txt"} {
+			path := filepath.Join(dir, name)
+			writeTextFile(t, path, files[name])
+			paths = append(paths, path)
+		}
+
+		source := ParseFiles[[]string](NewFileStream(paths), CSVParser{SniffDelimiter: true, FieldsPerRecord: -1})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{
+			{"a", "b"}, {"1", "2"},
+			{"a", "b"}, {"3", "4"},
+			{"x;y", "b"}, {"5", "6"},
+			{"a", "b", "c"}, {"7", "8", "9,0"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("only the first line counts", func(t *testing.T) {
+		cases := map[string]rune{
+			"name\n1,2,3\n":   0,
+			"a;b,c;d\ne,f,g":  ';',
+			"\"a,b,c\"|d\n":   '|',
+			"a\tb\r\n1,2,3,4": '\t',
+		}
+		for head, want := range cases {
+			if got := sniffDelimiter([]byte(head)); got != want {
+				t.Fatalf("sniffDelimiter(%q) = %q, want %q", head, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JournalEntry is one systemd journal record. Well-known fields are decoded;
+// Fields holds every field of the entry, including those.
+type JournalEntry struct {
+	Time       time.Time
+	Hostname   string
+	Unit       string
+	Identifier string
+	// Priority is the syslog level, 0 (emerg) to 7 (debug), or -1 if unset.
+	Priority int
+	Message  string
+	// Cursor identifies the entry; pass it as JournalOptions.AfterCursor to
+	// resume after it.
+	Cursor string
+	Fields map[string]string
+}
+
+// JournalOptions selects the entries read by NewJournalStream. Each option
+// maps to the journalctl flag of the same meaning.
+type JournalOptions struct {
+	// Units limits entries to these systemd units (-u).
+	Units []string
+	// Matches are additional FIELD=value filters, such as "_PID=1".
+	Matches []string
+	// Priority limits entries to a level or range such as "err" or "0..3"
+	// (-p).
+	Priority string
+	// Since and Until bound entry times when non-zero.
+	Since time.Time
+	Until time.Time
+	// AfterCursor starts after the entry with this cursor.
+	AfterCursor string
+	// Directory reads journal files from a directory instead of the local
+	// system journal (-D).
+	Directory string
+	// Follow keeps waiting for new entries until ctx is cancelled (-f).
+	Follow bool
+	// Command is the journalctl binary. Defaults to "journalctl" on PATH.
+	Command string
+}
+
+func (o JournalOptions) args() []string {
+	args := []string{"--output=json", "--no-pager", "--all"}
+	for _, unit := range o.Units {
+		args = append(args, "--unit="+unit)
+	}
+	if o.Priority != "" {
+		args = append(args, "--priority="+o.Priority)
+	}
+	if !o.Since.IsZero() {
+		args = append(args, "--since=@"+strconv.FormatInt(o.Since.Unix(), 10))
+	}
+	if !o.Until.IsZero() {
+		args = append(args, "--until=@"+strconv.FormatInt(o.Until.Unix(), 10))
+	}
+	if o.AfterCursor != "" {
+		args = append(args, "--after-cursor="+o.AfterCursor)
+	}
+	if o.Directory != "" {
+		args = append(args, "--directory="+o.Directory)
+	}
+	if o.Follow {
+		args = append(args, "--follow")
+	}
+	return append(args, o.Matches...)
+}
+
+// NewJournalStream runs journalctl and yields the selected journal entries,
+// oldest first. A new journalctl process is started for every run and
+// stopped when the consumer stops or ctx is cancelled, neither of which is an
+// error. A failing journalctl, including its stderr, and malformed output are
+// reported by Err.
+func NewJournalStream(ctx context.Context, opts JournalOptions) Input[JournalEntry] {
+	command := opts.Command
+	if command == "" {
+		command = "journalctl"
+	}
+	var state runErrState
+
+	seq := func(yield func(JournalEntry) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		cmd := exec.CommandContext(runCtx, command, opts.args()...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			setFirstErr(&runErr, fmt.Errorf("%s: %w", command, err))
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			setFirstErr(&runErr, fmt.Errorf("%s: %w", command, err))
+			return
+		}
+
+		stopped := false
+		defer func() {
+			if stopped || runErr != nil {
+				cancel()
+			}
+			waitErr := cmd.Wait()
+			if waitErr != nil && !stopped && runErr == nil && ctx.Err() == nil {
+				setFirstErr(&runErr, fmt.Errorf("%s: %w: %s", command, waitErr, strings.TrimSpace(stderr.String())))
+			}
+		}()
+
+		reader := bufio.NewReader(stdout)
+		for lineNo := 1; ; lineNo++ {
+			line, readErr := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				entry, err := parseJournalEntry(line)
+				if err != nil {
+					setFirstErr(&runErr, fmt.Errorf("%s: line %d: %w", command, lineNo, err))
+					return
+				}
+				if !yield(entry) {
+					stopped = true
+					return
+				}
+			}
+			if readErr != nil {
+				return
+			}
+		}
+	}
+
+	return Input[JournalEntry]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// parseJournalEntry decodes a line of journalctl JSON output. Field values
+// are strings, byte arrays for binary data, arrays of those for fields that
+// occur more than once (the first value is kept), or null for oversized
+// values (skipped).
+func parseJournalEntry(line []byte) (JournalEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return JournalEntry{}, err
+	}
+
+	entry := JournalEntry{Priority: -1, Fields: make(map[string]string, len(raw))}
+	for name, value := range raw {
+		s, ok, err := journalFieldValue(value)
+		if err != nil {
+			return JournalEntry{}, fmt.Errorf("field %s: %w", name, err)
+		}
+		if ok {
+			entry.Fields[name] = s
+		}
+	}
+
+	if usec, err := strconv.ParseInt(entry.Fields["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+		entry.Time = time.UnixMicro(usec)
+	}
+	if priority, err := strconv.Atoi(entry.Fields["PRIORITY"]); err == nil {
+		entry.Priority = priority
+	}
+	entry.Hostname = entry.Fields["_HOSTNAME"]
+	entry.Unit = entry.Fields["_SYSTEMD_UNIT"]
+	entry.Identifier = entry.Fields["SYSLOG_IDENTIFIER"]
+	entry.Message = entry.Fields["MESSAGE"]
+	entry.Cursor = entry.Fields["__CURSOR"]
+	return entry, nil
+}
+
+func journalFieldValue(value json.RawMessage) (string, bool, error) {
+	if string(value) == "null" {
+		return "", false, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s, true, nil
+	}
+	var data []byte
+	var ints []int
+	if err := json.Unmarshal(value, &ints); err == nil {
+		for _, n := range ints {
+			data = append(data, byte(n))
+		}
+		return string(data), true, nil
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(value, &values); err == nil {
+		if len(values) == 0 {
+			return "", false, nil
+		}
+		return journalFieldValue(values[0])
+	}
+	return "", false, fmt.Errorf("unexpected value %s", value)
+}
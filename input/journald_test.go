@@ -0,0 +1,120 @@
+package input
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// fakeJournalctl writes a shell script that records its arguments to
+// args.txt and then runs body.
+func fakeJournalctl(t *testing.T, body string) (command, argsFile string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake journalctl needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	argsFile = filepath.Join(dir, "args.txt")
+	command = filepath.Join(dir, "journalctl")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\n" + body + "\n"
+	if err := os.WriteFile(command, []byte(script), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return command, argsFile
+}
+
+func TestNewJournalStream(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("decodes entries and passes options", func(t *testing.T) {
+		output := `{"__CURSOR":"s=1","__REALTIME_TIMESTAMP":"1700000000000000","_HOSTNAME":"web1","_SYSTEMD_UNIT":"nginx.service","SYSLOG_IDENTIFIER":"nginx","PRIORITY":"3","MESSAGE":"upstream timed out"}
+{"__CURSOR":"s=2","__REALTIME_TIMESTAMP":"1700000001000000","MESSAGE":[104,105],"CODE_LINE":["10","11"],"DATA":null}
+`
+		dataFile := filepath.Join(t.TempDir(), "journal.json")
+		writeTextFile(t, dataFile, output)
+		command, argsFile := fakeJournalctl(t, "cat "+dataFile)
+
+		source := NewJournalStream(ctx, JournalOptions{
+			Units:       []string{"nginx.service"},
+			Priority:    "err",
+			Since:       time.Unix(1700000000, 0),
+			AfterCursor: "s=0",
+			Matches:     []string{"_PID=42"},
+			Command:     command,
+		})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[JournalEntry]()))
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("Stream() returned %d entries, want 2", len(got))
+		}
+
+		first := got[0]
+		if !first.Time.Equal(time.Unix(1700000000, 0)) || first.Hostname != "web1" || first.Unit != "nginx.service" ||
+			first.Identifier != "nginx" || first.Priority != 3 || first.Message != "upstream timed out" || first.Cursor != "s=1" {
+			t.Fatalf("first entry = %+v", first)
+		}
+		second := got[1]
+		if second.Message != "hi" || second.Priority != -1 || second.Fields["CODE_LINE"] != "10" {
+			t.Fatalf("second entry = %+v", second)
+		}
+		if _, ok := second.Fields["DATA"]; ok {
+			t.Fatalf("null field DATA present in %v", second.Fields)
+		}
+
+		args, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatalf("read args: %v", err)
+		}
+		wantArgs := []string{"--output=json", "--no-pager", "--all", "--unit=nginx.service", "--priority=err",
+			"--since=@1700000000", "--after-cursor=s=0", "_PID=42"}
+		if gotArgs := strings.Fields(string(args)); !reflect.DeepEqual(gotArgs, wantArgs) {
+			t.Fatalf("args = %v, want %v", gotArgs, wantArgs)
+		}
+	})
+
+	t.Run("stopping early ends a following journalctl", func(t *testing.T) {
+		command, _ := fakeJournalctl(t, `while true; do echo '{"MESSAGE":"tick"}'; sleep 0.01; done`)
+		source := NewJournalStream(ctx, JournalOptions{Follow: true, Command: command})
+
+		done := make(chan int, 1)
+		go func() {
+			done <- stream.Stream(source.Seq, stream.Take[JournalEntry](3, stream.End(stream.Count[JournalEntry]())))
+		}()
+		select {
+		case n := <-done:
+			if n != 3 {
+				t.Fatalf("Stream() = %d, want 3", n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Stream() did not return after the consumer stopped")
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports journalctl failures", func(t *testing.T) {
+		command, _ := fakeJournalctl(t, "echo 'No journal files were found.' >&2; exit 1")
+		source := NewJournalStream(ctx, JournalOptions{Command: command})
+		stream.Stream(source.Seq, stream.End(stream.Count[JournalEntry]()))
+		if err := source.Err(); err == nil || !strings.Contains(err.Error(), "No journal files were found.") {
+			t.Fatalf("Err() = %v, want error with stderr", err)
+		}
+
+		command, _ = fakeJournalctl(t, "echo 'not json'")
+		source = NewJournalStream(ctx, JournalOptions{Command: command})
+		stream.Stream(source.Seq, stream.End(stream.Count[JournalEntry]()))
+		if err := source.Err(); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Fatalf("Err() = %v, want line 1 error", err)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package input
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// MergeSorted merges inputs that are each already ordered by cmp into one
+// ordered input, lazily: it holds only the current head of every input.
+// Equal elements keep input order. Err reports the first error from any
+// input; inputs with a nil Err are treated as never failing.
+func MergeSorted[T any](cmp func(T, T) int, inputs ...Input[T]) Input[T] {
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		h := &mergeHeap[T]{cmp: cmp}
+		var stops []func()
+		defer func() {
+			for _, stop := range stops {
+				stop()
+			}
+			for _, input := range inputs {
+				if input.Err != nil {
+					setFirstErr(&runErr, input.Err())
+				}
+			}
+		}()
+
+		for i, input := range inputs {
+			next, stop := iter.Pull(input.Seq)
+			stops = append(stops, stop)
+			if v, ok := next(); ok {
+				h.items = append(h.items, mergeItem[T]{value: v, input: i, next: next})
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			head := &h.items[0]
+			if !yield(head.value) {
+				return
+			}
+			if v, ok := head.next(); ok {
+				head.value = v
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+type mergeItem[T any] struct {
+	value T
+	input int
+	next  func() (T, bool)
+}
+
+// mergeHeap orders the heads of the merged inputs by value, then input index.
+type mergeHeap[T any] struct {
+	items []mergeItem[T]
+	cmp   func(T, T) int
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.items) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	if c := h.cmp(h.items[i].value, h.items[j].value); c != 0 {
+		return c < 0
+	}
+	return h.items[i].input < h.items[j].input
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap[T]) Push(x any) { h.items = append(h.items, x.(mergeItem[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
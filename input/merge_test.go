@@ -0,0 +1,103 @@
+package input
+
+import (
+	"cmp"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func sliceInput[T any](values ...T) Input[T] {
+	return Input[T]{Seq: slices.Values(values), Err: func() error { return nil }}
+}
+
+func TestMergeSorted(t *testing.T) {
+	t.Run("merges sorted inputs in order", func(t *testing.T) {
+		source := MergeSorted(cmp.Compare[int],
+			sliceInput(1, 4, 7, 10),
+			sliceInput[int](),
+			sliceInput(2, 3, 8),
+			sliceInput(5, 6, 9, 11, 12),
+		)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[int]()))
+
+		want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, expected %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("inputs without Err", func(t *testing.T) {
+		source := MergeSorted(cmp.Compare[int], Input[int]{Seq: slices.Values([]int{1, 3})}, sliceInput(2))
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[int]()))
+		if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, expected %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("ties keep input order", func(t *testing.T) {
+		type event struct {
+			at  int
+			src string
+		}
+		byTime := func(a, b event) int { return cmp.Compare(a.at, b.at) }
+		source := MergeSorted(byTime,
+			sliceInput(event{1, "a"}, event{2, "a"}),
+			sliceInput(event{1, "b"}, event{2, "b"}),
+		)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[event]()))
+
+		want := []event{{1, "a"}, {1, "b"}, {2, "a"}, {2, "b"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, expected %v", got, want)
+		}
+	})
+
+	t.Run("merges time-partitioned files lazily", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.log")
+		fileB := filepath.Join(dir, "b.log")
+		writeTextFile(t, fileA, "09:00 a\n09:02 a\n09:05 a\n")
+		writeTextFile(t, fileB, "09:01 b\n09:03 b\n")
+
+		source := MergeSorted(strings.Compare,
+			NewFileLineStream([]string{fileA}),
+			NewFileLineStream([]string{fileB}),
+		)
+		got := stream.Stream(source.Seq, stream.Take[string](3, stream.End(stream.Collect[string]())))
+
+		want := []string{"09:00 a", "09:01 b", "09:02 a"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, expected %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports input errors", func(t *testing.T) {
+		dir := t.TempDir()
+		source := MergeSorted(strings.Compare,
+			sliceInput("a", "c"),
+			NewFileLineStream([]string{filepath.Join(dir, "missing.log")}),
+		)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		if want := []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, expected %v", got, want)
+		}
+		if err := source.Err(); err == nil {
+			t.Fatalf("Err() = nil, want missing file error")
+		}
+	})
+}
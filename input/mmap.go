@@ -0,0 +1,134 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// errMmapUnsupported is returned by mapFile on platforms without mmap.
+var errMmapUnsupported = errors.New("mmap not supported on this platform")
+
+// NewMmapFileStream is NewFileStream for large local files read through a
+// memory mapping instead of read system calls, which saves a copy into the
+// kernel's read path. On platforms without mmap, and for empty files, files
+// are read normally. Compressed files are decompressed as usual.
+//
+// A mapped file must not be truncated while it is parsed: accessing the
+// removed pages crashes the process.
+func NewMmapFileStream(paths []string) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				setFirstErr(&runErr, fmt.Errorf("stat %s: %w", path, err))
+				return
+			}
+
+			if !yield(mmapFileInput{path: path}) {
+				return
+			}
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+type mmapFileInput struct {
+	path string
+}
+
+func (f mmapFileInput) Path() string {
+	return f.path
+}
+
+func (f mmapFileInput) Open() (io.ReadCloser, error) {
+	rc, err := f.openMapped()
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.path, rc)
+}
+
+// OpenAt makes mmapFileInput a SeekableFileInput. Compressed files are
+// decompressed and read up to offset.
+func (f mmapFileInput) OpenAt(offset int64) (io.ReadCloser, error) {
+	rc, err := f.openMapped()
+	if err != nil {
+		return nil, err
+	}
+	mapped, ok := rc.(*mappedReader)
+	if !ok {
+		_ = rc.Close()
+		return localFileInput{path: f.path}.OpenAt(offset)
+	}
+	if detectCompression(f.path, mapped.data[:min(len(mapped.data), sniffHeaderLen)]) != nil {
+		_ = rc.Close()
+		return discardTo(f, offset)
+	}
+	if offset > int64(len(mapped.data)) {
+		_ = rc.Close()
+		return nil, fmt.Errorf("%w: %d > %d", errOffsetBeyondEnd, offset, len(mapped.data))
+	}
+	_, _ = mapped.Seek(offset, io.SeekStart)
+	return mapped, nil
+}
+
+// openMapped maps the file, falling back to the open file where mapping is
+// not possible.
+func (f mmapFileInput) openMapped() (io.ReadCloser, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if info.Size() == 0 || int64(int(info.Size())) != info.Size() {
+		return file, nil
+	}
+
+	data, err := mapFile(file, int(info.Size()))
+	if errors.Is(err, errMmapUnsupported) {
+		return file, nil
+	}
+	// The mapping stays valid after the descriptor is closed.
+	_ = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("mmap: %w", err)
+	}
+	return &mappedReader{Reader: bytes.NewReader(data), data: data}, nil
+}
+
+// mappedReader reads a memory-mapped file and unmaps it on Close.
+type mappedReader struct {
+	*bytes.Reader
+	data []byte
+	once sync.Once
+	err  error
+}
+
+func (r *mappedReader) Close() error {
+	r.once.Do(func() {
+		r.Reader = bytes.NewReader(nil)
+		r.err = unmapFile(r.data)
+		r.data = nil
+	})
+	return r.err
+}
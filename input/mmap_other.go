@@ -0,0 +1,13 @@
+//go:build !unix
+
+package input
+
+import "os"
+
+func mapFile(*os.File, int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
@@ -0,0 +1,94 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewMmapFileStream(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "a.log")
+	writeTextFile(t, plain, "alpha\nbeta\n")
+	empty := filepath.Join(dir, "empty.log")
+	writeTextFile(t, empty, "")
+	compressed := filepath.Join(dir, "c.log.gz")
+	if err := os.WriteFile(compressed, gzipBytes(t, "gamma\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	source := ParseFiles[string](NewMmapFileStream([]string{plain, empty, compressed}), LineParser{})
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+	if want := []string{"alpha", "beta", "gamma"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stream() = %v, want %v", got, want)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+
+	source = ParseFiles[string](NewMmapFileStream([]string{filepath.Join(dir, "missing.log")}), LineParser{})
+	stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+	if err := source.Err(); err == nil {
+		t.Fatalf("Err() = nil, want stat error")
+	}
+}
+
+func TestMmapFileInputOpenAt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.log")
+	writeTextFile(t, path, "alpha\nbeta\n")
+	compressed := filepath.Join(dir, "b.log.gz")
+	if err := os.WriteFile(compressed, gzipBytes(t, "alpha\nbeta\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	for _, p := range []string{path, compressed} {
+		var input SeekableFileInput = mmapFileInput{path: p}
+		rc, err := input.OpenAt(6)
+		if err != nil {
+			t.Fatalf("%s: OpenAt() error = %v", p, err)
+		}
+		rest, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil || string(rest) != "beta\n" {
+			t.Fatalf("%s: OpenAt(6) read %q, %v, want %q", p, rest, err, "beta\n")
+		}
+		if _, err := input.OpenAt(100); err == nil {
+			t.Fatalf("%s: OpenAt(100) error = nil, want offset error", p)
+		}
+	}
+}
+
+func BenchmarkLineParse(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.log")
+	var content strings.Builder
+	for i := 0; content.Len() < 32<<20; i++ {
+		fmt.Fprintf(&content, "2024-01-01T00:00:%02d host-%d GET /api/items/%d 200\n", i%60, i%16, i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		b.Fatalf("write: %v", err)
+	}
+
+	streams := map[string]func([]string) FileStream{
+		"bufio": NewFileStream,
+		"mmap":  NewMmapFileStream,
+	}
+	for _, name := range []string{"bufio", "mmap"} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(content.Len()))
+			for b.Loop() {
+				source := ParseFiles[string](streams[name]([]string{path}), LineParser{})
+				stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+				if err := source.Err(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
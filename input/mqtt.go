@@ -0,0 +1,332 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	errMQTTRefused         = errors.New("MQTT connection refused")
+	errMQTTSubscribeFailed = errors.New("MQTT subscription rejected")
+	errMQTTProtocol        = errors.New("MQTT protocol error")
+)
+
+// defaultMQTTKeepAlive is used when MQTTOptions.KeepAlive is not set.
+const defaultMQTTKeepAlive = 60 * time.Second
+
+// MQTT 3.1.1 control packet types, shifted into the fixed header's high bits.
+const (
+	mqttConnect    = 1 << 4
+	mqttConnAck    = 2 << 4
+	mqttPublish    = 3 << 4
+	mqttPubAck     = 4 << 4
+	mqttPubRec     = 5 << 4
+	mqttPubRel     = 6 << 4
+	mqttPubComp    = 7 << 4
+	mqttSubscribe  = 8 << 4
+	mqttSubAck     = 9 << 4
+	mqttPingReq    = 12 << 4
+	mqttPingResp   = 13 << 4
+	mqttDisconnect = 14 << 4
+)
+
+// MQTTOptions configures NewMQTTStream.
+type MQTTOptions struct {
+	// ClientID identifies the session; brokers assign one when empty.
+	ClientID string
+	Username string
+	Password string
+	// QoS is the maximum quality of service requested for the subscription
+	// (0, 1 or 2). Messages are acknowledged after they are yielded.
+	QoS byte
+	// KeepAlive is the ping interval agreed with the broker. Defaults to 60s.
+	// The protocol counts it in whole seconds, so shorter intervals are
+	// raised to one second.
+	KeepAlive time.Duration
+	// TLSConfig enables TLS when set.
+	TLSConfig *tls.Config
+}
+
+// MQTTMessage is a message received on a subscribed topic.
+type MQTTMessage struct {
+	Topic    string
+	Payload  []byte
+	QoS      byte
+	Retained bool
+}
+
+// NewMQTTStream connects to an MQTT 3.1.1 broker at addr ("host:port"),
+// subscribes to topic (wildcards allowed) and yields received messages until
+// ctx is cancelled or the consumer stops. A new clean session is started for
+// every run. Connection failures, refusals and the broker dropping the
+// connection are reported by Err; cancellation is not an error.
+func NewMQTTStream(ctx context.Context, addr, topic string, opts MQTTOptions) Input[MQTTMessage] {
+	keepAlive := opts.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = defaultMQTTKeepAlive
+	}
+	keepAlive = max(keepAlive, time.Second)
+	var state runErrState
+
+	seq := func(yield func(MQTTMessage) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		conn, err := dialMQTT(ctx, addr, opts.TLSConfig)
+		if err != nil {
+			if ctx.Err() == nil {
+				setFirstErr(&runErr, fmt.Errorf("dial %s: %w", addr, err))
+			}
+			return
+		}
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+		defer stop()
+
+		client := &mqttClient{conn: conn, reader: bufio.NewReader(conn)}
+		if err := client.handshake(opts, keepAlive, topic); err != nil {
+			if ctx.Err() == nil {
+				setFirstErr(&runErr, fmt.Errorf("mqtt %s: %w", addr, err))
+			}
+			return
+		}
+
+		done := make(chan struct{})
+		var pinger sync.WaitGroup
+		pinger.Add(1)
+		go func() {
+			defer pinger.Done()
+			client.keepAlive(keepAlive, done)
+		}()
+		defer func() {
+			close(done)
+			pinger.Wait()
+			_ = client.write(mqttDisconnect, nil)
+		}()
+
+		err = client.receive(topic, yield)
+		if err != nil && ctx.Err() == nil {
+			setFirstErr(&runErr, fmt.Errorf("mqtt %s: %w", addr, err))
+		}
+	}
+
+	return Input[MQTTMessage]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+func dialMQTT(ctx context.Context, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	if tlsConfig != nil {
+		dialer := tls.Dialer{Config: tlsConfig}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// mqttClient reads packets on one goroutine while writes, which also come
+// from the keep-alive pinger, are serialized by mu.
+type mqttClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+func (c *mqttClient) handshake(opts MQTTOptions, keepAlive time.Duration, topic string) error {
+	flags := byte(0x02) // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+	}
+	body := appendMQTTString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(min(keepAlive/time.Second, 0xffff)))
+	body = appendMQTTString(body, opts.ClientID)
+	if opts.Username != "" {
+		body = appendMQTTString(body, opts.Username)
+	}
+	if opts.Password != "" {
+		body = appendMQTTString(body, opts.Password)
+	}
+	if err := c.write(mqttConnect, body); err != nil {
+		return err
+	}
+
+	header, ack, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if header&0xf0 != mqttConnAck || len(ack) != 2 {
+		return fmt.Errorf("%w: expected CONNACK", errMQTTProtocol)
+	}
+	if ack[1] != 0 {
+		return fmt.Errorf("%w: return code %d", errMQTTRefused, ack[1])
+	}
+
+	body = binary.BigEndian.AppendUint16(nil, 1)
+	body = appendMQTTString(body, topic)
+	body = append(body, min(opts.QoS, 2))
+	// SUBACK is checked by receive, as retained messages may follow it
+	// immediately.
+	return c.write(mqttSubscribe|0x02, body)
+}
+
+func (c *mqttClient) keepAlive(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if c.write(mqttPingReq, nil) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *mqttClient) receive(topic string, yield func(MQTTMessage) bool) error {
+	for {
+		header, body, err := c.readPacket()
+		if err == io.EOF {
+			return fmt.Errorf("connection closed by broker: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		switch header & 0xf0 {
+		case mqttPublish:
+			msg, id, err := parseMQTTPublish(header, body)
+			if err != nil {
+				return err
+			}
+			// The message has been delivered even when the consumer stops
+			// after it, so it is acknowledged either way.
+			more := yield(msg)
+			switch msg.QoS {
+			case 1:
+				err = c.write(mqttPubAck, binary.BigEndian.AppendUint16(nil, id))
+			case 2:
+				err = c.write(mqttPubRec, binary.BigEndian.AppendUint16(nil, id))
+			}
+			if err != nil || !more {
+				return err
+			}
+		case mqttPubRel:
+			if len(body) != 2 {
+				return fmt.Errorf("%w: malformed PUBREL", errMQTTProtocol)
+			}
+			if err := c.write(mqttPubComp, body); err != nil {
+				return err
+			}
+		case mqttSubAck:
+			if len(body) != 3 {
+				return fmt.Errorf("%w: malformed SUBACK", errMQTTProtocol)
+			}
+			if body[2] == 0x80 {
+				return fmt.Errorf("%w: %s", errMQTTSubscribeFailed, topic)
+			}
+		case mqttPingResp:
+		default:
+			return fmt.Errorf("%w: unexpected packet type %d", errMQTTProtocol, header>>4)
+		}
+	}
+}
+
+func parseMQTTPublish(header byte, body []byte) (MQTTMessage, uint16, error) {
+	msg := MQTTMessage{QoS: (header >> 1) & 0x03, Retained: header&0x01 != 0}
+	if len(body) < 2 {
+		return msg, 0, fmt.Errorf("%w: malformed PUBLISH", errMQTTProtocol)
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	if len(body) < n {
+		return msg, 0, fmt.Errorf("%w: malformed PUBLISH", errMQTTProtocol)
+	}
+	msg.Topic, body = string(body[:n]), body[n:]
+
+	var id uint16
+	if msg.QoS > 0 {
+		if len(body) < 2 {
+			return msg, 0, fmt.Errorf("%w: malformed PUBLISH", errMQTTProtocol)
+		}
+		id, body = binary.BigEndian.Uint16(body), body[2:]
+	}
+	msg.Payload = body
+	return msg, id, nil
+}
+
+func (c *mqttClient) write(header byte, body []byte) error {
+	packet := append([]byte{header}, appendMQTTLength(nil, len(body))...)
+	packet = append(packet, body...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+func (c *mqttClient) readPacket() (byte, []byte, error) {
+	header, err := c.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, shift := 0, 0
+	for {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return 0, nil, noEOF(err)
+		}
+		length |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 21 {
+			return 0, nil, fmt.Errorf("%w: remaining length too large", errMQTTProtocol)
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return 0, nil, noEOF(err)
+	}
+	return header, body, nil
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendMQTTLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// fakeMQTTBroker accepts one client, answers its CONNECT with connAckCode and,
+// if accepted, its SUBSCRIBE, then hands the connection to serve.
+func fakeMQTTBroker(t *testing.T, connAckCode byte, serve func(c *mqttClient)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		broker := &mqttClient{conn: conn, reader: bufio.NewReader(conn)}
+
+		header, body, err := broker.readPacket()
+		if err != nil || header != mqttConnect || string(body[2:6]) != "MQTT" {
+			return
+		}
+		_ = broker.write(mqttConnAck, []byte{0, connAckCode})
+		if connAckCode != 0 {
+			return
+		}
+		header, body, err = broker.readPacket()
+		if err != nil || header != mqttSubscribe|0x02 {
+			return
+		}
+		_ = broker.write(mqttSubAck, []byte{body[0], body[1], body[len(body)-1]})
+		serve(broker)
+	}()
+	return ln.Addr().String()
+}
+
+func publishPacket(topic string, payload string, qos byte, id uint16) (byte, []byte) {
+	body := appendMQTTString(nil, topic)
+	if qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	return mqttPublish | qos<<1, append(body, payload...)
+}
+
+func TestNewMQTTStream(t *testing.T) {
+	t.Run("yields messages and acknowledges QoS 1 and 2", func(t *testing.T) {
+		acks := make(chan []byte, 4)
+		addr := fakeMQTTBroker(t, 0, func(broker *mqttClient) {
+			_ = broker.write(publishPacket("sensors/a/temp", "21.5", 0, 0))
+			_ = broker.write(publishPacket("sensors/b/temp", "19.0", 1, 7))
+			_ = broker.write(publishPacket("sensors/c/temp", "18.2", 2, 8))
+			for {
+				header, body, err := broker.readPacket()
+				if err != nil {
+					close(acks)
+					return
+				}
+				if header == mqttPubRec {
+					_ = broker.write(mqttPubRel|0x02, body)
+				}
+				acks <- append([]byte{header}, body...)
+			}
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		source := NewMQTTStream(ctx, addr, "sensors/+/temp", MQTTOptions{ClientID: "test", QoS: 2})
+		got := stream.Stream(
+			source.Seq,
+			stream.Map(func(m MQTTMessage) string { return m.Topic + "=" + string(m.Payload) },
+				stream.Take[string](3, stream.End(stream.Collect[string]())),
+			),
+		)
+
+		want := []string{"sensors/a/temp=21.5", "sensors/b/temp=19.0", "sensors/c/temp=18.2"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+
+		var packets [][]byte
+		for ack := range acks {
+			packets = append(packets, ack)
+		}
+		wantPackets := [][]byte{
+			{mqttPubAck, 0, 7},
+			{mqttPubRec, 0, 8},
+		}
+		if len(packets) < 2 || !reflect.DeepEqual(packets[:2], wantPackets) {
+			t.Fatalf("client packets = %v, want prefix %v", packets, wantPackets)
+		}
+	})
+
+	t.Run("cancellation ends the stream without error", func(t *testing.T) {
+		addr := fakeMQTTBroker(t, 0, func(broker *mqttClient) {
+			_ = broker.write(publishPacket("t", "x", 0, 0))
+			for {
+				if _, _, err := broker.readPacket(); err != nil {
+					return
+				}
+			}
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		source := NewMQTTStream(ctx, addr, "t", MQTTOptions{})
+		var got []string
+		for msg := range source.Seq {
+			got = append(got, string(msg.Payload))
+			cancel()
+		}
+		if !reflect.DeepEqual(got, []string{"x"}) {
+			t.Fatalf("Stream() = %v, want [x]", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("sub-second keep-alive is raised to one second", func(t *testing.T) {
+		addr := fakeMQTTBroker(t, 0, func(broker *mqttClient) {
+			_ = broker.write(publishPacket("t", "x", 0, 0))
+			for {
+				if _, _, err := broker.readPacket(); err != nil {
+					return
+				}
+			}
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		source := NewMQTTStream(ctx, addr, "t", MQTTOptions{KeepAlive: time.Nanosecond})
+		got := stream.Stream(source.Seq, stream.Take[MQTTMessage](1, stream.End(stream.Count[MQTTMessage]())))
+		if got != 1 {
+			t.Fatalf("Stream() took %d messages, want 1", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("refused connection and dropped broker are errors", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		source := NewMQTTStream(ctx, fakeMQTTBroker(t, 5, nil), "t", MQTTOptions{})
+		stream.Stream(source.Seq, stream.End(stream.Count[MQTTMessage]()))
+		if err := source.Err(); !errors.Is(err, errMQTTRefused) {
+			t.Fatalf("Err() = %v, want errMQTTRefused", err)
+		}
+
+		source = NewMQTTStream(ctx, fakeMQTTBroker(t, 0, func(*mqttClient) {}), "t", MQTTOptions{})
+		stream.Stream(source.Seq, stream.End(stream.Count[MQTTMessage]()))
+		if err := source.Err(); err == nil {
+			t.Fatalf("Err() = nil, want connection closed error")
+		}
+	})
+}
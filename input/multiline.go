@@ -0,0 +1,79 @@
+package input
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// MultiLineParser groups lines into records that span several lines, such as
+// Java stack traces or mail log entries, and yields each record as a single
+// string with its lines joined by "\n".
+//
+// With Start set, a line matching Start begins a new record and other lines
+// continue the current one. With Separator set, matching lines end the
+// current record and are dropped. With neither, records are separated by
+// blank lines. Records holding only blank lines are skipped.
+type MultiLineParser struct {
+	Start     *regexp.Regexp
+	Separator *regexp.Regexp
+}
+
+func (p MultiLineParser) Parse(_ string, r io.Reader, yield func(string) bool) error {
+	reader := bufio.NewReader(r)
+	var record []string
+	flush := func() bool {
+		defer func() { record = record[:0] }()
+		text := strings.Join(record, "\n")
+		if strings.TrimSpace(text) == "" {
+			return true
+		}
+		return yield(text)
+	}
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) > 0 {
+			line = trimLineEnding(line)
+			switch {
+			case p.Start != nil:
+				if p.Start.MatchString(line) && !flush() {
+					return nil
+				}
+				record = append(record, line)
+			case p.Separator != nil:
+				if p.Separator.MatchString(line) {
+					if !flush() {
+						return nil
+					}
+				} else {
+					record = append(record, line)
+				}
+			default:
+				if strings.TrimSpace(line) == "" {
+					if !flush() {
+						return nil
+					}
+				} else {
+					record = append(record, line)
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			flush()
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// NewFileMultiLineStream provides records starting at lines that match start
+// by composing FileStream -> MultiLineParser -> transform pipeline. A nil
+// start separates records by blank lines.
+func NewFileMultiLineStream(paths []string, start *regexp.Regexp) FileLineStream {
+	return ParseFiles[string](NewFileStream(paths), MultiLineParser{Start: start})
+}
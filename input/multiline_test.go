@@ -0,0 +1,71 @@
+package input
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewFileMultiLineStream(t *testing.T) {
+	t.Run("records begin at start lines", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.log")
+		fileB := filepath.Join(dir, "b.log")
+		writeTextFile(t, fileA, strings.Join([]string{
+			"orphan continuation",
+			"2024-01-01 ERROR boom",
+			"java.lang.IllegalStateException: bad",
+			"\tat com.example.Main.run(Main.java:10)",
+			"2024-01-01 INFO ok",
+		}, "\r\n"))
+		writeTextFile(t, fileB, "2024-01-02 INFO next\n")
+
+		source := NewFileMultiLineStream([]string{fileA, fileB}, regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `))
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{
+			"orphan continuation",
+			"2024-01-01 ERROR boom\njava.lang.IllegalStateException: bad\n\tat com.example.Main.run(Main.java:10)",
+			"2024-01-01 INFO ok",
+			"2024-01-02 INFO next",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("blank lines separate records by default", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "mail.log")
+		writeTextFile(t, file, "\n\nFrom: a\nTo: b\n\n  \nFrom: c\n")
+
+		source := NewFileMultiLineStream([]string{file}, nil)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"From: a\nTo: b", "From: c"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("separator lines are dropped", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "dump.txt")
+		writeTextFile(t, file, "a\nb\n%%\n%%\nc\n%%\n")
+
+		parser := MultiLineParser{Separator: regexp.MustCompile(`^%%$`)}
+		source := ParseFiles[string](NewFileStream([]string{file}), parser)
+		got := stream.Stream(source.Seq, stream.Take[string](1, stream.End(stream.Collect[string]())))
+
+		if want := []string{"a\nb"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package input
+
+import (
+	"fmt"
+	"iter"
+)
+
+// MustStream runs cont over source, like stream.Stream, and returns the
+// result, panicking when source.Err() reports an error. It is meant for short
+// scripts; production code should check Err. The panic value is an error
+// wrapping source's, which already names the file and, for parsers that
+// track them, the line, plus how many records were read before it.
+func MustStream[T, R any](source Input[T], cont func(iter.Seq[T]) R) R {
+	records := 0
+	result := cont(func(yield func(T) bool) {
+		for v := range source.Seq {
+			records++
+			if !yield(v) {
+				return
+			}
+		}
+	})
+	if source.Err == nil {
+		return result
+	}
+	if err := source.Err(); err != nil {
+		panic(fmt.Errorf("%w (after %d records)", err, records))
+	}
+	return result
+}
+
+// Must returns v, panicking if err is not nil. It wraps calls such as
+// Pipeline.Run or NewHyperLogLog in scripts.
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// MustSucceed panics with the first error reported by errFns, such as
+// Conversions.Err or MemoryBudget.Err checked after a run.
+func MustSucceed(errFns ...func() error) {
+	for _, errFn := range errFns {
+		if err := errFn(); err != nil {
+			panic(err)
+		}
+	}
+}
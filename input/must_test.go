@@ -0,0 +1,66 @@
+package input
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func recoverError(t *testing.T, fn func()) (err error) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatalf("expected a panic")
+		}
+		var ok bool
+		if err, ok = r.(error); !ok {
+			t.Fatalf("panic value %v is not an error", r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+func TestMustStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	writeTextFile(t, path, "x\ny\n")
+
+	count := MustStream(NewFileLineStream([]string{path}), stream.End(stream.Count[string]()))
+	if count != 2 {
+		t.Fatalf("MustStream()=%d, expected 2", count)
+	}
+
+	missing := filepath.Join(dir, "missing.txt")
+	err := recoverError(t, func() {
+		MustStream(NewFileLineStream([]string{path, missing}), stream.End(stream.Count[string]()))
+	})
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), missing) || !strings.Contains(err.Error(), "after 2 records") {
+		t.Fatalf("panic = %v, expected a not-exist error naming %s after 2 records", err, missing)
+	}
+}
+
+func TestMustAndMustSucceed(t *testing.T) {
+	if got := Must(42, nil); got != 42 {
+		t.Fatalf("Must()=%d, expected 42", got)
+	}
+	errBoom := errors.New("boom")
+	if err := recoverError(t, func() { Must(0, errBoom) }); !errors.Is(err, errBoom) {
+		t.Fatalf("Must() panic = %v, expected errBoom", err)
+	}
+
+	conv := NewConversions(false)
+	stream.Stream(slices.Values([]Row{NewRow([]string{"n"}, []string{"x"})}), ToInt(conv, "n", stream.End(stream.Count[int]())))
+	err := recoverError(t, func() { MustSucceed(func() error { return nil }, conv.Err) })
+	var columnErr *ColumnError
+	if !errors.As(err, &columnErr) || columnErr.Column != "n" {
+		t.Fatalf("MustSucceed() panic = %v, expected the conversion failure", err)
+	}
+	MustSucceed()
+}
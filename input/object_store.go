@@ -0,0 +1,66 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// newPagedFileStream creates a lazy FileStream from a paginated listing.
+// list is called with "" for the first page and then with each returned
+// token until the token is empty.
+func newPagedFileStream(list func(token string) ([]FileInput, string, error)) FileStream {
+	var state runErrState
+
+	seq := func(yield func(FileInput) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		token := ""
+		for {
+			inputs, next, err := list(token)
+			if err != nil {
+				setFirstErr(&runErr, err)
+				return
+			}
+			for _, input := range inputs {
+				if !yield(input) {
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			token = next
+		}
+	}
+
+	return FileStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// doObjectRequest sends req with client (http.DefaultClient when nil) and
+// turns non-2xx responses into errors that include the start of the body,
+// where object stores put their error codes.
+func doObjectRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,200 @@
+//go:build objectstore
+
+package input
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewGCSStream(t *testing.T) {
+	objects := map[string]string{
+		"logs/a.log":    "a1\n",
+		"logs/b.log":    "b1\nb2\n",
+		"logs/":         "",
+		"logs/c.log.gz": string(gzipBytes(t, "c1\n")),
+		"tmp/x.log":     "x\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		rest, ok := strings.CutPrefix(r.URL.EscapedPath(), "/storage/v1/b/data/o")
+		if !ok {
+			http.Error(w, `{"error":"bucket not found"}`, http.StatusNotFound)
+			return
+		}
+
+		if rest == "" {
+			prefix := r.URL.Query().Get("prefix")
+			names := []string{}
+			for name := range objects {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			slices.Sort(names)
+			// One object per page to exercise pagination.
+			start := 0
+			if token := r.URL.Query().Get("pageToken"); token != "" {
+				start = slices.Index(names, token)
+			}
+			page := map[string]any{"items": []map[string]string{{"name": names[start]}}}
+			if start+1 < len(names) {
+				page["nextPageToken"] = names[start+1]
+			}
+			_ = json.NewEncoder(w).Encode(page)
+			return
+		}
+
+		name, err := url.PathUnescape(strings.TrimPrefix(rest, "/"))
+		if err != nil || r.URL.Query().Get("alt") != "media" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(objects[name]))
+	}))
+	defer server.Close()
+
+	cfg := GCSConfig{Endpoint: server.URL, AccessToken: "test-token"}
+
+	t.Run("lists and parses objects", func(t *testing.T) {
+		files := NewGCSStream(cfg, "data", "logs/")
+		source := ParseFiles[string](files, LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "b1", "b2", "c1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+
+		paths := stream.Stream(files.Seq, stream.Map(FileInput.Path, stream.End(stream.Collect[string]())))
+		if paths[0] != "gs://data/logs/a.log" {
+			t.Fatalf("Path() = %q, want gs://data/logs/a.log", paths[0])
+		}
+	})
+
+	t.Run("reports listing errors", func(t *testing.T) {
+		files := NewGCSStream(cfg, "other", "")
+		_ = stream.Stream(files.Seq, stream.End(stream.Count[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+func TestAzureBlobConfigSign(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("secret-key"))
+	cfg := AzureBlobConfig{Account: "acct", AccountKey: key}
+
+	req, err := http.NewRequest(http.MethodGet, "https://acct.blob.core.windows.net/logs?restype=container&comp=list", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+	req.Header.Set("X-Ms-Version", azureStorageVersion)
+	if err := cfg.sign(req, time.Date(2013, 5, 24, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("sign() error: %v", err)
+	}
+
+	stringToSign := "GET\n" + strings.Repeat("\n", 11) +
+		"x-ms-date:Fri, 24 May 2013 00:00:00 GMT\n" +
+		"x-ms-version:" + azureStorageVersion + "\n" +
+		"/acct/logs\ncomp:list\nrestype:container"
+	mac := hmac.New(sha256.New, []byte("secret-key"))
+	mac.Write([]byte(stringToSign))
+	want := "SharedKey acct:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestNewAzureBlobStream(t *testing.T) {
+	blobs := map[string]string{
+		"2024/a.log": "a1\n",
+		"2024/b.log": "b1\n",
+		"2023/z.log": "z\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sig") != "abc" {
+			http.Error(w, "<Error><Code>AuthenticationFailed</Code></Error>", http.StatusForbidden)
+			return
+		}
+		container, name, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/devstoreaccount1/"), "/")
+		if container != "logs" {
+			http.Error(w, "<Error><Code>ContainerNotFound</Code></Error>", http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Query().Get("comp") == "list" {
+			names := []string{}
+			for n := range blobs {
+				if strings.HasPrefix(n, r.URL.Query().Get("prefix")) {
+					names = append(names, n)
+				}
+			}
+			slices.Sort(names)
+			start := 0
+			if marker := r.URL.Query().Get("marker"); marker != "" {
+				start = slices.Index(names, marker)
+			}
+			var body strings.Builder
+			body.WriteString("<EnumerationResults><Blobs><Blob><Name>" + names[start] + "</Name></Blob></Blobs>")
+			if start+1 < len(names) {
+				body.WriteString("<NextMarker>" + names[start+1] + "</NextMarker>")
+			} else {
+				body.WriteString("<NextMarker/>")
+			}
+			body.WriteString("</EnumerationResults>")
+			_, _ = w.Write([]byte(body.String()))
+			return
+		}
+		_, _ = w.Write([]byte(blobs[name]))
+	}))
+	defer server.Close()
+
+	cfg := AzureBlobConfig{
+		Account:  "devstoreaccount1",
+		Endpoint: server.URL + "/devstoreaccount1",
+		SASToken: "?sv=2021&sig=abc",
+	}
+
+	t.Run("lists and parses blobs", func(t *testing.T) {
+		source := ParseFiles[string](NewAzureBlobStream(cfg, "logs", "2024/"), LineParser{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"a1", "b1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports listing errors", func(t *testing.T) {
+		files := NewAzureBlobStream(cfg, "missing", "")
+		_ = stream.Stream(files.Seq, stream.End(stream.Count[FileInput]()))
+		if err := files.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var errOffsetBeyondEnd = errors.New("offset beyond end of file")
+
+// SeekableFileInput is a FileInput that can be opened at a byte offset of the
+// content Open returns. Local files from NewFileStream implement it, seeking
+// directly unless they are compressed.
+type SeekableFileInput interface {
+	FileInput
+	// OpenAt returns the content from offset on. It fails with an error
+	// wrapping errOffsetBeyondEnd when the content is shorter than offset,
+	// for example because the file was truncated.
+	OpenAt(offset int64) (io.ReadCloser, error)
+}
+
+func (f localFileInput) OpenAt(offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, sniffHeaderLen)
+	n, _ := file.ReadAt(header, 0)
+	if detectCompression(f.path, header[:n]) != nil {
+		_ = file.Close()
+		return discardTo(localFileInput{path: f.path}, offset)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if offset > info.Size() {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: %d > %d", errOffsetBeyondEnd, offset, info.Size())
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// openFileAt opens file at offset, seeking when it is a SeekableFileInput and
+// reading past the first offset bytes otherwise.
+func openFileAt(file FileInput, offset int64) (io.ReadCloser, error) {
+	if offset <= 0 {
+		return file.Open()
+	}
+	if seekable, ok := file.(SeekableFileInput); ok {
+		return seekable.OpenAt(offset)
+	}
+	return discardTo(file, offset)
+}
+
+func discardTo(file FileInput, offset int64) (io.ReadCloser, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.CopyN(io.Discard, rc, offset)
+	if err == io.EOF {
+		err = fmt.Errorf("%w: %d > %d", errOffsetBeyondEnd, offset, n)
+	}
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return rc, nil
+}
+
+// OffsetLine is a line read by NewOffsetLineStream. Offset is the position
+// just past the line in its file, which is where a later run resumes.
+type OffsetLine struct {
+	Path   string
+	Line   string
+	Offset int64
+}
+
+// NewOffsetLineStream reads lines from each file starting at the offset
+// stored for its path in offsets (0 when absent), and reports the offset
+// after every line, so a growing log can be processed incrementally across
+// runs by saving the last Offset per path.
+//
+// A final line without a newline is not yielded, since the writer may still
+// be appending to it; the next run picks it up whole. A stored offset beyond
+// the end of the file means it was truncated or replaced, and reading
+// restarts from the beginning.
+func NewOffsetLineStream(files FileStream, offsets map[string]int64) Input[OffsetLine] {
+	var state runErrState
+
+	seq := func(yield func(OffsetLine) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for file := range files.Seq {
+			stopped, err := readOffsetLines(file, offsets[file.Path()], yield)
+			setFirstErr(&runErr, err)
+			if stopped || runErr != nil {
+				return
+			}
+		}
+		if sourceErr := files.Err(); sourceErr != nil {
+			setFirstErr(&runErr, sourceErr)
+		}
+	}
+
+	return Input[OffsetLine]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+func readOffsetLines(file FileInput, offset int64, yield func(OffsetLine) bool) (stopped bool, err error) {
+	rc, err := openFileAt(file, offset)
+	if errors.Is(err, errOffsetBeyondEnd) {
+		offset = 0
+		rc, err = file.Open()
+	}
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", file.Path(), err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); closeErr != nil {
+			setFirstErr(&err, fmt.Errorf("close %s: %w", file.Path(), closeErr))
+		}
+	}()
+
+	reader := bufio.NewReader(rc)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr == nil {
+			offset += int64(len(line))
+			if !yield(OffsetLine{Path: file.Path(), Line: trimLineEnding(line), Offset: offset}) {
+				return true, nil
+			}
+			continue
+		}
+		if readErr == io.EOF {
+			return false, nil
+		}
+		return false, fmt.Errorf("read %s: %w", file.Path(), readErr)
+	}
+}
@@ -0,0 +1,95 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// runOffsetLines reads files from offsets and stores the new offsets back.
+func runOffsetLines(t *testing.T, files FileStream, offsets map[string]int64) []string {
+	t.Helper()
+	source := NewOffsetLineStream(files, offsets)
+	var lines []string
+	for line := range source.Seq {
+		lines = append(lines, line.Line)
+		offsets[line.Path] = line.Offset
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	return lines
+}
+
+func TestNewOffsetLineStream(t *testing.T) {
+	t.Run("resumes a growing file across runs", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "app.log")
+		writeTextFile(t, file, "one\r\ntwo\nthr")
+		offsets := map[string]int64{}
+
+		if got, want := runOffsetLines(t, NewFileStream([]string{file}), offsets), []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("first run = %v, want %v", got, want)
+		}
+		if offsets[file] != 9 {
+			t.Fatalf("offset = %d, want 9", offsets[file])
+		}
+
+		appendTextFile(t, file, "ee\nfour\n")
+		if got, want := runOffsetLines(t, NewFileStream([]string{file}), offsets), []string{"three", "four"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("second run = %v, want %v", got, want)
+		}
+		if got := runOffsetLines(t, NewFileStream([]string{file}), offsets); got != nil {
+			t.Fatalf("third run = %v, want nothing", got)
+		}
+
+		writeTextFile(t, file, "new\n")
+		if got, want := runOffsetLines(t, NewFileStream([]string{file}), offsets), []string{"new"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("after truncation = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("compressed and non-seekable inputs", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "app.log.gz")
+		writeTextFile(t, file, string(gzipBytes(t, "a\nb\nc\n")))
+		offsets := map[string]int64{file: 2}
+		if got, want := runOffsetLines(t, NewFileStream([]string{file}), offsets), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("gzip run = %v, want %v", got, want)
+		}
+
+		fsys := fstest.MapFS{"x.log": {Data: []byte("a\nb\nc\n")}}
+		offsets = map[string]int64{"x.log": 4}
+		if got, want := runOffsetLines(t, NewFSFileStream(fsys, []string{"x.log"}), offsets), []string{"c"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("fs run = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestLocalFileInputOpenAt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	writeTextFile(t, path, "0123456789")
+
+	var file FileInput = localFileInput{path: path}
+	seekable, ok := file.(SeekableFileInput)
+	if !ok {
+		t.Fatalf("localFileInput does not implement SeekableFileInput")
+	}
+	rc, err := seekable.OpenAt(7)
+	if err != nil {
+		t.Fatalf("OpenAt() error = %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != "789" {
+		t.Fatalf("OpenAt(7) = %q, want %q", got, "789")
+	}
+
+	if _, err := seekable.OpenAt(11); !errors.Is(err, errOffsetBeyondEnd) {
+		t.Fatalf("OpenAt(11) error = %v, want errOffsetBeyondEnd", err)
+	}
+}
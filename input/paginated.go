@@ -0,0 +1,180 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PageFunc decodes one page response into its items and the URL of the next
+// page, which may be relative to the current one. An empty next URL ends the
+// stream. The response body is closed by the caller.
+type PageFunc[T any] func(resp *http.Response) (items []T, next string, err error)
+
+// PaginationOptions configures NewPaginatedHTTPStream.
+type PaginationOptions struct {
+	// Client sends the requests. Nil uses http.DefaultClient.
+	Client *http.Client
+	// Header is added to every page request, e.g. for authorization.
+	Header http.Header
+	// MinInterval is the least time between two page requests, to stay
+	// under an API's rate limit.
+	MinInterval time.Duration
+	// MaxRetries bounds the retries of one page after transport errors,
+	// 429 and 5xx responses. Defaults to 3; negative disables retries.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry, doubled for each
+	// further one up to MaxBackoff. A Retry-After header takes precedence.
+	// They default to 500ms and 30s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// NewPaginatedHTTPStream fetches firstURL and then each next page returned by
+// page, yielding items lazily: a page is requested only once the items of the
+// previous one are consumed. Failed requests are retried with backoff as set
+// by opts; a page that still fails, or that page cannot decode, ends the
+// stream with the error reported by Err. Cancellation of ctx is not an error.
+func NewPaginatedHTTPStream[T any](ctx context.Context, firstURL string, page PageFunc[T], opts PaginationOptions) Input[T] {
+	fetcher := pageFetcher{ctx: ctx, opts: opts}
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		next := firstURL
+		var last time.Time
+		for next != "" {
+			if !sleepUntil(ctx, last.Add(opts.MinInterval)) {
+				return
+			}
+			last = time.Now()
+
+			current := next
+			resp, err := fetcher.get(current)
+			if err != nil {
+				if ctx.Err() == nil {
+					setFirstErr(&runErr, fmt.Errorf("get %s: %w", current, err))
+				}
+				return
+			}
+			items, nextRef, err := page(resp)
+			_ = resp.Body.Close()
+			if err != nil {
+				if ctx.Err() == nil {
+					setFirstErr(&runErr, fmt.Errorf("decode %s: %w", current, err))
+				}
+				return
+			}
+			for _, item := range items {
+				if !yield(item) {
+					return
+				}
+			}
+
+			next = ""
+			if nextRef != "" {
+				resolved, err := resp.Request.URL.Parse(nextRef)
+				if err != nil {
+					setFirstErr(&runErr, fmt.Errorf("parse next page url %s: %w", nextRef, err))
+					return
+				}
+				next = resolved.String()
+			}
+		}
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// LinkHeaderNext returns the rel="next" target of resp's Link header (RFC
+// 8288), as used by GitHub-style APIs, or "" when there is none. It is meant
+// for use inside a PageFunc.
+func LinkHeaderNext(resp *http.Response) string {
+	for _, header := range resp.Header.Values("Link") {
+		for link := range strings.SplitSeq(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for param := range strings.SplitSeq(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "rel") {
+					continue
+				}
+				for rel := range strings.FieldsSeq(strings.Trim(value, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+type pageFetcher struct {
+	ctx  context.Context
+	opts PaginationOptions
+}
+
+// get requests rawURL, retrying transient failures, and returns a 2xx
+// response.
+func (f pageFetcher) get(rawURL string) (*http.Response, error) {
+	client := f.opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	backoff := newRetryBackoff(f.opts.MaxRetries, f.opts.InitialBackoff, f.opts.MaxBackoff)
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(f.ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range f.opts.Header {
+			req.Header[name] = append(req.Header[name], values...)
+		}
+
+		resp, err := client.Do(req)
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if f.ctx.Err() != nil {
+				return nil, err
+			}
+		case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+			return resp, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			delay = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+		default:
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		if attempt >= backoff.retries {
+			return nil, fmt.Errorf("after %d attempts: %w", attempt+1, err)
+		}
+		if delay <= 0 {
+			delay = backoff.delay(attempt)
+		}
+		if !sleepUntil(f.ctx, time.Now().Add(delay)) {
+			return nil, f.ctx.Err()
+		}
+	}
+}
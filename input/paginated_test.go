@@ -0,0 +1,179 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+type testPage struct {
+	Items  []int  `json:"items"`
+	Cursor string `json:"cursor"`
+}
+
+// decodeTestPage reads a JSON page and turns its cursor into a relative URL.
+func decodeTestPage(resp *http.Response) ([]int, string, error) {
+	var page testPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", err
+	}
+	if page.Cursor == "" {
+		return page.Items, "", nil
+	}
+	return page.Items, "?cursor=" + page.Cursor, nil
+}
+
+func TestNewPaginatedHTTPStream(t *testing.T) {
+	ctx := context.Background()
+	fastRetry := PaginationOptions{InitialBackoff: time.Millisecond}
+
+	t.Run("follows cursors lazily", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Query().Get("cursor") {
+			case "":
+				_ = json.NewEncoder(w).Encode(testPage{Items: []int{1, 2}, Cursor: "b"})
+			case "b":
+				_ = json.NewEncoder(w).Encode(testPage{Items: []int{3}, Cursor: "c"})
+			case "c":
+				_ = json.NewEncoder(w).Encode(testPage{Items: []int{4, 5}})
+			}
+		}))
+		defer srv.Close()
+
+		opts := fastRetry
+		opts.Header = http.Header{"Authorization": {"Bearer token"}}
+		source := NewPaginatedHTTPStream(ctx, srv.URL+"/items", decodeTestPage, opts)
+
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[int]()))
+		if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+
+		requests.Store(0)
+		first := stream.Stream(source.Seq, stream.Take[int](2, stream.End(stream.Collect[int]())))
+		if !reflect.DeepEqual(first, []int{1, 2}) {
+			t.Fatalf("Stream() = %v, want [1 2]", first)
+		}
+		if n := requests.Load(); n != 1 {
+			t.Fatalf("requests = %d, want 1", n)
+		}
+	})
+
+	t.Run("retries rate limits and server errors", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch requests.Add(1) {
+			case 1:
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			case 2:
+				w.WriteHeader(http.StatusBadGateway)
+			default:
+				_ = json.NewEncoder(w).Encode(testPage{Items: []int{7}})
+			}
+		}))
+		defer srv.Close()
+
+		source := NewPaginatedHTTPStream(ctx, srv.URL, decodeTestPage, fastRetry)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[int]()))
+		if !reflect.DeepEqual(got, []int{7}) {
+			t.Fatalf("Stream() = %v, want [7]", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if n := requests.Load(); n != 3 {
+			t.Fatalf("requests = %d, want 3", n)
+		}
+	})
+
+	t.Run("reports exhausted retries and client errors", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				http.Error(w, "no such collection", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		opts := fastRetry
+		opts.MaxRetries = 2
+		source := NewPaginatedHTTPStream(ctx, srv.URL+"/down", decodeTestPage, opts)
+		stream.Stream(source.Seq, stream.End(stream.Count[int]()))
+		if err := source.Err(); err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+			t.Fatalf("Err() = %v, want error after 3 attempts", err)
+		}
+
+		source = NewPaginatedHTTPStream(ctx, srv.URL+"/missing", decodeTestPage, opts)
+		stream.Stream(source.Seq, stream.End(stream.Count[int]()))
+		if err := source.Err(); err == nil || !strings.Contains(err.Error(), "no such collection") {
+			t.Fatalf("Err() = %v, want not found error", err)
+		}
+	})
+
+	t.Run("decode errors and cancellation", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"items": [1], "cursor": "next"}`)
+		}))
+		defer srv.Close()
+
+		errBadPage := errors.New("bad page")
+		source := NewPaginatedHTTPStream(ctx, srv.URL, func(*http.Response) ([]int, string, error) {
+			return nil, "", errBadPage
+		}, fastRetry)
+		stream.Stream(source.Seq, stream.End(stream.Count[int]()))
+		if err := source.Err(); !errors.Is(err, errBadPage) {
+			t.Fatalf("Err() = %v, want %v", err, errBadPage)
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		opts := fastRetry
+		opts.MinInterval = time.Hour
+		source = NewPaginatedHTTPStream(runCtx, srv.URL, decodeTestPage, opts)
+		n := 0
+		for range source.Seq {
+			n++
+			cancel()
+		}
+		if n != 1 {
+			t.Fatalf("Stream() yielded %d items, want 1", n)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+}
+
+func TestLinkHeaderNext(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Add("Link", `<https://api.example.com/items?page=1>; rel="prev", <https://api.example.com/items?page=3>; rel="next"`)
+	if got, want := LinkHeaderNext(resp), "https://api.example.com/items?page=3"; got != want {
+		t.Fatalf("LinkHeaderNext() = %q, want %q", got, want)
+	}
+
+	resp.Header.Set("Link", `<https://api.example.com/items?page=1>; rel="first"`)
+	if got := LinkHeaderNext(resp); got != "" {
+		t.Fatalf("LinkHeaderNext() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,546 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"reflect"
+	"time"
+)
+
+var (
+	errParquetStructType       = errors.New("parquet rows must be structs")
+	errUnsupportedParquetField = errors.New("unsupported parquet field type")
+)
+
+// defaultParquetRowGroupSize is used when ParquetWriteOptions.RowGroupSize is
+// not set.
+const defaultParquetRowGroupSize = 64 * 1024
+
+const parquetMagic = "PAR1"
+
+// Parquet physical types.
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetFloat     = 4
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// Parquet converted types; parquetNoConverted leaves it unset.
+const (
+	parquetNoConverted     = -1
+	parquetUTF8            = 0
+	parquetTimestampMicros = 10
+	parquetUint8           = 11
+	parquetUint16          = 12
+	parquetUint32          = 13
+	parquetUint64          = 14
+	parquetInt8            = 15
+	parquetInt16           = 16
+)
+
+// Parquet enum values used in the metadata.
+const (
+	parquetRequired     = 0
+	parquetOptional     = 1
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+	parquetGzip         = 2
+)
+
+// ParquetWriteOptions configures WriteParquet.
+type ParquetWriteOptions struct {
+	// RowGroupSize is the number of rows buffered in memory and written as
+	// one row group. Defaults to 65536.
+	RowGroupSize int
+	// Compress gzips the column pages.
+	Compress bool
+}
+
+// WriteParquet writes structs of type T to w as a Parquet file. Each exported
+// field becomes a column named by its `parquet:"name"` tag or field name; a
+// "-" tag skips it. Supported field types are bool, integers, floats,
+// strings, []byte and time.Time (stored as UTC microsecond timestamps);
+// pointers to them are written as optional columns with nil as null.
+//
+// Rows are buffered up to RowGroupSize and written as one row group with a
+// single PLAIN-encoded page per column. The first error stops consumption and
+// is returned.
+func WriteParquet[T any](w io.Writer, opts ParquetWriteOptions) func(iter.Seq[T]) error {
+	return func(seq iter.Seq[T]) error {
+		columns, err := parquetColumns(reflect.TypeFor[T]())
+		if err != nil {
+			return err
+		}
+		rowGroupSize := opts.RowGroupSize
+		if rowGroupSize <= 0 {
+			rowGroupSize = defaultParquetRowGroupSize
+		}
+
+		pw := &parquetWriter{w: w, columns: columns, compress: opts.Compress}
+		if err := pw.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+		rows := 0
+		for v := range seq {
+			value := reflect.ValueOf(&v).Elem()
+			for _, column := range columns {
+				// A field promoted through a nil embedded pointer is null.
+				field, err := value.FieldByIndexErr(column.index)
+				if err != nil {
+					field = reflect.Value{}
+				}
+				column.add(field)
+			}
+			rows++
+			if rows == rowGroupSize {
+				if err := pw.flushRowGroup(rows); err != nil {
+					return err
+				}
+				rows = 0
+			}
+		}
+		if rows > 0 {
+			if err := pw.flushRowGroup(rows); err != nil {
+				return err
+			}
+		}
+		return pw.close()
+	}
+}
+
+// WriteParquetToFile is WriteParquet to a file created (or truncated) at path
+// when the stream runs.
+func WriteParquetToFile[T any](path string, opts ParquetWriteOptions) func(iter.Seq[T]) error {
+	return writeToFile(path, func(w io.Writer) func(iter.Seq[T]) error {
+		return WriteParquet[T](w, opts)
+	})
+}
+
+// parquetColumn maps a struct field to a column and buffers the values of
+// the current row group.
+type parquetColumn struct {
+	name      string
+	index     []int
+	physical  int32
+	converted int32
+	optional  bool
+	// pointer is set when the field itself is a pointer; optional columns
+	// without it are fields promoted through an embedded pointer.
+	pointer bool
+	encode  func(*bytes.Buffer, reflect.Value)
+
+	rows   int
+	defs   []byte
+	bools  []bool
+	values bytes.Buffer
+}
+
+func parquetColumns(typ reflect.Type) ([]*parquetColumn, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", errParquetStructType, typ)
+	}
+	var columns []*parquetColumn
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous || promotedThroughUnexportedPointer(typ, field.Index) {
+			continue
+		}
+		name := field.Tag.Get("parquet")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		column := &parquetColumn{name: name, index: field.Index, optional: promotedThroughPointer(typ, field.Index)}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			column.optional, column.pointer = true, true
+			fieldType = fieldType.Elem()
+		}
+		if !column.setType(fieldType) {
+			return nil, fmt.Errorf("field %s: %w %s", field.Name, errUnsupportedParquetField, field.Type)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// promotedThroughPointer reports whether the field at index is promoted
+// through an embedded pointer, so it is missing when that pointer is nil.
+func promotedThroughPointer(typ reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		typ = typ.Field(i).Type
+		if typ.Kind() == reflect.Pointer {
+			return true
+		}
+	}
+	return false
+}
+
+// setType chooses the physical and converted type and the PLAIN encoder of
+// values of typ.
+func (c *parquetColumn) setType(typ reflect.Type) bool {
+	le := binary.LittleEndian
+	int32Of := func(buf *bytes.Buffer, v reflect.Value) { buf.Write(le.AppendUint32(nil, uint32(v.Int()))) }
+	uint32Of := func(buf *bytes.Buffer, v reflect.Value) { buf.Write(le.AppendUint32(nil, uint32(v.Uint()))) }
+	c.converted = parquetNoConverted
+
+	if typ == timeType {
+		c.physical, c.converted = parquetInt64, parquetTimestampMicros
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) {
+			buf.Write(le.AppendUint64(nil, uint64(v.Interface().(time.Time).UnixMicro())))
+		}
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		c.physical = parquetBoolean
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		c.physical, c.encode = parquetInt32, int32Of
+		switch typ.Kind() {
+		case reflect.Int8:
+			c.converted = parquetInt8
+		case reflect.Int16:
+			c.converted = parquetInt16
+		}
+	case reflect.Int, reflect.Int64:
+		c.physical = parquetInt64
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) { buf.Write(le.AppendUint64(nil, uint64(v.Int()))) }
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		c.physical, c.encode = parquetInt32, uint32Of
+		switch typ.Kind() {
+		case reflect.Uint8:
+			c.converted = parquetUint8
+		case reflect.Uint16:
+			c.converted = parquetUint16
+		default:
+			c.converted = parquetUint32
+		}
+	case reflect.Uint, reflect.Uint64:
+		c.physical, c.converted = parquetInt64, parquetUint64
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) { buf.Write(le.AppendUint64(nil, v.Uint())) }
+	case reflect.Float32:
+		c.physical = parquetFloat
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) {
+			buf.Write(le.AppendUint32(nil, math.Float32bits(float32(v.Float()))))
+		}
+	case reflect.Float64:
+		c.physical = parquetDouble
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) { buf.Write(le.AppendUint64(nil, math.Float64bits(v.Float()))) }
+	case reflect.String:
+		c.physical, c.converted = parquetByteArray, parquetUTF8
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) {
+			buf.Write(le.AppendUint32(nil, uint32(v.Len())))
+			buf.WriteString(v.String())
+		}
+	case reflect.Slice:
+		if typ.Elem().Kind() != reflect.Uint8 {
+			return false
+		}
+		c.physical = parquetByteArray
+		c.encode = func(buf *bytes.Buffer, v reflect.Value) {
+			buf.Write(le.AppendUint32(nil, uint32(v.Len())))
+			buf.Write(v.Bytes())
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *parquetColumn) add(v reflect.Value) {
+	c.rows++
+	if c.optional {
+		if !v.IsValid() || (c.pointer && v.IsNil()) {
+			c.defs = append(c.defs, 0)
+			return
+		}
+		c.defs = append(c.defs, 1)
+		if c.pointer {
+			v = v.Elem()
+		}
+	}
+	if c.physical == parquetBoolean {
+		c.bools = append(c.bools, v.Bool())
+		return
+	}
+	c.encode(&c.values, v)
+}
+
+// page returns the body of a data page holding the buffered values and
+// resets the buffers.
+func (c *parquetColumn) page() []byte {
+	var body []byte
+	if c.optional {
+		levels := appendRLELevels(nil, c.defs)
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(levels)))
+		body = append(body, levels...)
+	}
+	if c.physical == parquetBoolean {
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, b := range c.bools {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		body = append(body, packed...)
+	} else {
+		body = append(body, c.values.Bytes()...)
+	}
+	c.rows, c.defs, c.bools = 0, c.defs[:0], c.bools[:0]
+	c.values.Reset()
+	return body
+}
+
+// appendRLELevels encodes levels of bit width 1 as runs of the RLE/bit-packed
+// hybrid encoding.
+func appendRLELevels(dst []byte, levels []byte) []byte {
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		dst = binary.AppendUvarint(dst, uint64(j-i)<<1)
+		dst = append(dst, levels[i])
+		i = j
+	}
+	return dst
+}
+
+type parquetColumnChunk struct {
+	offset       int64
+	values       int
+	uncompressed int64
+	compressed   int64
+}
+
+type parquetRowGroup struct {
+	rows   int
+	chunks []parquetColumnChunk
+}
+
+// parquetWriter writes column chunks and, on close, the file metadata.
+type parquetWriter struct {
+	w         io.Writer
+	pos       int64
+	columns   []*parquetColumn
+	compress  bool
+	rowGroups []parquetRowGroup
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.pos += int64(n)
+	return err
+}
+
+func (p *parquetWriter) flushRowGroup(rows int) error {
+	group := parquetRowGroup{rows: rows}
+	for _, column := range p.columns {
+		values := column.rows
+		body := column.page()
+		stored := body
+		if p.compress {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write(body)
+			if err := zw.Close(); err != nil {
+				return err
+			}
+			stored = buf.Bytes()
+		}
+
+		var header thriftWriter
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(body)))
+		header.i32(3, int32(len(stored)))
+		header.beginStruct(5)
+		header.i32(1, int32(values))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.end()
+
+		chunk := parquetColumnChunk{
+			offset:       p.pos,
+			values:       values,
+			uncompressed: int64(len(header.buf) + len(body)),
+			compressed:   int64(len(header.buf) + len(stored)),
+		}
+		if err := p.write(header.buf); err != nil {
+			return err
+		}
+		if err := p.write(stored); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+	}
+	p.rowGroups = append(p.rowGroups, group)
+	return nil
+}
+
+func (p *parquetWriter) close() error {
+	codec := int32(parquetUncompressed)
+	if p.compress {
+		codec = parquetGzip
+	}
+	totalRows := 0
+	for _, group := range p.rowGroups {
+		totalRows += group.rows
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.list(2, thriftStruct, len(p.columns)+1)
+	meta.beginElem()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(p.columns)))
+	meta.end()
+	for _, column := range p.columns {
+		meta.beginElem()
+		meta.i32(1, column.physical)
+		repetition := int32(parquetRequired)
+		if column.optional {
+			repetition = parquetOptional
+		}
+		meta.i32(3, repetition)
+		meta.binary(4, column.name)
+		if column.converted != parquetNoConverted {
+			meta.i32(6, column.converted)
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(totalRows))
+	meta.list(4, thriftStruct, len(p.rowGroups))
+	for _, group := range p.rowGroups {
+		meta.beginElem()
+		meta.list(1, thriftStruct, len(group.chunks))
+		var totalSize int64
+		for i, chunk := range group.chunks {
+			column := p.columns[i]
+			totalSize += chunk.uncompressed
+			meta.beginElem()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3)
+			meta.i32(1, column.physical)
+			meta.list(2, thriftI32, 2)
+			meta.appendVarint(parquetPlain)
+			meta.appendVarint(parquetRLE)
+			meta.list(3, thriftBinary, 1)
+			meta.appendString(column.name)
+			meta.i32(4, codec)
+			meta.i64(5, int64(chunk.values))
+			meta.i64(6, chunk.uncompressed)
+			meta.i64(7, chunk.compressed)
+			meta.i64(9, chunk.offset)
+			meta.end()
+			meta.end()
+		}
+		meta.i64(2, totalSize)
+		meta.i64(3, int64(group.rows))
+		meta.end()
+	}
+	meta.binary(6, "go-stream")
+	meta.end()
+
+	if err := p.write(meta.buf); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(meta.buf)))); err != nil {
+		return err
+	}
+	return p.write([]byte(parquetMagic))
+}
+
+// Thrift compact protocol type codes.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol used by Parquet metadata.
+// Fields are written in increasing id order; end closes the innermost struct.
+type thriftWriter struct {
+	buf    []byte
+	last   int16
+	parent []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.appendVarint(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) appendVarint(v int64) {
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) appendString(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.appendVarint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.appendVarint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.appendString(s)
+}
+
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+// beginElem starts a struct element of a list.
+func (t *thriftWriter) beginElem() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+// end writes the stop field of the current struct.
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	if n := len(t.parent); n > 0 {
+		t.last = t.parent[n-1]
+		t.parent = t.parent[:n-1]
+	}
+}
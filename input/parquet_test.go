@@ -0,0 +1,207 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// readThriftStruct decodes a Thrift compact struct into field id -> value,
+// with structs as maps, lists as slices, integers as int64 and binaries as
+// strings.
+func readThriftStruct(t *testing.T, r *bytes.Reader) map[int16]any {
+	t.Helper()
+	fields := map[int16]any{}
+	var last int16
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("read field header: %v", err)
+		}
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v, _ := binary.ReadVarint(r)
+			id = int16(v)
+		}
+		last = id
+		fields[id] = readThriftValue(t, r, header&0x0f)
+	}
+}
+
+func readThriftValue(t *testing.T, r *bytes.Reader, typ byte) any {
+	t.Helper()
+	switch typ {
+	case thriftI32, thriftI64:
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			t.Fatalf("read int: %v", err)
+		}
+		return v
+	case thriftBinary:
+		n, _ := binary.ReadUvarint(r)
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatalf("read binary: %v", err)
+		}
+		return string(b)
+	case thriftList:
+		header, _ := r.ReadByte()
+		n := uint64(header >> 4)
+		if n == 15 {
+			n, _ = binary.ReadUvarint(r)
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i] = readThriftValue(t, r, header&0x0f)
+		}
+		return items
+	case thriftStruct:
+		return readThriftStruct(t, r)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+// parquetPages reads a Parquet file's metadata and returns, per column name,
+// the decompressed page bodies of all row groups.
+func parquetPages(t *testing.T, data []byte) (map[int16]any, map[string][][]byte) {
+	t.Helper()
+	if string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatalf("missing magic in %q", data)
+	}
+	size := binary.LittleEndian.Uint32(data[len(data)-8:])
+	meta := readThriftStruct(t, bytes.NewReader(data[len(data)-8-int(size):len(data)-8]))
+
+	pages := map[string][][]byte{}
+	for _, group := range meta[4].([]any) {
+		for _, chunk := range group.(map[int16]any)[1].([]any) {
+			columnMeta := chunk.(map[int16]any)[3].(map[int16]any)
+			name := columnMeta[3].([]any)[0].(string)
+			r := bytes.NewReader(data[columnMeta[9].(int64):])
+			header := readThriftStruct(t, r)
+			body := make([]byte, header[3].(int64))
+			if _, err := io.ReadFull(r, body); err != nil {
+				t.Fatal(err)
+			}
+			if columnMeta[4].(int64) == parquetGzip {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ = io.ReadAll(zr)
+			}
+			if int64(len(body)) != header[2].(int64) {
+				t.Fatalf("%s: page size = %d, want %d", name, len(body), header[2])
+			}
+			pages[name] = append(pages[name], body)
+		}
+	}
+	return meta, pages
+}
+
+type parquetRow struct {
+	ID     int64     `parquet:"id"`
+	Name   string    `parquet:"name"`
+	Score  *float64  `parquet:"score"`
+	Active bool      `parquet:"active"`
+	Seen   time.Time `parquet:"seen"`
+	Secret string    `parquet:"-"`
+}
+
+// PBase is embedded through a pointer in TestWriteParquet.
+type PBase struct {
+	ID int64 `parquet:"id"`
+}
+
+func TestWriteParquet(t *testing.T) {
+	score := 9.5
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rows := []parquetRow{
+		{ID: 1, Name: "ada", Score: &score, Active: true, Seen: seen},
+		{ID: 2, Name: "bob", Seen: seen},
+		{ID: 3, Name: "cy", Score: &score, Active: true, Seen: seen},
+	}
+
+	for _, compress := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "rows.parquet")
+		opts := ParquetWriteOptions{RowGroupSize: 2, Compress: compress}
+		if err := stream.Stream(slices.Values(rows), stream.End(WriteParquetToFile[parquetRow](path, opts))); err != nil {
+			t.Fatalf("WriteParquet() error = %v, want nil", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, pages := parquetPages(t, data)
+
+		if meta[3] != int64(3) || len(meta[4].([]any)) != 2 {
+			t.Fatalf("num_rows = %v with %d row groups, want 3 rows in 2 groups", meta[3], len(meta[4].([]any)))
+		}
+		var names []string
+		for _, element := range meta[2].([]any)[1:] {
+			names = append(names, element.(map[int16]any)[4].(string))
+		}
+		if want := []string{"id", "name", "score", "active", "seen"}; !slices.Equal(names, want) {
+			t.Fatalf("schema = %v, want %v", names, want)
+		}
+
+		le := binary.LittleEndian
+		wantName := append(append(le.AppendUint32(nil, 3), "ada"...), le.AppendUint32(nil, 3)...)
+		wantName = append(wantName, "bob"...)
+		if !bytes.Equal(pages["name"][0], wantName) {
+			t.Fatalf("name page = %q, want %q", pages["name"][0], wantName)
+		}
+		// Definition levels [1 0] as two RLE runs, then the one non-null value.
+		wantScore := append([]byte{4, 0, 0, 0, 2, 1, 2, 0}, le.AppendUint64(nil, math.Float64bits(score))...)
+		if !bytes.Equal(pages["score"][0], wantScore) {
+			t.Fatalf("score page = %v, want %v", pages["score"][0], wantScore)
+		}
+		if !bytes.Equal(pages["active"][0], []byte{0b01}) || !bytes.Equal(pages["active"][1], []byte{0b1}) {
+			t.Fatalf("active pages = %v, want [[1] [1]]", pages["active"])
+		}
+		if got := int64(le.Uint64(pages["seen"][1])); got != seen.UnixMicro() {
+			t.Fatalf("seen = %d, want %d", got, seen.UnixMicro())
+		}
+	}
+
+	t.Run("fields promoted through a nil embedded pointer are null", func(t *testing.T) {
+		type embedded struct {
+			*PBase
+			Name string `parquet:"name"`
+		}
+		path := filepath.Join(t.TempDir(), "embedded.parquet")
+		rows := []embedded{{Name: "ada"}, {PBase: &PBase{ID: 7}, Name: "bob"}}
+		if err := stream.Stream(slices.Values(rows), stream.End(WriteParquetToFile[embedded](path, ParquetWriteOptions{}))); err != nil {
+			t.Fatalf("WriteParquet() error = %v, want nil", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, pages := parquetPages(t, data)
+		// Definition levels [0 1], then the one non-null value.
+		wantID := append([]byte{4, 0, 0, 0, 2, 0, 2, 1}, binary.LittleEndian.AppendUint64(nil, 7)...)
+		if !bytes.Equal(pages["id"][0], wantID) {
+			t.Fatalf("id page = %v, want %v", pages["id"][0], wantID)
+		}
+	})
+
+	type unsupported struct{ Tags []string }
+	err := stream.Stream(slices.Values([]unsupported{{}}), stream.End(WriteParquet[unsupported](io.Discard, ParquetWriteOptions{})))
+	if !errors.Is(err, errUnsupportedParquetField) {
+		t.Fatalf("WriteParquet() error = %v, want %v", err, errUnsupportedParquetField)
+	}
+}
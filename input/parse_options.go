@@ -0,0 +1,235 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ParseOptions adjusts which bytes of each file reach the parser in
+// ParseFilesWithOptions, and how much is parsed. Sampling and byte limits work
+// on lines, before parsing, so they are far cheaper than filtering parsed
+// records; they suit line-oriented formats (text, CSV without quoted
+// newlines, JSON lines).
+//
+// Reaching a limit ends reading quietly: Err stays nil.
+type ParseOptions struct {
+	// SampleEvery passes only every Nth line of each file to the parser,
+	// starting with the first. Values below 2 pass every line.
+	SampleEvery int
+	// SkipBytes starts each file at the first line beginning at or after
+	// this byte offset.
+	SkipBytes int64
+	// KeepFirstLine always passes the first line of each file, such as a CSV
+	// header, regardless of SampleEvery and SkipBytes.
+	KeepFirstLine bool
+
+	// MaxBytesPerFile passes only the lines that end within the first N
+	// bytes of each file.
+	MaxBytesPerFile int64
+	// MaxBytes caps the bytes read across all files of a run; the line that
+	// would cross it, and everything after, is not passed.
+	MaxBytes int64
+	// MaxRecordsPerFile stops parsing a file after N records.
+	MaxRecordsPerFile int
+	// MaxRecords ends the run after N records in total.
+	MaxRecords int
+
+	// ReadBufferSize sets the read buffer handed to the parser for each
+	// file. Parsers buffering with bufio (LineParser, CSVParser,
+	// JSONLinesParser and those built on them) use it in place of their
+	// 4 KiB default, so larger buffers cut read calls on huge files. Sizes
+	// below 4 KiB are raised to it: bufio would wrap a smaller buffer in a
+	// default one rather than save memory. LineParser with an Encoding
+	// buffers the decoded text with this size too. CSVParser with
+	// SniffDelimiter needs 64 KiB to inspect the first line and uses that
+	// when ReadBufferSize is smaller. Zero keeps the defaults.
+	ReadBufferSize int
+}
+
+func (o ParseOptions) linesFiltered() bool {
+	return o.SampleEvery > 1 || o.SkipBytes > 0 || o.MaxBytesPerFile > 0 || o.MaxBytes > 0
+}
+
+// ParseFilesWithOptions is ParseFiles with ParseOptions applied to every file.
+func ParseFilesWithOptions[T any](files FileStream, parser FileParser[T], opts ParseOptions) Input[T] {
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		var budget *int64
+		if opts.MaxBytes > 0 {
+			remaining := opts.MaxBytes
+			budget = &remaining
+		}
+		total := 0
+		for file := range files.Seq {
+			if opts.linesFiltered() {
+				file = sampledFileInput{FileInput: file, opts: opts, budget: budget}
+			}
+
+			fileRecords := 0
+			stopped := false
+			_, err := parseFileBuffered[T](file, parser, opts.ReadBufferSize, func(v T) bool {
+				if !yield(v) {
+					stopped = true
+					return false
+				}
+				fileRecords++
+				total++
+				if opts.MaxRecords > 0 && total >= opts.MaxRecords {
+					stopped = true
+					return false
+				}
+				return opts.MaxRecordsPerFile <= 0 || fileRecords < opts.MaxRecordsPerFile
+			})
+			setFirstErr(&runErr, err)
+			if stopped || runErr != nil || (budget != nil && *budget <= 0) {
+				return
+			}
+		}
+		if sourceErr := files.Err(); sourceErr != nil {
+			setFirstErr(&runErr, sourceErr)
+		}
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// sampledFileInput applies line-level ParseOptions to a FileInput.
+type sampledFileInput struct {
+	FileInput
+	opts ParseOptions
+	// budget is the run's remaining MaxBytes, or nil.
+	budget *int64
+}
+
+func (f sampledFileInput) Open() (io.ReadCloser, error) {
+	if _, ok := f.FileInput.(SeekableFileInput); ok && f.opts.SkipBytes > 0 {
+		return f.openSkipped()
+	}
+	rc, err := f.FileInput.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&lineFilterReader{src: bufio.NewReader(rc), opts: f.opts, budget: f.budget}, rc}, nil
+}
+
+// openSkipped seeks to SkipBytes instead of reading up to it.
+func (f sampledFileInput) openSkipped() (io.ReadCloser, error) {
+	var head []byte
+	if f.opts.KeepFirstLine {
+		rc, err := f.FileInput.Open()
+		if err != nil {
+			return nil, err
+		}
+		head, err = bufio.NewReader(rc).ReadBytes('\n')
+		_ = rc.Close()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	// Open one byte early so a line starting exactly at SkipBytes is kept:
+	// the rest of the line holding that byte is dropped.
+	rc, err := openFileAt(f.FileInput, f.opts.SkipBytes-1)
+	if errors.Is(err, errOffsetBeyondEnd) {
+		return io.NopCloser(bytes.NewReader(head)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	src := bufio.NewReader(rc)
+	rest, err := src.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		_ = rc.Close()
+		return nil, err
+	}
+
+	opts := f.opts
+	opts.KeepFirstLine = false
+	return struct {
+		io.Reader
+		io.Closer
+	}{&lineFilterReader{
+		src:     src,
+		opts:    opts,
+		budget:  f.budget,
+		started: true,
+		offset:  f.opts.SkipBytes - 1 + int64(len(rest)),
+		pending: head,
+	}, rc}, nil
+}
+
+// lineFilterReader passes through the lines of src selected by opts.
+type lineFilterReader struct {
+	src     *bufio.Reader
+	opts    ParseOptions
+	budget  *int64
+	started bool
+	// offset is the position of src in the file.
+	offset  int64
+	lines   int
+	pending []byte
+	err     error
+}
+
+func (r *lineFilterReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, err := r.src.ReadBytes('\n')
+		r.err = err
+		if len(line) == 0 {
+			continue
+		}
+
+		first := !r.started
+		r.started = true
+		start := r.offset
+		r.offset += int64(len(line))
+		if r.opts.MaxBytesPerFile > 0 && r.offset > r.opts.MaxBytesPerFile {
+			r.err = io.EOF
+			continue
+		}
+		if r.budget != nil {
+			if int64(len(line)) > *r.budget {
+				*r.budget = 0
+				r.err = io.EOF
+				continue
+			}
+			*r.budget -= int64(len(line))
+		}
+		if first && r.opts.KeepFirstLine {
+			r.pending = line
+			continue
+		}
+		// A line starting before SkipBytes is skipped, even if it ends after.
+		if start < r.opts.SkipBytes {
+			continue
+		}
+		if r.opts.SampleEvery > 1 && r.lines%r.opts.SampleEvery != 0 {
+			r.lines++
+			continue
+		}
+		r.lines++
+		r.pending = line
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
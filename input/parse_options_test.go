@@ -0,0 +1,212 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func numberedLines(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, "line%02d\n", i)
+	}
+	return sb.String()
+}
+
+func TestParseFilesWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "big.log")
+	// Each line is 7 bytes: "lineNN\n".
+	writeTextFile(t, file, numberedLines(10))
+
+	tests := []struct {
+		name string
+		opts ParseOptions
+		want []string
+	}{
+		{name: "no options", opts: ParseOptions{}, want: strings.Fields(numberedLines(10))},
+		{name: "every third line", opts: ParseOptions{SampleEvery: 3}, want: []string{"line00", "line03", "line06", "line09"}},
+		{name: "skip to a line start", opts: ParseOptions{SkipBytes: 56}, want: []string{"line08", "line09"}},
+		{name: "skip into a line", opts: ParseOptions{SkipBytes: 50}, want: []string{"line08", "line09"}},
+		{name: "skip past the end", opts: ParseOptions{SkipBytes: 1000}, want: []string{}},
+		{
+			name: "keep header while sampling after a skip",
+			opts: ParseOptions{SkipBytes: 14, SampleEvery: 4, KeepFirstLine: true},
+			want: []string{"line00", "line02", "line06"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := ParseFilesWithOptions[string](NewFileStream([]string{file}), LineParser{}, tt.opts)
+			got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Stream() = %v, want %v", got, tt.want)
+			}
+			if err := source.Err(); err != nil {
+				t.Fatalf("Err() = %v, want nil", err)
+			}
+
+			// Non-seekable inputs read up to SkipBytes instead and must agree.
+			fsys := fstest.MapFS{"big.log": {Data: []byte(numberedLines(10))}}
+			source = ParseFilesWithOptions[string](NewFSFileStream(fsys, []string{"big.log"}), LineParser{}, tt.opts)
+			if got := stream.Stream(source.Seq, stream.End(stream.Collect[string]())); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Stream() over fs.FS = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("samples CSV records keeping the header", func(t *testing.T) {
+		csvFile := filepath.Join(dir, "rows.csv")
+		writeTextFile(t, csvFile, "id,name\n1,a\n2,b\n3,c\n4,d\n5,e\n")
+
+		source := ParseFilesWithOptions[map[string]string](NewFileStream([]string{csvFile}), CSVHeaderParser{}, ParseOptions{SampleEvery: 2, KeepFirstLine: true})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+
+		want := []map[string]string{{"id": "1", "name": "a"}, {"id": "3", "name": "c"}, {"id": "5", "name": "e"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+}
+
+func TestParseFilesWithOptionsLimits(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.log")
+	fileB := filepath.Join(dir, "b.log")
+	writeTextFile(t, fileA, numberedLines(5))
+	writeTextFile(t, fileB, strings.ReplaceAll(numberedLines(5), "line", "next"))
+	paths := []string{fileA, fileB}
+
+	tests := []struct {
+		name string
+		opts ParseOptions
+		want []string
+	}{
+		{
+			name: "records per file",
+			opts: ParseOptions{MaxRecordsPerFile: 2},
+			want: []string{"line00", "line01", "next00", "next01"},
+		},
+		{
+			name: "records overall",
+			opts: ParseOptions{MaxRecords: 3},
+			want: []string{"line00", "line01", "line02"},
+		},
+		{
+			name: "bytes per file cut at line ends",
+			opts: ParseOptions{MaxBytesPerFile: 20},
+			want: []string{"line00", "line01", "next00", "next01"},
+		},
+		{
+			name: "bytes overall span files",
+			opts: ParseOptions{MaxBytes: 50},
+			want: []string{"line00", "line01", "line02", "line03", "line04", "next00", "next01"},
+		},
+		{
+			name: "bytes per file after a seek",
+			opts: ParseOptions{SkipBytes: 7, MaxBytesPerFile: 21},
+			want: []string{"line01", "line02", "next01", "next02"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := ParseFilesWithOptions[string](NewFileStream(paths), LineParser{}, tt.opts)
+			got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Stream() = %v, want %v", got, tt.want)
+			}
+			if err := source.Err(); err != nil {
+				t.Fatalf("Err() = %v, want nil", err)
+			}
+
+			// The byte budget is per run.
+			if again := stream.Stream(source.Seq, stream.End(stream.Collect[string]())); !reflect.DeepEqual(again, tt.want) {
+				t.Fatalf("second run = %v, want %v", again, tt.want)
+			}
+		})
+	}
+}
+
+// bufferProbe records the buffer size of the reader each file is parsed from.
+type bufferProbe struct {
+	sizes *[]int
+}
+
+func (p bufferProbe) Parse(path string, r io.Reader, yield func(string) bool) error {
+	size := 0
+	if br, ok := r.(*bufio.Reader); ok {
+		size = br.Size()
+	}
+	*p.sizes = append(*p.sizes, size)
+	return LineParser{}.Parse(path, r, yield)
+}
+
+func TestParseFilesWithOptionsReadBufferSize(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "big.log")
+	writeTextFile(t, file, numberedLines(10))
+
+	var sizes []int
+	source := ParseFilesWithOptions[string](NewFileStream([]string{file, file}), bufferProbe{&sizes}, ParseOptions{ReadBufferSize: 64 << 10})
+	got := stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if got != 20 || !reflect.DeepEqual(sizes, []int{64 << 10, 64 << 10}) {
+		t.Fatalf("parsed %d lines with buffers %v, want 20 lines with 64 KiB buffers", got, sizes)
+	}
+
+	sizes = nil
+	source = ParseFilesWithOptions[string](NewFileStream([]string{file}), bufferProbe{&sizes}, ParseOptions{ReadBufferSize: 100})
+	stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+	if !reflect.DeepEqual(sizes, []int{4096}) {
+		t.Fatalf("buffers = %v, want a small size raised to 4 KiB", sizes)
+	}
+
+	sizes = nil
+	source = ParseFilesWithOptions[string](NewFileStream([]string{file}), bufferProbe{&sizes}, ParseOptions{})
+	stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+	if !reflect.DeepEqual(sizes, []int{0}) {
+		t.Fatalf("buffers = %v, want the file passed unbuffered", sizes)
+	}
+}
+
+func TestCSVParserReuseRecord(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.csv")
+	writeTextFile(t, file, "a,b,c\n1,2,3\n4,5,6\n")
+
+	for _, parser := range []CSVParser{{ReuseRecord: true}, {ReuseRecord: true, ColumnIndexes: []int{2, 0}}} {
+		var firsts []string
+		var backing []*string
+		source := ParseFiles[[]string](NewFileStream([]string{file}), parser)
+		for record := range source.Seq {
+			firsts = append(firsts, record[0])
+			backing = append(backing, &record[0])
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v", err)
+		}
+		want := []string{"a", "1", "4"}
+		if parser.ColumnIndexes != nil {
+			want = []string{"c", "3", "6"}
+		}
+		if !reflect.DeepEqual(firsts, want) {
+			t.Fatalf("first fields = %v, want %v", firsts, want)
+		}
+		if backing[1] != backing[2] {
+			t.Fatalf("records 2 and 3 use different slices, want the record reused")
+		}
+	}
+}
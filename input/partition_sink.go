@@ -0,0 +1,176 @@
+package input
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+)
+
+// defaultMaxOpenPartitions is used when PartitionOptions.MaxOpenFiles is not
+// set.
+const defaultMaxOpenPartitions = 64
+
+// PartitionOptions configures WritePartitioned.
+type PartitionOptions struct {
+	// MaxOpenFiles bounds the partition files held open at once. When a
+	// record arrives for another file, the least recently written one is
+	// flushed and closed, and reopened for appending if it is needed again.
+	// Defaults to 64.
+	MaxOpenFiles int
+	// Header, such as a CSV header row, is written at the start of every
+	// partition file.
+	Header []byte
+	// Perm is the mode of created files; directories get Perm plus execute
+	// bits. Defaults to 0o644.
+	Perm os.FileMode
+}
+
+// WritePartitioned writes each element to the file at pathFn(element),
+// creating parent directories as needed, so one terminal produces Hive-style
+// partitioned output such as "out/date=2024-05-01/events.jsonl". encode
+// writes one element, e.g. EncodeLine or EncodeJSONL.
+//
+// Partition files are truncated the first time they are written in a run.
+// The first error stops consumption; all files are flushed and closed before
+// it is returned.
+func WritePartitioned[A any](pathFn func(A) string, encode func(io.Writer, A) error, opts PartitionOptions) func(iter.Seq[A]) error {
+	return func(seq iter.Seq[A]) (err error) {
+		files := newPartitionFiles(opts)
+		defer func() {
+			if closeErr := files.closeAll(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+
+		for v := range seq {
+			path := pathFn(v)
+			w, err := files.get(path)
+			if err != nil {
+				return err
+			}
+			if err := encode(w, v); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+}
+
+// EncodeLine writes s followed by a newline, for WritePartitioned.
+func EncodeLine(w io.Writer, s string) error {
+	_, err := io.WriteString(w, s+"\n")
+	return err
+}
+
+// EncodeJSONL writes v as one JSON line, for WritePartitioned.
+func EncodeJSONL[T any](w io.Writer, v T) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+type partitionFile struct {
+	path string
+	file *os.File
+	buf  *bufio.Writer
+}
+
+// partitionFiles keeps an LRU of open partition files.
+type partitionFiles struct {
+	opts    PartitionOptions
+	maxOpen int
+	open    map[string]*list.Element
+	lru     *list.List
+	// written records the files already created in this run, which are
+	// appended to rather than truncated when reopened.
+	written map[string]bool
+}
+
+func newPartitionFiles(opts PartitionOptions) *partitionFiles {
+	maxOpen := opts.MaxOpenFiles
+	if maxOpen <= 0 {
+		maxOpen = defaultMaxOpenPartitions
+	}
+	if opts.Perm == 0 {
+		opts.Perm = 0o644
+	}
+	return &partitionFiles{
+		opts:    opts,
+		maxOpen: maxOpen,
+		open:    make(map[string]*list.Element),
+		lru:     list.New(),
+		written: make(map[string]bool),
+	}
+}
+
+func (p *partitionFiles) get(path string) (io.Writer, error) {
+	if elem, ok := p.open[path]; ok {
+		p.lru.MoveToFront(elem)
+		return elem.Value.(*partitionFile).buf, nil
+	}
+
+	if p.lru.Len() >= p.maxOpen {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		part := oldest.Value.(*partitionFile)
+		delete(p.open, part.path)
+		if err := part.close(); err != nil {
+			return nil, err
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	created := !p.written[path]
+	if created {
+		dirPerm := p.opts.Perm | (p.opts.Perm&0o444)>>2
+		if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+			return nil, err
+		}
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, p.opts.Perm)
+	if err != nil {
+		return nil, err
+	}
+	p.written[path] = true
+
+	part := &partitionFile{path: path, file: file, buf: bufio.NewWriter(file)}
+	p.open[path] = p.lru.PushFront(part)
+	if created && len(p.opts.Header) > 0 {
+		if _, err := part.buf.Write(p.opts.Header); err != nil {
+			return nil, fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+	return part.buf, nil
+}
+
+func (p *partitionFiles) closeAll() error {
+	var errs []error
+	for elem := p.lru.Front(); elem != nil; elem = elem.Next() {
+		errs = append(errs, elem.Value.(*partitionFile).close())
+	}
+	p.lru.Init()
+	clear(p.open)
+	return errors.Join(errs...)
+}
+
+func (f *partitionFile) close() error {
+	flushErr := f.buf.Flush()
+	closeErr := f.file.Close()
+	if flushErr != nil {
+		return fmt.Errorf("write %s: %w", f.path, flushErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("close %s: %w", f.path, closeErr)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestWritePartitioned(t *testing.T) {
+	type event struct {
+		Date string `json:"date"`
+		ID   int    `json:"id"`
+	}
+
+	t.Run("routes records by key with bounded open files", func(t *testing.T) {
+		dir := t.TempDir()
+		var events []event
+		for i := 0; i < 30; i++ {
+			events = append(events, event{Date: fmt.Sprintf("2024-05-%02d", 1+i%4), ID: i})
+		}
+		// Leave stale content that must be replaced.
+		stale := filepath.Join(dir, "date=2024-05-01", "events.jsonl")
+		if err := os.MkdirAll(filepath.Dir(stale), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTextFile(t, stale, "stale\n")
+
+		pathFn := func(e event) string {
+			return filepath.Join(dir, "date="+e.Date, "events.jsonl")
+		}
+		err := stream.Stream(slices.Values(events), stream.End(WritePartitioned(pathFn, EncodeJSONL[event], PartitionOptions{MaxOpenFiles: 2})))
+		if err != nil {
+			t.Fatalf("WritePartitioned() error = %v, want nil", err)
+		}
+
+		for day := 1; day <= 4; day++ {
+			path := filepath.Join(dir, fmt.Sprintf("date=2024-05-%02d", day), "events.jsonl")
+			source := NewFileJSONLStream[event]([]string{path})
+			got := stream.Stream(source.Seq, stream.End(stream.Collect[event]()))
+			if err := source.Err(); err != nil {
+				t.Fatalf("%s: Err() = %v, want nil", path, err)
+			}
+			var want []event
+			for _, e := range events {
+				if e.Date == fmt.Sprintf("2024-05-%02d", day) {
+					want = append(want, e)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s: Stream() = %v, want %v", path, got, want)
+			}
+		}
+	})
+
+	t.Run("header is written once per file", func(t *testing.T) {
+		dir := t.TempDir()
+		lines := []string{"a,1", "b,2", "a,3", "c,4", "a,5"}
+		pathFn := func(s string) string { return filepath.Join(dir, s[:1]+".csv") }
+		opts := PartitionOptions{MaxOpenFiles: 1, Header: []byte("key,value\n")}
+		if err := stream.Stream(slices.Values(lines), stream.End(WritePartitioned(pathFn, EncodeLine, opts))); err != nil {
+			t.Fatalf("WritePartitioned() error = %v, want nil", err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "a.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "key,value\na,1\na,3\na,5\n" {
+			t.Fatalf("a.csv = %q, expected %q", got, "key,value\na,1\na,3\na,5\n")
+		}
+	})
+
+	t.Run("encode errors stop the run", func(t *testing.T) {
+		dir := t.TempDir()
+		errBad := errors.New("bad record")
+		encode := func(w io.Writer, s string) error {
+			if s == "bad" {
+				return errBad
+			}
+			return EncodeLine(w, s)
+		}
+		pathFn := func(string) string { return filepath.Join(dir, "out.txt") }
+		err := stream.Stream(slices.Values([]string{"ok", "bad", "never"}), stream.End(WritePartitioned(pathFn, encode, PartitionOptions{})))
+		if !errors.Is(err, errBad) {
+			t.Fatalf("WritePartitioned() error = %v, want %v", err, errBad)
+		}
+		got, _ := os.ReadFile(filepath.Join(dir, "out.txt"))
+		if string(got) != "ok\n" {
+			t.Fatalf("out.txt = %q, expected records before the error to be flushed", got)
+		}
+	})
+}
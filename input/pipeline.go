@@ -0,0 +1,61 @@
+package input
+
+import "iter"
+
+// Pipeline is a pipeline definition, stages plus terminal, kept apart from
+// any source so it can be run again and again: once per file, per day or per
+// test case. Every run builds a fresh chain, so results never leak between
+// runs.
+type Pipeline[T, R any] struct {
+	build func() func(iter.Seq[T]) R
+}
+
+// NewPipeline defines a pipeline from a continuation chain such as
+// stream.Filter(fn, stream.End(stream.Count[T]())). Operators keep their
+// state inside each run, so the same chain is safe to reuse; when stages
+// capture state of their own, such as a sketch or metrics, use
+// NewPipelineFunc instead.
+func NewPipeline[T, R any](cont func(iter.Seq[T]) R) Pipeline[T, R] {
+	return Pipeline[T, R]{build: func() func(iter.Seq[T]) R { return cont }}
+}
+
+// NewPipelineFunc defines a pipeline whose chain is rebuilt by build before
+// every run.
+func NewPipelineFunc[T, R any](build func() func(iter.Seq[T]) R) Pipeline[T, R] {
+	return Pipeline[T, R]{build: build}
+}
+
+// Run executes the pipeline over in and returns its result together with
+// in.Err().
+func (p Pipeline[T, R]) Run(in Input[T]) (R, error) {
+	result := p.build()(in.Seq)
+	if in.Err == nil {
+		return result, nil
+	}
+	return result, in.Err()
+}
+
+// RunSeq executes the pipeline over a plain sequence.
+func (p Pipeline[T, R]) RunSeq(seq iter.Seq[T]) R {
+	return p.build()(seq)
+}
+
+// RunEach executes the pipeline once per input, in order, yielding each
+// input's index and the run's PipelineResult. Stopping early skips the
+// remaining inputs.
+func (p Pipeline[T, R]) RunEach(inputs ...Input[T]) iter.Seq2[int, PipelineResult[R]] {
+	return func(yield func(int, PipelineResult[R]) bool) {
+		for i, in := range inputs {
+			value, err := p.Run(in)
+			if !yield(i, PipelineResult[R]{Value: value, Err: err}) {
+				return
+			}
+		}
+	}
+}
+
+// PipelineResult is the outcome of one run in Pipeline.RunEach.
+type PipelineResult[R any] struct {
+	Value R
+	Err   error
+}
@@ -0,0 +1,87 @@
+package input
+
+import (
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/sketch"
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestPipelineRunsAgainstSeveralInputs(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.log")
+	fileB := filepath.Join(dir, "b.log")
+	writeTextFile(t, fileA, "error: disk\ninfo: ok\nerror: net\n")
+	writeTextFile(t, fileB, "info: ok\nerror: cpu\n")
+
+	errorLines := NewPipeline(stream.Filter(func(s string) bool { return strings.HasPrefix(s, "error") },
+		stream.End(stream.Collect[string]()),
+	))
+
+	got, err := errorLines.Run(NewFileLineStream([]string{fileA}))
+	if err != nil {
+		t.Fatalf("Run(a) returned error: %v", err)
+	}
+	if !slices.Equal(got, []string{"error: disk", "error: net"}) {
+		t.Fatalf("Run(a)=%v, expected [error: disk error: net]", got)
+	}
+	got, err = errorLines.Run(NewFileLineStream([]string{fileB}))
+	if err != nil {
+		t.Fatalf("Run(b) returned error: %v", err)
+	}
+	if !slices.Equal(got, []string{"error: cpu"}) {
+		t.Fatalf("Run(b)=%v, expected [error: cpu]", got)
+	}
+
+	if _, err := errorLines.Run(NewFileLineStream([]string{filepath.Join(dir, "missing.log")})); err == nil {
+		t.Fatalf("Run(missing) returned nil, expected error")
+	}
+	if got := errorLines.RunSeq(slices.Values([]string{"error: x", "ok"})); len(got) != 1 {
+		t.Fatalf("RunSeq()=%v, expected one element", got)
+	}
+}
+
+func TestPipelineFuncBuildsFreshState(t *testing.T) {
+	builds := 0
+	distinct := NewPipelineFunc(func() func(iter.Seq[string]) uint64 {
+		builds++
+		hll, err := sketch.NewHyperLogLog(sketch.DefaultHLLPrecision)
+		if err != nil {
+			t.Fatalf("NewHyperLogLog() returned error: %v", err)
+		}
+		return stream.Reduce(uint64(0), func(_ uint64, s string) uint64 {
+			hll.AddString(s)
+			return hll.Estimate()
+		})
+	})
+
+	inputs := []Input[string]{
+		sliceInput("a", "b", "c"),
+		sliceInput("a"),
+		sliceInput("x", "y"),
+	}
+	var estimates []uint64
+	for i, result := range distinct.RunEach(inputs...) {
+		if result.Err != nil {
+			t.Fatalf("run %d returned error: %v", i, result.Err)
+		}
+		estimates = append(estimates, result.Value)
+	}
+	if !slices.Equal(estimates, []uint64{3, 1, 2}) {
+		t.Fatalf("RunEach() estimates=%v, expected [3 1 2]", estimates)
+	}
+	if builds != 3 {
+		t.Fatalf("built %d times, expected 3", builds)
+	}
+
+	for range distinct.RunEach(inputs...) {
+		break
+	}
+	if builds != 4 {
+		t.Fatalf("built %d times after stopping early, expected 4", builds)
+	}
+}
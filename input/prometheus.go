@@ -0,0 +1,227 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusMetrics counts the activity of one long-running pipeline, such
+// as an ingestion daemon over NewFollowLineStream, for Prometheus. Feed it
+// with ObserveRecords, ObserveInput and ObserveSink, and expose it with
+// PrometheusHandler or RegisterWith.
+type PrometheusMetrics struct {
+	pipeline string
+
+	mu           sync.Mutex
+	records      uint64
+	parseErrors  uint64
+	sinkFailures uint64
+	latest       time.Time
+
+	// now replaces time.Now in tests.
+	now func() time.Time
+}
+
+// PrometheusMetric describes one metric of a PrometheusMetrics for
+// RegisterWith.
+type PrometheusMetric struct {
+	Name    string
+	Help    string
+	Counter bool
+	Labels  map[string]string
+	Value   func() float64
+}
+
+func NewPrometheusMetrics(pipeline string) *PrometheusMetrics {
+	return &PrometheusMetrics{pipeline: pipeline, now: time.Now}
+}
+
+// ObserveRecords counts the elements passing this point as processed
+// records. If timeFn is not nil it also tracks the latest event time, from
+// which the lag, how far the pipeline is behind its source, is reported.
+func ObserveRecords[F, A any](pm *PrometheusMetrics, timeFn func(A) time.Time, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			for v := range seq {
+				pm.mu.Lock()
+				pm.records++
+				if timeFn != nil {
+					if t := timeFn(v); t.After(pm.latest) {
+						pm.latest = t
+					}
+				}
+				pm.mu.Unlock()
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// ObserveInput counts a parse error when a run of in ends with an error.
+func ObserveInput[T any](pm *PrometheusMetrics, in Input[T]) Input[T] {
+	return Input[T]{
+		Seq: func(yield func(T) bool) {
+			in.Seq(yield)
+			if in.Err() != nil {
+				pm.AddParseErrors(1)
+			}
+		},
+		Err: in.Err,
+	}
+}
+
+// ObserveSink counts a sink failure when sink returns an error.
+func ObserveSink[A any](pm *PrometheusMetrics, sink func(iter.Seq[A]) error) func(iter.Seq[A]) error {
+	return func(seq iter.Seq[A]) error {
+		err := sink(seq)
+		if err != nil {
+			pm.AddSinkFailures(1)
+		}
+		return err
+	}
+}
+
+// AddParseErrors counts parse errors found outside of ObserveInput, e.g.
+// lines skipped by a lenient parser.
+func (pm *PrometheusMetrics) AddParseErrors(n uint64) {
+	pm.mu.Lock()
+	pm.parseErrors += n
+	pm.mu.Unlock()
+}
+
+// AddSinkFailures counts sink failures found outside of ObserveSink, e.g.
+// retried writes.
+func (pm *PrometheusMetrics) AddSinkFailures(n uint64) {
+	pm.mu.Lock()
+	pm.sinkFailures += n
+	pm.mu.Unlock()
+}
+
+// Metrics returns the metrics of pm. The lag is only included once an event
+// time has been observed.
+func (pm *PrometheusMetrics) Metrics() []PrometheusMetric {
+	labels := map[string]string{"pipeline": pm.pipeline}
+	read := func(field *uint64) func() float64 {
+		return func() float64 {
+			pm.mu.Lock()
+			defer pm.mu.Unlock()
+			return float64(*field)
+		}
+	}
+	metrics := []PrometheusMetric{
+		{Name: "go_stream_records_total", Help: "Records processed by the pipeline.", Counter: true, Labels: labels, Value: read(&pm.records)},
+		{Name: "go_stream_parse_errors_total", Help: "Parse errors in the pipeline input.", Counter: true, Labels: labels, Value: read(&pm.parseErrors)},
+		{Name: "go_stream_sink_failures_total", Help: "Failed writes of the pipeline sink.", Counter: true, Labels: labels, Value: read(&pm.sinkFailures)},
+	}
+	pm.mu.Lock()
+	tracked := !pm.latest.IsZero()
+	pm.mu.Unlock()
+	if tracked {
+		metrics = append(metrics, PrometheusMetric{
+			Name:   "go_stream_lag_seconds",
+			Help:   "Time since the event time of the latest record.",
+			Labels: labels,
+			Value: func() float64 {
+				pm.mu.Lock()
+				defer pm.mu.Unlock()
+				return pm.now().Sub(pm.latest).Seconds()
+			},
+		})
+	}
+	return metrics
+}
+
+// RegisterWith passes each metric of pm to register, the hook into an
+// existing registry. With the Prometheus client library:
+//
+//	pm.RegisterWith(func(m PrometheusMetric) {
+//		opts := prometheus.Opts{Name: m.Name, Help: m.Help, ConstLabels: m.Labels}
+//		if m.Counter {
+//			prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts(opts), m.Value))
+//		} else {
+//			prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts), m.Value))
+//		}
+//	})
+//
+// The lag is registered only if an event time has been observed by then,
+// so register after the first records or use PrometheusHandler.
+func (pm *PrometheusMetrics) RegisterWith(register func(PrometheusMetric)) {
+	for _, m := range pm.Metrics() {
+		register(m)
+	}
+}
+
+// WritePrometheus writes the metrics of the given pipelines in the
+// Prometheus text exposition format.
+func WritePrometheus(w io.Writer, pipelines ...*PrometheusMetrics) error {
+	var order []string
+	byName := map[string][]PrometheusMetric{}
+	for _, pm := range pipelines {
+		for _, m := range pm.Metrics() {
+			if _, ok := byName[m.Name]; !ok {
+				order = append(order, m.Name)
+			}
+			byName[m.Name] = append(byName[m.Name], m)
+		}
+	}
+
+	var b bytes.Buffer
+	for _, name := range order {
+		metrics := byName[name]
+		kind := "gauge"
+		if metrics[0].Counter {
+			kind = "counter"
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, metrics[0].Help, name, kind)
+		for _, m := range metrics {
+			fmt.Fprintf(&b, "%s%s %s\n", name, formatPrometheusLabels(m.Labels), formatPrometheusValue(m.Value()))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// PrometheusHandler serves the metrics of the given pipelines for scraping,
+// e.g. at /metrics.
+func PrometheusHandler(pipelines ...*PrometheusMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, pipelines...)
+	})
+}
+
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[key])
+		pairs = append(pairs, key+`="`+value+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatPrometheusValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	logs := NewPrometheusMetrics("logs")
+	logs.now = func() time.Time { return clock }
+	events := NewPrometheusMetrics(`ev"ents`)
+
+	path := filepath.Join(t.TempDir(), "in.jsonl")
+	writeTextFile(t, path, "{\"sec\":10}\n{\"sec\":40}\nbad\n")
+	input := ObserveInput(logs, ParseFiles(NewFileStream([]string{path}), JSONLinesParser[map[string]int]{}))
+	timeFn := func(m map[string]int) time.Time { return clock.Add(time.Duration(m["sec"]-60) * time.Second) }
+	sink := ObserveSink(logs, func(seq iter.Seq[map[string]int]) error {
+		for range seq {
+		}
+		return errors.New("write failed")
+	})
+	if err := stream.Stream(input.Seq, ObserveRecords(logs, timeFn, stream.End(sink))); err == nil {
+		t.Fatalf("expected the sink error")
+	}
+	stream.Stream(slices.Values([]int{1, 2, 3}), ObserveRecords(events, nil, stream.End(stream.Count[int]())))
+
+	srv := httptest.NewServer(PrometheusHandler(logs, events))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	want := strings.Join([]string{
+		"# HELP go_stream_records_total Records processed by the pipeline.",
+		"# TYPE go_stream_records_total counter",
+		`go_stream_records_total{pipeline="logs"} 2`,
+		`go_stream_records_total{pipeline="ev\"ents"} 3`,
+		"# HELP go_stream_parse_errors_total Parse errors in the pipeline input.",
+		"# TYPE go_stream_parse_errors_total counter",
+		`go_stream_parse_errors_total{pipeline="logs"} 1`,
+		`go_stream_parse_errors_total{pipeline="ev\"ents"} 0`,
+		"# HELP go_stream_sink_failures_total Failed writes of the pipeline sink.",
+		"# TYPE go_stream_sink_failures_total counter",
+		`go_stream_sink_failures_total{pipeline="logs"} 1`,
+		`go_stream_sink_failures_total{pipeline="ev\"ents"} 0`,
+		"# HELP go_stream_lag_seconds Time since the event time of the latest record.",
+		"# TYPE go_stream_lag_seconds gauge",
+		`go_stream_lag_seconds{pipeline="logs"} 20`,
+		"",
+	}, "\n")
+	if string(body) != want {
+		t.Fatalf("metrics =\n%s\nexpected\n%s", body, want)
+	}
+
+	var registered []string
+	logs.RegisterWith(func(m PrometheusMetric) { registered = append(registered, m.Name) })
+	if len(registered) != 4 {
+		t.Fatalf("RegisterWith() registered %v, expected 4 metrics", registered)
+	}
+}
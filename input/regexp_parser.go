@@ -0,0 +1,88 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+var errNoRegexpMatch = errors.New("line does not match pattern")
+
+// NonMatchPolicy decides what a RegexpParser does with lines that don't match.
+type NonMatchPolicy int
+
+const (
+	// SkipNonMatching drops lines that don't match.
+	SkipNonMatching NonMatchPolicy = iota
+	// ErrorOnNonMatching stops parsing with an error naming the line.
+	ErrorOnNonMatching
+)
+
+// RegexpParser matches each line against Re and yields its named capture
+// groups as a map from group name to the captured text. Groups that did not
+// participate in the match map to "".
+type RegexpParser struct {
+	Re       *regexp.Regexp
+	NonMatch NonMatchPolicy
+}
+
+func (p RegexpParser) Parse(_ string, r io.Reader, yield func(map[string]string) bool) error {
+	names := p.Re.SubexpNames()
+	return matchLines(r, p.Re, p.NonMatch, func(groups []string) bool {
+		row := make(map[string]string, len(names))
+		for i, name := range names {
+			if i > 0 && name != "" {
+				row[name] = groups[i]
+			}
+		}
+		return yield(row)
+	})
+}
+
+// RegexpSubmatchParser matches each line against Re and yields its capture
+// groups, in pattern order and without the whole match, as []string.
+type RegexpSubmatchParser struct {
+	Re       *regexp.Regexp
+	NonMatch NonMatchPolicy
+}
+
+func (p RegexpSubmatchParser) Parse(_ string, r io.Reader, yield func([]string) bool) error {
+	return matchLines(r, p.Re, p.NonMatch, func(groups []string) bool {
+		return yield(groups[1:])
+	})
+}
+
+// NewFileRegexpStream provides named capture groups of matching lines by
+// composing FileStream -> RegexpParser -> transform pipeline. Lines that
+// don't match are skipped.
+func NewFileRegexpStream(paths []string, re *regexp.Regexp) Input[map[string]string] {
+	return ParseFiles[map[string]string](NewFileStream(paths), RegexpParser{Re: re})
+}
+
+// matchLines yields the submatches of each line matching re.
+func matchLines(r io.Reader, re *regexp.Regexp, policy NonMatchPolicy, yield func([]string) bool) error {
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) > 0 {
+			lineNo++
+			groups := re.FindStringSubmatch(trimLineEnding(line))
+			if groups == nil && policy == ErrorOnNonMatching {
+				return fmt.Errorf("line %d: %w", lineNo, errNoRegexpMatch)
+			}
+			if groups != nil && !yield(groups) {
+				return nil
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package input
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewFileRegexpStream(t *testing.T) {
+	re := regexp.MustCompile(`^(?P<level>[A-Z]+) (?P<component>\w+)(?: code=(?P<code>\d+))?: (.*)$`)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	writeTextFile(t, file, strings.Join([]string{
+		"INFO api: started",
+		"-- separator --",
+		"ERROR db code=42: connection lost",
+	}, "\n"))
+
+	t.Run("named groups skipping non-matching lines", func(t *testing.T) {
+		source := NewFileRegexpStream([]string{file}, re)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+
+		want := []map[string]string{
+			{"level": "INFO", "component": "api", "code": ""},
+			{"level": "ERROR", "component": "db", "code": "42"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("all groups as slices", func(t *testing.T) {
+		source := ParseFiles[[]string](NewFileStream([]string{file}), RegexpSubmatchParser{Re: re})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{
+			{"INFO", "api", "", "started"},
+			{"ERROR", "db", "42", "connection lost"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("error on non-matching lines", func(t *testing.T) {
+		parser := RegexpParser{Re: re, NonMatch: ErrorOnNonMatching}
+		source := ParseFiles[map[string]string](NewFileStream([]string{file}), parser)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+
+		if len(got) != 1 {
+			t.Fatalf("Stream() = %v, want 1 record before the error", got)
+		}
+		err := source.Err()
+		if !errors.Is(err, errNoRegexpMatch) || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("Err() = %v, want non-match on line 2", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package input
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry defaults shared by the HTTP sources and sinks.
+const (
+	defaultRetries    = 3
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// retryBackoff computes exponential retry delays.
+type retryBackoff struct {
+	retries int
+	initial time.Duration
+	max     time.Duration
+}
+
+// newRetryBackoff applies the defaults to unset values; negative retries
+// disable retrying.
+func newRetryBackoff(retries int, initial, maxDelay time.Duration) retryBackoff {
+	if retries == 0 {
+		retries = defaultRetries
+	}
+	if initial <= 0 {
+		initial = defaultBackoff
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxBackoff
+	}
+	return retryBackoff{retries: retries, initial: initial, max: maxDelay}
+}
+
+// delay returns the wait before retry attempt+1: initial doubled per attempt,
+// capped at max.
+func (b retryBackoff) delay(attempt int) time.Duration {
+	if attempt >= 16 {
+		return b.max
+	}
+	return min(b.initial<<attempt, b.max)
+}
+
+// sleepUntil waits until t and reports false if ctx was cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryAfter parses a Retry-After value given in seconds or as an HTTP date.
+// It returns 0 when the value is missing or invalid.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,262 @@
+package input
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rotatedTimeLayout names rotated files; it sorts in time order.
+const rotatedTimeLayout = "20060102T150405.000000000"
+
+// RotateOptions configures WriteRotating.
+type RotateOptions struct {
+	// MaxSize rotates the file before a record would take it past this many
+	// bytes. Zero disables size-based rotation.
+	MaxSize int64
+	// MaxAge rotates the file once its first record, or the opening of an
+	// existing file, is this old. It is checked when a record arrives. Zero
+	// disables time-based rotation.
+	MaxAge time.Duration
+	// Compress gzips rotated files, adding a ".gz" suffix.
+	Compress bool
+	// MaxBackups removes the oldest rotated files beyond this many. Zero
+	// keeps all of them.
+	MaxBackups int
+	// Perm is the mode of created files. Defaults to 0o644.
+	Perm os.FileMode
+
+	// now replaces time.Now in tests.
+	now func() time.Time
+}
+
+// WriteRotating writes each element to the file at path using encode, e.g.
+// EncodeLine or EncodeJSONL, and rotates it by size and age: the current file
+// is renamed to path plus a UTC timestamp suffix, such as
+// "app.log.20240501T120000.000000000", optionally compressed, and a new file
+// is started at path. An existing file at path is appended to.
+//
+// Records are written unbuffered, so the file can be followed while a
+// long-running pipeline writes it. The first error stops consumption and is
+// returned.
+func WriteRotating[A any](path string, encode func(io.Writer, A) error, opts RotateOptions) func(iter.Seq[A]) error {
+	return func(seq iter.Seq[A]) (err error) {
+		r, err := openRotatingFile(path, opts)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := r.file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("close %s: %w", path, closeErr)
+			}
+		}()
+
+		var record bytes.Buffer
+		for v := range seq {
+			record.Reset()
+			if err := encode(&record, v); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+			if r.due(int64(record.Len())) {
+				if err := r.rotate(); err != nil {
+					return err
+				}
+			}
+			if r.size == 0 {
+				r.opened = r.opts.now()
+			}
+			n, err := r.file.Write(record.Bytes())
+			r.size += int64(n)
+			if err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+}
+
+// rotatingFile is the current output file of WriteRotating.
+type rotatingFile struct {
+	path   string
+	opts   RotateOptions
+	file   *os.File
+	size   int64
+	opened time.Time
+	// stamp and seq name the last rotated file, so later rotations within
+	// the same instant take higher suffixes even after pruning frees lower
+	// ones.
+	stamp string
+	seq   int
+}
+
+func openRotatingFile(path string, opts RotateOptions) (*rotatingFile, error) {
+	if opts.Perm == 0 {
+		opts.Perm = 0o644
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	r := &rotatingFile{path: path, opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, r.opts.Perm)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file, r.size, r.opened = file, info.Size(), r.opts.now()
+	return nil
+}
+
+// due reports whether the file must be rotated before writing n more bytes.
+// An empty file is never rotated, so a record larger than MaxSize gets a
+// file of its own.
+func (r *rotatingFile) due(n int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.opts.MaxSize > 0 && r.size+n > r.opts.MaxSize {
+		return true
+	}
+	return r.opts.MaxAge > 0 && r.opts.now().Sub(r.opened) >= r.opts.MaxAge
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", r.path, err)
+	}
+	if stamp := r.opts.now().UTC().Format(rotatedTimeLayout); stamp != r.stamp {
+		r.stamp, r.seq = stamp, 0
+	} else {
+		r.seq++
+	}
+	rotated := r.rotatedName()
+	for fileExists(rotated) || fileExists(rotated+".gz") {
+		r.seq++
+		rotated = r.rotatedName()
+	}
+	if err := os.Rename(r.path, rotated); err != nil {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	if r.opts.Compress {
+		if err := gzipFile(rotated, r.opts.Perm); err != nil {
+			return err
+		}
+	}
+	return r.prune()
+}
+
+func (r *rotatingFile) rotatedName() string {
+	if r.seq == 0 {
+		return r.path + "." + r.stamp
+	}
+	return fmt.Sprintf("%s.%s-%d", r.path, r.stamp, r.seq)
+}
+
+// prune removes the oldest rotated files beyond MaxBackups. Backups are
+// ordered by their timestamp and then by the "-N" suffix that tells apart
+// files rotated within the same instant.
+func (r *rotatingFile) prune() error {
+	if r.opts.MaxBackups <= 0 {
+		return nil
+	}
+	dir, base := filepath.Split(r.path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return err
+	}
+	type backup struct {
+		path  string
+		stamp time.Time
+		n     int
+	}
+	var backups []backup
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), base+".")
+		if !ok {
+			continue
+		}
+		stamp, n, hasN := strings.Cut(strings.TrimSuffix(suffix, ".gz"), "-")
+		t, err := time.Parse(rotatedTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		b := backup{path: filepath.Join(dir, entry.Name()), stamp: t}
+		if hasN {
+			if b.n, err = strconv.Atoi(n); err != nil || b.n <= 0 {
+				continue
+			}
+		}
+		backups = append(backups, b)
+	}
+	if len(backups) <= r.opts.MaxBackups {
+		return nil
+	}
+	slices.SortFunc(backups, func(a, b backup) int {
+		return cmp.Or(a.stamp.Compare(b.stamp), cmp.Compare(a.n, b.n))
+	})
+	var errs []error
+	for _, old := range backups[:len(backups)-r.opts.MaxBackups] {
+		errs = append(errs, os.Remove(old.path))
+	}
+	return errors.Join(errs...)
+}
+
+// gzipFile replaces path with a gzip-compressed path+".gz".
+func gzipFile(path string, perm os.FileMode) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := dst.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	if _, err := io.Copy(zw, src); err != nil {
+		return fmt.Errorf("compress %s: %w", path, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress %s: %w", path, err)
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
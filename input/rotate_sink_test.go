@@ -0,0 +1,123 @@
+package input
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// rotatedFiles returns the contents of the rotated files next to path, oldest
+// first, decompressing gzipped ones.
+func rotatedFiles(t *testing.T, path string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), filepath.Base(path)+".") {
+			continue
+		}
+		file, err := os.Open(filepath.Join(filepath.Dir(path), entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = file
+		if strings.HasSuffix(entry.Name(), ".gz") {
+			zr, err := gzip.NewReader(file)
+			if err != nil {
+				t.Fatalf("%s: %v", entry.Name(), err)
+			}
+			r = zr
+		}
+		data, err := io.ReadAll(r)
+		_ = file.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", entry.Name(), err)
+		}
+		contents = append(contents, string(data))
+	}
+	return contents
+}
+
+func TestWriteRotating(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("rotates by size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		writeTextFile(t, path, "old\n")
+		lines := []string{"aaaa", "bbbb", "cccc", "dd", "e"}
+		opts := RotateOptions{MaxSize: 10, now: func() time.Time { return start }}
+		if err := stream.Stream(slices.Values(lines), stream.End(WriteRotating(path, EncodeLine, opts))); err != nil {
+			t.Fatalf("WriteRotating() error = %v, want nil", err)
+		}
+		got := rotatedFiles(t, path)
+		if want := []string{"old\naaaa\n", "bbbb\ncccc\n"}; !slices.Equal(got, want) {
+			t.Fatalf("rotated files = %q, want %q", got, want)
+		}
+		current, _ := os.ReadFile(path)
+		if string(current) != "dd\ne\n" {
+			t.Fatalf("current file = %q, expected %q", current, "dd\ne\n")
+		}
+	})
+
+	t.Run("rotates by age, compresses and prunes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		lines := []string{"1", "2", "3", "4", "5", "6", "7"}
+		// Records arrive one second apart, so every file holds two.
+		now := start
+		tick := func(s string) string {
+			now = now.Add(time.Second)
+			return s
+		}
+		opts := RotateOptions{MaxAge: 2 * time.Second, Compress: true, MaxBackups: 2, now: func() time.Time { return now }}
+		if err := stream.Stream(slices.Values(lines), stream.Map(tick, stream.End(WriteRotating(path, EncodeLine, opts)))); err != nil {
+			t.Fatalf("WriteRotating() error = %v, want nil", err)
+		}
+		matches, _ := filepath.Glob(path + ".*.gz")
+		if len(matches) != 2 {
+			t.Fatalf("rotated files = %v, want 2 gzipped backups", matches)
+		}
+		got := append(rotatedFiles(t, path), "")
+		current, _ := os.ReadFile(path)
+		got[len(got)-1] = string(current)
+		if want := []string{"3\n4\n", "5\n6\n", "7\n"}; !slices.Equal(got, want) {
+			t.Fatalf("files = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("prunes same-instant backups in numeric order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		var lines []string
+		for i := 1; i <= 13; i++ {
+			lines = append(lines, strconv.Itoa(i))
+		}
+		// Every record gets its own file, all rotated at the same instant.
+		opts := RotateOptions{MaxSize: 1, MaxBackups: 2, now: func() time.Time { return start }}
+		if err := stream.Stream(slices.Values(lines), stream.End(WriteRotating(path, EncodeLine, opts))); err != nil {
+			t.Fatalf("WriteRotating() error = %v, want nil", err)
+		}
+		got := rotatedFiles(t, path)
+		slices.Sort(got)
+		if want := []string{"11\n", "12\n"}; !slices.Equal(got, want) {
+			t.Fatalf("rotated files = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("missing directory fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", "app.log")
+		err := stream.Stream(slices.Values([]string{"x"}), stream.End(WriteRotating(path, EncodeLine, RotateOptions{})))
+		if !os.IsNotExist(err) {
+			t.Fatalf("WriteRotating() error = %v, want not exist", err)
+		}
+	})
+}
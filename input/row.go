@@ -0,0 +1,218 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var (
+	errMissingColumn  = errors.New("no such column")
+	errNegativeColumn = errors.New("negative column index")
+)
+
+// ColumnError reports a Row column that is missing or could not be
+// converted.
+type ColumnError struct {
+	Path   string
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *ColumnError) Error() string {
+	if errors.Is(e.Err, errMissingColumn) {
+		return fmt.Sprintf("%s:%d: column %q: %v", e.Path, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: column %q: cannot convert %q: %v", e.Path, e.Line, e.Column, e.Value, e.Err)
+}
+
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
+
+// Row is a CSV record together with its file's header, so columns are read
+// by name and keep working when an export reorders them. Path and Line
+// locate the record for error messages.
+type Row struct {
+	Path   string
+	Line   int
+	header *rowHeader
+	values []string
+}
+
+// rowHeader is shared by every Row of a file.
+type rowHeader struct {
+	names   []string
+	columns map[string]int
+}
+
+func newRowHeader(names []string) *rowHeader {
+	columns := make(map[string]int, len(names))
+	for i, name := range names {
+		columns[name] = i
+	}
+	return &rowHeader{names: names, columns: columns}
+}
+
+// NewRow builds a Row from a header and values, mainly for tests and for
+// adapting records read elsewhere.
+func NewRow(header, values []string) Row {
+	return Row{header: newRowHeader(header), values: values}
+}
+
+// Header returns the column names of the row's file. It must not be
+// modified.
+func (r Row) Header() []string {
+	if r.header == nil {
+		return nil
+	}
+	return r.header.names
+}
+
+// Values returns the record's fields in file order. It must not be modified.
+func (r Row) Values() []string {
+	return r.values
+}
+
+// Lookup returns the value of the named column and whether the row has it.
+// A column missing from a short record is reported as absent.
+func (r Row) Lookup(name string) (string, bool) {
+	if r.header == nil {
+		return "", false
+	}
+	i, ok := r.header.columns[name]
+	if !ok || i >= len(r.values) {
+		return "", false
+	}
+	return r.values[i], true
+}
+
+// Get returns the value of the named column, or "" when it is absent.
+func (r Row) Get(name string) string {
+	v, _ := r.Lookup(name)
+	return v
+}
+
+// Text returns the value of the named column, or a *ColumnError when it is
+// absent.
+func (r Row) Text(name string) (string, error) {
+	v, ok := r.Lookup(name)
+	if !ok {
+		return "", r.columnError(name, "", errMissingColumn)
+	}
+	return v, nil
+}
+
+// Int parses the named column as a base-10 integer.
+func (r Row) Int(name string) (int, error) {
+	return rowValue(r, name, strconv.Atoi)
+}
+
+// Float parses the named column as a float64.
+func (r Row) Float(name string) (float64, error) {
+	return rowValue(r, name, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// Bool parses the named column with strconv.ParseBool.
+func (r Row) Bool(name string) (bool, error) {
+	return rowValue(r, name, strconv.ParseBool)
+}
+
+// Duration parses the named column with time.ParseDuration.
+func (r Row) Duration(name string) (time.Duration, error) {
+	return rowValue(r, name, time.ParseDuration)
+}
+
+// Time parses the named column with layout, or time.RFC3339 when layout is
+// empty.
+func (r Row) Time(name, layout string) (time.Time, error) {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return rowValue(r, name, func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	})
+}
+
+// Map returns the row as a map from column name to value, as
+// CSVHeaderParser yields it.
+func (r Row) Map() map[string]string {
+	m := make(map[string]string, len(r.values))
+	for i, name := range r.Header()[:min(len(r.values), len(r.Header()))] {
+		m[name] = r.values[i]
+	}
+	return m
+}
+
+func (r Row) columnError(name, value string, err error) error {
+	return &ColumnError{Path: r.Path, Line: r.Line, Column: name, Value: value, Err: err}
+}
+
+func rowValue[T any](r Row, name string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	s, ok := r.Lookup(name)
+	if !ok {
+		return zero, r.columnError(name, "", errMissingColumn)
+	}
+	v, err := parse(s)
+	if err != nil {
+		return zero, r.columnError(name, s, err)
+	}
+	return v, nil
+}
+
+// CSVRowParser parses CSV files whose first record is a header and yields
+// each following record as a Row. The header is read per file.
+type CSVRowParser struct {
+	CSVParser
+}
+
+func (p CSVRowParser) Parse(path string, r io.Reader, yield func(Row) bool) error {
+	reader := p.newReader(r)
+	names, err := readCSVHeader(reader)
+	if err != nil || names == nil {
+		return err
+	}
+	var indexes []int
+	if p.projected() {
+		if indexes, err = p.projection(names); err != nil {
+			return err
+		}
+		names = projectRecord(names, indexes)
+	}
+	header := newRowHeader(names)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var values []string
+		if indexes != nil {
+			values = projectRecord(record, indexes)
+		} else {
+			values = append([]string(nil), record...)
+		}
+		line, _ := reader.FieldPos(0)
+		row := Row{Path: path, Line: line, header: header, values: values}
+		if !yield(row) {
+			return nil
+		}
+	}
+}
+
+// NewFileCSVRowStream provides CSV input as Rows by composing
+// FileStream -> CSVRowParser -> transform pipeline.
+func NewFileCSVRowStream(paths []string) Input[Row] {
+	return ParseFiles[Row](NewFileStream(paths), CSVRowParser{})
+}
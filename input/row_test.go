@@ -0,0 +1,93 @@
+package input
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewFileCSVRowStreamReadsColumnsByName(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.csv")
+	fileB := filepath.Join(dir, "b.csv")
+	writeTextFile(t, fileA, "status,latency_ms,path\n200,12,/a\n500,340,/b\n")
+	// The second export orders its columns differently.
+	writeTextFile(t, fileB, "path,status,latency_ms\n/c,200,7\n")
+
+	source := NewFileCSVRowStream([]string{fileA, fileB})
+	rows := stream.Stream(source.Seq, stream.End(stream.Collect[Row]()))
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() returned error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, expected 3", len(rows))
+	}
+
+	var latencies []int
+	for _, row := range rows {
+		ms, err := row.Int("latency_ms")
+		if err != nil {
+			t.Fatalf("Int(latency_ms) returned error: %v", err)
+		}
+		latencies = append(latencies, ms)
+	}
+	if !slices.Equal(latencies, []int{12, 340, 7}) {
+		t.Fatalf("latencies=%v, expected [12 340 7]", latencies)
+	}
+	if rows[2].Get("path") != "/c" || rows[2].Path != fileB || rows[2].Line != 2 {
+		t.Fatalf("rows[2]=%+v, expected /c at %s:2", rows[2], fileB)
+	}
+	if !slices.Equal(rows[0].Header(), []string{"status", "latency_ms", "path"}) {
+		t.Fatalf("Header()=%v, expected file a's header", rows[0].Header())
+	}
+}
+
+func TestRowConversionErrors(t *testing.T) {
+	row := NewRow(
+		[]string{"n", "f", "ok", "wait", "at", "bad"},
+		[]string{"42", "2.5", "true", "1m30s", "2024-05-01T10:00:00Z", "x"},
+	)
+	if v, err := row.Int("n"); err != nil || v != 42 {
+		t.Fatalf("Int(n)=%d, %v, expected 42", v, err)
+	}
+	if v, err := row.Float("f"); err != nil || v != 2.5 {
+		t.Fatalf("Float(f)=%v, %v, expected 2.5", v, err)
+	}
+	if v, err := row.Bool("ok"); err != nil || !v {
+		t.Fatalf("Bool(ok)=%v, %v, expected true", v, err)
+	}
+	if v, err := row.Duration("wait"); err != nil || v != 90*time.Second {
+		t.Fatalf("Duration(wait)=%v, %v, expected 1m30s", v, err)
+	}
+	if v, err := row.Time("at", ""); err != nil || v.Hour() != 10 {
+		t.Fatalf("Time(at)=%v, %v, expected 10:00", v, err)
+	}
+
+	_, err := row.Int("bad")
+	var columnErr *ColumnError
+	if !errors.As(err, &columnErr) || columnErr.Column != "bad" || columnErr.Value != "x" {
+		t.Fatalf("Int(bad) error = %v, expected *ColumnError for column bad", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("Int(bad) error = %v, expected to wrap strconv.ErrSyntax", err)
+	}
+
+	if _, err := row.Text("missing"); !errors.Is(err, errMissingColumn) {
+		t.Fatalf("Text(missing) error = %v, expected errMissingColumn", err)
+	}
+	if v, ok := row.Lookup("missing"); ok || v != "" {
+		t.Fatalf("Lookup(missing)=%q, %v, expected absent", v, ok)
+	}
+	short := NewRow([]string{"a", "b"}, []string{"1"})
+	if _, ok := short.Lookup("b"); ok {
+		t.Fatalf("Lookup(b) on a short record reported present")
+	}
+	if m := short.Map(); len(m) != 1 || m["a"] != "1" {
+		t.Fatalf("Map()=%v, expected map[a:1]", m)
+	}
+}
@@ -0,0 +1,258 @@
+package input
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config describes how to reach an S3-compatible object store.
+type S3Config struct {
+	// Region is used for request signing and the default endpoint.
+	Region string
+	// Endpoint overrides the AWS endpoint, e.g. for MinIO or localstack.
+	// Custom endpoints are addressed path-style (endpoint/bucket/key).
+	Endpoint string
+	// Credentials for Signature Version 4. Requests are sent unsigned when
+	// AccessKeyID is empty, which works for public buckets.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// S3ConfigFromEnv reads the standard AWS_* environment variables.
+func S3ConfigFromEnv() S3Config {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return S3Config{
+		Region:          region,
+		Endpoint:        os.Getenv("AWS_ENDPOINT_URL_S3"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// s3Input is a FileInput for one S3 object, fetched with GetObject.
+type s3Input struct {
+	cfg    S3Config
+	bucket string
+	key    string
+}
+
+func (f s3Input) Path() string {
+	return "s3://" + f.bucket + "/" + f.key
+}
+
+// Open streams the object body; compressed objects are decompressed as for
+// local files.
+func (f s3Input) Open() (io.ReadCloser, error) {
+	resp, err := f.cfg.do(f.bucket, f.key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return openDecompressed(f.key, resp.Body)
+}
+
+// NewS3Stream lists objects under prefix lazily, one ListObjectsV2 page at a
+// time, and yields each object as a FileInput. Configuration comes from the
+// AWS_* environment variables; see NewS3StreamWithConfig.
+func NewS3Stream(bucket, prefix string) FileStream {
+	return NewS3StreamWithConfig(S3ConfigFromEnv(), bucket, prefix)
+}
+
+// NewS3StreamWithConfig is like NewS3Stream with explicit configuration.
+// Keys are yielded in the lexical order S3 lists them; "directory" marker
+// keys ending in "/" are skipped.
+func NewS3StreamWithConfig(cfg S3Config, bucket, prefix string) FileStream {
+	return newPagedFileStream(func(token string) ([]FileInput, string, error) {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := cfg.do(bucket, "", query)
+		if err != nil {
+			return nil, "", fmt.Errorf("list s3://%s/%s: %w", bucket, prefix, err)
+		}
+		defer resp.Body.Close()
+
+		var page struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(&page); err != nil {
+			return nil, "", fmt.Errorf("list s3://%s/%s: %w", bucket, prefix, err)
+		}
+
+		inputs := make([]FileInput, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+			inputs = append(inputs, s3Input{cfg: cfg, bucket: bucket, key: obj.Key})
+		}
+		if !page.IsTruncated {
+			return inputs, "", nil
+		}
+		return inputs, page.NextContinuationToken, nil
+	})
+}
+
+// do sends a signed GET request for bucket/key and checks the status.
+func (cfg S3Config) do(bucket, key string, query url.Values) (*http.Response, error) {
+	u, err := cfg.objectURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AccessKeyID != "" {
+		cfg.sign(req, time.Now().UTC())
+	}
+
+	return doObjectRequest(cfg.HTTPClient, req)
+}
+
+func (cfg S3Config) objectURL(bucket, key string) (*url.URL, error) {
+	if cfg.Endpoint == "" {
+		return &url.URL{
+			Scheme:  "https",
+			Host:    bucket + ".s3." + cfg.Region + ".amazonaws.com",
+			Path:    "/" + key,
+			RawPath: "/" + uriEncodePath(key),
+		}, nil
+	}
+
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(u.EscapedPath(), "/")
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+	u.RawPath = base + "/" + uriEncodePath(bucket) + "/" + uriEncodePath(key)
+	return u, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+func (cfg S3Config) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := day + "/" + cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), day)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key with RFC 3986 escaping, as
+// required for SigV4 canonical requests.
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// uriEncodePath escapes each segment of a slash-separated path.
+func uriEncodePath(s string) string {
+	segments := strings.Split(s, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
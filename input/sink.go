@@ -0,0 +1,295 @@
+package input
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var errCSVDelimiter = errors.New("invalid CSV delimiter")
+
+// WriteLines writes each element to w followed by a newline. Writes are
+// buffered and flushed when the stream ends. The first write error stops
+// consumption and is returned.
+func WriteLines(w io.Writer) func(iter.Seq[string]) error {
+	return func(seq iter.Seq[string]) error {
+		bw := bufio.NewWriter(w)
+		for line := range seq {
+			if _, err := bw.WriteString(line); err != nil {
+				return err
+			}
+			if err := bw.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+}
+
+// WriteLinesToFile is WriteLines to a file created (or truncated) at path
+// when the stream runs. Errors from creating, writing, flushing and closing
+// the file are all reported.
+func WriteLinesToFile(path string) func(iter.Seq[string]) error {
+	return writeToFile(path, WriteLines)
+}
+
+// WriteJSONL marshals each element to one line of JSON (NDJSON), as read back
+// by JSONLinesParser. Writes are buffered and flushed when the stream ends.
+// HTML characters are not escaped. The first marshal or write error stops
+// consumption and is returned.
+func WriteJSONL[T any](w io.Writer) func(iter.Seq[T]) error {
+	return func(seq iter.Seq[T]) error {
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		enc.SetEscapeHTML(false)
+		for v := range seq {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+}
+
+// WriteJSONLToFile is WriteJSONL to a file created (or truncated) at path
+// when the stream runs.
+func WriteJSONLToFile[T any](path string) func(iter.Seq[T]) error {
+	return writeToFile(path, WriteJSONL[T])
+}
+
+// CSVWriteOptions configures the CSV terminals.
+type CSVWriteOptions struct {
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+	// UseCRLF ends records with \r\n instead of \n.
+	UseCRLF bool
+	// QuoteAll quotes every field; otherwise fields are quoted only when
+	// they contain the delimiter, quotes, newlines or leading spaces.
+	QuoteAll bool
+	// TimeLayout formats time.Time struct fields without a layout tag
+	// option. Defaults to time.RFC3339.
+	TimeLayout string
+}
+
+// WriteCSV writes each record to w as CSV, preceded by header unless it is
+// nil. The first write error stops consumption and is returned.
+func WriteCSV(w io.Writer, header []string) func(iter.Seq[[]string]) error {
+	return WriteCSVWithOptions(w, header, CSVWriteOptions{})
+}
+
+// WriteCSVWithOptions is WriteCSV with a configurable delimiter and quoting.
+func WriteCSVWithOptions(w io.Writer, header []string, opts CSVWriteOptions) func(iter.Seq[[]string]) error {
+	return func(seq iter.Seq[[]string]) error {
+		cw, err := newCSVRecordWriter(w, opts)
+		if err != nil {
+			return err
+		}
+		if header != nil {
+			if err := cw.write(header); err != nil {
+				return err
+			}
+		}
+		for record := range seq {
+			if err := cw.write(record); err != nil {
+				return err
+			}
+		}
+		return cw.flush()
+	}
+}
+
+// WriteCSVStructs writes structs of type T as CSV with a header row. Columns
+// follow the field order and `csv` tags understood by CSVStructParser, so
+// the output parses back into T. Nil pointers, including nil embedded struct
+// pointers, are written as empty fields.
+func WriteCSVStructs[T any](w io.Writer, opts CSVWriteOptions) func(iter.Seq[T]) error {
+	return func(seq iter.Seq[T]) error {
+		fields, err := csvStructFields(reflect.TypeFor[T](), opts.TimeLayout)
+		if err != nil {
+			return err
+		}
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			if !formattableCSVFieldType(field.typ) {
+				return fmt.Errorf("field %s: %w %s", field.plan.name, errUnsupportedCSVField, field.typ)
+			}
+			header[i] = field.column
+		}
+
+		cw, err := newCSVRecordWriter(w, opts)
+		if err != nil {
+			return err
+		}
+		if err := cw.write(header); err != nil {
+			return err
+		}
+		record := make([]string, len(fields))
+		for v := range seq {
+			value := reflect.ValueOf(&v).Elem()
+			for i, field := range fields {
+				// A field promoted through a nil embedded pointer is empty.
+				fieldValue, err := value.FieldByIndexErr(field.plan.index)
+				if err != nil {
+					record[i] = ""
+					continue
+				}
+				s, err := formatCSVField(fieldValue, field.plan.layout)
+				if err != nil {
+					return fmt.Errorf("field %s: %w", field.plan.name, err)
+				}
+				record[i] = s
+			}
+			if err := cw.write(record); err != nil {
+				return err
+			}
+		}
+		return cw.flush()
+	}
+}
+
+// csvRecordWriter writes records with encoding/csv, or quotes every field
+// itself when QuoteAll is set.
+type csvRecordWriter struct {
+	csv      *csv.Writer
+	buf      *bufio.Writer
+	comma    string
+	lineEnd  string
+	quoteAll bool
+}
+
+func newCSVRecordWriter(w io.Writer, opts CSVWriteOptions) (*csvRecordWriter, error) {
+	comma := opts.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	if comma == '"' || comma == '\r' || comma == '\n' || comma == utf8.RuneError || !utf8.ValidRune(comma) {
+		return nil, fmt.Errorf("%w %q", errCSVDelimiter, comma)
+	}
+	if !opts.QuoteAll {
+		cw := csv.NewWriter(w)
+		cw.Comma = comma
+		cw.UseCRLF = opts.UseCRLF
+		return &csvRecordWriter{csv: cw}, nil
+	}
+
+	lineEnd := "\n"
+	if opts.UseCRLF {
+		lineEnd = "\r\n"
+	}
+	return &csvRecordWriter{buf: bufio.NewWriter(w), comma: string(comma), lineEnd: lineEnd, quoteAll: true}, nil
+}
+
+func (w *csvRecordWriter) write(record []string) error {
+	if !w.quoteAll {
+		return w.csv.Write(record)
+	}
+	for i, field := range record {
+		if i > 0 {
+			_, _ = w.buf.WriteString(w.comma)
+		}
+		_ = w.buf.WriteByte('"')
+		_, _ = w.buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		_ = w.buf.WriteByte('"')
+	}
+	_, err := w.buf.WriteString(w.lineEnd)
+	return err
+}
+
+func (w *csvRecordWriter) flush() error {
+	if !w.quoteAll {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.buf.Flush()
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+func formattableCSVFieldType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == timeType || typ.Implements(textMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// formatCSVField is the inverse of setCSVField.
+func formatCSVField(v reflect.Value, layout string) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(layout), nil
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("%w %s", errUnsupportedCSVField, v.Type())
+}
+
+// writeToFile adapts a writer-based terminal to one that writes a new file at
+// path, closing it even when writing fails.
+func writeToFile[A any](path string, sink func(io.Writer) func(iter.Seq[A]) error) func(iter.Seq[A]) error {
+	return func(seq iter.Seq[A]) (err error) {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("close %s: %w", path, closeErr)
+			}
+		}()
+		if err := sink(file)(seq); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,226 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// failingWriter accepts limit bytes and then fails.
+type failingWriter struct {
+	limit int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errWriteFailed
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteLines(t *testing.T) {
+	t.Run("writes one line per element", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := stream.Stream(
+			slices.Values([]string{"a", "b", "c"}),
+			stream.Filter(func(s string) bool { return s != "b" },
+				stream.End(WriteLines(&buf)),
+			),
+		)
+		if err != nil {
+			t.Fatalf("WriteLines() error = %v, want nil", err)
+		}
+		if buf.String() != "a\nc\n" {
+			t.Fatalf("output = %q, expected %q", buf.String(), "a\nc\n")
+		}
+	})
+
+	t.Run("stops on write errors", func(t *testing.T) {
+		consumed := 0
+		seq := func(yield func(string) bool) {
+			for range 10000 {
+				consumed++
+				if !yield(strings.Repeat("x", 100)) {
+					return
+				}
+			}
+		}
+		err := stream.Stream(seq, stream.End(WriteLines(&failingWriter{limit: 10})))
+		if !errors.Is(err, errWriteFailed) {
+			t.Fatalf("WriteLines() error = %v, want %v", err, errWriteFailed)
+		}
+		if consumed == 10000 {
+			t.Fatal("expected consumption to stop after the write error")
+		}
+	})
+}
+
+func TestWriteLinesToFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+	writeTextFile(t, path, "old content that is longer\n")
+
+	if err := stream.Stream(slices.Values([]string{"x", "y"}), stream.End(WriteLinesToFile(path))); err != nil {
+		t.Fatalf("WriteLinesToFile() error = %v, want nil", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "x\ny\n" {
+		t.Fatalf("file content = %q, expected %q", got, "x\ny\n")
+	}
+
+	err = stream.Stream(slices.Values([]string{"x"}), stream.End(WriteLinesToFile(filepath.Join(dir, "missing", "out.txt"))))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("WriteLinesToFile() error = %v, want not exist", err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	records := [][]string{{"1", "plain"}, {"2", "has,comma"}, {"3", "say \"hi\"\nbye"}}
+
+	t.Run("header and minimal quoting", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := stream.Stream(slices.Values(records), stream.End(WriteCSV(&buf, []string{"id", "note"}))); err != nil {
+			t.Fatalf("WriteCSV() error = %v, want nil", err)
+		}
+		want := "id,note\n1,plain\n2,\"has,comma\"\n3,\"say \"\"hi\"\"\nbye\"\n"
+		if buf.String() != want {
+			t.Fatalf("output = %q, expected %q", buf.String(), want)
+		}
+	})
+
+	t.Run("delimiter, CRLF and quote all", func(t *testing.T) {
+		var buf bytes.Buffer
+		opts := CSVWriteOptions{Comma: ';', UseCRLF: true, QuoteAll: true}
+		if err := stream.Stream(slices.Values(records[:2]), stream.End(WriteCSVWithOptions(&buf, nil, opts))); err != nil {
+			t.Fatalf("WriteCSVWithOptions() error = %v, want nil", err)
+		}
+		want := "\"1\";\"plain\"\r\n\"2\";\"has,comma\"\r\n"
+		if buf.String() != want {
+			t.Fatalf("output = %q, expected %q", buf.String(), want)
+		}
+
+		err := stream.Stream(slices.Values(records), stream.End(WriteCSVWithOptions(&buf, nil, CSVWriteOptions{Comma: '"'})))
+		if !errors.Is(err, errCSVDelimiter) {
+			t.Fatalf("WriteCSVWithOptions() error = %v, want %v", err, errCSVDelimiter)
+		}
+	})
+
+	t.Run("output parses back", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out.csv")
+		var buf bytes.Buffer
+		if err := stream.Stream(slices.Values(records), stream.End(WriteCSV(&buf, nil))); err != nil {
+			t.Fatalf("WriteCSV() error = %v, want nil", err)
+		}
+		writeTextFile(t, path, buf.String())
+		source := NewFileCSVStream([]string{path})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+		if !slices.EqualFunc(got, records, slices.Equal) {
+			t.Fatalf("Stream() = %q, want %q", got, records)
+		}
+	})
+}
+
+type csvExportRow struct {
+	ID      int       `csv:"id"`
+	Name    string    `csv:"name"`
+	Score   float64   `csv:"score"`
+	Seen    time.Time `csv:"seen,layout=2006-01-02"`
+	Manager *string   `csv:"manager"`
+	Secret  string    `csv:"-"`
+	Active  bool
+}
+
+func TestWriteCSVStructs(t *testing.T) {
+	boss := "ada"
+	rows := []csvExportRow{
+		{ID: 1, Name: "Grace", Score: 9.5, Seen: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Manager: &boss, Secret: "x", Active: true},
+		{ID: 2, Name: "Linus, Jr.", Score: 7, Seen: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	if err := stream.Stream(slices.Values(rows), stream.End(WriteCSVStructs[csvExportRow](&buf, CSVWriteOptions{}))); err != nil {
+		t.Fatalf("WriteCSVStructs() error = %v, want nil", err)
+	}
+	want := "id,name,score,seen,manager,Active\n" +
+		"1,Grace,9.5,2024-03-01,ada,true\n" +
+		"2,\"Linus, Jr.\",7,2024-03-02,,false\n"
+	if buf.String() != want {
+		t.Fatalf("output = %q, expected %q", buf.String(), want)
+	}
+
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	writeTextFile(t, path, buf.String())
+	source := NewFileCSVStructStream[csvExportRow]([]string{path})
+	got := stream.Stream(source.Seq, stream.End(stream.Collect[csvExportRow]()))
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	if len(got) != 2 || got[0].Name != rows[0].Name || *got[0].Manager != boss || got[1].Manager != nil || !got[1].Seen.Equal(rows[1].Seen) {
+		t.Fatalf("round trip = %+v, want %+v", got, rows)
+	}
+
+	// csvEmbedded is defined in csv_struct_test.go.
+	embedded := []csvEmbedded{{Name: "ann"}, {CSVBase: &CSVBase{ID: 7}, Name: "bob"}}
+	buf.Reset()
+	if err := stream.Stream(slices.Values(embedded), stream.End(WriteCSVStructs[csvEmbedded](&buf, CSVWriteOptions{}))); err != nil {
+		t.Fatalf("WriteCSVStructs() error = %v, want nil", err)
+	}
+	if want := "id,name\n,ann\n7,bob\n"; buf.String() != want {
+		t.Fatalf("output = %q, expected %q", buf.String(), want)
+	}
+
+	type unsupported struct{ Tags []string }
+	err := stream.Stream(slices.Values([]unsupported{{}}), stream.End(WriteCSVStructs[unsupported](&buf, CSVWriteOptions{})))
+	if !errors.Is(err, errUnsupportedCSVField) {
+		t.Fatalf("WriteCSVStructs() error = %v, want %v", err, errUnsupportedCSVField)
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Path string `json:"path"`
+	}
+	events := []event{{1, "/a?x=1&y=<2>"}, {2, "/b"}}
+
+	var buf bytes.Buffer
+	if err := stream.Stream(slices.Values(events), stream.End(WriteJSONL[event](&buf))); err != nil {
+		t.Fatalf("WriteJSONL() error = %v, want nil", err)
+	}
+	want := "{\"id\":1,\"path\":\"/a?x=1&y=<2>\"}\n{\"id\":2,\"path\":\"/b\"}\n"
+	if buf.String() != want {
+		t.Fatalf("output = %q, expected %q", buf.String(), want)
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := stream.Stream(slices.Values(events), stream.End(WriteJSONLToFile[event](path))); err != nil {
+		t.Fatalf("WriteJSONLToFile() error = %v, want nil", err)
+	}
+	source := NewFileJSONLStream[event]([]string{path})
+	if got := stream.Stream(source.Seq, stream.End(stream.Collect[event]())); !slices.Equal(got, events) {
+		t.Fatalf("Stream() = %v, want %v", got, events)
+	}
+
+	err := stream.Stream(slices.Values([]float64{math.NaN()}), stream.End(WriteJSONL[float64](&buf)))
+	var unsupported *json.UnsupportedValueError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("WriteJSONL() error = %v, want *json.UnsupportedValueError", err)
+	}
+}
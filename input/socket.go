@@ -0,0 +1,184 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// NewSocketLineStream dials addr on a stream network ("tcp", "unix", ...) and
+// yields newline-delimited records until the peer closes the connection or
+// ctx is cancelled. A new connection is dialed for every run.
+// Cancellation ends the stream without an error.
+func NewSocketLineStream(ctx context.Context, network, addr string) FileLineStream {
+	var state runErrState
+
+	seq := func(yield func(string) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			if ctx.Err() == nil {
+				setFirstErr(&runErr, fmt.Errorf("dial %s %s: %w", network, addr, err))
+			}
+			return
+		}
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+		defer stop()
+
+		err = readSocketLines(conn, yield)
+		if err != nil && ctx.Err() == nil {
+			setFirstErr(&runErr, fmt.Errorf("read %s %s: %w", network, addr, err))
+		}
+	}
+
+	return FileLineStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// NewListenerLineStream accepts connections on ln and yields newline-delimited
+// records from all of them, interleaved as they arrive, until ctx is
+// cancelled or the consumer stops. Lines from one connection keep their order.
+//
+// The stream owns ln and closes it when the run ends, so it can be consumed
+// only once. Errors on individual connections end that connection only;
+// an Accept failure ends the stream and is reported by Err.
+func NewListenerLineStream(ctx context.Context, ln net.Listener) FileLineStream {
+	var state runErrState
+
+	seq := func(yield func(string) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		lines := make(chan string, 64)
+		done := make(chan struct{})
+		var (
+			mu       sync.Mutex
+			conns    = map[net.Conn]struct{}{}
+			stopping bool
+		)
+		shutdown := func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if stopping {
+				return
+			}
+			stopping = true
+			close(done)
+			_ = ln.Close()
+			for conn := range conns {
+				_ = conn.Close()
+			}
+		}
+		stopOnCancel := context.AfterFunc(ctx, shutdown)
+		defer stopOnCancel()
+
+		var acceptErr error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					mu.Lock()
+					if !stopping {
+						acceptErr = err
+					}
+					mu.Unlock()
+					return
+				}
+
+				mu.Lock()
+				if stopping {
+					mu.Unlock()
+					_ = conn.Close()
+					return
+				}
+				conns[conn] = struct{}{}
+				mu.Unlock()
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() {
+						mu.Lock()
+						delete(conns, conn)
+						mu.Unlock()
+						_ = conn.Close()
+					}()
+					_ = readSocketLines(conn, func(line string) bool {
+						select {
+						case lines <- line:
+							return true
+						case <-done:
+							return false
+						}
+					})
+				}()
+			}
+		}()
+		go func() {
+			wg.Wait()
+			close(lines)
+		}()
+
+		defer func() {
+			shutdown()
+			for range lines {
+			}
+			if acceptErr != nil {
+				setFirstErr(&runErr, fmt.Errorf("accept %s: %w", ln.Addr(), acceptErr))
+			}
+		}()
+
+		for line := range lines {
+			if !yield(line) {
+				return
+			}
+		}
+	}
+
+	return FileLineStream{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// readSocketLines yields lines read from conn until the peer closes it, the
+// connection is closed locally, or yield asks to stop. A final unterminated
+// line is yielded when the peer closes the connection.
+func readSocketLines(conn net.Conn, yield func(string) bool) error {
+	reader := bufio.NewReader(conn)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) > 0 && (readErr == nil || readErr == io.EOF) {
+			if !yield(trimLineEnding(line)) {
+				return nil
+			}
+		}
+		if readErr == io.EOF || errors.Is(readErr, net.ErrClosed) {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package input
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewSocketLineStream(t *testing.T) {
+	t.Run("reads lines until the peer closes", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen() error: %v", err)
+		}
+		defer ln.Close()
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("<13>first\r\n<13>second\n<13>last"))
+			_ = conn.Close()
+		}()
+
+		source := NewSocketLineStream(context.Background(), "tcp", ln.Addr().String())
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+
+		want := []string{"<13>first", "<13>second", "<13>last"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("stops on context cancel", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen() error: %v", err)
+		}
+		defer ln.Close()
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, _ = conn.Write([]byte("hello\n"))
+			time.Sleep(5 * time.Second)
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		source := NewSocketLineStream(ctx, "tcp", ln.Addr().String())
+		got := stream.Stream(source.Seq, stream.Map(func(s string) string {
+			cancel()
+			return s
+		}, stream.End(stream.Collect[string]())))
+
+		if !reflect.DeepEqual(got, []string{"hello"}) {
+			t.Fatalf("Stream() = %v, want [hello]", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports dial errors", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen() error: %v", err)
+		}
+		addr := ln.Addr().String()
+		_ = ln.Close()
+
+		source := NewSocketLineStream(context.Background(), "tcp", addr)
+		_ = stream.Stream(source.Seq, stream.End(stream.Count[string]()))
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+}
+
+func TestNewListenerLineStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+
+	send := func(lines string) {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Errorf("Dial() error: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte(lines))
+	}
+	go send("a1\na2\n")
+	go send("b1\nb2\n")
+
+	source := NewListenerLineStream(context.Background(), ln)
+	got := stream.Stream(source.Seq, stream.Take(4, stream.End(stream.Collect[string]())))
+	slices.Sort(got)
+
+	want := []string{"a1", "a2", "b1", "b2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stream() = %v, want %v", got, want)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	if _, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		t.Fatal("listener still accepting after the run ended")
+	}
+}
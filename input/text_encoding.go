@@ -0,0 +1,132 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// TextDecoder converts the bytes of a file in some text encoding to UTF-8.
+// It is called once per file. Decoders from golang.org/x/text plug in as
+//
+//	func(r io.Reader) io.Reader { return japanese.ShiftJIS.NewDecoder().Reader(r) }
+//
+// creating the decoder inside the function, as x/text decoders are stateful.
+type TextDecoder func(r io.Reader) io.Reader
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// DecodeBOM is a TextDecoder that detects the encoding from a leading byte
+// order mark: UTF-8, UTF-16LE or UTF-16BE. The mark is dropped; content
+// without one is passed through as UTF-8.
+func DecodeBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(3)
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		_, _ = br.Discard(len(utf8BOM))
+		return br
+	case bytes.HasPrefix(head, utf16LEBOM):
+		_, _ = br.Discard(len(utf16LEBOM))
+		return newUTF16Reader(br, binary.LittleEndian)
+	case bytes.HasPrefix(head, utf16BEBOM):
+		_, _ = br.Discard(len(utf16BEBOM))
+		return newUTF16Reader(br, binary.BigEndian)
+	}
+	return br
+}
+
+// DecodeUTF16LE is a TextDecoder for little-endian UTF-16, the usual
+// "Unicode" export format on Windows. A leading byte order mark is dropped.
+func DecodeUTF16LE(r io.Reader) io.Reader {
+	return newUTF16Reader(skipPrefix(r, utf16LEBOM), binary.LittleEndian)
+}
+
+// DecodeUTF16BE is a TextDecoder for big-endian UTF-16. A leading byte order
+// mark is dropped.
+func DecodeUTF16BE(r io.Reader) io.Reader {
+	return newUTF16Reader(skipPrefix(r, utf16BEBOM), binary.BigEndian)
+}
+
+func skipPrefix(r io.Reader, prefix []byte) io.Reader {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(prefix)); bytes.Equal(head, prefix) {
+		_, _ = br.Discard(len(prefix))
+	}
+	return br
+}
+
+// utf16Reader decodes UTF-16 from src into UTF-8. Unpaired surrogates and a
+// trailing odd byte become utf8.RuneError.
+type utf16Reader struct {
+	src   io.Reader
+	order binary.ByteOrder
+	raw   []byte
+	// carry is the number of undecoded bytes at the start of raw: an odd
+	// byte or a high surrogate waiting for its pair.
+	carry int
+	buf   []byte
+	// out is the decoded part of buf not yet read.
+	out []byte
+	err error
+}
+
+func newUTF16Reader(src io.Reader, order binary.ByteOrder) *utf16Reader {
+	return &utf16Reader{src: src, order: order, raw: make([]byte, 4096)}
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *utf16Reader) fill() {
+	n, err := r.src.Read(r.raw[r.carry:])
+	n += r.carry
+
+	out := r.buf[:0]
+	i := 0
+	for ; i+1 < n; i += 2 {
+		u := rune(r.order.Uint16(r.raw[i:]))
+		if u >= 0xd800 && u < 0xdc00 {
+			if i+3 >= n {
+				if err == nil {
+					break
+				}
+				out = utf8.AppendRune(out, utf8.RuneError)
+				continue
+			}
+			if pair := utf16.DecodeRune(u, rune(r.order.Uint16(r.raw[i+2:]))); pair != utf8.RuneError {
+				out = utf8.AppendRune(out, pair)
+				i += 2
+				continue
+			}
+		}
+		// Lone surrogates are not valid runes and encode as RuneError.
+		out = utf8.AppendRune(out, u)
+	}
+	r.carry = copy(r.raw, r.raw[i:n])
+
+	if err != nil {
+		if r.carry > 0 && err == io.EOF {
+			out = utf8.AppendRune(out, utf8.RuneError)
+			r.carry = 0
+		}
+		r.err = err
+	}
+	r.buf, r.out = out, out
+}
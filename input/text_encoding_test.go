@@ -0,0 +1,85 @@
+package input
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func utf16Bytes(s string, order binary.AppendByteOrder, bom bool) []byte {
+	var b []byte
+	if bom {
+		b = order.AppendUint16(b, 0xfeff)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, u)
+	}
+	return b
+}
+
+func TestLineParserEncoding(t *testing.T) {
+	dir := t.TempDir()
+	text := "名前,値\r\ncafé 😀\r\nlast"
+	want := []string{"名前,値", "café 😀", "last"}
+
+	files := map[string][]byte{
+		"utf16le-bom.txt": utf16Bytes(text, binary.LittleEndian, true),
+		"utf16be-bom.txt": utf16Bytes(text, binary.BigEndian, true),
+		"utf8-bom.txt":    append(bytes.Clone(utf8BOM), text...),
+		"utf8.txt":        []byte(text),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		source := ParseFiles[string](NewFileStream([]string{path}), LineParser{Encoding: DecodeBOM})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[string]()))
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: Stream() = %q, want %q", name, got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("%s: Err() = %v, want nil", name, err)
+		}
+	}
+
+	path := filepath.Join(dir, "no-bom.txt")
+	if err := os.WriteFile(path, utf16Bytes(text, binary.LittleEndian, false), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	source := ParseFiles[string](NewFileStream([]string{path}), LineParser{Encoding: DecodeUTF16LE})
+	if got := stream.Stream(source.Seq, stream.End(stream.Collect[string]())); !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeUTF16LE: Stream() = %q, want %q", got, want)
+	}
+}
+
+func TestUTF16Reader(t *testing.T) {
+	t.Run("surrogate pairs split across reads", func(t *testing.T) {
+		text := strings.Repeat("a😀", 3000)
+		r := DecodeUTF16BE(iotest.OneByteReader(bytes.NewReader(utf16Bytes(text, binary.BigEndian, false))))
+		got, err := io.ReadAll(r)
+		if err != nil || string(got) != text {
+			t.Fatalf("ReadAll() = %d bytes, %v, want %d bytes", len(got), err, len(text))
+		}
+	})
+
+	t.Run("invalid input becomes replacement characters", func(t *testing.T) {
+		input := binary.LittleEndian.AppendUint16(nil, 0xd800) // lone high surrogate
+		input = binary.LittleEndian.AppendUint16(input, 'x')
+		input = binary.LittleEndian.AppendUint16(input, 0xdc00) // lone low surrogate
+		input = append(input, 'y')                              // odd trailing byte
+		got, err := io.ReadAll(DecodeUTF16LE(bytes.NewReader(input)))
+		if err != nil || string(got) != "�x��" {
+			t.Fatalf("ReadAll() = %q, %v, want %q", got, err, "�x��")
+		}
+	})
+}
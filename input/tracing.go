@@ -0,0 +1,162 @@
+package input
+
+import (
+	"context"
+	"io"
+)
+
+// Tracer starts spans. It is the subset of an OpenTelemetry tracer the
+// tracing hooks need, so this package does not depend on the OTel SDK; an
+// adapter is a few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+//		ctx, span := t.Tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttributes(attrs ...SpanAttribute) {
+//		for _, a := range attrs {
+//			switch v := a.Value.(type) {
+//			case string:
+//				s.Span.SetAttributes(attribute.String(a.Key, v))
+//			case int64:
+//				s.Span.SetAttributes(attribute.Int64(a.Key, v))
+//			}
+//		}
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.Span.RecordError(err)
+//		s.Span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.Span.End() }
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a started span, ended with End.
+type Span interface {
+	SetAttributes(attrs ...SpanAttribute)
+	RecordError(err error)
+	End()
+}
+
+// SpanAttribute is a span attribute. Values are strings or int64s.
+type SpanAttribute struct {
+	Key   string
+	Value any
+}
+
+// Span attribute keys set by ParseFilesTraced.
+const (
+	AttrFilePath    = "file.path"
+	AttrFileRecords = "file.records"
+	AttrFileBytes   = "file.bytes"
+	AttrFileErrors  = "file.errors"
+)
+
+// TraceRun runs a pipeline inside a span named name, recording the error it
+// returns. run receives the span's context; pass it to ParseFilesTraced so
+// the file spans become children of the run span. A nil tracer just calls
+// run.
+func TraceRun(ctx context.Context, tracer Tracer, name string, run func(ctx context.Context) error) error {
+	if tracer == nil {
+		return run(ctx)
+	}
+	ctx, span := tracer.Start(ctx, name)
+	defer span.End()
+	err := run(ctx)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// ParseFilesTraced is ParseFiles with a span named "parse file" per file,
+// a child of the span in ctx, carrying the path, the number of records
+// yielded and of bytes read (after decompression), and whether the file
+// failed. A nil tracer
+// parses without spans.
+func ParseFilesTraced[T any](ctx context.Context, tracer Tracer, files FileStream, parser FileParser[T]) Input[T] {
+	if tracer == nil {
+		return ParseFiles(files, parser)
+	}
+	var state runErrState
+
+	seq := func(yield func(T) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		for file := range files.Seq {
+			_, span := tracer.Start(ctx, "parse file")
+			counted := &countingFileInput{FileInput: file}
+			var records int64
+			consumerStopped, err := parseFileWith[T](counted, parser, func(v T) bool {
+				records++
+				return yield(v)
+			})
+			failed := int64(0)
+			if err != nil {
+				failed = 1
+				span.RecordError(err)
+			}
+			span.SetAttributes(
+				SpanAttribute{Key: AttrFilePath, Value: file.Path()},
+				SpanAttribute{Key: AttrFileRecords, Value: records},
+				SpanAttribute{Key: AttrFileBytes, Value: counted.n},
+				SpanAttribute{Key: AttrFileErrors, Value: failed},
+			)
+			span.End()
+
+			setFirstErr(&runErr, err)
+			if consumerStopped || runErr != nil {
+				return
+			}
+		}
+		if sourceErr := files.Err(); sourceErr != nil {
+			setFirstErr(&runErr, sourceErr)
+		}
+	}
+
+	return Input[T]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+// countingFileInput counts the bytes read from the files it opens.
+type countingFileInput struct {
+	FileInput
+	n int64
+}
+
+func (f *countingFileInput) Open() (io.ReadCloser, error) {
+	rc, err := f.FileInput.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&countingReader{r: rc, n: &f.n}, rc}, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	*r.n += int64(n)
+	return n, err
+}
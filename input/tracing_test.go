@@ -0,0 +1,89 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+// fakeTracer records spans; the parent is taken from the context.
+type fakeTracer struct {
+	spans []*recordedSpan
+}
+
+type spanNameKey struct{}
+
+func (tr *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanNameKey{}).(string)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[string]any{}}
+	tr.spans = append(tr.spans, span)
+	return context.WithValue(ctx, spanNameKey{}, name), span
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...SpanAttribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+
+func (s *recordedSpan) End() { s.ended = true }
+
+func TestParseFilesTraced(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.jsonl")
+	bad := filepath.Join(dir, "b.jsonl")
+	writeTextFile(t, good, "{\"n\":1}\n{\"n\":2}\n")
+	writeTextFile(t, bad, "{\"n\":3}\nnot json\n")
+
+	tracer := &fakeTracer{}
+	var count int
+	err := TraceRun(context.Background(), tracer, "etl", func(ctx context.Context) error {
+		input := ParseFilesTraced(ctx, tracer, NewFileStream([]string{good, bad}), JSONLinesParser[map[string]int]{})
+		count = stream.Stream(input.Seq, stream.End(stream.Count[map[string]int]()))
+		return input.Err()
+	})
+	if err == nil || count != 3 {
+		t.Fatalf("run = %d records, error %v, expected 3 records and a parse error", count, err)
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("got %d spans, expected 3", len(tracer.spans))
+	}
+	run, first, second := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	if run.name != "etl" || !errors.Is(run.err, err) || !run.ended {
+		t.Fatalf("run span = %+v, expected ended etl span with the error", run)
+	}
+	want := map[string]any{AttrFilePath: good, AttrFileRecords: int64(2), AttrFileBytes: int64(16), AttrFileErrors: int64(0)}
+	if first.parent != "etl" || !reflect.DeepEqual(first.attrs, want) || first.err != nil || !first.ended {
+		t.Fatalf("first file span = %+v, expected attributes %v under etl", first, want)
+	}
+	if second.attrs[AttrFileErrors] != int64(1) || second.attrs[AttrFileRecords] != int64(1) || second.err == nil {
+		t.Fatalf("second file span = %+v, expected one record and an error", second)
+	}
+}
+
+func TestParseFilesTracedNilTracer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	writeTextFile(t, path, "x\ny\n")
+	input := ParseFilesTraced(context.Background(), nil, NewFileStream([]string{path}), LineParser{})
+	if n := stream.Stream(input.Seq, stream.End(stream.Count[string]())); n != 2 || input.Err() != nil {
+		t.Fatalf("Count()=%d, Err()=%v, expected 2 and nil", n, input.Err())
+	}
+	if err := TraceRun(context.Background(), nil, "run", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("TraceRun() = %v, expected nil", err)
+	}
+}
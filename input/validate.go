@@ -0,0 +1,149 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	errRequiredValue   = errors.New("required value is empty")
+	errPatternMismatch = errors.New("does not match pattern")
+	errOutOfRange      = errors.New("out of range")
+)
+
+// FieldType is the type a FieldRule expects a column's value to parse as.
+type FieldType int
+
+const (
+	// FieldString accepts any value.
+	FieldString FieldType = iota
+	FieldInt
+	FieldFloat
+	FieldBool
+	// FieldTime parses with FieldRule.Layout, or time.RFC3339 when empty.
+	FieldTime
+	FieldDuration
+)
+
+// FieldRule declares the checks for one column. A column that is absent or
+// empty fails only when Required; otherwise its other checks are skipped.
+type FieldRule struct {
+	Column   string
+	Required bool
+	Type     FieldType
+	Layout   string
+	// Pattern must match the raw value when set.
+	Pattern *regexp.Regexp
+	// Min and Max bound FieldInt and FieldFloat values, inclusive, when set.
+	Min, Max *float64
+}
+
+// Schema is the set of rules a Row must satisfy.
+type Schema []FieldRule
+
+// Check returns every violation in row, each a *ColumnError, joined; nil
+// means the row is valid.
+func (s Schema) Check(row Row) error {
+	var errs []error
+	for _, rule := range s {
+		value, _ := row.Lookup(rule.Column)
+		if value == "" {
+			if rule.Required {
+				errs = append(errs, row.columnError(rule.Column, value, errRequiredValue))
+			}
+			continue
+		}
+		if err := rule.check(value); err != nil {
+			errs = append(errs, row.columnError(rule.Column, value, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r FieldRule) check(value string) error {
+	if r.Pattern != nil && !r.Pattern.MatchString(value) {
+		return fmt.Errorf("%w %s", errPatternMismatch, r.Pattern)
+	}
+
+	var number float64
+	var err error
+	switch r.Type {
+	case FieldInt:
+		var n int64
+		n, err = strconv.ParseInt(value, 10, 64)
+		number = float64(n)
+	case FieldFloat:
+		number, err = strconv.ParseFloat(value, 64)
+	case FieldBool:
+		_, err = strconv.ParseBool(value)
+	case FieldTime:
+		layout := r.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		_, err = time.Parse(layout, value)
+	case FieldDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.Type == FieldInt || r.Type == FieldFloat {
+		if (r.Min != nil && number < *r.Min) || (r.Max != nil && number > *r.Max) {
+			return fmt.Errorf("%w [%s, %s]", errOutOfRange, formatBound(r.Min), formatBound(r.Max))
+		}
+	}
+	return nil
+}
+
+func formatBound(b *float64) string {
+	if b == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*b, 'g', -1, 64)
+}
+
+// ValidatedRow is a Row tagged with the result of Schema.Check.
+type ValidatedRow struct {
+	Row Row
+	Err error
+}
+
+// Validate tags every row with its schema violations and passes all of them
+// on, so later stages can count, route or report invalid records.
+func Validate[F any](schema Schema, cont func(iter.Seq[ValidatedRow]) F) func(iter.Seq[Row]) F {
+	return func(seq iter.Seq[Row]) F {
+		return cont(func(yield func(ValidatedRow) bool) {
+			for row := range seq {
+				if !yield(ValidatedRow{Row: row, Err: schema.Check(row)}) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// ValidateOrDivert passes only valid rows on and hands each invalid one to
+// onInvalid, e.g. to write a reject file. A nil onInvalid drops them.
+func ValidateOrDivert[F any](schema Schema, onInvalid func(ValidatedRow), cont func(iter.Seq[Row]) F) func(iter.Seq[Row]) F {
+	return func(seq iter.Seq[Row]) F {
+		return cont(func(yield func(Row) bool) {
+			for row := range seq {
+				if err := schema.Check(row); err != nil {
+					if onInvalid != nil {
+						onInvalid(ValidatedRow{Row: row, Err: err})
+					}
+					continue
+				}
+				if !yield(row) {
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package input
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestSchemaCheck(t *testing.T) {
+	schema := Schema{
+		{Column: "id", Required: true, Type: FieldInt},
+		{Column: "status", Pattern: regexp.MustCompile(`^[1-5][0-9]{2}$`)},
+		{Column: "latency_ms", Type: FieldFloat, Min: float(0), Max: float(1000)},
+		{Column: "at", Type: FieldTime},
+	}
+	header := []string{"id", "status", "latency_ms", "at"}
+
+	cases := []struct {
+		name     string
+		values   []string
+		expected []error
+	}{
+		{"valid", []string{"1", "200", "12.5", "2024-05-01T10:00:00Z"}, nil},
+		{"optional columns empty", []string{"1", "", "", ""}, nil},
+		{"required missing", []string{"", "200", "1", ""}, []error{errRequiredValue}},
+		{"several violations", []string{"x", "99", "1500", "yesterday"}, []error{errPatternMismatch, errOutOfRange}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.Check(NewRow(header, tc.values))
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("Check() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Check() returned nil, expected errors")
+			}
+			for _, want := range tc.expected {
+				if !errors.Is(err, want) {
+					t.Fatalf("Check() error = %v, expected to wrap %v", err, want)
+				}
+			}
+			var columnErr *ColumnError
+			if !errors.As(err, &columnErr) {
+				t.Fatalf("Check() error = %v, expected *ColumnError", err)
+			}
+		})
+	}
+
+	if err := schema.Check(NewRow(header, []string{"x", "200", "1", ""})); !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("Check() error = %v, expected a syntax error for a bad int", err)
+	}
+}
+
+func TestValidateTagsAndDivertsRows(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.csv")
+	writeTextFile(t, path, "id,latency_ms\n1,12\n,5\n3,-1\n4,8\n")
+	schema := Schema{
+		{Column: "id", Required: true, Type: FieldInt},
+		{Column: "latency_ms", Type: FieldInt, Min: float(0)},
+	}
+
+	source := NewFileCSVRowStream([]string{path})
+	tagged := stream.Stream(source.Seq, Validate(schema, stream.End(stream.Collect[ValidatedRow]())))
+	var invalidLines []int
+	for _, v := range tagged {
+		if v.Err != nil {
+			invalidLines = append(invalidLines, v.Row.Line)
+		}
+	}
+	if len(tagged) != 4 || !slices.Equal(invalidLines, []int{3, 4}) {
+		t.Fatalf("Validate() tagged %d rows with invalid lines %v, expected 4 rows and [3 4]", len(tagged), invalidLines)
+	}
+
+	var rejected []ValidatedRow
+	valid := stream.Stream(source.Seq, ValidateOrDivert(schema, func(v ValidatedRow) {
+		rejected = append(rejected, v)
+	}, stream.Map(func(r Row) string { return r.Get("id") }, stream.End(stream.Collect[string]()))))
+	if !slices.Equal(valid, []string{"1", "4"}) {
+		t.Fatalf("ValidateOrDivert() passed ids %v, expected [1 4]", valid)
+	}
+	if len(rejected) != 2 || !errors.Is(rejected[0].Err, errRequiredValue) || !errors.Is(rejected[1].Err, errOutOfRange) {
+		t.Fatalf("ValidateOrDivert() rejected %v, expected a missing id and an out-of-range latency", rejected)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("Err() returned error: %v", err)
+	}
+}
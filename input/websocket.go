@@ -0,0 +1,350 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	errWebSocketHandshake   = errors.New("websocket handshake failed")
+	errWebSocketProtocol    = errors.New("websocket protocol error")
+	errWebSocketMessageSize = errors.New("websocket message too large")
+	errWebSocketClosed      = errors.New("websocket closed abnormally")
+)
+
+// defaultWebSocketMaxMessage is used when WebSocketOptions.MaxMessageSize is
+// not set.
+const defaultWebSocketMaxMessage = 16 << 20
+
+// websocketGUID is appended to the handshake key to derive the accept value
+// (RFC 6455 section 1.3).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// WebSocket close status codes that end a stream without an error.
+const (
+	wsCloseNormal    = 1000
+	wsCloseGoingAway = 1001
+	wsCloseNoStatus  = 1005
+)
+
+// WebSocketOptions configures NewWebSocketStream.
+type WebSocketOptions struct {
+	// Header is sent with the opening handshake, e.g. for authorization or
+	// Sec-WebSocket-Protocol.
+	Header http.Header
+	// TLSConfig is used for wss URLs. Nil uses the default configuration.
+	TLSConfig *tls.Config
+	// MaxMessageSize bounds a reassembled message. Defaults to 16MiB.
+	MaxMessageSize int
+}
+
+// WebSocketMessage is a complete message received from the server.
+type WebSocketMessage struct {
+	// Binary reports a binary message; otherwise Data holds UTF-8 text.
+	Binary bool
+	Data   []byte
+}
+
+// NewWebSocketStream connects to a ws:// or wss:// URL and yields each
+// message the server sends, reassembling fragmented messages, until the server
+// closes the connection, ctx is cancelled or the consumer stops. A new
+// connection is opened for every run. Pings are answered automatically.
+//
+// A normal close from the server and cancellation end the stream without an
+// error; handshake failures, protocol violations, abnormal close codes and
+// dropped connections are reported by Err.
+func NewWebSocketStream(ctx context.Context, rawURL string, opts WebSocketOptions) Input[WebSocketMessage] {
+	maxSize := opts.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = defaultWebSocketMaxMessage
+	}
+	var state runErrState
+
+	seq := func(yield func(WebSocketMessage) bool) {
+		var runErr error
+		defer func() {
+			state.Set(runErr)
+		}()
+
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			setFirstErr(&runErr, fmt.Errorf("parse url %s: %w", rawURL, err))
+			return
+		}
+		if u.Scheme != "ws" && u.Scheme != "wss" {
+			setFirstErr(&runErr, fmt.Errorf("parse url %s: unsupported scheme %q", rawURL, u.Scheme))
+			return
+		}
+
+		conn, err := dialWebSocket(ctx, u, opts.TLSConfig)
+		if err != nil {
+			if ctx.Err() == nil {
+				setFirstErr(&runErr, fmt.Errorf("dial %s: %w", rawURL, err))
+			}
+			return
+		}
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+		defer stop()
+
+		ws := &wsConn{conn: conn, reader: bufio.NewReader(conn), maxSize: maxSize}
+		if err := ws.handshake(u, opts.Header); err != nil {
+			if ctx.Err() == nil {
+				setFirstErr(&runErr, fmt.Errorf("websocket %s: %w", rawURL, err))
+			}
+			return
+		}
+
+		err = ws.receive(yield)
+		if err != nil && ctx.Err() == nil {
+			setFirstErr(&runErr, fmt.Errorf("websocket %s: %w", rawURL, err))
+		}
+	}
+
+	return Input[WebSocketMessage]{
+		Seq: seq,
+		Err: func() error {
+			return state.Get()
+		},
+	}
+}
+
+func dialWebSocket(ctx context.Context, u *url.URL, tlsConfig *tls.Config) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	if u.Scheme == "wss" {
+		dialer := tls.Dialer{Config: tlsConfig}
+		return dialer.DialContext(ctx, "tcp", host)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", host)
+}
+
+// wsConn is the client end of a WebSocket connection. All reads and writes
+// happen on the consuming goroutine.
+type wsConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	maxSize int
+}
+
+func (c *wsConn) handshake(u *url.URL, header http.Header) error {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header.Clone(),
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(c.conn); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(c.reader, req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = resp.Body.Close()
+		return fmt.Errorf("%w: unexpected status %s", errWebSocketHandshake, resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return fmt.Errorf("%w: missing Upgrade header", errWebSocketHandshake)
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return fmt.Errorf("%w: invalid Sec-WebSocket-Accept", errWebSocketHandshake)
+	}
+	return nil
+}
+
+// receive reads frames, answering control frames, and yields each complete
+// data message. When the consumer stops, a normal close is sent.
+func (c *wsConn) receive(yield func(WebSocketMessage) bool) error {
+	var (
+		message    []byte
+		fragmented bool
+		binaryMsg  bool
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err == io.EOF {
+			return fmt.Errorf("connection closed without close frame: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			return c.closed(payload)
+		case wsText, wsBinary:
+			if fragmented {
+				return fmt.Errorf("%w: new message inside fragmented message", errWebSocketProtocol)
+			}
+			message, binaryMsg = payload, opcode == wsBinary
+		case wsContinuation:
+			if !fragmented {
+				return fmt.Errorf("%w: unexpected continuation frame", errWebSocketProtocol)
+			}
+			if len(message)+len(payload) > c.maxSize {
+				return fmt.Errorf("%w: exceeds %d bytes", errWebSocketMessageSize, c.maxSize)
+			}
+			message = append(message, payload...)
+		default:
+			return fmt.Errorf("%w: unknown opcode %#x", errWebSocketProtocol, opcode)
+		}
+
+		fragmented = !fin
+		if fragmented {
+			continue
+		}
+		if !yield(WebSocketMessage{Binary: binaryMsg, Data: message}) {
+			_ = c.writeFrame(wsClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+			return nil
+		}
+		message = nil
+	}
+}
+
+// closed echoes the server's close frame and reports abnormal close codes.
+func (c *wsConn) closed(payload []byte) error {
+	code := wsCloseNoStatus
+	if len(payload) >= 2 {
+		code = int(binary.BigEndian.Uint16(payload))
+		_ = c.writeFrame(wsClose, payload[:2])
+	} else {
+		_ = c.writeFrame(wsClose, nil)
+	}
+	switch code {
+	case wsCloseNormal, wsCloseGoingAway, wsCloseNoStatus:
+		return nil
+	}
+	if len(payload) > 2 {
+		return fmt.Errorf("%w: code %d: %s", errWebSocketClosed, code, payload[2:])
+	}
+	return fmt.Errorf("%w: code %d", errWebSocketClosed, code)
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.reader, head[:1]); err != nil {
+		return false, 0, nil, err
+	}
+	if _, err := io.ReadFull(c.reader, head[1:]); err != nil {
+		return false, 0, nil, noEOF(err)
+	}
+	fin, opcode = head[0]&0x80 != 0, head[0]&0x0f
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", errWebSocketProtocol)
+	}
+	if head[1]&0x80 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: masked server frame", errWebSocketProtocol)
+	}
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, noEOF(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, noEOF(err)
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= wsClose && (length > 125 || !fin) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", errWebSocketProtocol)
+	}
+	if length > uint64(c.maxSize) {
+		return false, 0, nil, fmt.Errorf("%w: exceeds %d bytes", errWebSocketMessageSize, c.maxSize)
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, noEOF(err)
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends a single masked frame, as required of clients.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,194 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// wsTestServer upgrades each request and hands the raw connection to serve.
+// It returns the ws:// URL of the server.
+func wsTestServer(t *testing.T, serve func(rw *bufio.ReadWriter)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+			return
+		}
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		_ = rw.Flush()
+		serve(rw)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func writeServerFrame(rw *bufio.ReadWriter, fin bool, opcode byte, payload string) {
+	head := opcode
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head}
+	if len(payload) < 126 {
+		frame = append(frame, byte(len(payload)))
+	} else {
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+	_, _ = rw.Write(append(frame, payload...))
+	_ = rw.Flush()
+}
+
+// readClientFrame reads one masked client frame with a short payload.
+func readClientFrame(r io.Reader) (byte, []byte, error) {
+	var head [6]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, head[1]&0x7f)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= head[2+i%4]
+	}
+	return head[0] & 0x0f, payload, nil
+}
+
+func TestNewWebSocketStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("yields text, binary and fragmented messages until close", func(t *testing.T) {
+		pong := make(chan string, 1)
+		url := wsTestServer(t, func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, true, wsText, "hello")
+			writeServerFrame(rw, true, wsPing, "p1")
+			if op, payload, err := readClientFrame(rw); err == nil && op == wsPong {
+				pong <- string(payload)
+			}
+			writeServerFrame(rw, true, wsBinary, "\x00\x01")
+			writeServerFrame(rw, false, wsText, "frag")
+			writeServerFrame(rw, true, wsContinuation, strings.Repeat("x", 200))
+			writeServerFrame(rw, true, wsClose, "\x03\xe8bye")
+			_, _, _ = readClientFrame(rw)
+		})
+
+		source := NewWebSocketStream(ctx, url, WebSocketOptions{})
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[WebSocketMessage]()))
+
+		want := []WebSocketMessage{
+			{Data: []byte("hello")},
+			{Binary: true, Data: []byte{0, 1}},
+			{Data: []byte("frag" + strings.Repeat("x", 200))},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if p := <-pong; p != "p1" {
+			t.Fatalf("pong payload = %q, want p1", p)
+		}
+	})
+
+	t.Run("consumer stop sends a normal close", func(t *testing.T) {
+		closeCode := make(chan uint16, 1)
+		url := wsTestServer(t, func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, true, wsText, "a")
+			writeServerFrame(rw, true, wsText, "b")
+			if op, payload, err := readClientFrame(rw); err == nil && op == wsClose && len(payload) == 2 {
+				closeCode <- binary.BigEndian.Uint16(payload)
+			}
+			close(closeCode)
+		})
+
+		source := NewWebSocketStream(ctx, url, WebSocketOptions{})
+		got := stream.Stream(source.Seq, stream.Take[WebSocketMessage](1, stream.End(stream.Count[WebSocketMessage]())))
+		if got != 1 {
+			t.Fatalf("Stream() = %d, want 1", got)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+		if code := <-closeCode; code != wsCloseNormal {
+			t.Fatalf("close code = %d, want %d", code, wsCloseNormal)
+		}
+	})
+
+	t.Run("cancellation ends the stream without error", func(t *testing.T) {
+		url := wsTestServer(t, func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, true, wsText, "a")
+			_, _, _ = readClientFrame(rw)
+		})
+
+		runCtx, runCancel := context.WithCancel(ctx)
+		defer runCancel()
+		source := NewWebSocketStream(runCtx, url, WebSocketOptions{})
+		n := 0
+		for range source.Seq {
+			n++
+			runCancel()
+		}
+		if n != 1 {
+			t.Fatalf("Stream() yielded %d messages, want 1", n)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("connection failures are errors", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			serve func(rw *bufio.ReadWriter)
+			want  error
+		}{
+			{"abnormal close", func(rw *bufio.ReadWriter) {
+				writeServerFrame(rw, true, wsClose, "\x03\xf3overloaded")
+				_, _, _ = readClientFrame(rw)
+			}, errWebSocketClosed},
+			{"dropped connection", func(rw *bufio.ReadWriter) {}, io.ErrUnexpectedEOF},
+			{"unknown opcode", func(rw *bufio.ReadWriter) {
+				writeServerFrame(rw, true, 0x3, "")
+			}, errWebSocketProtocol},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				source := NewWebSocketStream(ctx, wsTestServer(t, tt.serve), WebSocketOptions{})
+				stream.Stream(source.Seq, stream.End(stream.Count[WebSocketMessage]()))
+				if err := source.Err(); !errors.Is(err, tt.want) {
+					t.Fatalf("Err() = %v, want %v", err, tt.want)
+				}
+			})
+		}
+
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+		source := NewWebSocketStream(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), WebSocketOptions{})
+		stream.Stream(source.Seq, stream.End(stream.Count[WebSocketMessage]()))
+		if err := source.Err(); !errors.Is(err, errWebSocketHandshake) {
+			t.Fatalf("Err() = %v, want errWebSocketHandshake", err)
+		}
+	})
+}
@@ -0,0 +1,290 @@
+package input
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var errXLSXSheetNotFound = errors.New("sheet not found")
+
+// XLSXParser parses Excel workbooks (.xlsx) and yields the rows of one sheet
+// as []string. Cells missing from a row are returned as "", and rows without
+// cells are skipped. Numbers, including dates, are returned as stored (dates
+// are serial day numbers); booleans become "TRUE" or "FALSE".
+//
+// The workbook is read into memory because its zip container needs random
+// access; the sheet itself is decoded incrementally.
+type XLSXParser struct {
+	// Sheet selects the worksheet by name. Empty selects the first sheet.
+	Sheet string
+}
+
+func (p XLSXParser) Parse(_ string, r io.Reader, yield func([]string) bool) error {
+	return p.parseRows(r, yield)
+}
+
+// XLSXHeaderParser parses a sheet whose first row is a header and yields each
+// following row as a map from column name to value, like CSVHeaderParser.
+type XLSXHeaderParser struct {
+	XLSXParser
+}
+
+func (p XLSXHeaderParser) Parse(_ string, r io.Reader, yield func(map[string]string) bool) error {
+	var header []string
+	var headerErr error
+	err := p.parseRows(r, func(record []string) bool {
+		if header == nil {
+			header = record
+			headerErr = checkHeaderNames(header)
+			return headerErr == nil
+		}
+		row := make(map[string]string, len(header))
+		for i, value := range record[:min(len(record), len(header))] {
+			row[header[i]] = value
+		}
+		return yield(row)
+	})
+	if headerErr != nil {
+		return headerErr
+	}
+	return err
+}
+
+// NewFileXLSXStream provides the rows of a worksheet by composing
+// FileStream -> XLSXParser -> transform pipeline.
+func NewFileXLSXStream(paths []string, sheet string) Input[[]string] {
+	return ParseFiles[[]string](NewFileStream(paths), XLSXParser{Sheet: sheet})
+}
+
+// NewFileXLSXHeaderStream provides worksheet rows keyed by header names by
+// composing FileStream -> XLSXHeaderParser -> transform pipeline.
+func NewFileXLSXHeaderStream(paths []string, sheet string) Input[map[string]string] {
+	return ParseFiles[map[string]string](NewFileStream(paths), XLSXHeaderParser{XLSXParser{Sheet: sheet}})
+}
+
+func (p XLSXParser) parseRows(r io.Reader, yield func([]string) bool) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := p.sheetPath(files)
+	if err != nil {
+		return err
+	}
+	shared, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return fmt.Errorf("shared strings: %w", err)
+	}
+
+	sheet, err := files[sheetPath].Open()
+	if err != nil {
+		return err
+	}
+	defer sheet.Close()
+	if err := readSheetRows(sheet, shared, yield); err != nil {
+		return fmt.Errorf("%s: %w", sheetPath, err)
+	}
+	return nil
+}
+
+// sheetPath resolves the selected sheet to its part name in the archive.
+func (p XLSXParser) sheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeXLSXPart(files["xl/workbook.xml"], &workbook); err != nil {
+		return "", fmt.Errorf("workbook: %w", err)
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXLSXPart(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", fmt.Errorf("workbook relationships: %w", err)
+	}
+
+	for _, sheet := range workbook.Sheets {
+		if p.Sheet != "" && sheet.Name != p.Sheet {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.ID != sheet.ID {
+				continue
+			}
+			target := strings.TrimPrefix(rel.Target, "/")
+			if !strings.HasPrefix(rel.Target, "/") {
+				target = path.Join("xl", target)
+			}
+			if files[target] == nil {
+				break
+			}
+			return target, nil
+		}
+		return "", fmt.Errorf("%w: %q has no worksheet part", errXLSXSheetNotFound, sheet.Name)
+	}
+	return "", fmt.Errorf("%w: %q", errXLSXSheetNotFound, p.Sheet)
+}
+
+func decodeXLSXPart(file *zip.File, v any) error {
+	if file == nil {
+		return errors.New("missing part")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// readSharedStrings loads the shared string table. Rich-text entries are
+// flattened to their text; phonetic runs are dropped.
+func readSharedStrings(file *zip.File) ([]string, error) {
+	if file == nil {
+		return nil, nil
+	}
+	var table struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodeXLSXPart(file, &table); err != nil {
+		return nil, err
+	}
+	shared := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		shared[i] = text
+	}
+	return shared, nil
+}
+
+// xlsxCell is a <c> element of a worksheet row.
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"is"`
+}
+
+// readSheetRows streams <row> elements from a worksheet part.
+func readSheetRows(r io.Reader, shared []string, yield func([]string) bool) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row struct {
+			Cells []xlsxCell `xml:"c"`
+		}
+		if err := decoder.DecodeElement(&row, &start); err != nil {
+			return err
+		}
+		if len(row.Cells) == 0 {
+			continue
+		}
+
+		var record []string
+		for _, cell := range row.Cells {
+			col := len(record)
+			if cell.Ref != "" {
+				if col, err = xlsxColumn(cell.Ref); err != nil {
+					return err
+				}
+			}
+			value, err := cell.text(shared)
+			if err != nil {
+				return fmt.Errorf("cell %s: %w", cell.Ref, err)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			record[col] = value
+		}
+		if !yield(record) {
+			return nil
+		}
+	}
+}
+
+func (c xlsxCell) text(shared []string) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", fmt.Errorf("invalid shared string index %q", c.Value)
+		}
+		return shared[i], nil
+	case "inlineStr":
+		text := c.Inline.Text
+		for _, run := range c.Inline.Runs {
+			text += run.Text
+		}
+		return text, nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	return c.Value, nil
+}
+
+// xlsxColumn returns the zero-based column of a cell reference such as "AB12".
+// Columns past XFD, the 16384th, are rejected as soon as they are reached,
+// so long references cannot overflow.
+func xlsxColumn(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > 16384 {
+			return 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+	if i == 0 || col <= 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
@@ -0,0 +1,128 @@
+package input
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+const (
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Orders" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="worksheet" Target="/xl/worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Type="sharedStrings" Target="sharedStrings.xml"/>
+</Relationships>`
+	xlsxSharedStrings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>id</t></si><si><t>customer</t></si><si><t>paid</t></si>
+<si><r><t>Ali</t></r><r><t>ce</t></r></si>
+</sst>`
+	xlsxSummary = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>total</t></is></c><c r="B1"><v>2</v></c></row>
+</sheetData></worksheet>`
+	xlsxOrders = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="s"><v>3</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="3"/>
+<row r="4"><c r="A4"><v>2.5</v></c><c r="C4" t="b"><v>0</v></c></row>
+</sheetData></worksheet>`
+)
+
+func writeXLSXFile(t *testing.T, path string) {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml":            xlsxWorkbook,
+		"xl/_rels/workbook.xml.rels": xlsxRels,
+		"xl/sharedStrings.xml":       xlsxSharedStrings,
+		"xl/worksheets/sheet1.xml":   xlsxSummary,
+		"xl/worksheets/sheet2.xml":   xlsxOrders,
+	}
+	writeTextFile(t, path, string(zipBytes(t, parts,
+		"xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/sharedStrings.xml",
+		"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml",
+	)))
+}
+
+func TestNewFileXLSXStream(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "book.xlsx")
+	writeXLSXFile(t, file)
+
+	t.Run("first sheet by default", func(t *testing.T) {
+		source := NewFileXLSXStream([]string{file}, "")
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{{"total", "2"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("named sheet with gaps", func(t *testing.T) {
+		source := NewFileXLSXStream([]string{file}, "Orders")
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+
+		want := [][]string{
+			{"id", "customer", "paid"},
+			{"1", "Alice", "TRUE"},
+			{"2.5", "", "FALSE"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("header maps", func(t *testing.T) {
+		source := NewFileXLSXHeaderStream([]string{file}, "Orders")
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+
+		want := []map[string]string{
+			{"id": "1", "customer": "Alice", "paid": "TRUE"},
+			{"id": "2.5", "customer": "", "paid": "FALSE"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("unknown sheet", func(t *testing.T) {
+		source := NewFileXLSXStream([]string{file}, "Missing")
+		stream.Stream(source.Seq, stream.End(stream.Collect[[]string]()))
+		if err := source.Err(); !errors.Is(err, errXLSXSheetNotFound) {
+			t.Fatalf("Err() = %v, want errXLSXSheetNotFound", err)
+		}
+	})
+}
+
+func TestXLSXColumn(t *testing.T) {
+	cases := map[string]int{"A1": 0, "Z9": 25, "AB12": 27, "XFD1": 16383}
+	for ref, want := range cases {
+		if got, err := xlsxColumn(ref); err != nil || got != want {
+			t.Fatalf("xlsxColumn(%q) = %d, %v, want %d", ref, got, err, want)
+		}
+	}
+	for _, ref := range []string{"", "12", "XFE1", "AAAAAAAAAAAAAAAA1"} {
+		if _, err := xlsxColumn(ref); err == nil {
+			t.Fatalf("xlsxColumn(%q) error = nil, want error", ref)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errNoYAMLUnmarshal = errors.New("YAMLParser requires an Unmarshal function")
+
+// YAMLParser splits multi-document YAML files on "---" boundaries and decodes
+// each document into T with Unmarshal, which has the signature of
+// yaml.Unmarshal from gopkg.in/yaml.v3 or sigs.k8s.io/yaml.
+//
+// YAMLParser only splits documents; it has no YAML decoder of its own. The
+// caller must supply Unmarshal, and a zero YAMLParser fails every file with
+// an error.
+//
+// A "---" line starts a new document; text after the marker on the same line
+// belongs to that document. A "..." line ends the current document.
+// Directives ("%YAML", "%TAG") preceding a document are dropped, and
+// documents holding only blank lines and comments are skipped.
+type YAMLParser[T any] struct {
+	// Unmarshal decodes one document. It is required.
+	Unmarshal func(data []byte, v any) error
+}
+
+func (p YAMLParser[T]) Parse(_ string, r io.Reader, yield func(T) bool) error {
+	if p.Unmarshal == nil {
+		return errNoYAMLUnmarshal
+	}
+
+	reader := bufio.NewReader(r)
+	var doc bytes.Buffer
+	docStart, lineNo := 1, 0
+	hasContent := false
+	emit := func() (bool, error) {
+		defer func() {
+			doc.Reset()
+			hasContent = false
+			docStart = lineNo + 1
+		}()
+		if !hasContent {
+			return true, nil
+		}
+		var v T
+		if err := p.Unmarshal(doc.Bytes(), &v); err != nil {
+			return false, fmt.Errorf("document at line %d: %w", docStart, err)
+		}
+		return yield(v), nil
+	}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+			trimmed := bytes.TrimRight(line, "\r\n")
+			switch {
+			case isYAMLMarker(trimmed, "---"):
+				ok, err := emit()
+				if err != nil || !ok {
+					return err
+				}
+				if rest := bytes.TrimSpace(trimmed[3:]); len(rest) > 0 {
+					docStart = lineNo
+					doc.Write(rest)
+					doc.WriteByte('\n')
+					hasContent = isYAMLContent(rest)
+				}
+			case isYAMLMarker(trimmed, "..."):
+				ok, err := emit()
+				if err != nil || !ok {
+					return err
+				}
+			case !hasContent && bytes.HasPrefix(trimmed, []byte("%")):
+				// Directive for the next document.
+			default:
+				doc.Write(line)
+				hasContent = hasContent || isYAMLContent(bytes.TrimSpace(trimmed))
+			}
+		}
+
+		if readErr == io.EOF {
+			_, err := emit()
+			return err
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// isYAMLMarker reports whether line starts with a document marker followed by
+// the end of the line or whitespace.
+func isYAMLMarker(line []byte, marker string) bool {
+	if !bytes.HasPrefix(line, []byte(marker)) {
+		return false
+	}
+	return len(line) == len(marker) || line[len(marker)] == ' ' || line[len(marker)] == '\t'
+}
+
+// isYAMLContent reports whether a trimmed line is neither blank nor a comment.
+func isYAMLContent(line []byte) bool {
+	return len(line) > 0 && line[0] != '#'
+}
+
+// NewFileYAMLStream provides multi-document YAML input decoded into T by
+// composing FileStream -> YAMLParser -> transform pipeline.
+func NewFileYAMLStream[T any](paths []string, unmarshal func(data []byte, v any) error) Input[T] {
+	return ParseFiles[T](NewFileStream(paths), YAMLParser[T]{Unmarshal: unmarshal})
+}
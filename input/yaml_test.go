@@ -0,0 +1,98 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// flatYAMLUnmarshal decodes "key: value" lines into a map[string]string,
+// standing in for a real YAML library.
+func flatYAMLUnmarshal(data []byte, v any) error {
+	out := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+		out[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	*v.(*map[string]string) = out
+	return nil
+}
+
+func TestNewFileYAMLStream(t *testing.T) {
+	t.Run("splits documents across files", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.yaml")
+		fileB := filepath.Join(dir, "b.yaml")
+		writeTextFile(t, fileA, strings.Join([]string{
+			"%YAML 1.2",
+			"---",
+			"kind: Service",
+			"name: web",
+			"--- # empty document",
+			"# only a comment",
+			"---",
+			"kind: Deployment",
+			"...",
+			"--- kind: ConfigMap",
+		}, "\n"))
+		writeTextFile(t, fileB, "kind: Secret\r\n---\r\n---\r\n")
+
+		source := NewFileYAMLStream[map[string]string]([]string{fileA, fileB}, flatYAMLUnmarshal)
+		got := stream.Stream(
+			source.Seq,
+			stream.Map(func(doc map[string]string) string { return doc["kind"] },
+				stream.End(stream.Collect[string]()),
+			),
+		)
+
+		want := []string{"Service", "Deployment", "ConfigMap", "Secret"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err != nil {
+			t.Fatalf("Err() = %v, want nil", err)
+		}
+	})
+
+	t.Run("reports the failing document", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "bad.yaml")
+		writeTextFile(t, file, "a: 1\n---\nb: 2\nbroken\n---\nc: 3\n")
+
+		source := NewFileYAMLStream[map[string]string]([]string{file}, flatYAMLUnmarshal)
+		got := stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+
+		want := []map[string]string{{"a": "1"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		err := source.Err()
+		if err == nil || !strings.Contains(err.Error(), "document at line 3") {
+			t.Fatalf("Err() = %v, want error for document at line 3", err)
+		}
+	})
+
+	t.Run("requires Unmarshal", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "a.yaml")
+		writeTextFile(t, file, "a: 1\n")
+
+		source := NewFileYAMLStream[map[string]string]([]string{file}, nil)
+		stream.Stream(source.Seq, stream.End(stream.Collect[map[string]string]()))
+		if err := source.Err(); !errors.Is(err, errNoYAMLUnmarshal) {
+			t.Fatalf("Err() = %v, want errNoYAMLUnmarshal", err)
+		}
+	})
+}
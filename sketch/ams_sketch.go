@@ -0,0 +1,132 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+	"slices"
+)
+
+var (
+	errNilAMSSketch    = errors.New("ams sketch is nil")
+	errIncompatibleAMS = errors.New("ams sketches are incompatible")
+)
+
+// AMSSketch estimates the second frequency moment F2, the sum of squared key
+// frequencies, which is the self-join size of the key stream and grows with
+// its skew. It is the fast variant of the AMS "tug of war" sketch: each row
+// adds every key with a random sign into one of width counters, and the
+// median over rows of the summed squares is the estimate, with relative
+// error about sqrt(2/width).
+type AMSSketch struct {
+	width int
+	depth int
+	table [][]int64
+	total uint64
+}
+
+type AMSSketchResult struct {
+	Sketch *AMSSketch
+	Err    error
+}
+
+func NewAMSSketch(width, depth int) (*AMSSketch, error) {
+	if width <= 0 {
+		return nil, errInvalidWidth
+	}
+	if depth <= 0 {
+		return nil, errInvalidDepth
+	}
+
+	table := make([][]int64, depth)
+	for i := range table {
+		table[i] = make([]int64, width)
+	}
+	return &AMSSketch{width: width, depth: depth, table: table}, nil
+}
+
+func (ams *AMSSketch) Width() int {
+	return ams.width
+}
+
+func (ams *AMSSketch) Depth() int {
+	return ams.depth
+}
+
+func (ams *AMSSketch) TotalCount() uint64 {
+	return ams.total
+}
+
+func (ams *AMSSketch) AddString(key string, count uint64) {
+	ams.AddBytes([]byte(key), count)
+}
+
+func (ams *AMSSketch) AddBytes(key []byte, count uint64) {
+	if count == 0 {
+		return
+	}
+	h := FNVHash(key)
+	for row := range ams.table {
+		// Bucket and sign come from independent bits of a per-row hash.
+		rh := mix64(h + uint64(row+1)*0x9e3779b97f4a7c15)
+		col := int((rh >> 1) % uint64(ams.width))
+		if rh&1 == 0 {
+			ams.table[row][col] += int64(count)
+		} else {
+			ams.table[row][col] -= int64(count)
+		}
+	}
+	ams.total += count
+}
+
+// EstimateF2 returns the estimated sum of squared key frequencies.
+func (ams *AMSSketch) EstimateF2() float64 {
+	rows := make([]float64, ams.depth)
+	for i, row := range ams.table {
+		for _, v := range row {
+			rows[i] += float64(v) * float64(v)
+		}
+	}
+	slices.Sort(rows)
+	if ams.depth%2 == 1 {
+		return rows[ams.depth/2]
+	}
+	return (rows[ams.depth/2-1] + rows[ams.depth/2]) / 2
+}
+
+func (ams *AMSSketch) Merge(other *AMSSketch) error {
+	if ams == nil || other == nil {
+		return errNilAMSSketch
+	}
+	if ams.width != other.width || ams.depth != other.depth {
+		return errIncompatibleAMS
+	}
+
+	for row := range ams.table {
+		for col, v := range other.table[row] {
+			ams.table[row][col] += v
+		}
+	}
+	ams.total += other.total
+	return nil
+}
+
+func (ams *AMSSketch) Reset() {
+	for _, row := range ams.table {
+		clear(row)
+	}
+	ams.total = 0
+}
+
+func AMSSketchCollect[A any](width, depth int, keyFn func(A) string) func(iter.Seq[A]) AMSSketchResult {
+	return func(seq iter.Seq[A]) AMSSketchResult {
+		ams, err := NewAMSSketch(width, depth)
+		if err != nil {
+			return AMSSketchResult{Err: err}
+		}
+
+		for v := range seq {
+			ams.AddString(keyFn(v), 1)
+		}
+		return AMSSketchResult{Sketch: ams}
+	}
+}
@@ -0,0 +1,72 @@
+package sketch
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewAMSSketchValidation(t *testing.T) {
+	if _, err := NewAMSSketch(0, 5); err == nil {
+		t.Fatalf("expected error for width=0")
+	}
+	if _, err := NewAMSSketch(64, 0); err == nil {
+		t.Fatalf("expected error for depth=0")
+	}
+}
+
+func TestAMSSketchCollectEstimatesF2(t *testing.T) {
+	// Key i occurs i times, so F2 = sum of i^2.
+	seq := func(yield func(string) bool) {
+		for i := 1; i <= 200; i++ {
+			for range i {
+				if !yield("key-" + strconv.Itoa(i)) {
+					return
+				}
+			}
+		}
+	}
+	exact := 0.0
+	for i := 1; i <= 200; i++ {
+		exact += float64(i * i)
+	}
+
+	result := stream.Stream(seq, stream.End(AMSSketchCollect(1024, 7, func(s string) string { return s })))
+	if result.Err != nil {
+		t.Fatalf("AMSSketchCollect() returned error: %v", result.Err)
+	}
+	if got := result.Sketch.EstimateF2(); math.Abs(got-exact)/exact > 0.1 {
+		t.Fatalf("EstimateF2()=%v, expected within 10%% of %v", got, exact)
+	}
+}
+
+func TestAMSSketchMergeAndReset(t *testing.T) {
+	left, _ := NewAMSSketch(256, 5)
+	right, _ := NewAMSSketch(256, 5)
+	left.AddString("a", 30)
+	right.AddString("a", 10)
+	right.AddString("b", 5)
+
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	// 40^2 + 5^2, exact unless a and b collide in most rows.
+	if got := left.EstimateF2(); got != 1625 {
+		t.Fatalf("EstimateF2()=%v, expected 1625", got)
+	}
+	if left.TotalCount() != 45 {
+		t.Fatalf("TotalCount()=%d, expected 45", left.TotalCount())
+	}
+
+	other, _ := NewAMSSketch(128, 5)
+	if err := left.Merge(other); err == nil {
+		t.Fatalf("expected error merging different widths")
+	}
+
+	left.Reset()
+	if left.EstimateF2() != 0 || left.TotalCount() != 0 {
+		t.Fatalf("expected zero estimate after reset")
+	}
+}
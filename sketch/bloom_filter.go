@@ -0,0 +1,378 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/bits"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+var (
+	errInvalidBitSize           = errors.New("bitSize must be > 0")
+	errInvalidHashFuncs         = errors.New("hashFuncs must be > 0")
+	errInvalidExpectedItems     = errors.New("expectedItems must be > 0")
+	errInvalidFalsePositiveRate = errors.New("falsePositiveRate must be in (0, 1)")
+	errNilBloomFilter           = errors.New("bloom filter is nil")
+	errIncompatibleBloomFilter  = errors.New("bloom filters are incompatible")
+	errBloomEncoding            = errors.New("invalid bloom filter encoding")
+	errBloomVersion             = errors.New("unsupported bloom filter encoding version")
+)
+
+// bloomMagic and bloomVersion start the binary encoding of a BloomFilter,
+// followed by the bit size (uint64), the number of hash functions (uint32),
+// the added count (uint64) and then the bit words (uint64), all
+// little-endian.
+// Version 1 filters set bits derived by an older index scheme and are
+// rejected.
+const (
+	bloomMagic      = "GBLF"
+	bloomVersion    = 2
+	bloomHeaderSize = len(bloomMagic) + 1 + 8 + 4 + 8
+)
+
+// BloomFilter is a probabilistic set for membership tests.
+// It can return false positives but never false negatives.
+type BloomFilter struct {
+	bitSize   int
+	hashFuncs int
+	hash      HashFunc
+	bits      []uint64
+	added     uint64
+}
+
+type BloomFilterResult struct {
+	Filter *BloomFilter
+	Err    error
+}
+
+func NewBloomFilter(bitSize, hashFuncs int) (*BloomFilter, error) {
+	return NewBloomFilterWithHash(bitSize, hashFuncs, FNVHash)
+}
+
+// NewBloomFilterWithHash uses hash instead of FNVHash. Filters are only
+// compatible for Merge when they use the same hash.
+func NewBloomFilterWithHash(bitSize, hashFuncs int, hash HashFunc) (*BloomFilter, error) {
+	if hash == nil {
+		hash = FNVHash
+	}
+	if bitSize <= 0 {
+		return nil, errInvalidBitSize
+	}
+	if hashFuncs <= 0 {
+		return nil, errInvalidHashFuncs
+	}
+
+	wordCount := (bitSize + 63) / 64
+	return &BloomFilter{
+		bitSize:   bitSize,
+		hashFuncs: hashFuncs,
+		hash:      hash,
+		bits:      make([]uint64, wordCount),
+	}, nil
+}
+
+// NewBloomFilterByError calculates parameters from capacity and false positive rate.
+func NewBloomFilterByError(expectedItems int, falsePositiveRate float64) (*BloomFilter, error) {
+	if expectedItems <= 0 {
+		return nil, errInvalidExpectedItems
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, errInvalidFalsePositiveRate
+	}
+
+	n := float64(expectedItems)
+	p := falsePositiveRate
+	ln2 := math.Ln2
+	m := int(math.Ceil((-n * math.Log(p)) / (ln2 * ln2)))
+	k := int(math.Ceil((float64(m) / n) * ln2))
+	if k <= 0 {
+		k = 1
+	}
+
+	return NewBloomFilter(m, k)
+}
+
+func (bf *BloomFilter) BitSize() int {
+	return bf.bitSize
+}
+
+func (bf *BloomFilter) HashFuncs() int {
+	return bf.hashFuncs
+}
+
+func (bf *BloomFilter) AddedCount() uint64 {
+	return bf.added
+}
+
+func (bf *BloomFilter) AddString(key string) {
+	bf.AddBytes([]byte(key))
+}
+
+func (bf *BloomFilter) AddBytes(key []byte) {
+	h := bf.hash(key)
+	for i := 0; i < bf.hashFuncs; i++ {
+		bf.setBit(kmIndex(h, i, bf.bitSize))
+	}
+	bf.added++
+}
+
+// testAndAdd adds key and reports whether it tested positive before.
+func (bf *BloomFilter) testAndAdd(key []byte) bool {
+	h := bf.hash(key)
+	present := true
+	for i := 0; i < bf.hashFuncs; i++ {
+		idx := kmIndex(h, i, bf.bitSize)
+		if !bf.hasBit(idx) {
+			present = false
+			bf.setBit(idx)
+		}
+	}
+	bf.added++
+	return present
+}
+
+func (bf *BloomFilter) TestString(key string) bool {
+	return bf.TestBytes([]byte(key))
+}
+
+func (bf *BloomFilter) TestBytes(key []byte) bool {
+	h := bf.hash(key)
+	for i := 0; i < bf.hashFuncs; i++ {
+		if !bf.hasBit(kmIndex(h, i, bf.bitSize)) {
+			return false
+		}
+	}
+	return true
+}
+
+// FillRatio returns the fraction of bits set. A filter sized with
+// NewBloomFilterByError reaches about 0.5 at its expected capacity.
+func (bf *BloomFilter) FillRatio() float64 {
+	return float64(bf.setBits()) / float64(bf.bitSize)
+}
+
+// EstimateCardinality estimates the number of distinct keys added from the
+// number of set bits. It is +Inf once every bit is set.
+func (bf *BloomFilter) EstimateCardinality() float64 {
+	return bloomCardinality(bf.bitSize, bf.hashFuncs, bf.setBits())
+}
+
+func bloomCardinality(bitSize, hashFuncs, setBits int) float64 {
+	m := float64(bitSize)
+	return -m / float64(hashFuncs) * math.Log1p(-float64(setBits)/m)
+}
+
+// EstimateUnion estimates the number of distinct keys added to a or b from
+// the bits set in either. Like EstimateCardinality it is +Inf once the
+// combined bits are all set.
+func EstimateUnion(a, b *BloomFilter) (float64, error) {
+	if err := checkBloomPair(a, b); err != nil {
+		return 0, err
+	}
+	n := 0
+	for i, word := range a.bits {
+		n += bits.OnesCount64(word | b.bits[i])
+	}
+	return bloomCardinality(a.bitSize, a.hashFuncs, n), nil
+}
+
+// EstimateIntersection estimates the number of distinct keys added to both a
+// and b by inclusion-exclusion over the cardinality estimates. Its absolute
+// error is that of the union, so small overlaps of large sets are imprecise.
+func EstimateIntersection(a, b *BloomFilter) (float64, error) {
+	union, err := EstimateUnion(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return max(a.EstimateCardinality()+b.EstimateCardinality()-union, 0), nil
+}
+
+// Jaccard estimates the Jaccard similarity of the key sets of a and b, the
+// size of their intersection over that of their union. Two empty filters
+// have similarity 0.
+func Jaccard(a, b *BloomFilter) (float64, error) {
+	union, err := EstimateUnion(a, b)
+	if err != nil || union == 0 {
+		return 0, err
+	}
+	intersection, _ := EstimateIntersection(a, b)
+	return min(intersection/union, 1), nil
+}
+
+func checkBloomPair(a, b *BloomFilter) error {
+	if a == nil || b == nil {
+		return errNilBloomFilter
+	}
+	if a.bitSize != b.bitSize || a.hashFuncs != b.hashFuncs {
+		return errIncompatibleBloomFilter
+	}
+	return nil
+}
+
+// CurrentFalsePositiveRate returns the probability that a key never added
+// tests positive given the bits set so far. Comparing it to the target rate
+// shows when a filter is over capacity.
+func (bf *BloomFilter) CurrentFalsePositiveRate() float64 {
+	return math.Pow(bf.FillRatio(), float64(bf.hashFuncs))
+}
+
+func (bf *BloomFilter) setBits() int {
+	n := 0
+	for _, word := range bf.bits {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+func (bf *BloomFilter) Merge(other *BloomFilter) error {
+	if err := checkBloomPair(bf, other); err != nil {
+		return err
+	}
+
+	for i := range bf.bits {
+		bf.bits[i] |= other.bits[i]
+	}
+	bf.added += other.added
+	return nil
+}
+
+func (bf *BloomFilter) Reset() {
+	clear(bf.bits)
+	bf.added = 0
+}
+
+func (bf *BloomFilter) MarshalBinary() ([]byte, error) {
+	if bf == nil {
+		return nil, errNilBloomFilter
+	}
+
+	data := make([]byte, 0, bloomHeaderSize+8*len(bf.bits))
+	data = append(data, bloomMagic...)
+	data = append(data, bloomVersion)
+	data = binary.LittleEndian.AppendUint64(data, uint64(bf.bitSize))
+	data = binary.LittleEndian.AppendUint32(data, uint32(bf.hashFuncs))
+	data = binary.LittleEndian.AppendUint64(data, bf.added)
+	for _, word := range bf.bits {
+		data = binary.LittleEndian.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the filter with one encoded by MarshalBinary. The
+// hash is not encoded: a filter made with NewBloomFilterWithHash keeps its
+// hash, and a zero BloomFilter uses FNVHash.
+func (bf *BloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < bloomHeaderSize || string(data[:len(bloomMagic)]) != bloomMagic {
+		return errBloomEncoding
+	}
+	if version := data[len(bloomMagic)]; version != bloomVersion {
+		return fmt.Errorf("%w %d", errBloomVersion, version)
+	}
+	header := data[len(bloomMagic)+1:]
+	bitSize := binary.LittleEndian.Uint64(header)
+	hashFuncs := binary.LittleEndian.Uint32(header[8:])
+	added := binary.LittleEndian.Uint64(header[12:])
+	body := data[bloomHeaderSize:]
+	if bitSize == 0 || bitSize > math.MaxInt-63 || hashFuncs == 0 || hashFuncs > math.MaxInt32 ||
+		uint64(len(body)) != 8*((bitSize+63)/64) {
+		return fmt.Errorf("%w: %d bits in %d bytes", errBloomEncoding, bitSize, len(body))
+	}
+
+	words := make([]uint64, len(body)/8)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(body[8*i:])
+	}
+	if tail := bitSize % 64; tail != 0 && words[len(words)-1]>>tail != 0 {
+		return fmt.Errorf("%w: bits set beyond bit size %d", errBloomEncoding, bitSize)
+	}
+
+	if bf.hash == nil {
+		bf.hash = FNVHash
+	}
+	bf.bitSize, bf.hashFuncs, bf.bits, bf.added = int(bitSize), int(hashFuncs), words, added
+	return nil
+}
+
+func (bf *BloomFilter) setBit(index int) {
+	word := index / 64
+	offset := uint(index % 64)
+	bf.bits[word] |= uint64(1) << offset
+}
+
+func (bf *BloomFilter) hasBit(index int) bool {
+	word := index / 64
+	offset := uint(index % 64)
+	return bf.bits[word]&(uint64(1)<<offset) != 0
+}
+
+func BloomFilterCollect[A any](bitSize, hashFuncs int, keyFn func(A) string) func(iter.Seq[A]) BloomFilterResult {
+	return func(seq iter.Seq[A]) BloomFilterResult {
+		bf, err := NewBloomFilter(bitSize, hashFuncs)
+		if err != nil {
+			return BloomFilterResult{Err: err}
+		}
+
+		for v := range seq {
+			bf.AddString(keyFn(v))
+		}
+		return BloomFilterResult{Filter: bf}
+	}
+}
+
+func BloomFilterCollectByError[A any](expectedItems int, falsePositiveRate float64, keyFn func(A) string) func(iter.Seq[A]) BloomFilterResult {
+	return func(seq iter.Seq[A]) BloomFilterResult {
+		bf, err := NewBloomFilterByError(expectedItems, falsePositiveRate)
+		if err != nil {
+			return BloomFilterResult{Err: err}
+		}
+
+		for v := range seq {
+			bf.AddString(keyFn(v))
+		}
+		return BloomFilterResult{Filter: bf}
+	}
+}
+
+// FilterByBloom passes on the elements whose key tests positive in bf, e.g.
+// to keep only records seen in a first pass. False positives let some other
+// elements through.
+func FilterByBloom[F, A any](bf *BloomFilter, keyFn func(A) string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return stream.Filter(func(v A) bool { return bf.TestString(keyFn(v)) }, cont)
+}
+
+// FilterNotInBloom passes on the elements whose key is not in bf. False
+// positives drop some elements that were never added.
+func FilterNotInBloom[F, A any](bf *BloomFilter, keyFn func(A) string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return stream.Filter(func(v A) bool { return !bf.TestString(keyFn(v)) }, cont)
+}
+
+// DistinctApprox is Distinct with a Bloom filter sized for expectedItems
+// keys at fpRate instead of a map, so memory stays fixed however many keys
+// the stream has. The price is that a new key is wrongly dropped as a
+// duplicate with probability up to fpRate, rising once the stream exceeds
+// expectedItems keys. It panics if expectedItems or fpRate are invalid.
+func DistinctApprox[A any, F any](expectedItems int, fpRate float64, keyFn func(A) string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	if expectedItems <= 0 {
+		panic(errInvalidExpectedItems)
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		panic(errInvalidFalsePositiveRate)
+	}
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			bf, _ := NewBloomFilterByError(expectedItems, fpRate)
+			for v := range seq {
+				if bf.testAndAdd([]byte(keyFn(v))) {
+					continue
+				}
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
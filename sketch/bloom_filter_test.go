@@ -0,0 +1,272 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewBloomFilterValidation(t *testing.T) {
+	if _, err := NewBloomFilter(0, 3); err == nil {
+		t.Fatalf("expected error for bitSize=0")
+	}
+	if _, err := NewBloomFilter(128, 0); err == nil {
+		t.Fatalf("expected error for hashFuncs=0")
+	}
+	if _, err := NewBloomFilterByError(0, 0.01); err == nil {
+		t.Fatalf("expected error for expectedItems=0")
+	}
+	if _, err := NewBloomFilterByError(100, 1); err == nil {
+		t.Fatalf("expected error for falsePositiveRate=1")
+	}
+}
+
+func TestNewBloomFilterByErrorDimensions(t *testing.T) {
+	bf, err := NewBloomFilterByError(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewBloomFilterByError() returned error: %v", err)
+	}
+	if bf.BitSize() <= 0 || bf.HashFuncs() <= 0 {
+		t.Fatalf("invalid bloom dimensions: bitSize=%d hashFuncs=%d", bf.BitSize(), bf.HashFuncs())
+	}
+}
+
+func TestBloomFilterNoFalseNegative(t *testing.T) {
+	bf, err := NewBloomFilter(8192, 6)
+	if err != nil {
+		t.Fatalf("NewBloomFilter() returned error: %v", err)
+	}
+
+	keys := []string{"apple", "banana", "orange", "grape"}
+	for _, k := range keys {
+		bf.AddString(k)
+	}
+
+	if bf.AddedCount() != uint64(len(keys)) {
+		t.Fatalf("AddedCount()=%d, expected %d", bf.AddedCount(), len(keys))
+	}
+
+	for _, k := range keys {
+		if !bf.TestString(k) {
+			t.Fatalf("TestString(%q)=false, expected true", k)
+		}
+	}
+}
+
+func TestBloomFilterMergeAndReset(t *testing.T) {
+	left, err := NewBloomFilter(2048, 4)
+	if err != nil {
+		t.Fatalf("NewBloomFilter(left) error: %v", err)
+	}
+	right, err := NewBloomFilter(2048, 4)
+	if err != nil {
+		t.Fatalf("NewBloomFilter(right) error: %v", err)
+	}
+
+	left.AddString("apple")
+	left.AddString("banana")
+	right.AddString("orange")
+	right.AddString("grape")
+
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if !left.TestString("apple") || !left.TestString("orange") {
+		t.Fatalf("merged filter should include keys from both filters")
+	}
+	if left.AddedCount() != 4 {
+		t.Fatalf("AddedCount()=%d, expected 4", left.AddedCount())
+	}
+
+	left.Reset()
+	if left.AddedCount() != 0 {
+		t.Fatalf("AddedCount()=%d, expected 0 after reset", left.AddedCount())
+	}
+	if left.TestString("apple") {
+		t.Fatalf("TestString(apple)=true after reset, expected likely false for empty filter")
+	}
+}
+
+func TestBloomFilterCollectAggregatesStreamItems(t *testing.T) {
+	data := []string{"apple", "banana", "apple", "orange", "banana", "apple"}
+
+	result := stream.Stream(
+		slices.Values(data),
+		stream.End(BloomFilterCollect(4096, 5, func(s string) string { return s })),
+	)
+	if result.Err != nil {
+		t.Fatalf("BloomFilterCollect() returned error: %v", result.Err)
+	}
+	if result.Filter == nil {
+		t.Fatalf("BloomFilterCollect() returned nil filter")
+	}
+
+	for _, key := range []string{"apple", "banana", "orange"} {
+		if !result.Filter.TestString(key) {
+			t.Fatalf("TestString(%q)=false, expected true", key)
+		}
+	}
+}
+
+func TestBloomFilterSaturationStats(t *testing.T) {
+	bf, err := NewBloomFilterByError(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewBloomFilterByError() returned error: %v", err)
+	}
+	if bf.FillRatio() != 0 || bf.EstimateCardinality() != 0 || bf.CurrentFalsePositiveRate() != 0 {
+		t.Fatalf("expected zero stats for an empty filter")
+	}
+
+	for i := 0; i < 1000; i++ {
+		bf.AddString(fmt.Sprintf("key-%d", i))
+		bf.AddString(fmt.Sprintf("key-%d", i))
+	}
+	if est := bf.EstimateCardinality(); math.Abs(est-1000) > 100 {
+		t.Fatalf("EstimateCardinality()=%v, expected about 1000", est)
+	}
+	if fill := bf.FillRatio(); math.Abs(fill-0.5) > 0.05 {
+		t.Fatalf("FillRatio()=%v, expected about 0.5 at capacity", fill)
+	}
+	atCapacity := bf.CurrentFalsePositiveRate()
+	if math.Abs(atCapacity-0.01) > 0.005 {
+		t.Fatalf("CurrentFalsePositiveRate()=%v, expected about 0.01 at capacity", atCapacity)
+	}
+
+	for i := 1000; i < 5000; i++ {
+		bf.AddString(fmt.Sprintf("key-%d", i))
+	}
+	if rate := bf.CurrentFalsePositiveRate(); rate < 10*atCapacity {
+		t.Fatalf("CurrentFalsePositiveRate()=%v, expected well above %v over capacity", rate, atCapacity)
+	}
+}
+
+func TestFilterByBloom(t *testing.T) {
+	type order struct {
+		ID     string
+		Amount int
+	}
+	identity := func(s string) string { return s }
+	refunded := stream.Stream(slices.Values([]string{"o-2", "o-4"}), stream.End(BloomFilterCollectByError(100, 0.001, identity)))
+	if refunded.Err != nil {
+		t.Fatalf("BloomFilterCollectByError() returned error: %v", refunded.Err)
+	}
+
+	orders := []order{{"o-1", 10}, {"o-2", 20}, {"o-3", 30}, {"o-4", 40}}
+	keyFn := func(o order) string { return o.ID }
+	in := stream.Stream(slices.Values(orders), FilterByBloom(refunded.Filter, keyFn, stream.End(stream.Collect[order]())))
+	if !slices.Equal(in, []order{{"o-2", 20}, {"o-4", 40}}) {
+		t.Fatalf("FilterByBloom()=%v, expected o-2 and o-4", in)
+	}
+	out := stream.Stream(slices.Values(orders), FilterNotInBloom(refunded.Filter, keyFn, stream.End(stream.Collect[order]())))
+	if !slices.Equal(out, []order{{"o-1", 10}, {"o-3", 30}}) {
+		t.Fatalf("FilterNotInBloom()=%v, expected o-1 and o-3", out)
+	}
+}
+
+func TestDistinctApprox(t *testing.T) {
+	data := []string{"apple", "apple", "banana", "orange", "banana", "grape"}
+	result := stream.Stream(
+		slices.Values(data),
+		DistinctApprox(100, 0.001, func(s string) string { return s },
+			stream.End(stream.Collect[string]()),
+		),
+	)
+	if !slices.Equal(result, []string{"apple", "banana", "orange", "grape"}) {
+		t.Fatalf("DistinctApprox()=%v, expected [apple banana orange grape]", result)
+	}
+
+	// Within capacity, few distinct keys are wrongly dropped.
+	seq := func(yield func(int) bool) {
+		for i := 0; i < 20000; i++ {
+			if !yield(i % 10000) {
+				return
+			}
+		}
+	}
+	count := stream.Stream(seq, DistinctApprox(10000, 0.01, func(i int) string { return fmt.Sprint(i) }, stream.End(stream.Count[int]())))
+	if count < 9800 || count > 10000 {
+		t.Fatalf("DistinctApprox() count=%d, expected about 10000", count)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic for fpRate=1")
+		}
+	}()
+	DistinctApprox(10, 1, func(s string) string { return s }, stream.End(stream.Count[string]()))
+}
+
+func TestBloomFilterSetEstimates(t *testing.T) {
+	a, _ := NewBloomFilterByError(10000, 0.01)
+	b, _ := NewBloomFilterByError(10000, 0.01)
+	for i := 0; i < 2000; i++ {
+		a.AddString(fmt.Sprintf("key-%d", i))
+		b.AddString(fmt.Sprintf("key-%d", i+1000))
+	}
+
+	union, err := EstimateUnion(a, b)
+	if err != nil {
+		t.Fatalf("EstimateUnion() returned error: %v", err)
+	}
+	if math.Abs(union-3000) > 150 {
+		t.Fatalf("EstimateUnion()=%v, expected about 3000", union)
+	}
+	intersection, _ := EstimateIntersection(a, b)
+	if math.Abs(intersection-1000) > 150 {
+		t.Fatalf("EstimateIntersection()=%v, expected about 1000", intersection)
+	}
+	jaccard, _ := Jaccard(a, b)
+	if math.Abs(jaccard-1.0/3) > 0.05 {
+		t.Fatalf("Jaccard()=%v, expected about 0.33", jaccard)
+	}
+
+	empty, _ := NewBloomFilterByError(10000, 0.01)
+	if j, err := Jaccard(empty, empty); err != nil || j != 0 {
+		t.Fatalf("Jaccard() of empty filters = %v, %v, expected 0", j, err)
+	}
+	small, _ := NewBloomFilter(64, 3)
+	if _, err := Jaccard(a, small); err == nil {
+		t.Fatalf("expected error for incompatible filters")
+	}
+	if _, err := EstimateUnion(a, nil); err == nil {
+		t.Fatalf("expected error for nil filter")
+	}
+}
+
+func TestBloomFilterBinaryRoundTrip(t *testing.T) {
+	bf, _ := NewBloomFilter(100, 3)
+	bf.AddString("apple")
+	bf.AddString("banana")
+
+	data, err := bf.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() returned error: %v", err)
+	}
+	var restored BloomFilter
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() returned error: %v", err)
+	}
+	if restored.BitSize() != 100 || restored.HashFuncs() != 3 || restored.AddedCount() != 2 {
+		t.Fatalf("restored %d bits %d hashes %d added, expected 100, 3, 2", restored.BitSize(), restored.HashFuncs(), restored.AddedCount())
+	}
+	if !restored.TestString("apple") || !restored.TestString("banana") {
+		t.Fatalf("restored filter lost keys")
+	}
+
+	corrupt := map[string][]byte{
+		"truncated":   data[:len(data)-8],
+		"bad magic":   append([]byte("XBLF"), data[4:]...),
+		"bad version": append(append([]byte(bloomMagic), 9), data[5:]...),
+		"version 1":   append(append([]byte(bloomMagic), 1), data[5:]...),
+		"bad tail":    append(slices.Clone(data[:len(data)-1]), 0xff),
+	}
+	for name, bad := range corrupt {
+		var target BloomFilter
+		if err := target.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("%s: UnmarshalBinary() returned nil, expected error", name)
+		}
+	}
+}
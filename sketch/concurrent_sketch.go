@@ -0,0 +1,255 @@
+package sketch
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// ConcurrentBloomFilter is a BloomFilter that is safe for concurrent use.
+// Bits are set with atomic word updates, so parallel workers can add and
+// test keys on a shared filter without a global lock.
+type ConcurrentBloomFilter struct {
+	bitSize   int
+	hashFuncs int
+	hash      HashFunc
+	bits      []atomic.Uint64
+	added     atomic.Uint64
+}
+
+func NewConcurrentBloomFilter(bitSize, hashFuncs int) (*ConcurrentBloomFilter, error) {
+	return NewConcurrentBloomFilterWithHash(bitSize, hashFuncs, FNVHash)
+}
+
+// NewConcurrentBloomFilterWithHash uses hash instead of FNVHash.
+func NewConcurrentBloomFilterWithHash(bitSize, hashFuncs int, hash HashFunc) (*ConcurrentBloomFilter, error) {
+	if hash == nil {
+		hash = FNVHash
+	}
+	if bitSize <= 0 {
+		return nil, errInvalidBitSize
+	}
+	if hashFuncs <= 0 {
+		return nil, errInvalidHashFuncs
+	}
+
+	wordCount := (bitSize + 63) / 64
+	return &ConcurrentBloomFilter{
+		bitSize:   bitSize,
+		hashFuncs: hashFuncs,
+		hash:      hash,
+		bits:      make([]atomic.Uint64, wordCount),
+	}, nil
+}
+
+// NewConcurrentBloomFilterByError calculates parameters from capacity and false positive rate.
+func NewConcurrentBloomFilterByError(expectedItems int, falsePositiveRate float64) (*ConcurrentBloomFilter, error) {
+	bf, err := NewBloomFilterByError(expectedItems, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return NewConcurrentBloomFilter(bf.bitSize, bf.hashFuncs)
+}
+
+func (bf *ConcurrentBloomFilter) BitSize() int {
+	return bf.bitSize
+}
+
+func (bf *ConcurrentBloomFilter) HashFuncs() int {
+	return bf.hashFuncs
+}
+
+func (bf *ConcurrentBloomFilter) AddedCount() uint64 {
+	return bf.added.Load()
+}
+
+func (bf *ConcurrentBloomFilter) AddString(key string) {
+	bf.AddBytes([]byte(key))
+}
+
+func (bf *ConcurrentBloomFilter) AddBytes(key []byte) {
+	h := bf.hash(key)
+	for i := 0; i < bf.hashFuncs; i++ {
+		idx := kmIndex(h, i, bf.bitSize)
+		bf.bits[idx/64].Or(uint64(1) << uint(idx%64))
+	}
+	bf.added.Add(1)
+}
+
+func (bf *ConcurrentBloomFilter) TestString(key string) bool {
+	return bf.TestBytes([]byte(key))
+}
+
+func (bf *ConcurrentBloomFilter) TestBytes(key []byte) bool {
+	h := bf.hash(key)
+	for i := 0; i < bf.hashFuncs; i++ {
+		idx := kmIndex(h, i, bf.bitSize)
+		if bf.bits[idx/64].Load()&(uint64(1)<<uint(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge folds a plain BloomFilter into bf. It is safe to call while other
+// goroutines are adding keys.
+func (bf *ConcurrentBloomFilter) Merge(other *BloomFilter) error {
+	if bf == nil || other == nil {
+		return errNilBloomFilter
+	}
+	if bf.bitSize != other.bitSize || bf.hashFuncs != other.hashFuncs {
+		return errIncompatibleBloomFilter
+	}
+
+	for i := range bf.bits {
+		bf.bits[i].Or(other.bits[i])
+	}
+	bf.added.Add(other.added)
+	return nil
+}
+
+// Snapshot copies the current state into a plain BloomFilter.
+// Keys added concurrently with the copy may or may not be included.
+func (bf *ConcurrentBloomFilter) Snapshot() *BloomFilter {
+	out := &BloomFilter{
+		bitSize:   bf.bitSize,
+		hashFuncs: bf.hashFuncs,
+		hash:      bf.hash,
+		bits:      make([]uint64, len(bf.bits)),
+		added:     bf.added.Load(),
+	}
+	for i := range bf.bits {
+		out.bits[i] = bf.bits[i].Load()
+	}
+	return out
+}
+
+// ConcurrentCountMinSketch is a CountMinSketch that is safe for concurrent use.
+// Counters are updated atomically, so parallel workers can add to a shared
+// sketch without a global lock.
+type ConcurrentCountMinSketch struct {
+	width int
+	depth int
+	hash  HashFunc
+	table []atomic.Uint64
+	total atomic.Uint64
+}
+
+func NewConcurrentCountMinSketch(width, depth int) (*ConcurrentCountMinSketch, error) {
+	return NewConcurrentCountMinSketchWithHash(width, depth, FNVHash)
+}
+
+// NewConcurrentCountMinSketchWithHash uses hash instead of FNVHash.
+func NewConcurrentCountMinSketchWithHash(width, depth int, hash HashFunc) (*ConcurrentCountMinSketch, error) {
+	if hash == nil {
+		hash = FNVHash
+	}
+	if width <= 0 {
+		return nil, errInvalidWidth
+	}
+	if depth <= 0 {
+		return nil, errInvalidDepth
+	}
+
+	return &ConcurrentCountMinSketch{
+		width: width,
+		depth: depth,
+		hash:  hash,
+		table: make([]atomic.Uint64, width*depth),
+	}, nil
+}
+
+// NewConcurrentCountMinSketchByError creates sketch dimensions from error bounds.
+func NewConcurrentCountMinSketchByError(epsilon, delta float64) (*ConcurrentCountMinSketch, error) {
+	cms, err := NewCountMinSketchByError(epsilon, delta)
+	if err != nil {
+		return nil, err
+	}
+	return NewConcurrentCountMinSketch(cms.width, cms.depth)
+}
+
+func (cms *ConcurrentCountMinSketch) Width() int {
+	return cms.width
+}
+
+func (cms *ConcurrentCountMinSketch) Depth() int {
+	return cms.depth
+}
+
+func (cms *ConcurrentCountMinSketch) TotalCount() uint64 {
+	return cms.total.Load()
+}
+
+func (cms *ConcurrentCountMinSketch) AddString(key string, count uint64) {
+	cms.AddBytes([]byte(key), count)
+}
+
+func (cms *ConcurrentCountMinSketch) AddBytes(key []byte, count uint64) {
+	if count == 0 {
+		return
+	}
+
+	h := cms.hash(key)
+	for row := 0; row < cms.depth; row++ {
+		cms.cell(h, row).Add(count)
+	}
+	cms.total.Add(count)
+}
+
+func (cms *ConcurrentCountMinSketch) EstimateString(key string) uint64 {
+	return cms.EstimateBytes([]byte(key))
+}
+
+func (cms *ConcurrentCountMinSketch) EstimateBytes(key []byte) uint64 {
+	min := uint64(math.MaxUint64)
+	h := cms.hash(key)
+	for row := 0; row < cms.depth; row++ {
+		v := cms.cell(h, row).Load()
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// Merge folds a plain CountMinSketch into cms. It is safe to call while
+// other goroutines are adding keys.
+func (cms *ConcurrentCountMinSketch) Merge(other *CountMinSketch) error {
+	if cms == nil || other == nil {
+		return errNilCountMinSketch
+	}
+	if cms.width != other.width || cms.depth != other.depth {
+		return errIncompatibleCMS
+	}
+
+	for row := 0; row < cms.depth; row++ {
+		for col := 0; col < cms.width; col++ {
+			cms.table[row*cms.width+col].Add(other.table[row][col])
+		}
+	}
+	cms.total.Add(other.total)
+	return nil
+}
+
+// Snapshot copies the current counters into a plain CountMinSketch.
+// Updates made concurrently with the copy may be partially included.
+func (cms *ConcurrentCountMinSketch) Snapshot() *CountMinSketch {
+	table := make([][]uint64, cms.depth)
+	for row := range table {
+		table[row] = make([]uint64, cms.width)
+		for col := range table[row] {
+			table[row][col] = cms.table[row*cms.width+col].Load()
+		}
+	}
+	return &CountMinSketch{
+		width: cms.width,
+		depth: cms.depth,
+		hash:  cms.hash,
+		table: table,
+		total: cms.total.Load(),
+	}
+}
+
+func (cms *ConcurrentCountMinSketch) cell(h uint64, row int) *atomic.Uint64 {
+	col := kmIndex(h, row, cms.width)
+	return &cms.table[row*cms.width+col]
+}
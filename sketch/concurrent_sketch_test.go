@@ -0,0 +1,129 @@
+package sketch
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentBloomFilterParallelAdds(t *testing.T) {
+	bf, err := NewConcurrentBloomFilterByError(10000, 0.01)
+	if err != nil {
+		t.Fatalf("NewConcurrentBloomFilterByError() returned error: %v", err)
+	}
+
+	const workers = 8
+	const perWorker = 500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				bf.AddString(strconv.Itoa(w*perWorker + i))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if bf.AddedCount() != workers*perWorker {
+		t.Fatalf("AddedCount()=%d, expected %d", bf.AddedCount(), workers*perWorker)
+	}
+	for i := 0; i < workers*perWorker; i++ {
+		if !bf.TestString(strconv.Itoa(i)) {
+			t.Fatalf("TestString(%d)=false, expected true", i)
+		}
+	}
+}
+
+func TestConcurrentBloomFilterMergeAndSnapshot(t *testing.T) {
+	shared, err := NewConcurrentBloomFilter(2048, 4)
+	if err != nil {
+		t.Fatalf("NewConcurrentBloomFilter() error: %v", err)
+	}
+	plain, err := NewBloomFilter(2048, 4)
+	if err != nil {
+		t.Fatalf("NewBloomFilter() error: %v", err)
+	}
+
+	shared.AddString("apple")
+	plain.AddString("orange")
+	if err := shared.Merge(plain); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+
+	snapshot := shared.Snapshot()
+	if !snapshot.TestString("apple") || !snapshot.TestString("orange") {
+		t.Fatalf("snapshot should include keys from both filters")
+	}
+	if snapshot.AddedCount() != 2 {
+		t.Fatalf("AddedCount()=%d, expected 2", snapshot.AddedCount())
+	}
+
+	incompatible, _ := NewBloomFilter(1024, 4)
+	if err := shared.Merge(incompatible); err == nil {
+		t.Fatalf("expected error when merging incompatible filters")
+	}
+}
+
+func TestConcurrentCountMinSketchParallelAdds(t *testing.T) {
+	cms, err := NewConcurrentCountMinSketch(256, 5)
+	if err != nil {
+		t.Fatalf("NewConcurrentCountMinSketch() returned error: %v", err)
+	}
+
+	const workers = 8
+	const perWorker = 1000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				cms.AddString("apple", 1)
+				if i%2 == 0 {
+					cms.AddString("banana", 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cms.TotalCount() != workers*perWorker*3/2 {
+		t.Fatalf("TotalCount()=%d, expected %d", cms.TotalCount(), workers*perWorker*3/2)
+	}
+	if cms.EstimateString("apple") < workers*perWorker {
+		t.Fatalf("EstimateString(apple)=%d, expected >= %d", cms.EstimateString("apple"), workers*perWorker)
+	}
+	if cms.EstimateString("banana") < workers*perWorker/2 {
+		t.Fatalf("EstimateString(banana)=%d, expected >= %d", cms.EstimateString("banana"), workers*perWorker/2)
+	}
+}
+
+func TestConcurrentCountMinSketchMergeAndSnapshot(t *testing.T) {
+	shared, err := NewConcurrentCountMinSketchByError(0.01, 0.01)
+	if err != nil {
+		t.Fatalf("NewConcurrentCountMinSketchByError() error: %v", err)
+	}
+	plain, err := NewCountMinSketchByError(0.01, 0.01)
+	if err != nil {
+		t.Fatalf("NewCountMinSketchByError() error: %v", err)
+	}
+
+	shared.AddString("apple", 2)
+	plain.AddString("apple", 3)
+	if err := shared.Merge(plain); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+
+	snapshot := shared.Snapshot()
+	if snapshot.TotalCount() != 5 {
+		t.Fatalf("TotalCount()=%d, expected 5", snapshot.TotalCount())
+	}
+	if snapshot.EstimateString("apple") < 5 {
+		t.Fatalf("EstimateString(apple)=%d, expected >= 5", snapshot.EstimateString("apple"))
+	}
+	if snapshot.Width() != shared.Width() || snapshot.Depth() != shared.Depth() {
+		t.Fatalf("snapshot dimensions %dx%d, expected %dx%d", snapshot.Width(), snapshot.Depth(), shared.Width(), shared.Depth())
+	}
+}
@@ -1,9 +1,9 @@
-package main
+package sketch
 
 import (
 	"encoding/binary"
 	"errors"
-	"hash/fnv"
+	"fmt"
 	"iter"
 	"math"
 )
@@ -15,6 +15,19 @@ var (
 	errInvalidDelta      = errors.New("delta must be in (0, 1)")
 	errNilCountMinSketch = errors.New("count-min sketch is nil")
 	errIncompatibleCMS   = errors.New("count-min sketches are incompatible")
+	errCMSEncoding       = errors.New("invalid count-min sketch encoding")
+	errCMSVersion        = errors.New("unsupported count-min sketch encoding version")
+)
+
+// cmsMagic and cmsVersion start the binary encoding of a CountMinSketch,
+// followed by width and depth (uint32), the total count and then the table
+// row by row (uint64), all little-endian.
+// Version 1 sketches counted in columns derived by an older index scheme and
+// are rejected.
+const (
+	cmsMagic      = "GCMS"
+	cmsVersion    = 2
+	cmsHeaderSize = len(cmsMagic) + 1 + 4 + 4 + 8
 )
 
 // CountMinSketch is a probabilistic frequency estimator.
@@ -22,6 +35,7 @@ var (
 type CountMinSketch struct {
 	width int
 	depth int
+	hash  HashFunc
 	table [][]uint64
 	total uint64
 }
@@ -32,6 +46,15 @@ type CountMinSketchResult struct {
 }
 
 func NewCountMinSketch(width, depth int) (*CountMinSketch, error) {
+	return NewCountMinSketchWithHash(width, depth, FNVHash)
+}
+
+// NewCountMinSketchWithHash uses hash instead of FNVHash. Sketches are only
+// compatible for Merge when they use the same hash.
+func NewCountMinSketchWithHash(width, depth int, hash HashFunc) (*CountMinSketch, error) {
+	if hash == nil {
+		hash = FNVHash
+	}
 	if width <= 0 {
 		return nil, errInvalidWidth
 	}
@@ -47,6 +70,7 @@ func NewCountMinSketch(width, depth int) (*CountMinSketch, error) {
 	return &CountMinSketch{
 		width: width,
 		depth: depth,
+		hash:  hash,
 		table: table,
 	}, nil
 }
@@ -87,9 +111,9 @@ func (cms *CountMinSketch) AddBytes(key []byte, count uint64) {
 		return
 	}
 
+	h := cms.hash(key)
 	for row := 0; row < cms.depth; row++ {
-		col := cms.column(key, row)
-		cms.table[row][col] += count
+		cms.table[row][kmIndex(h, row, cms.width)] += count
 	}
 	cms.total += count
 }
@@ -100,9 +124,9 @@ func (cms *CountMinSketch) EstimateString(key string) uint64 {
 
 func (cms *CountMinSketch) EstimateBytes(key []byte) uint64 {
 	min := uint64(math.MaxUint64)
+	h := cms.hash(key)
 	for row := 0; row < cms.depth; row++ {
-		col := cms.column(key, row)
-		v := cms.table[row][col]
+		v := cms.table[row][kmIndex(h, row, cms.width)]
 		if v < min {
 			min = v
 		}
@@ -134,18 +158,70 @@ func (cms *CountMinSketch) Reset() {
 	cms.total = 0
 }
 
-func (cms *CountMinSketch) column(key []byte, row int) int {
-	return int(hashRowKey(key, row) % uint64(cms.width))
+// MarshalBinary encodes the sketch so partial sketches can be stored or
+// shipped and merged later.
+func (cms *CountMinSketch) MarshalBinary() ([]byte, error) {
+	if cms == nil {
+		return nil, errNilCountMinSketch
+	}
+	if uint64(cms.width) > math.MaxUint32 || uint64(cms.depth) > math.MaxUint32 {
+		return nil, errCMSEncoding
+	}
+
+	data := make([]byte, 0, cmsHeaderSize+8*cms.width*cms.depth)
+	data = append(data, cmsMagic...)
+	data = append(data, cmsVersion)
+	data = binary.LittleEndian.AppendUint32(data, uint32(cms.width))
+	data = binary.LittleEndian.AppendUint32(data, uint32(cms.depth))
+	data = binary.LittleEndian.AppendUint64(data, cms.total)
+	for _, row := range cms.table {
+		for _, v := range row {
+			data = binary.LittleEndian.AppendUint64(data, v)
+		}
+	}
+	return data, nil
 }
 
-func hashRowKey(key []byte, row int) uint64 {
-	var rowPrefix [8]byte
-	binary.LittleEndian.PutUint64(rowPrefix[:], uint64(row))
+// UnmarshalBinary replaces the sketch with one encoded by MarshalBinary. The
+// dimensions must match the data length and every row must sum to the total
+// count. The hash is not encoded: a sketch made with NewCountMinSketchWithHash
+// keeps its hash, and a zero CountMinSketch uses FNVHash.
+func (cms *CountMinSketch) UnmarshalBinary(data []byte) error {
+	if len(data) < cmsHeaderSize || string(data[:len(cmsMagic)]) != cmsMagic {
+		return errCMSEncoding
+	}
+	if version := data[len(cmsMagic)]; version != cmsVersion {
+		return fmt.Errorf("%w %d", errCMSVersion, version)
+	}
+	header := data[len(cmsMagic)+1:]
+	width := uint64(binary.LittleEndian.Uint32(header))
+	depth := uint64(binary.LittleEndian.Uint32(header[4:]))
+	total := binary.LittleEndian.Uint64(header[8:])
+	body := data[cmsHeaderSize:]
+	if width == 0 || depth == 0 || uint64(len(body))/8/width != depth || uint64(len(body)) != 8*width*depth {
+		return fmt.Errorf("%w: %dx%d table in %d bytes", errCMSEncoding, width, depth, len(body))
+	}
+
+	table := make([][]uint64, depth)
+	for row := range table {
+		table[row] = make([]uint64, width)
+		var sum uint64
+		for col := range table[row] {
+			v := binary.LittleEndian.Uint64(body)
+			body = body[8:]
+			table[row][col] = v
+			sum += v
+		}
+		if sum != total {
+			return fmt.Errorf("%w: row %d sums to %d, want %d", errCMSEncoding, row, sum, total)
+		}
+	}
 
-	h := fnv.New64a()
-	_, _ = h.Write(rowPrefix[:])
-	_, _ = h.Write(key)
-	return h.Sum64()
+	if cms.hash == nil {
+		cms.hash = FNVHash
+	}
+	cms.width, cms.depth, cms.table, cms.total = int(width), int(depth), table, total
+	return nil
 }
 
 func CountMinSketchCollect[A any](width, depth int, keyFn func(A) string) func(iter.Seq[A]) CountMinSketchResult {
@@ -1,16 +1,18 @@
-package main
+package sketch
 
 import (
 	"slices"
 	"testing"
+
+	"github.com/donkomura/go-stream/stream"
 )
 
 func TestCountMinSketchCollectAggregatesStreamItems(t *testing.T) {
 	data := []string{"apple", "banana", "apple", "orange", "banana", "apple"}
 
-	result := Stream(
+	result := stream.Stream(
 		slices.Values(data),
-		End(CountMinSketchCollect(128, 5, func(s string) string { return s })),
+		stream.End(CountMinSketchCollect(128, 5, func(s string) string { return s })),
 	)
 	if result.Err != nil {
 		t.Fatalf("CountMinSketchCollect() returned error: %v", result.Err)
@@ -133,3 +135,49 @@ func TestCountMinSketchMergeAndReset(t *testing.T) {
 		t.Fatalf("EstimateString(apple)=%d, expected 0 after reset", left.EstimateString("apple"))
 	}
 }
+
+func TestCountMinSketchBinaryRoundTrip(t *testing.T) {
+	cms, err := NewCountMinSketch(64, 4)
+	if err != nil {
+		t.Fatalf("NewCountMinSketch() returned error: %v", err)
+	}
+	cms.AddString("apple", 3)
+	cms.AddString("banana", 2)
+
+	data, err := cms.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() returned error: %v", err)
+	}
+	var restored CountMinSketch
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() returned error: %v", err)
+	}
+	if restored.Width() != 64 || restored.Depth() != 4 || restored.TotalCount() != 5 {
+		t.Fatalf("restored dimensions %dx%d total %d, expected 64x4 total 5", restored.Width(), restored.Depth(), restored.TotalCount())
+	}
+	if restored.EstimateString("apple") != cms.EstimateString("apple") {
+		t.Fatalf("EstimateString(apple)=%d, expected %d", restored.EstimateString("apple"), cms.EstimateString("apple"))
+	}
+
+	// A restored partial sketch merges like the original.
+	if err := restored.Merge(cms); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if restored.EstimateString("apple") < 6 {
+		t.Fatalf("EstimateString(apple)=%d, expected >= 6", restored.EstimateString("apple"))
+	}
+
+	corrupt := map[string][]byte{
+		"truncated":   data[:len(data)-8],
+		"bad magic":   append([]byte("XCMS"), data[4:]...),
+		"bad version": append(append([]byte(cmsMagic), 9), data[5:]...),
+		"version 1":   append(append([]byte(cmsMagic), 1), data[5:]...),
+		"bad total":   append(slices.Clone(data[:len(data)-1]), 0xff),
+	}
+	for name, bad := range corrupt {
+		var target CountMinSketch
+		if err := target.UnmarshalBinary(bad); err == nil {
+			t.Fatalf("%s: UnmarshalBinary() returned nil, expected error", name)
+		}
+	}
+}
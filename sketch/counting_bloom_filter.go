@@ -0,0 +1,190 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+)
+
+// countingBloomMax is the largest value of a 4-bit counter.
+const countingBloomMax = 15
+
+var (
+	errCounterOverflow = errors.New("counting bloom filter counter overflow")
+	errKeyNotPresent   = errors.New("key is not in the filter")
+)
+
+// CountingBloomOverflow selects what happens when a counter would exceed
+// its 4-bit maximum.
+type CountingBloomOverflow int
+
+const (
+	// OverflowSaturate leaves full counters at the maximum and never
+	// decrements them again, so removals cannot cause false negatives at
+	// the cost of positions that stay set.
+	OverflowSaturate CountingBloomOverflow = iota
+	// OverflowError makes Add fail without changing the filter.
+	OverflowError
+)
+
+// CountingBloomFilter is a Bloom filter with a 4-bit counter per position
+// instead of a bit, so keys can be removed again, e.g. when they leave a
+// sliding dedup window. It uses four times the memory of a BloomFilter of
+// the same size.
+type CountingBloomFilter struct {
+	size      int
+	hashFuncs int
+	overflow  CountingBloomOverflow
+	counters  []byte
+	count     uint64
+}
+
+type CountingBloomFilterResult struct {
+	Filter *CountingBloomFilter
+	Err    error
+}
+
+func NewCountingBloomFilter(size, hashFuncs int, overflow CountingBloomOverflow) (*CountingBloomFilter, error) {
+	if size <= 0 {
+		return nil, errInvalidBitSize
+	}
+	if hashFuncs <= 0 {
+		return nil, errInvalidHashFuncs
+	}
+
+	return &CountingBloomFilter{
+		size:      size,
+		hashFuncs: hashFuncs,
+		overflow:  overflow,
+		counters:  make([]byte, (size+1)/2),
+	}, nil
+}
+
+// NewCountingBloomFilterByError calculates parameters from capacity and false positive rate.
+func NewCountingBloomFilterByError(expectedItems int, falsePositiveRate float64, overflow CountingBloomOverflow) (*CountingBloomFilter, error) {
+	bf, err := NewBloomFilterByError(expectedItems, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	return NewCountingBloomFilter(bf.bitSize, bf.hashFuncs, overflow)
+}
+
+func (cbf *CountingBloomFilter) Size() int {
+	return cbf.size
+}
+
+func (cbf *CountingBloomFilter) HashFuncs() int {
+	return cbf.hashFuncs
+}
+
+// Count returns the number of keys added and not removed.
+func (cbf *CountingBloomFilter) Count() uint64 {
+	return cbf.count
+}
+
+func (cbf *CountingBloomFilter) AddString(key string) error {
+	return cbf.AddBytes([]byte(key))
+}
+
+func (cbf *CountingBloomFilter) AddBytes(key []byte) error {
+	indexes := cbf.indexes(key)
+	if cbf.overflow == OverflowError {
+		for i, idx := range indexes {
+			hits := 0
+			for _, other := range indexes[:i+1] {
+				if other == idx {
+					hits++
+				}
+			}
+			if cbf.counter(idx)+hits > countingBloomMax {
+				return errCounterOverflow
+			}
+		}
+	}
+
+	for _, idx := range indexes {
+		if c := cbf.counter(idx); c < countingBloomMax {
+			cbf.setCounter(idx, c+1)
+		}
+	}
+	cbf.count++
+	return nil
+}
+
+// RemoveString removes one occurrence of key. It fails without changing the
+// filter when key is definitely not present; removing a key that was never
+// added but tests positive corrupts the filter.
+func (cbf *CountingBloomFilter) RemoveString(key string) error {
+	return cbf.RemoveBytes([]byte(key))
+}
+
+func (cbf *CountingBloomFilter) RemoveBytes(key []byte) error {
+	indexes := cbf.indexes(key)
+	for _, idx := range indexes {
+		if cbf.counter(idx) == 0 {
+			return errKeyNotPresent
+		}
+	}
+
+	for _, idx := range indexes {
+		// Saturated counters have lost their true value and stay set.
+		if c := cbf.counter(idx); c > 0 && c < countingBloomMax {
+			cbf.setCounter(idx, c-1)
+		}
+	}
+	if cbf.count > 0 {
+		cbf.count--
+	}
+	return nil
+}
+
+func (cbf *CountingBloomFilter) TestString(key string) bool {
+	return cbf.TestBytes([]byte(key))
+}
+
+func (cbf *CountingBloomFilter) TestBytes(key []byte) bool {
+	h := FNVHash(key)
+	for i := 0; i < cbf.hashFuncs; i++ {
+		if cbf.counter(kmIndex(h, i, cbf.size)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (cbf *CountingBloomFilter) Reset() {
+	clear(cbf.counters)
+	cbf.count = 0
+}
+
+func (cbf *CountingBloomFilter) indexes(key []byte) []int {
+	h := FNVHash(key)
+	indexes := make([]int, cbf.hashFuncs)
+	for i := range indexes {
+		indexes[i] = kmIndex(h, i, cbf.size)
+	}
+	return indexes
+}
+
+func (cbf *CountingBloomFilter) counter(index int) int {
+	return int(cbf.counters[index/2]>>(4*(index%2))) & 0x0f
+}
+
+func (cbf *CountingBloomFilter) setCounter(index, value int) {
+	shift := 4 * (index % 2)
+	b := &cbf.counters[index/2]
+	*b = *b&^(0x0f<<shift) | byte(value)<<shift
+}
+
+func CountingBloomFilterCollect[A any](size, hashFuncs int, keyFn func(A) string) func(iter.Seq[A]) CountingBloomFilterResult {
+	return func(seq iter.Seq[A]) CountingBloomFilterResult {
+		cbf, err := NewCountingBloomFilter(size, hashFuncs, OverflowSaturate)
+		if err != nil {
+			return CountingBloomFilterResult{Err: err}
+		}
+
+		for v := range seq {
+			_ = cbf.AddString(keyFn(v))
+		}
+		return CountingBloomFilterResult{Filter: cbf}
+	}
+}
@@ -0,0 +1,101 @@
+package sketch
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewCountingBloomFilterValidation(t *testing.T) {
+	if _, err := NewCountingBloomFilter(0, 3, OverflowSaturate); err == nil {
+		t.Fatalf("expected error for size=0")
+	}
+	if _, err := NewCountingBloomFilter(128, 0, OverflowSaturate); err == nil {
+		t.Fatalf("expected error for hashFuncs=0")
+	}
+	if _, err := NewCountingBloomFilterByError(0, 0.01, OverflowSaturate); err == nil {
+		t.Fatalf("expected error for expectedItems=0")
+	}
+}
+
+func TestCountingBloomFilterAddRemove(t *testing.T) {
+	cbf, err := NewCountingBloomFilterByError(1000, 0.01, OverflowSaturate)
+	if err != nil {
+		t.Fatalf("NewCountingBloomFilterByError() returned error: %v", err)
+	}
+
+	keys := []string{"apple", "banana", "orange", "grape"}
+	for _, k := range keys {
+		if err := cbf.AddString(k); err != nil {
+			t.Fatalf("AddString(%q) returned error: %v", k, err)
+		}
+	}
+	_ = cbf.AddString("apple")
+
+	if err := cbf.RemoveString("banana"); err != nil {
+		t.Fatalf("RemoveString(banana) returned error: %v", err)
+	}
+	if cbf.TestString("banana") {
+		t.Fatalf("TestString(banana)=true, expected false after removal")
+	}
+	if err := cbf.RemoveString("apple"); err != nil {
+		t.Fatalf("RemoveString(apple) returned error: %v", err)
+	}
+	for _, k := range []string{"apple", "orange", "grape"} {
+		if !cbf.TestString(k) {
+			t.Fatalf("TestString(%q)=false, expected true", k)
+		}
+	}
+	if cbf.Count() != 3 {
+		t.Fatalf("Count()=%d, expected 3", cbf.Count())
+	}
+	if err := cbf.RemoveString("banana"); !errors.Is(err, errKeyNotPresent) {
+		t.Fatalf("RemoveString(banana)=%v, expected %v", err, errKeyNotPresent)
+	}
+
+	cbf.Reset()
+	if cbf.TestString("apple") || cbf.Count() != 0 {
+		t.Fatalf("expected empty filter after reset")
+	}
+}
+
+func TestCountingBloomFilterOverflow(t *testing.T) {
+	strict, _ := NewCountingBloomFilter(64, 2, OverflowError)
+	for i := 0; i < 7; i++ {
+		if err := strict.AddString("hot"); err != nil {
+			t.Fatalf("AddString() #%d returned error: %v", i+1, err)
+		}
+	}
+	// The two hash rounds may share a position, so at most 15 adds fit.
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = strict.AddString("hot")
+	}
+	if !errors.Is(err, errCounterOverflow) {
+		t.Fatalf("AddString()=%v, expected %v", err, errCounterOverflow)
+	}
+
+	saturating, _ := NewCountingBloomFilter(64, 2, OverflowSaturate)
+	for i := 0; i < 20; i++ {
+		_ = saturating.AddString("hot")
+	}
+	for i := 0; i < 20; i++ {
+		_ = saturating.RemoveString("hot")
+	}
+	if !saturating.TestString("hot") {
+		t.Fatalf("TestString(hot)=false, expected saturated counters to stay set")
+	}
+}
+
+func TestCountingBloomFilterCollect(t *testing.T) {
+	data := []string{"a", "b", "a"}
+	result := stream.Stream(slices.Values(data), stream.End(CountingBloomFilterCollect(256, 3, func(s string) string { return s })))
+	if result.Err != nil {
+		t.Fatalf("CountingBloomFilterCollect() returned error: %v", result.Err)
+	}
+	if result.Filter.Count() != 3 || !result.Filter.TestString("b") {
+		t.Fatalf("Count()=%d, expected 3 with b present", result.Filter.Count())
+	}
+}
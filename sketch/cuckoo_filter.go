@@ -0,0 +1,203 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+)
+
+const (
+	cuckooBucketSize = 4
+	cuckooMaxKicks   = 500
+)
+
+var (
+	errInvalidCapacity = errors.New("capacity must be > 0")
+	errFilterFull      = errors.New("cuckoo filter is full")
+)
+
+// CuckooFilter is a probabilistic set with deletion, storing a 16-bit
+// fingerprint per key in buckets of four. At a false positive rate of about
+// 0.012% it uses roughly 2 bytes per key, less than a Bloom filter of the
+// same rate, and keys can be deleted.
+type CuckooFilter struct {
+	buckets [][cuckooBucketSize]uint16
+	mask    uint64
+	count   uint64
+	rng     *rand.Rand
+	// victim holds a fingerprint evicted when an insert ran out of kicks,
+	// so that no previously added key is lost.
+	victim      uint16
+	victimIndex uint64
+	hasVictim   bool
+}
+
+type CuckooFilterResult struct {
+	Filter *CuckooFilter
+	Err    error
+}
+
+// NewCuckooFilter sizes a filter for capacity keys at a load factor of
+// about 95%.
+func NewCuckooFilter(capacity int) (*CuckooFilter, error) {
+	if capacity <= 0 {
+		return nil, errInvalidCapacity
+	}
+	buckets := uint64(capacity*100/95+cuckooBucketSize-1) / cuckooBucketSize
+	buckets = max(1, uint64(1)<<bits.Len64(buckets-1))
+	return &CuckooFilter{
+		buckets: make([][cuckooBucketSize]uint16, buckets),
+		mask:    buckets - 1,
+		rng:     rand.New(rand.NewPCG(buckets, uint64(capacity))),
+	}, nil
+}
+
+// Capacity returns the number of fingerprint slots.
+func (cf *CuckooFilter) Capacity() int {
+	return len(cf.buckets) * cuckooBucketSize
+}
+
+func (cf *CuckooFilter) Count() uint64 {
+	return cf.count
+}
+
+func (cf *CuckooFilter) AddString(key string) error {
+	return cf.AddBytes([]byte(key))
+}
+
+// AddBytes inserts key. Adding the same key twice stores it twice. Once the
+// filter is full, adds fail with an error and the filter keeps answering
+// correctly for the keys already added.
+func (cf *CuckooFilter) AddBytes(key []byte) error {
+	if cf.hasVictim {
+		return errFilterFull
+	}
+	fp, i1, i2 := cf.locate(key)
+	if cf.insert(i1, fp) || cf.insert(i2, fp) {
+		cf.count++
+		return nil
+	}
+
+	i := i1
+	if cf.rng.IntN(2) == 1 {
+		i = i2
+	}
+	for range cuckooMaxKicks {
+		slot := cf.rng.IntN(cuckooBucketSize)
+		fp, cf.buckets[i][slot] = cf.buckets[i][slot], fp
+		i = cf.altIndex(i, fp)
+		if cf.insert(i, fp) {
+			cf.count++
+			return nil
+		}
+	}
+	cf.victim, cf.victimIndex, cf.hasVictim = fp, i, true
+	cf.count++
+	return nil
+}
+
+func (cf *CuckooFilter) TestString(key string) bool {
+	return cf.TestBytes([]byte(key))
+}
+
+func (cf *CuckooFilter) TestBytes(key []byte) bool {
+	fp, i1, i2 := cf.locate(key)
+	if cf.hasVictim && cf.victim == fp && (cf.victimIndex == i1 || cf.victimIndex == i2) {
+		return true
+	}
+	return cf.find(i1, fp) >= 0 || cf.find(i2, fp) >= 0
+}
+
+func (cf *CuckooFilter) DeleteString(key string) bool {
+	return cf.DeleteBytes([]byte(key))
+}
+
+// DeleteBytes removes one occurrence of key and reports whether it was
+// found. Deleting a key that was never added may remove another key with
+// the same fingerprint.
+func (cf *CuckooFilter) DeleteBytes(key []byte) bool {
+	fp, i1, i2 := cf.locate(key)
+	if cf.hasVictim && cf.victim == fp && (cf.victimIndex == i1 || cf.victimIndex == i2) {
+		cf.hasVictim = false
+		cf.count--
+		return true
+	}
+	for _, i := range [2]uint64{i1, i2} {
+		if slot := cf.find(i, fp); slot >= 0 {
+			cf.buckets[i][slot] = 0
+			cf.count--
+			cf.reinsertVictim()
+			return true
+		}
+	}
+	return false
+}
+
+func (cf *CuckooFilter) Reset() {
+	clear(cf.buckets)
+	cf.count = 0
+	cf.hasVictim = false
+}
+
+// locate returns key's non-zero fingerprint and its two candidate buckets.
+func (cf *CuckooFilter) locate(key []byte) (uint16, uint64, uint64) {
+	h := FNVHash(key)
+	fp := uint16(h >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	i1 := h & cf.mask
+	return fp, i1, cf.altIndex(i1, fp)
+}
+
+// altIndex maps between a fingerprint's two buckets in both directions.
+func (cf *CuckooFilter) altIndex(i uint64, fp uint16) uint64 {
+	return (i ^ FNVHash([]byte{byte(fp), byte(fp >> 8)})) & cf.mask
+}
+
+func (cf *CuckooFilter) insert(i uint64, fp uint16) bool {
+	for slot, v := range cf.buckets[i] {
+		if v == 0 {
+			cf.buckets[i][slot] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (cf *CuckooFilter) find(i uint64, fp uint16) int {
+	for slot, v := range cf.buckets[i] {
+		if v == fp {
+			return slot
+		}
+	}
+	return -1
+}
+
+func (cf *CuckooFilter) reinsertVictim() {
+	if !cf.hasVictim {
+		return
+	}
+	if cf.insert(cf.victimIndex, cf.victim) || cf.insert(cf.altIndex(cf.victimIndex, cf.victim), cf.victim) {
+		cf.hasVictim = false
+	}
+}
+
+// CuckooFilterCollect adds the key of every element to a new filter. Err is
+// errFilterFull if the stream had more keys than fit.
+func CuckooFilterCollect[A any](capacity int, keyFn func(A) string) func(iter.Seq[A]) CuckooFilterResult {
+	return func(seq iter.Seq[A]) CuckooFilterResult {
+		cf, err := NewCuckooFilter(capacity)
+		if err != nil {
+			return CuckooFilterResult{Err: err}
+		}
+
+		for v := range seq {
+			if err := cf.AddString(keyFn(v)); err != nil {
+				return CuckooFilterResult{Filter: cf, Err: err}
+			}
+		}
+		return CuckooFilterResult{Filter: cf}
+	}
+}
@@ -0,0 +1,85 @@
+package sketch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewCuckooFilterValidation(t *testing.T) {
+	if _, err := NewCuckooFilter(0); err == nil {
+		t.Fatalf("expected error for capacity=0")
+	}
+}
+
+func TestCuckooFilterCollectNoFalseNegative(t *testing.T) {
+	result := stream.Stream(keyRange(0, 10000), stream.End(CuckooFilterCollect(10000, func(s string) string { return s })))
+	if result.Err != nil {
+		t.Fatalf("CuckooFilterCollect() returned error: %v", result.Err)
+	}
+	cf := result.Filter
+	if cf.Count() != 10000 {
+		t.Fatalf("Count()=%d, expected 10000", cf.Count())
+	}
+	for key := range keyRange(0, 10000) {
+		if !cf.TestString(key) {
+			t.Fatalf("TestString(%q)=false, expected true", key)
+		}
+	}
+
+	falsePositives := 0
+	for key := range keyRange(10000, 110000) {
+		if cf.TestString(key) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / 100000; rate > 0.001 {
+		t.Fatalf("false positive rate=%v, expected <= 0.001", rate)
+	}
+}
+
+func TestCuckooFilterDelete(t *testing.T) {
+	cf, _ := NewCuckooFilter(100)
+	_ = cf.AddString("apple")
+	_ = cf.AddString("apple")
+	_ = cf.AddString("banana")
+
+	if !cf.DeleteString("apple") || !cf.TestString("apple") {
+		t.Fatalf("expected one copy of apple to remain after one delete")
+	}
+	if !cf.DeleteString("apple") || cf.TestString("apple") {
+		t.Fatalf("expected apple to be gone after two deletes")
+	}
+	if cf.DeleteString("apple") {
+		t.Fatalf("DeleteString(apple)=true, expected false")
+	}
+	if cf.Count() != 1 || !cf.TestString("banana") {
+		t.Fatalf("Count()=%d, expected 1 with banana present", cf.Count())
+	}
+
+	cf.Reset()
+	if cf.TestString("banana") || cf.Count() != 0 {
+		t.Fatalf("expected empty filter after reset")
+	}
+}
+
+func TestCuckooFilterFull(t *testing.T) {
+	cf, _ := NewCuckooFilter(8)
+	var err error
+	added := 0
+	for key := range keyRange(0, 1000) {
+		if err = cf.AddString(key); err != nil {
+			break
+		}
+		added++
+	}
+	if !errors.Is(err, errFilterFull) {
+		t.Fatalf("AddString()=%v, expected %v", err, errFilterFull)
+	}
+	for key := range keyRange(0, added) {
+		if !cf.TestString(key) {
+			t.Fatalf("TestString(%q)=false after filling, expected true", key)
+		}
+	}
+}
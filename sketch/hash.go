@@ -0,0 +1,52 @@
+// Package sketch provides probabilistic data structures (bloom filters,
+// count-min sketches, cardinality and quantile estimators) and the
+// collectors that build them from a stream.
+package sketch
+
+import (
+	"hash/fnv"
+	"hash/maphash"
+)
+
+// HashFunc hashes a key to 64 well-mixed bits. Bloom filters and Count-Min
+// sketches derive all their indexes from one call per key, so any fast
+// 64-bit hash can be plugged in, such as xxhash.Sum64, a murmur3 variant to
+// match another system, or MaphashFunc.
+type HashFunc func(key []byte) uint64
+
+// FNVHash is the default HashFunc: FNV-1a followed by the murmur3 finalizer,
+// which spreads FNV's weak high bits. It is stable across processes, so
+// sketches built in different runs can be merged.
+func FNVHash(key []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(key)
+	return mix64(h.Sum64())
+}
+
+// MaphashFunc returns a HashFunc using hash/maphash with seed. It is faster
+// than FNVHash, but sketches are only compatible when built with the same
+// seed, which cannot be persisted.
+func MaphashFunc(seed maphash.Seed) HashFunc {
+	return func(key []byte) uint64 {
+		return maphash.Bytes(seed, key)
+	}
+}
+
+// mix64 is the murmur3 64-bit finalizer.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// kmIndex returns the i-th index in [0, n) derived from hash h with the
+// Kirsch-Mitzenmacher double hashing g_i = h1 + i*h2, taking h1 and h2 from
+// the two halves of h.
+func kmIndex(h uint64, i, n int) int {
+	h1 := h & 0xffffffff
+	h2 := h>>32 | 1
+	return int((h1 + uint64(i)*h2) % uint64(n))
+}
@@ -0,0 +1,55 @@
+package sketch
+
+import (
+	"hash/maphash"
+	"testing"
+)
+
+func TestSketchesUsePluggableHash(t *testing.T) {
+	calls := 0
+	seed := maphash.MakeSeed()
+	counted := func(key []byte) uint64 {
+		calls++
+		return maphash.Bytes(seed, key)
+	}
+
+	bf, err := NewBloomFilterWithHash(8192, 7, counted)
+	if err != nil {
+		t.Fatalf("NewBloomFilterWithHash() returned error: %v", err)
+	}
+	bf.AddString("apple")
+	if calls != 1 {
+		t.Fatalf("hash calls=%d, expected 1 per key for 7 indexes", calls)
+	}
+	if !bf.TestString("apple") {
+		t.Fatalf("TestString(apple)=false, expected true")
+	}
+
+	cms, err := NewCountMinSketchWithHash(256, 5, MaphashFunc(seed))
+	if err != nil {
+		t.Fatalf("NewCountMinSketchWithHash() returned error: %v", err)
+	}
+	cms.AddString("apple", 3)
+	if cms.EstimateString("apple") < 3 {
+		t.Fatalf("EstimateString(apple)=%d, expected >= 3", cms.EstimateString("apple"))
+	}
+}
+
+func TestBloomFilterDoubleHashingFalsePositiveRate(t *testing.T) {
+	for name, hash := range map[string]HashFunc{"fnv": FNVHash, "maphash": MaphashFunc(maphash.MakeSeed())} {
+		bf, _ := NewBloomFilterByError(10000, 0.01)
+		bf.hash = hash
+		for key := range keyRange(0, 10000) {
+			bf.AddString(key)
+		}
+		falsePositives := 0
+		for key := range keyRange(10000, 110000) {
+			if bf.TestString(key) {
+				falsePositives++
+			}
+		}
+		if rate := float64(falsePositives) / 100000; rate > 0.015 {
+			t.Fatalf("%s: false positive rate=%v, expected about 0.01", name, rate)
+		}
+	}
+}
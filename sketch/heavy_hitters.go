@@ -0,0 +1,131 @@
+package sketch
+
+import (
+	"cmp"
+	"container/heap"
+	"errors"
+	"iter"
+	"slices"
+)
+
+var errInvalidPhi = errors.New("phi must be in (0, 1)")
+
+// HeavyHitters reports every key whose frequency is at least a fraction phi
+// of the total, using a CountMinSketch for counts and a min-heap of
+// candidates. Since the sketch never underestimates, there are no false
+// negatives; keys with a true frequency above (phi-epsilon) of the total may
+// be reported as well.
+type HeavyHitters struct {
+	phi        float64
+	sketch     *CountMinSketch
+	candidates topKHeap
+}
+
+// HeavyHitter is a reported key with its estimated count.
+type HeavyHitter struct {
+	Key   string
+	Count uint64
+}
+
+type HeavyHittersResult struct {
+	Sketch *HeavyHitters
+	Err    error
+}
+
+// NewHeavyHitters tracks keys above phi with a sketch sized by
+// NewCountMinSketchByError(epsilon, delta).
+func NewHeavyHitters(phi, epsilon, delta float64) (*HeavyHitters, error) {
+	if phi <= 0 || phi >= 1 {
+		return nil, errInvalidPhi
+	}
+	cms, err := NewCountMinSketchByError(epsilon, delta)
+	if err != nil {
+		return nil, err
+	}
+	return &HeavyHitters{
+		phi:        phi,
+		sketch:     cms,
+		candidates: topKHeap{index: map[string]int{}},
+	}, nil
+}
+
+func (hh *HeavyHitters) Phi() float64 {
+	return hh.phi
+}
+
+func (hh *HeavyHitters) TotalCount() uint64 {
+	return hh.sketch.TotalCount()
+}
+
+// Sketch returns the underlying CountMinSketch.
+func (hh *HeavyHitters) Sketch() *CountMinSketch {
+	return hh.sketch
+}
+
+func (hh *HeavyHitters) AddString(key string, count uint64) {
+	if count == 0 {
+		return
+	}
+	hh.sketch.AddString(key, count)
+	estimate := hh.sketch.EstimateString(key)
+	threshold := hh.threshold()
+
+	if i, ok := hh.candidates.index[key]; ok {
+		hh.candidates.items[i].Count = estimate
+		heap.Fix(&hh.candidates, i)
+	} else if float64(estimate) >= threshold {
+		heap.Push(&hh.candidates, TopKItem{Key: key, Count: estimate})
+	}
+	// The threshold only grows, so candidates below it cannot qualify again
+	// without being added, which re-inserts them.
+	for hh.candidates.Len() > 0 && float64(hh.candidates.items[0].Count) < threshold {
+		heap.Pop(&hh.candidates)
+	}
+}
+
+func (hh *HeavyHitters) AddBytes(key []byte, count uint64) {
+	hh.AddString(string(key), count)
+}
+
+// Items returns the keys whose estimated count is at least phi of the total,
+// most frequent first.
+func (hh *HeavyHitters) Items() []HeavyHitter {
+	threshold := hh.threshold()
+	var items []HeavyHitter
+	for _, c := range hh.candidates.items {
+		if float64(c.Count) >= threshold {
+			items = append(items, HeavyHitter{Key: c.Key, Count: c.Count})
+		}
+	}
+	slices.SortFunc(items, func(a, b HeavyHitter) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return items
+}
+
+func (hh *HeavyHitters) Reset() {
+	hh.sketch.Reset()
+	hh.candidates.items = hh.candidates.items[:0]
+	clear(hh.candidates.index)
+}
+
+func (hh *HeavyHitters) threshold() float64 {
+	return hh.phi * float64(hh.sketch.TotalCount())
+}
+
+func HeavyHittersCollect[A any](phi, epsilon, delta float64, keyFn func(A) string) func(iter.Seq[A]) HeavyHittersResult {
+	return func(seq iter.Seq[A]) HeavyHittersResult {
+		hh, err := NewHeavyHitters(phi, epsilon, delta)
+		if err != nil {
+			return HeavyHittersResult{Err: err}
+		}
+
+		for v := range seq {
+			hh.AddString(keyFn(v), 1)
+		}
+		return HeavyHittersResult{Sketch: hh}
+	}
+}
@@ -0,0 +1,71 @@
+package sketch
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewHeavyHittersValidation(t *testing.T) {
+	if _, err := NewHeavyHitters(0, 0.001, 0.01); err == nil {
+		t.Fatalf("expected error for phi=0")
+	}
+	if _, err := NewHeavyHitters(0.01, 0, 0.01); err == nil {
+		t.Fatalf("expected error for epsilon=0")
+	}
+}
+
+func TestHeavyHittersCollectFindsAbusiveIPs(t *testing.T) {
+	// Two abusive IPs at 10% and 5% of requests; one that was heavy early
+	// but falls below 2% by the end.
+	var requests []string
+	for i := 0; i < 300; i++ {
+		requests = append(requests, "10.0.0.99")
+	}
+	for i := 0; i < 20000; i++ {
+		switch {
+		case i%10 == 0:
+			requests = append(requests, "10.0.0.1")
+		case i%20 == 1:
+			requests = append(requests, "10.0.0.2")
+		default:
+			requests = append(requests, "192.168."+strconv.Itoa(i%250)+"."+strconv.Itoa(i/250))
+		}
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	rng.Shuffle(len(requests)-300, func(i, j int) {
+		requests[300+i], requests[300+j] = requests[300+j], requests[300+i]
+	})
+
+	result := stream.Stream(slices.Values(requests), stream.End(HeavyHittersCollect(0.02, 0.001, 0.01, func(s string) string { return s })))
+	if result.Err != nil {
+		t.Fatalf("HeavyHittersCollect() returned error: %v", result.Err)
+	}
+	items := result.Sketch.Items()
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	if !slices.Equal(keys, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Fatalf("Items() keys=%v, expected [10.0.0.1 10.0.0.2]", keys)
+	}
+	if items[0].Count < 2000 {
+		t.Fatalf("Items()[0].Count=%d, expected >= 2000", items[0].Count)
+	}
+}
+
+func TestHeavyHittersReset(t *testing.T) {
+	hh, _ := NewHeavyHitters(0.5, 0.01, 0.01)
+	hh.AddString("a", 3)
+	hh.AddBytes([]byte("b"), 1)
+	if items := hh.Items(); len(items) != 1 || items[0] != (HeavyHitter{Key: "a", Count: 3}) {
+		t.Fatalf("Items()=%v, expected [{a 3}]", items)
+	}
+	hh.Reset()
+	if len(hh.Items()) != 0 || hh.TotalCount() != 0 {
+		t.Fatalf("expected no items after reset")
+	}
+}
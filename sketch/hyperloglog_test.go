@@ -0,0 +1,88 @@
+package sketch
+
+import (
+	"iter"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+// keyRange yields the keys "key-from" to "key-(to-1)".
+func keyRange(from, to int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := from; i < to; i++ {
+			if !yield("key-" + strconv.Itoa(i)) {
+				return
+			}
+		}
+	}
+}
+
+func relativeError(estimate uint64, actual int) float64 {
+	return math.Abs(float64(estimate)-float64(actual)) / float64(actual)
+}
+
+func TestNewHyperLogLogValidation(t *testing.T) {
+	if _, err := NewHyperLogLog(3); err == nil {
+		t.Fatalf("expected error for precision=3")
+	}
+	if _, err := NewHyperLogLog(19); err == nil {
+		t.Fatalf("expected error for precision=19")
+	}
+	result := stream.Stream(keyRange(0, 10), stream.End(HLLCollectWithPrecision(0, func(s string) string { return s })))
+	if result.Err == nil {
+		t.Fatalf("expected error for precision=0")
+	}
+}
+
+func TestHLLCollectEstimatesDistinctKeys(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		// Every key appears twice.
+		seq := func(yield func(string) bool) {
+			for range 2 {
+				for key := range keyRange(0, n) {
+					if !yield(key) {
+						return
+					}
+				}
+			}
+		}
+		result := stream.Stream(seq, stream.End(HLLCollect(func(s string) string { return s })))
+		if result.Err != nil {
+			t.Fatalf("HLLCollect() returned error: %v", result.Err)
+		}
+		if e := result.Sketch.Estimate(); relativeError(e, n) > 0.03 {
+			t.Fatalf("Estimate()=%d, expected within 3%% of %d", e, n)
+		}
+	}
+}
+
+func TestHyperLogLogMergeAndReset(t *testing.T) {
+	left, _ := NewHyperLogLog(12)
+	right, _ := NewHyperLogLog(12)
+	for key := range keyRange(0, 30000) {
+		left.AddString(key)
+	}
+	for key := range keyRange(20000, 50000) {
+		right.AddString(key)
+	}
+
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if e := left.Estimate(); relativeError(e, 50000) > 0.05 {
+		t.Fatalf("Estimate()=%d, expected within 5%% of 50000", e)
+	}
+
+	other, _ := NewHyperLogLog(10)
+	if err := left.Merge(other); err == nil {
+		t.Fatalf("expected error merging precision 12 and 10")
+	}
+
+	left.Reset()
+	if e := left.Estimate(); e != 0 {
+		t.Fatalf("Estimate()=%d, expected 0 after reset", e)
+	}
+}
@@ -0,0 +1,219 @@
+package sketch
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// DefaultKLLK gives a normalized rank error of about 1.65/k, i.e. 0.8%, while
+// keeping roughly 3k values.
+const DefaultKLLK = 200
+
+var (
+	errInvalidKLLK     = errors.New("k must be >= 8")
+	errNilKLL          = errors.New("kll sketch is nil")
+	errIncompatibleKLL = errors.New("kll sketches are incompatible")
+)
+
+// KLL is a KLL rank sketch. Values are kept in levels of compactors where a
+// value at level h stands for 2^h inputs; a full level is sorted and every
+// other value, starting at a random offset, is promoted to the next level.
+// Unlike TDigest its rank error is bounded independently of the input
+// distribution, and merging sketches keeps that bound.
+type KLL struct {
+	k      int
+	levels [][]float64
+	size   int
+	count  uint64
+	min    float64
+	max    float64
+}
+
+type KLLResult struct {
+	Sketch *KLL
+	Err    error
+}
+
+func NewKLL(k int) (*KLL, error) {
+	if k < 8 {
+		return nil, errInvalidKLLK
+	}
+	return &KLL{
+		k:      k,
+		levels: make([][]float64, 1),
+		min:    math.Inf(1),
+		max:    math.Inf(-1),
+	}, nil
+}
+
+func (kll *KLL) K() int {
+	return kll.k
+}
+
+// Count returns the number of values added.
+func (kll *KLL) Count() float64 {
+	return float64(kll.count)
+}
+
+// Add adds x. NaN values are ignored.
+func (kll *KLL) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	kll.levels[0] = append(kll.levels[0], x)
+	kll.size++
+	kll.count++
+	kll.min = min(kll.min, x)
+	kll.max = max(kll.max, x)
+	kll.compress()
+}
+
+// Quantile returns the smallest value whose estimated rank is at least
+// q*Count for q in [0, 1], or NaN for an empty sketch.
+func (kll *KLL) Quantile(q float64) float64 {
+	if kll.count == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return kll.min
+	}
+	if q >= 1 {
+		return kll.max
+	}
+
+	items := kll.weighted()
+	target := q * float64(kll.count)
+	cumulative := 0.0
+	for _, item := range items {
+		cumulative += item.weight
+		if cumulative >= target {
+			return item.value
+		}
+	}
+	return kll.max
+}
+
+// Rank returns the estimated fraction of values <= x.
+func (kll *KLL) Rank(x float64) float64 {
+	if kll.count == 0 {
+		return math.NaN()
+	}
+	below := 0.0
+	for h, level := range kll.levels {
+		for _, v := range level {
+			if v <= x {
+				below += math.Ldexp(1, h)
+			}
+		}
+	}
+	return below / float64(kll.count)
+}
+
+func (kll *KLL) Merge(other *KLL) error {
+	if kll == nil || other == nil {
+		return errNilKLL
+	}
+	if kll.k != other.k {
+		return errIncompatibleKLL
+	}
+
+	for h, level := range other.levels {
+		if h == len(kll.levels) {
+			kll.levels = append(kll.levels, nil)
+		}
+		kll.levels[h] = append(kll.levels[h], level...)
+		kll.size += len(level)
+	}
+	kll.count += other.count
+	kll.min = min(kll.min, other.min)
+	kll.max = max(kll.max, other.max)
+	for kll.size > kll.maxSize() {
+		kll.compress()
+	}
+	return nil
+}
+
+func (kll *KLL) Reset() {
+	kll.levels = make([][]float64, 1)
+	kll.size, kll.count = 0, 0
+	kll.min, kll.max = math.Inf(1), math.Inf(-1)
+}
+
+// capacity shrinks geometrically by 2/3 from the top level down.
+func (kll *KLL) capacity(h int) int {
+	depth := len(kll.levels) - h - 1
+	return max(2, int(math.Ceil(float64(kll.k)*math.Pow(2.0/3, float64(depth)))))
+}
+
+func (kll *KLL) maxSize() int {
+	total := 0
+	for h := range kll.levels {
+		total += kll.capacity(h)
+	}
+	return total
+}
+
+// compress compacts the lowest full level once the sketch holds more values
+// than the capacities allow.
+func (kll *KLL) compress() {
+	if kll.size < kll.maxSize() {
+		return
+	}
+	for h, level := range kll.levels {
+		if len(level) < kll.capacity(h) {
+			continue
+		}
+		if h+1 == len(kll.levels) {
+			kll.levels = append(kll.levels, nil)
+		}
+		slices.Sort(level)
+		// An odd value out stays behind.
+		n := len(level) &^ 1
+		for i := rand.IntN(2); i < n; i += 2 {
+			kll.levels[h+1] = append(kll.levels[h+1], level[i])
+		}
+		kll.levels[h] = append(level[:0], level[n:]...)
+		kll.size -= n / 2
+		return
+	}
+}
+
+type kllItem struct {
+	value  float64
+	weight float64
+}
+
+func (kll *KLL) weighted() []kllItem {
+	items := make([]kllItem, 0, kll.size)
+	for h, level := range kll.levels {
+		for _, v := range level {
+			items = append(items, kllItem{value: v, weight: math.Ldexp(1, h)})
+		}
+	}
+	slices.SortFunc(items, func(a, b kllItem) int { return cmp.Compare(a.value, b.value) })
+	return items
+}
+
+// KLLCollect builds a KLL sketch of valueFn over the stream with the default
+// k.
+func KLLCollect[A any](valueFn func(A) float64) func(iter.Seq[A]) KLLResult {
+	return KLLCollectWithK(DefaultKLLK, valueFn)
+}
+
+func KLLCollectWithK[A any](k int, valueFn func(A) float64) func(iter.Seq[A]) KLLResult {
+	return func(seq iter.Seq[A]) KLLResult {
+		kll, err := NewKLL(k)
+		if err != nil {
+			return KLLResult{Err: err}
+		}
+
+		for v := range seq {
+			kll.Add(valueFn(v))
+		}
+		return KLLResult{Sketch: kll}
+	}
+}
@@ -0,0 +1,100 @@
+package sketch
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func shuffledRange(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	rng.Shuffle(n, func(i, j int) { values[i], values[j] = values[j], values[i] })
+	return values
+}
+
+func TestNewKLLValidation(t *testing.T) {
+	if _, err := NewKLL(4); err == nil {
+		t.Fatalf("expected error for k=4")
+	}
+	if _, err := NewQuantileSketch(QuantileAlgorithm(9)); err == nil {
+		t.Fatalf("expected error for an unknown algorithm")
+	}
+}
+
+func TestKLLCollectEstimatesQuantiles(t *testing.T) {
+	const n = 100000
+	result := stream.Stream(slices.Values(shuffledRange(n)), stream.End(KLLCollect(func(v float64) float64 { return v })))
+	if result.Err != nil {
+		t.Fatalf("KLLCollect() returned error: %v", result.Err)
+	}
+	kll := result.Sketch
+	if kll.Count() != n {
+		t.Fatalf("Count()=%v, expected %d", kll.Count(), n)
+	}
+	if kll.size > 4*DefaultKLLK {
+		t.Fatalf("sketch keeps %d values, expected at most %d", kll.size, 4*DefaultKLLK)
+	}
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.99} {
+		if got, want := kll.Quantile(q), q*n; math.Abs(got-want) > 0.02*n {
+			t.Fatalf("Quantile(%v)=%v, expected about %v", q, got, want)
+		}
+	}
+	if got := kll.Rank(n / 4); math.Abs(got-0.25) > 0.02 {
+		t.Fatalf("Rank(%d)=%v, expected about 0.25", n/4, got)
+	}
+	if kll.Quantile(0) != 0 || kll.Quantile(1) != n-1 {
+		t.Fatalf("Quantile(0), Quantile(1) = %v, %v, expected 0, %d", kll.Quantile(0), kll.Quantile(1), n-1)
+	}
+}
+
+func TestKLLMergeAndReset(t *testing.T) {
+	values := shuffledRange(50000)
+	left, _ := NewKLL(DefaultKLLK)
+	right, _ := NewKLL(DefaultKLLK)
+	for i, v := range values {
+		if i%2 == 0 {
+			left.Add(v)
+		} else {
+			right.Add(v)
+		}
+	}
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if got := left.Quantile(0.5); math.Abs(got-25000) > 1000 {
+		t.Fatalf("Quantile(0.5)=%v, expected about 25000", got)
+	}
+	other, _ := NewKLL(100)
+	if err := left.Merge(other); err == nil {
+		t.Fatalf("expected error merging different k")
+	}
+
+	left.Reset()
+	if left.Count() != 0 || !math.IsNaN(left.Quantile(0.5)) {
+		t.Fatalf("expected empty sketch after reset")
+	}
+}
+
+func TestQuantileCollectSelectsAlgorithm(t *testing.T) {
+	values := shuffledRange(10000)
+	identity := func(v float64) float64 { return v }
+	for _, algorithm := range []QuantileAlgorithm{QuantileTDigest, QuantileKLL} {
+		result := stream.Stream(slices.Values(values), stream.End(QuantileCollect(algorithm, identity)))
+		if result.Err != nil {
+			t.Fatalf("QuantileCollect(%d) returned error: %v", algorithm, result.Err)
+		}
+		if got := result.Sketch.Quantile(0.9); math.Abs(got-9000) > 200 {
+			t.Fatalf("QuantileCollect(%d) Quantile(0.9)=%v, expected about 9000", algorithm, got)
+		}
+	}
+	if _, ok := stream.Stream(slices.Values(values), stream.End(QuantileCollect(QuantileKLL, identity))).Sketch.(*KLL); !ok {
+		t.Fatalf("QuantileCollect(QuantileKLL) did not build a KLL sketch")
+	}
+}
@@ -0,0 +1,78 @@
+package sketch
+
+import (
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewLinearCountingValidation(t *testing.T) {
+	if _, err := NewLinearCounting(0); err == nil {
+		t.Fatalf("expected error for bitSize=0")
+	}
+	if _, err := NewDistinctCounter(0); err == nil {
+		t.Fatalf("expected error for expectedItems=0")
+	}
+}
+
+func TestLinearCountingCollect(t *testing.T) {
+	identity := func(s string) string { return s }
+	result := stream.Stream(keyRange(0, 5000), stream.End(LinearCountingCollect(10000, identity)))
+	if result.Err != nil {
+		t.Fatalf("LinearCountingCollect() returned error: %v", result.Err)
+	}
+	if got := result.Sketch.Estimate(); relativeError(got, 5000) > 0.02 {
+		t.Fatalf("Estimate()=%d, expected within 2%% of 5000", got)
+	}
+
+	small := stream.Stream(keyRange(0, 1000), stream.End(LinearCountingCollect(64, identity)))
+	if !small.Sketch.Saturated() {
+		t.Fatalf("expected a 64-bit bitmap to saturate with 1000 keys")
+	}
+}
+
+func TestLinearCountingMergeAndReset(t *testing.T) {
+	left, _ := NewLinearCountingWithEstimates(1000)
+	right, _ := NewLinearCountingWithEstimates(1000)
+	for k := range keyRange(0, 600) {
+		left.AddString(k)
+	}
+	for k := range keyRange(400, 1000) {
+		right.AddString(k)
+	}
+
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if got := left.Estimate(); relativeError(got, 1000) > 0.05 {
+		t.Fatalf("Estimate()=%d, expected within 5%% of 1000", got)
+	}
+	other, _ := NewLinearCounting(64)
+	if err := left.Merge(other); err == nil {
+		t.Fatalf("expected error merging different bit sizes")
+	}
+
+	left.Reset()
+	if left.Estimate() != 0 {
+		t.Fatalf("Estimate()=%d, expected 0 after reset", left.Estimate())
+	}
+}
+
+func TestDistinctCountCollectChoosesCounter(t *testing.T) {
+	identity := func(s string) string { return s }
+	small := stream.Stream(keyRange(0, 1000), stream.End(DistinctCountCollect(1000, identity)))
+	if _, ok := small.Counter.(*LinearCounting); !ok {
+		t.Fatalf("DistinctCountCollect(1000) used %T, expected *LinearCounting", small.Counter)
+	}
+	if got := small.Counter.Estimate(); relativeError(got, 1000) > 0.05 {
+		t.Fatalf("Estimate()=%d, expected within 5%% of 1000", got)
+	}
+
+	large := stream.Stream(keyRange(0, 100000), stream.End(DistinctCountCollect(1000000, identity)))
+	if _, ok := large.Counter.(*HyperLogLog); !ok {
+		t.Fatalf("DistinctCountCollect(1000000) used %T, expected *HyperLogLog", large.Counter)
+	}
+	if got := large.Counter.Estimate(); relativeError(got, 100000) > 0.05 {
+		t.Fatalf("Estimate()=%d, expected within 5%% of 100000", got)
+	}
+}
@@ -0,0 +1,115 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"slices"
+)
+
+var (
+	errInvalidPermutations = errors.New("permutations must be > 0")
+	errNilMinHash          = errors.New("minhash is nil")
+	errIncompatibleMinHash = errors.New("minhashes are incompatible")
+)
+
+// MinHash is a signature of a key set for estimating Jaccard similarity.
+// It keeps the minimum of one hash function per permutation; the fraction
+// of equal minima between two signatures estimates their similarity with a
+// standard error of about 1/sqrt(permutations).
+type MinHash struct {
+	mins []uint64
+}
+
+type MinHashResult struct {
+	Sketch *MinHash
+	Err    error
+}
+
+func NewMinHash(permutations int) (*MinHash, error) {
+	if permutations <= 0 {
+		return nil, errInvalidPermutations
+	}
+	mins := make([]uint64, permutations)
+	for i := range mins {
+		mins[i] = math.MaxUint64
+	}
+	return &MinHash{mins: mins}, nil
+}
+
+func (mh *MinHash) Permutations() int {
+	return len(mh.mins)
+}
+
+func (mh *MinHash) AddString(key string) {
+	mh.AddBytes([]byte(key))
+}
+
+func (mh *MinHash) AddBytes(key []byte) {
+	h := FNVHash(key)
+	for i, m := range mh.mins {
+		// Each permutation rehashes the key hash with its own offset.
+		if v := mix64(h + uint64(i+1)*0x9e3779b97f4a7c15); v < m {
+			mh.mins[i] = v
+		}
+	}
+}
+
+// Signature returns a copy of the per-permutation minima.
+func (mh *MinHash) Signature() []uint64 {
+	return slices.Clone(mh.mins)
+}
+
+// Jaccard estimates |A∩B| / |A∪B| of the key sets behind the two
+// signatures. Two empty sets have similarity 1.
+func (mh *MinHash) Jaccard(other *MinHash) (float64, error) {
+	if mh == nil || other == nil {
+		return 0, errNilMinHash
+	}
+	if len(mh.mins) != len(other.mins) {
+		return 0, errIncompatibleMinHash
+	}
+
+	equal := 0
+	for i, m := range mh.mins {
+		if m == other.mins[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(mh.mins)), nil
+}
+
+// Merge makes mh the signature of the union of both key sets.
+func (mh *MinHash) Merge(other *MinHash) error {
+	if mh == nil || other == nil {
+		return errNilMinHash
+	}
+	if len(mh.mins) != len(other.mins) {
+		return errIncompatibleMinHash
+	}
+
+	for i, m := range other.mins {
+		mh.mins[i] = min(mh.mins[i], m)
+	}
+	return nil
+}
+
+func (mh *MinHash) Reset() {
+	for i := range mh.mins {
+		mh.mins[i] = math.MaxUint64
+	}
+}
+
+func MinHashCollect[A any](permutations int, keyFn func(A) string) func(iter.Seq[A]) MinHashResult {
+	return func(seq iter.Seq[A]) MinHashResult {
+		mh, err := NewMinHash(permutations)
+		if err != nil {
+			return MinHashResult{Err: err}
+		}
+
+		for v := range seq {
+			mh.AddString(keyFn(v))
+		}
+		return MinHashResult{Sketch: mh}
+	}
+}
@@ -0,0 +1,66 @@
+package sketch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewMinHashValidation(t *testing.T) {
+	if _, err := NewMinHash(0); err == nil {
+		t.Fatalf("expected error for permutations=0")
+	}
+}
+
+func TestMinHashCollectEstimatesJaccard(t *testing.T) {
+	identity := func(s string) string { return s }
+	// Days share 6000 of 10000 distinct users: Jaccard 0.6.
+	day1 := stream.Stream(keyRange(0, 8000), stream.End(MinHashCollect(512, identity)))
+	day2 := stream.Stream(keyRange(2000, 10000), stream.End(MinHashCollect(512, identity)))
+	if day1.Err != nil || day2.Err != nil {
+		t.Fatalf("MinHashCollect() returned errors: %v, %v", day1.Err, day2.Err)
+	}
+
+	j, err := day1.Sketch.Jaccard(day2.Sketch)
+	if err != nil {
+		t.Fatalf("Jaccard() returned error: %v", err)
+	}
+	if math.Abs(j-0.6) > 0.08 {
+		t.Fatalf("Jaccard()=%v, expected about 0.6", j)
+	}
+	if self, _ := day1.Sketch.Jaccard(day1.Sketch); self != 1 {
+		t.Fatalf("Jaccard(self)=%v, expected 1", self)
+	}
+
+	other, _ := NewMinHash(64)
+	if _, err := day1.Sketch.Jaccard(other); err == nil {
+		t.Fatalf("expected error comparing 512 and 64 permutations")
+	}
+}
+
+func TestMinHashMergeAndReset(t *testing.T) {
+	left, _ := NewMinHash(128)
+	right, _ := NewMinHash(128)
+	union, _ := NewMinHash(128)
+	for key := range keyRange(0, 500) {
+		left.AddString(key)
+		union.AddString(key)
+	}
+	for key := range keyRange(500, 1000) {
+		right.AddString(key)
+		union.AddString(key)
+	}
+
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if j, _ := left.Jaccard(union); j != 1 {
+		t.Fatalf("Jaccard(union)=%v, expected 1 after merge", j)
+	}
+
+	left.Reset()
+	if j, _ := left.Jaccard(union); j != 0 {
+		t.Fatalf("Jaccard(union)=%v, expected 0 after reset", j)
+	}
+}
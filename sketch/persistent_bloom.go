@@ -0,0 +1,110 @@
+package sketch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+)
+
+// PersistentBloom is a BloomFilter stored in a file, for deduplicating
+// records across runs of a pipeline: open it at the start, pass the records
+// through DedupPersistent and Save it once the run has succeeded.
+type PersistentBloom struct {
+	path   string
+	filter *BloomFilter
+}
+
+// OpenPersistentBloom loads the filter stored at path, or starts an empty one
+// sized for expectedItems keys at falsePositiveRate if the file does not
+// exist. A loaded filter keeps the size it was created with, so expectedItems
+// should cover the keys of all runs to come.
+func OpenPersistentBloom(path string, expectedItems int, falsePositiveRate float64) (*PersistentBloom, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		bf, err := NewBloomFilterByError(expectedItems, falsePositiveRate)
+		if err != nil {
+			return nil, err
+		}
+		return &PersistentBloom{path: path, filter: bf}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bf := &BloomFilter{}
+	if err := bf.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	return &PersistentBloom{path: path, filter: bf}, nil
+}
+
+func (pb *PersistentBloom) Path() string {
+	return pb.path
+}
+
+func (pb *PersistentBloom) Filter() *BloomFilter {
+	return pb.filter
+}
+
+// Save writes the filter to a temporary file next to path and renames it
+// over path, so an interrupted save leaves the previous filter intact.
+func (pb *PersistentBloom) Save() error {
+	data, err := pb.filter.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(pb.path, data, 0o644)
+}
+
+// DedupPersistent drops the elements whose key tests positive in pb and adds
+// the keys of the others as they pass, so a key is let through at most once
+// across all runs sharing pb's file. As with DistinctApprox, a new key is
+// wrongly dropped with the filter's false positive rate.
+//
+// Keys are added when their element passes, before later stages have
+// processed it. Only Save pb after the pipeline has succeeded, so a failed
+// run is retried in full.
+func DedupPersistent[F, A any](pb *PersistentBloom, keyFn func(A) string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			for v := range seq {
+				if pb.filter.testAndAdd([]byte(keyFn(v))) {
+					continue
+				}
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tmp.Name(), err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,58 @@
+package sketch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestDedupPersistentAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.bloom")
+	identity := func(s string) string { return s }
+	run := func(ids []string) []string {
+		t.Helper()
+		pb, err := OpenPersistentBloom(path, 1000, 0.001)
+		if err != nil {
+			t.Fatalf("OpenPersistentBloom() returned error: %v", err)
+		}
+		got := stream.Stream(slices.Values(ids), DedupPersistent(pb, identity, stream.End(stream.Collect[string]())))
+		if err := pb.Save(); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+		return got
+	}
+
+	if got, want := run([]string{"a", "b", "a", "c"}), []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("first run = %v, expected %v", got, want)
+	}
+	if got, want := run([]string{"c", "d", "a", "e", "d"}), []string{"d", "e"}; !slices.Equal(got, want) {
+		t.Fatalf("second run = %v, expected %v", got, want)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	if len(matches) != 1 {
+		t.Fatalf("files = %v, expected only the filter", matches)
+	}
+}
+
+func TestOpenPersistentBloomErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenPersistentBloom(filepath.Join(dir, "new.bloom"), 0, 0.01); err == nil {
+		t.Fatalf("expected error for expectedItems=0")
+	}
+	corrupt := filepath.Join(dir, "corrupt.bloom")
+	if err := os.WriteFile(corrupt, []byte("not a filter"), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", corrupt, err)
+	}
+	if _, err := OpenPersistentBloom(corrupt, 1000, 0.01); err == nil {
+		t.Fatalf("expected error for a corrupt filter file")
+	}
+
+	pb, _ := OpenPersistentBloom(filepath.Join(dir, "missing", "f.bloom"), 1000, 0.01)
+	if err := pb.Save(); !os.IsNotExist(err) {
+		t.Fatalf("Save() error = %v, expected not exist", err)
+	}
+}
@@ -0,0 +1,62 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+)
+
+var errUnknownQuantileAlgorithm = errors.New("unknown quantile algorithm")
+
+// QuantileSketch is implemented by TDigest and KLL.
+type QuantileSketch interface {
+	Add(x float64)
+	Quantile(q float64) float64
+	Count() float64
+	Reset()
+}
+
+// QuantileAlgorithm selects the sketch built by QuantileCollect.
+type QuantileAlgorithm int
+
+const (
+	// QuantileTDigest is most accurate at the tails, e.g. for latency
+	// percentiles such as p99.
+	QuantileTDigest QuantileAlgorithm = iota
+	// QuantileKLL bounds the rank error for any distribution, also when
+	// sketches are merged.
+	QuantileKLL
+)
+
+type QuantileResult struct {
+	Sketch QuantileSketch
+	Err    error
+}
+
+// NewQuantileSketch returns a sketch of the given algorithm with its default
+// parameters.
+func NewQuantileSketch(algorithm QuantileAlgorithm) (QuantileSketch, error) {
+	switch algorithm {
+	case QuantileTDigest:
+		return NewTDigest(DefaultTDigestCompression)
+	case QuantileKLL:
+		return NewKLL(DefaultKLLK)
+	}
+	return nil, errUnknownQuantileAlgorithm
+}
+
+// QuantileCollect builds a quantile sketch of valueFn over the stream with
+// the chosen algorithm, so callers can switch algorithms without changing
+// how they query the result.
+func QuantileCollect[A any](algorithm QuantileAlgorithm, valueFn func(A) float64) func(iter.Seq[A]) QuantileResult {
+	return func(seq iter.Seq[A]) QuantileResult {
+		sketch, err := NewQuantileSketch(algorithm)
+		if err != nil {
+			return QuantileResult{Err: err}
+		}
+
+		for v := range seq {
+			sketch.Add(valueFn(v))
+		}
+		return QuantileResult{Sketch: sketch}
+	}
+}
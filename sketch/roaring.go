@@ -0,0 +1,233 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// roaringArrayMax is the cardinality above which a container switches from
+// a sorted array (2 bytes per value) to a bitmap (8KiB).
+const roaringArrayMax = 4096
+
+var errNilRoaringBitmap = errors.New("roaring bitmap is nil")
+
+// RoaringBitmap is an exact set of uint32 values. Values are split by their
+// high 16 bits into containers that store the low 16 bits as a sorted array
+// while sparse and as a bitmap once dense, so memory follows the data: a
+// few bytes per value for scattered IDs and one bit per value for dense
+// ranges.
+type RoaringBitmap struct {
+	keys       []uint16
+	containers []*roaringContainer
+	card       uint64
+}
+
+type roaringContainer struct {
+	array  []uint16
+	bitmap []uint64
+}
+
+func NewRoaringBitmap() *RoaringBitmap {
+	return &RoaringBitmap{}
+}
+
+// Add adds x and reports whether it was not in the set before.
+func (rb *RoaringBitmap) Add(x uint32) bool {
+	high, low := uint16(x>>16), uint16(x)
+	i, found := slices.BinarySearch(rb.keys, high)
+	if !found {
+		rb.keys = slices.Insert(rb.keys, i, high)
+		rb.containers = slices.Insert(rb.containers, i, &roaringContainer{})
+	}
+	if !rb.containers[i].add(low) {
+		return false
+	}
+	rb.card++
+	return true
+}
+
+func (rb *RoaringBitmap) Contains(x uint32) bool {
+	i, found := slices.BinarySearch(rb.keys, uint16(x>>16))
+	return found && rb.containers[i].contains(uint16(x))
+}
+
+// Cardinality returns the number of distinct values added.
+func (rb *RoaringBitmap) Cardinality() uint64 {
+	return rb.card
+}
+
+// Values yields the values of the set in increasing order.
+func (rb *RoaringBitmap) Values() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		for i, c := range rb.containers {
+			high := uint32(rb.keys[i]) << 16
+			for low := range c.values() {
+				if !yield(high | uint32(low)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Merge adds the values of other, making rb their union.
+func (rb *RoaringBitmap) Merge(other *RoaringBitmap) error {
+	if rb == nil || other == nil {
+		return errNilRoaringBitmap
+	}
+	for x := range other.Values() {
+		rb.Add(x)
+	}
+	return nil
+}
+
+func (rb *RoaringBitmap) Reset() {
+	rb.keys, rb.containers, rb.card = nil, nil, 0
+}
+
+func (c *roaringContainer) add(low uint16) bool {
+	if c.bitmap != nil {
+		word, bit := low/64, uint64(1)<<(low%64)
+		if c.bitmap[word]&bit != 0 {
+			return false
+		}
+		c.bitmap[word] |= bit
+		return true
+	}
+
+	i, found := slices.BinarySearch(c.array, low)
+	if found {
+		return false
+	}
+	if len(c.array) < roaringArrayMax {
+		c.array = slices.Insert(c.array, i, low)
+		return true
+	}
+	c.bitmap = make([]uint64, 1<<16/64)
+	for _, v := range c.array {
+		c.bitmap[v/64] |= 1 << (v % 64)
+	}
+	c.array = nil
+	return c.add(low)
+}
+
+func (c *roaringContainer) contains(low uint16) bool {
+	if c.bitmap != nil {
+		return c.bitmap[low/64]&(1<<(low%64)) != 0
+	}
+	_, found := slices.BinarySearch(c.array, low)
+	return found
+}
+
+func (c *roaringContainer) values() iter.Seq[uint16] {
+	return func(yield func(uint16) bool) {
+		if c.bitmap == nil {
+			for _, v := range c.array {
+				if !yield(v) {
+					return
+				}
+			}
+			return
+		}
+		for i, word := range c.bitmap {
+			for word != 0 {
+				v := uint16(i*64 + bits.TrailingZeros64(word))
+				if !yield(v) {
+					return
+				}
+				word &= word - 1
+			}
+		}
+	}
+}
+
+// Roaring64Bitmap is an exact set of uint64 values, kept as a RoaringBitmap
+// of the low 32 bits per distinct high 32 bits.
+type Roaring64Bitmap struct {
+	keys    []uint32
+	bitmaps []*RoaringBitmap
+	card    uint64
+}
+
+func NewRoaring64Bitmap() *Roaring64Bitmap {
+	return &Roaring64Bitmap{}
+}
+
+// Add adds x and reports whether it was not in the set before.
+func (rb *Roaring64Bitmap) Add(x uint64) bool {
+	high := uint32(x >> 32)
+	i, found := slices.BinarySearch(rb.keys, high)
+	if !found {
+		rb.keys = slices.Insert(rb.keys, i, high)
+		rb.bitmaps = slices.Insert(rb.bitmaps, i, NewRoaringBitmap())
+	}
+	if !rb.bitmaps[i].Add(uint32(x)) {
+		return false
+	}
+	rb.card++
+	return true
+}
+
+func (rb *Roaring64Bitmap) Contains(x uint64) bool {
+	i, found := slices.BinarySearch(rb.keys, uint32(x>>32))
+	return found && rb.bitmaps[i].Contains(uint32(x))
+}
+
+// Cardinality returns the number of distinct values added.
+func (rb *Roaring64Bitmap) Cardinality() uint64 {
+	return rb.card
+}
+
+// Values yields the values of the set in increasing order.
+func (rb *Roaring64Bitmap) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for i, bm := range rb.bitmaps {
+			high := uint64(rb.keys[i]) << 32
+			for low := range bm.Values() {
+				if !yield(high | uint64(low)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Merge adds the values of other, making rb their union.
+func (rb *Roaring64Bitmap) Merge(other *Roaring64Bitmap) error {
+	if rb == nil || other == nil {
+		return errNilRoaringBitmap
+	}
+	for x := range other.Values() {
+		rb.Add(x)
+	}
+	return nil
+}
+
+func (rb *Roaring64Bitmap) Reset() {
+	rb.keys, rb.bitmaps, rb.card = nil, nil, 0
+}
+
+// RoaringCollect collects the distinct uint32 keys of a stream, e.g. user IDs
+// or IPv4 addresses, into a RoaringBitmap whose Cardinality is the exact
+// distinct count.
+func RoaringCollect[A any](keyFn func(A) uint32) func(iter.Seq[A]) *RoaringBitmap {
+	return func(seq iter.Seq[A]) *RoaringBitmap {
+		rb := NewRoaringBitmap()
+		for v := range seq {
+			rb.Add(keyFn(v))
+		}
+		return rb
+	}
+}
+
+func Roaring64Collect[A any](keyFn func(A) uint64) func(iter.Seq[A]) *Roaring64Bitmap {
+	return func(seq iter.Seq[A]) *Roaring64Bitmap {
+		rb := NewRoaring64Bitmap()
+		for v := range seq {
+			rb.Add(keyFn(v))
+		}
+		return rb
+	}
+}
@@ -0,0 +1,74 @@
+package sketch
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestRoaringCollect(t *testing.T) {
+	// Container 0 turns into a bitmap, container 1 stays sparse.
+	seq := func(yield func(uint32) bool) {
+		for i := range uint32(10000) {
+			if !yield(i%6000) || !yield(1<<16+i%7*1000) {
+				return
+			}
+		}
+	}
+	rb := stream.Stream(seq, stream.End(RoaringCollect(func(x uint32) uint32 { return x })))
+	if rb.Cardinality() != 6007 {
+		t.Fatalf("Cardinality()=%d, expected 6007", rb.Cardinality())
+	}
+	if !rb.Contains(5999) || rb.Contains(6000) || !rb.Contains(1<<16+6000) || rb.Contains(1<<16+1) {
+		t.Fatalf("Contains() returned unexpected results")
+	}
+
+	values := slices.Collect(rb.Values())
+	if len(values) != 6007 || !slices.IsSorted(values) || values[6006] != 1<<16+6000 {
+		t.Fatalf("Values() yielded %d values ending in %d, expected 6007 sorted ending in %d", len(values), values[len(values)-1], 1<<16+6000)
+	}
+}
+
+func TestRoaringBitmapMergeAndReset(t *testing.T) {
+	left, right := NewRoaringBitmap(), NewRoaringBitmap()
+	for i := range uint32(5000) {
+		left.Add(i)
+		right.Add(i + 2500)
+	}
+	if right.Add(2500) {
+		t.Fatalf("Add() of a present value returned true")
+	}
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if left.Cardinality() != 7500 {
+		t.Fatalf("Cardinality()=%d, expected 7500", left.Cardinality())
+	}
+	if err := left.Merge(nil); err == nil {
+		t.Fatalf("expected error merging nil")
+	}
+
+	left.Reset()
+	if left.Cardinality() != 0 || left.Contains(1) {
+		t.Fatalf("expected empty bitmap after reset")
+	}
+}
+
+func TestRoaring64Collect(t *testing.T) {
+	keys := []uint64{1, 1 << 40, 1<<40 + 1, 1, 1 << 63, 1 << 40}
+	rb := stream.Stream(slices.Values(keys), stream.End(Roaring64Collect(func(x uint64) uint64 { return x })))
+	if rb.Cardinality() != 4 {
+		t.Fatalf("Cardinality()=%d, expected 4", rb.Cardinality())
+	}
+	if want := []uint64{1, 1 << 40, 1<<40 + 1, 1 << 63}; !slices.Equal(slices.Collect(rb.Values()), want) {
+		t.Fatalf("Values()=%v, expected %v", slices.Collect(rb.Values()), want)
+	}
+
+	other := NewRoaring64Bitmap()
+	other.Add(2)
+	other.Add(1 << 63)
+	if err := rb.Merge(other); err != nil || rb.Cardinality() != 5 || !rb.Contains(2) {
+		t.Fatalf("Merge() = %v with cardinality %d, expected 5", err, rb.Cardinality())
+	}
+}
@@ -0,0 +1,99 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+)
+
+var errEmptySketchSet = errors.New("sketch set config selects no sketch")
+
+// SketchSetConfig selects the sketches SketchSetCollect builds. A sketch is
+// built when its parameters are set and skipped when they are zero.
+type SketchSetConfig struct {
+	// BloomExpectedItems and BloomFalsePositiveRate size a BloomFilter as in
+	// NewBloomFilterByError.
+	BloomExpectedItems     int
+	BloomFalsePositiveRate float64
+	// CMSEpsilon and CMSDelta size a CountMinSketch as in
+	// NewCountMinSketchByError.
+	CMSEpsilon float64
+	CMSDelta   float64
+	// HLLPrecision is the precision of a HyperLogLog.
+	HLLPrecision int
+	// TopK is the number of keys tracked by a TopK sketch.
+	TopK int
+}
+
+// SketchSet holds the sketches built in one pass; unselected ones are nil.
+type SketchSet struct {
+	Bloom    *BloomFilter
+	CountMin *CountMinSketch
+	HLL      *HyperLogLog
+	TopK     *TopK
+}
+
+type SketchSetResult struct {
+	Sketches SketchSet
+	Err      error
+}
+
+func NewSketchSet(cfg SketchSetConfig) (SketchSet, error) {
+	var set SketchSet
+	var err error
+	if cfg.BloomExpectedItems != 0 || cfg.BloomFalsePositiveRate != 0 {
+		if set.Bloom, err = NewBloomFilterByError(cfg.BloomExpectedItems, cfg.BloomFalsePositiveRate); err != nil {
+			return SketchSet{}, err
+		}
+	}
+	if cfg.CMSEpsilon != 0 || cfg.CMSDelta != 0 {
+		if set.CountMin, err = NewCountMinSketchByError(cfg.CMSEpsilon, cfg.CMSDelta); err != nil {
+			return SketchSet{}, err
+		}
+	}
+	if cfg.HLLPrecision != 0 {
+		if set.HLL, err = NewHyperLogLog(cfg.HLLPrecision); err != nil {
+			return SketchSet{}, err
+		}
+	}
+	if cfg.TopK != 0 {
+		if set.TopK, err = NewTopK(cfg.TopK); err != nil {
+			return SketchSet{}, err
+		}
+	}
+	if set == (SketchSet{}) {
+		return SketchSet{}, errEmptySketchSet
+	}
+	return set, nil
+}
+
+// AddString feeds key into every sketch of the set.
+func (s SketchSet) AddString(key string) {
+	if s.Bloom != nil {
+		s.Bloom.AddString(key)
+	}
+	if s.CountMin != nil {
+		s.CountMin.AddString(key, 1)
+	}
+	if s.HLL != nil {
+		s.HLL.AddString(key)
+	}
+	if s.TopK != nil {
+		s.TopK.AddString(key, 1)
+	}
+}
+
+// SketchSetCollect feeds the key of each element into all sketches selected
+// by cfg, so a single scan produces every approximate statistic.
+func SketchSetCollect[A any](cfg SketchSetConfig, keyFn func(A) string) func(iter.Seq[A]) SketchSetResult {
+	return func(seq iter.Seq[A]) SketchSetResult {
+		set, err := NewSketchSet(cfg)
+		if err != nil {
+			return SketchSetResult{Err: err}
+		}
+
+		for v := range seq {
+			set.AddString(keyFn(v))
+		}
+		return SketchSetResult{Sketches: set}
+	}
+}
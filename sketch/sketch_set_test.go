@@ -0,0 +1,69 @@
+package sketch
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestSketchSetCollectBuildsAllSketchesInOnePass(t *testing.T) {
+	data := []string{"apple", "banana", "apple", "orange", "banana", "apple"}
+	cfg := SketchSetConfig{
+		BloomExpectedItems:     100,
+		BloomFalsePositiveRate: 0.01,
+		CMSEpsilon:             0.01,
+		CMSDelta:               0.01,
+		HLLPrecision:           10,
+		TopK:                   2,
+	}
+
+	passes := 0
+	seq := func(yield func(string) bool) {
+		passes++
+		for _, v := range data {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+	result := stream.Stream(seq, stream.End(SketchSetCollect(cfg, func(s string) string { return s })))
+	if result.Err != nil {
+		t.Fatalf("SketchSetCollect() returned error: %v", result.Err)
+	}
+	if passes != 1 {
+		t.Fatalf("passes=%d, expected 1", passes)
+	}
+
+	s := result.Sketches
+	if !s.Bloom.TestString("orange") {
+		t.Fatalf("Bloom.TestString(orange)=false, expected true")
+	}
+	if s.CountMin.EstimateString("apple") < 3 {
+		t.Fatalf("CountMin.EstimateString(apple)=%d, expected >= 3", s.CountMin.EstimateString("apple"))
+	}
+	if s.HLL.Estimate() != 3 {
+		t.Fatalf("HLL.Estimate()=%d, expected 3", s.HLL.Estimate())
+	}
+	if top := s.TopK.Items(); top[0].Key != "apple" {
+		t.Fatalf("TopK.Items()=%v, expected apple first", top)
+	}
+}
+
+func TestSketchSetCollectSelection(t *testing.T) {
+	identity := func(s string) string { return s }
+	result := stream.Stream(slices.Values([]string{"a"}), stream.End(SketchSetCollect(SketchSetConfig{HLLPrecision: 12}, identity)))
+	if result.Err != nil || result.Sketches.HLL == nil || result.Sketches.Bloom != nil || result.Sketches.TopK != nil {
+		t.Fatalf("SketchSetCollect()=%+v, expected only an HLL", result)
+	}
+
+	result = stream.Stream(slices.Values([]string{"a"}), stream.End(SketchSetCollect(SketchSetConfig{}, identity)))
+	if !errors.Is(result.Err, errEmptySketchSet) {
+		t.Fatalf("SketchSetCollect() error=%v, expected %v", result.Err, errEmptySketchSet)
+	}
+	result = stream.Stream(slices.Values([]string{"a"}), stream.End(SketchSetCollect(SketchSetConfig{CMSEpsilon: 0.01}, identity)))
+	if result.Err == nil {
+		t.Fatalf("expected error for CMSDelta=0")
+	}
+}
@@ -0,0 +1,181 @@
+package sketch
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"math"
+	"slices"
+)
+
+// DefaultTDigestCompression balances accuracy and size for most workloads,
+// keeping a few hundred centroids.
+const DefaultTDigestCompression = 100
+
+var (
+	errInvalidCompression = errors.New("compression must be > 0")
+	errNilTDigest         = errors.New("t-digest is nil")
+)
+
+// TDigest estimates quantiles of a stream of values with a merging t-digest.
+// Centroids are small near the tails, so extreme quantiles such as p99 are
+// estimated with low relative error while memory stays bounded by the
+// compression parameter.
+type TDigest struct {
+	compression float64
+	centroids   []tdCentroid
+	buffer      []tdCentroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+type tdCentroid struct {
+	mean   float64
+	weight float64
+}
+
+type TDigestResult struct {
+	Sketch *TDigest
+	Err    error
+}
+
+func NewTDigest(compression float64) (*TDigest, error) {
+	if !(compression > 0) {
+		return nil, errInvalidCompression
+	}
+	return &TDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}, nil
+}
+
+func (td *TDigest) Compression() float64 {
+	return td.compression
+}
+
+// Count returns the total weight added.
+func (td *TDigest) Count() float64 {
+	return td.count
+}
+
+func (td *TDigest) Add(x float64) {
+	td.AddWeighted(x, 1)
+}
+
+// AddWeighted adds x with weight w. NaN values and non-positive weights are
+// ignored.
+func (td *TDigest) AddWeighted(x, w float64) {
+	if math.IsNaN(x) || !(w > 0) {
+		return
+	}
+	td.buffer = append(td.buffer, tdCentroid{mean: x, weight: w})
+	td.count += w
+	td.min = min(td.min, x)
+	td.max = max(td.max, x)
+	if len(td.buffer) >= int(5*td.compression)+10 {
+		td.compress()
+	}
+}
+
+// Quantile returns the estimated value at quantile q in [0, 1], or NaN for
+// an empty digest.
+func (td *TDigest) Quantile(q float64) float64 {
+	td.compress()
+	if len(td.centroids) == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return td.min
+	}
+	if q >= 1 {
+		return td.max
+	}
+	if len(td.centroids) == 1 {
+		return td.centroids[0].mean
+	}
+
+	// Interpolate between centroid centers, and between the outer centers
+	// and the exact minimum and maximum.
+	target := q * td.count
+	cumulative := 0.0
+	prevMean, prevCenter := td.min, 0.0
+	for _, c := range td.centroids {
+		center := cumulative + c.weight/2
+		if target < center {
+			return prevMean + (c.mean-prevMean)*(target-prevCenter)/(center-prevCenter)
+		}
+		prevMean, prevCenter = c.mean, center
+		cumulative += c.weight
+	}
+	return prevMean + (td.max-prevMean)*(target-prevCenter)/(td.count-prevCenter)
+}
+
+func (td *TDigest) Merge(other *TDigest) error {
+	if td == nil || other == nil {
+		return errNilTDigest
+	}
+	td.buffer = append(td.buffer, other.centroids...)
+	td.buffer = append(td.buffer, other.buffer...)
+	td.count += other.count
+	td.min = min(td.min, other.min)
+	td.max = max(td.max, other.max)
+	td.compress()
+	return nil
+}
+
+func (td *TDigest) Reset() {
+	td.centroids = td.centroids[:0]
+	td.buffer = td.buffer[:0]
+	td.count = 0
+	td.min, td.max = math.Inf(1), math.Inf(-1)
+}
+
+// compress merges the buffered values into the centroids, allowing a
+// centroid at quantile q to grow to 4*count*q*(1-q)/compression.
+func (td *TDigest) compress() {
+	if len(td.buffer) == 0 {
+		return
+	}
+	all := append(td.buffer, td.centroids...)
+	slices.SortFunc(all, func(a, b tdCentroid) int { return cmp.Compare(a.mean, b.mean) })
+
+	merged := make([]tdCentroid, 0, len(td.centroids)+1)
+	current := all[0]
+	before := 0.0
+	for _, c := range all[1:] {
+		proposed := current.weight + c.weight
+		q := (before + proposed/2) / td.count
+		if proposed <= 4*td.count*q*(1-q)/td.compression {
+			current.mean += (c.mean - current.mean) * c.weight / proposed
+			current.weight = proposed
+			continue
+		}
+		merged = append(merged, current)
+		before += current.weight
+		current = c
+	}
+	td.centroids = append(merged, current)
+	td.buffer = td.buffer[:0]
+}
+
+// TDigestCollect builds a t-digest of valueFn over the stream with the
+// default compression.
+func TDigestCollect[A any](valueFn func(A) float64) func(iter.Seq[A]) TDigestResult {
+	return TDigestCollectWithCompression(DefaultTDigestCompression, valueFn)
+}
+
+func TDigestCollectWithCompression[A any](compression float64, valueFn func(A) float64) func(iter.Seq[A]) TDigestResult {
+	return func(seq iter.Seq[A]) TDigestResult {
+		td, err := NewTDigest(compression)
+		if err != nil {
+			return TDigestResult{Err: err}
+		}
+
+		for v := range seq {
+			td.Add(valueFn(v))
+		}
+		return TDigestResult{Sketch: td}
+	}
+}
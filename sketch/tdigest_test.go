@@ -0,0 +1,70 @@
+package sketch
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewTDigestValidation(t *testing.T) {
+	if _, err := NewTDigest(0); err == nil {
+		t.Fatalf("expected error for compression=0")
+	}
+	if _, err := NewTDigest(math.NaN()); err == nil {
+		t.Fatalf("expected error for compression=NaN")
+	}
+}
+
+func TestTDigestCollectEstimatesQuantiles(t *testing.T) {
+	const n = 100000
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	rng.Shuffle(n, func(i, j int) { values[i], values[j] = values[j], values[i] })
+
+	result := stream.Stream(slices.Values(values), stream.End(TDigestCollect(func(v float64) float64 { return v })))
+	if result.Err != nil {
+		t.Fatalf("TDigestCollect() returned error: %v", result.Err)
+	}
+	td := result.Sketch
+	if td.Count() != n {
+		t.Fatalf("Count()=%v, expected %d", td.Count(), n)
+	}
+	for _, q := range []float64{0.01, 0.5, 0.95, 0.99, 0.999} {
+		if got, want := td.Quantile(q), q*n; math.Abs(got-want) > 0.005*n {
+			t.Fatalf("Quantile(%v)=%v, expected about %v", q, got, want)
+		}
+	}
+	if td.Quantile(0) != 0 || td.Quantile(1) != n-1 {
+		t.Fatalf("Quantile(0)=%v Quantile(1)=%v, expected 0 and %d", td.Quantile(0), td.Quantile(1), n-1)
+	}
+}
+
+func TestTDigestMergeAndReset(t *testing.T) {
+	left, _ := NewTDigest(100)
+	right, _ := NewTDigest(100)
+	for i := 0; i < 50000; i++ {
+		left.Add(float64(i))
+		right.Add(float64(50000 + i))
+	}
+	if err := left.Merge(right); err != nil {
+		t.Fatalf("Merge() returned error: %v", err)
+	}
+	if got := left.Quantile(0.75); math.Abs(got-75000) > 500 {
+		t.Fatalf("Quantile(0.75)=%v, expected about 75000", got)
+	}
+
+	left.Reset()
+	if !math.IsNaN(left.Quantile(0.5)) {
+		t.Fatalf("Quantile(0.5)=%v, expected NaN after reset", left.Quantile(0.5))
+	}
+	left.Add(42)
+	if left.Quantile(0.5) != 42 {
+		t.Fatalf("Quantile(0.5)=%v, expected 42", left.Quantile(0.5))
+	}
+}
@@ -0,0 +1,142 @@
+package sketch
+
+import (
+	"cmp"
+	"container/heap"
+	"errors"
+	"iter"
+	"slices"
+)
+
+var errInvalidK = errors.New("k must be > 0")
+
+// TopK tracks the most frequent keys of a stream with the Space-Saving
+// algorithm in O(k) memory. Counts may be overestimated by at most the
+// item's Error, and every key occurring more than TotalCount()/k times is
+// guaranteed to be tracked.
+type TopK struct {
+	k     int
+	total uint64
+	heap  topKHeap
+	index map[string]int
+}
+
+// TopKItem is a tracked key with its estimated count. The true count lies in
+// [Count-Error, Count].
+type TopKItem struct {
+	Key   string
+	Count uint64
+	Error uint64
+}
+
+type TopKResult struct {
+	Sketch *TopK
+	Err    error
+}
+
+func NewTopK(k int) (*TopK, error) {
+	if k <= 0 {
+		return nil, errInvalidK
+	}
+	tk := &TopK{k: k, index: make(map[string]int, k)}
+	tk.heap.index = tk.index
+	return tk, nil
+}
+
+func (tk *TopK) K() int {
+	return tk.k
+}
+
+func (tk *TopK) TotalCount() uint64 {
+	return tk.total
+}
+
+func (tk *TopK) AddString(key string, count uint64) {
+	if count == 0 {
+		return
+	}
+	tk.total += count
+
+	if i, ok := tk.index[key]; ok {
+		tk.heap.items[i].Count += count
+		heap.Fix(&tk.heap, i)
+		return
+	}
+	if len(tk.heap.items) < tk.k {
+		heap.Push(&tk.heap, TopKItem{Key: key, Count: count})
+		return
+	}
+
+	// Replace the least frequent key; its count bounds the new key's error.
+	evicted := tk.heap.items[0]
+	delete(tk.index, evicted.Key)
+	tk.heap.items[0] = TopKItem{Key: key, Count: evicted.Count + count, Error: evicted.Count}
+	tk.index[key] = 0
+	heap.Fix(&tk.heap, 0)
+}
+
+func (tk *TopK) AddBytes(key []byte, count uint64) {
+	tk.AddString(string(key), count)
+}
+
+// Items returns the tracked keys, most frequent first.
+func (tk *TopK) Items() []TopKItem {
+	items := slices.Clone(tk.heap.items)
+	slices.SortFunc(items, func(a, b TopKItem) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return items
+}
+
+func (tk *TopK) Reset() {
+	tk.heap.items = tk.heap.items[:0]
+	clear(tk.index)
+	tk.total = 0
+}
+
+// topKHeap is a min-heap by count that keeps index in sync with positions.
+type topKHeap struct {
+	items []TopKItem
+	index map[string]int
+}
+
+func (h *topKHeap) Len() int { return len(h.items) }
+
+func (h *topKHeap) Less(i, j int) bool { return h.items[i].Count < h.items[j].Count }
+
+func (h *topKHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].Key] = i
+	h.index[h.items[j].Key] = j
+}
+
+func (h *topKHeap) Push(x any) {
+	item := x.(TopKItem)
+	h.index[item.Key] = len(h.items)
+	h.items = append(h.items, item)
+}
+
+func (h *topKHeap) Pop() any {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items = h.items[:n-1]
+	delete(h.index, item.Key)
+	return item
+}
+
+func TopKCollect[A any](k int, keyFn func(A) string) func(iter.Seq[A]) TopKResult {
+	return func(seq iter.Seq[A]) TopKResult {
+		tk, err := NewTopK(k)
+		if err != nil {
+			return TopKResult{Err: err}
+		}
+
+		for v := range seq {
+			tk.AddString(keyFn(v), 1)
+		}
+		return TopKResult{Sketch: tk}
+	}
+}
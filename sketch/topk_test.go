@@ -0,0 +1,77 @@
+package sketch
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+func TestNewTopKValidation(t *testing.T) {
+	if _, err := NewTopK(0); err == nil {
+		t.Fatalf("expected error for k=0")
+	}
+}
+
+func TestTopKCollectFindsHeavyHitters(t *testing.T) {
+	// Three heavy keys among many distinct light ones.
+	var data []string
+	for i := 0; i < 2000; i++ {
+		data = append(data, "light-"+strconv.Itoa(i))
+		switch {
+		case i%4 == 0:
+			data = append(data, "ip-a", "ip-a")
+		case i%4 == 1:
+			data = append(data, "ip-b")
+		case i%4 == 2:
+			data = append(data, "ip-c")
+		}
+	}
+
+	result := stream.Stream(slices.Values(data), stream.End(TopKCollect(10, func(s string) string { return s })))
+	if result.Err != nil {
+		t.Fatalf("TopKCollect() returned error: %v", result.Err)
+	}
+	tk := result.Sketch
+	if tk.TotalCount() != uint64(len(data)) {
+		t.Fatalf("TotalCount()=%d, expected %d", tk.TotalCount(), len(data))
+	}
+
+	items := tk.Items()
+	if len(items) != 10 {
+		t.Fatalf("len(Items())=%d, expected 10", len(items))
+	}
+	var top []string
+	for _, item := range items[:3] {
+		top = append(top, item.Key)
+	}
+	if !slices.Equal(top, []string{"ip-a", "ip-b", "ip-c"}) {
+		t.Fatalf("top keys=%v, expected [ip-a ip-b ip-c]", top)
+	}
+	actual := map[string]uint64{"ip-a": 1000, "ip-b": 500, "ip-c": 500}
+	for _, item := range items[:3] {
+		if item.Count < actual[item.Key] || item.Count-item.Error > actual[item.Key] {
+			t.Fatalf("%s: Count=%d Error=%d, expected bounds around %d", item.Key, item.Count, item.Error, actual[item.Key])
+		}
+	}
+}
+
+func TestTopKExactWhenKeysFit(t *testing.T) {
+	tk, err := NewTopK(3)
+	if err != nil {
+		t.Fatalf("NewTopK() returned error: %v", err)
+	}
+	tk.AddString("b", 2)
+	tk.AddString("a", 5)
+	tk.AddBytes([]byte("c"), 2)
+	want := []TopKItem{{Key: "a", Count: 5}, {Key: "b", Count: 2}, {Key: "c", Count: 2}}
+	if got := tk.Items(); !slices.Equal(got, want) {
+		t.Fatalf("Items()=%v, expected %v", got, want)
+	}
+
+	tk.Reset()
+	if len(tk.Items()) != 0 || tk.TotalCount() != 0 {
+		t.Fatalf("expected empty sketch after reset")
+	}
+}
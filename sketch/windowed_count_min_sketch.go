@@ -0,0 +1,178 @@
+package sketch
+
+import (
+	"errors"
+	"iter"
+	"sync"
+	"time"
+)
+
+var (
+	errInvalidWindow = errors.New("window must be > 0")
+	errInvalidSlots  = errors.New("slots must be > 0")
+)
+
+// WindowedCountMinSketch is a Count-Min Sketch over a sliding time window,
+// built as a ring of per-slot sketches: counts older than the window expire
+// slot by slot, so estimates reflect only recent traffic such as the last
+// five minutes of a follow-mode source. The window advances with the times
+// passed to AddStringAt and Advance. It is safe for concurrent use, so a
+// pipeline can feed it while another goroutine queries it.
+type WindowedCountMinSketch struct {
+	mu       sync.Mutex
+	slotSize time.Duration
+	slots    []*CountMinSketch
+	// sum is the element-wise sum of all slots; estimating from it is
+	// tighter than summing per-slot estimates.
+	sum     *CountMinSketch
+	current int
+	start   time.Time
+}
+
+type WindowedCountMinSketchResult struct {
+	Sketch *WindowedCountMinSketch
+	Err    error
+}
+
+// NewWindowedCountMinSketch covers window with slots sub-sketches of the
+// given dimensions; counts expire in steps of window/slots.
+func NewWindowedCountMinSketch(width, depth int, window time.Duration, slots int) (*WindowedCountMinSketch, error) {
+	if window <= 0 {
+		return nil, errInvalidWindow
+	}
+	if slots <= 0 {
+		return nil, errInvalidSlots
+	}
+	sum, err := NewCountMinSketch(width, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := make([]*CountMinSketch, slots)
+	for i := range ring {
+		ring[i], _ = NewCountMinSketch(width, depth)
+	}
+	return &WindowedCountMinSketch{
+		slotSize: max(window/time.Duration(slots), 1),
+		slots:    ring,
+		sum:      sum,
+	}, nil
+}
+
+// Window returns the covered duration.
+func (w *WindowedCountMinSketch) Window() time.Duration {
+	return w.slotSize * time.Duration(len(w.slots))
+}
+
+// AddStringAt counts key at time t. Times before the current slot are
+// counted in it.
+func (w *WindowedCountMinSketch) AddStringAt(t time.Time, key string, count uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance(t)
+	w.slots[w.current].AddString(key, count)
+	w.sum.AddString(key, count)
+}
+
+// AddString counts key at the current time.
+func (w *WindowedCountMinSketch) AddString(key string, count uint64) {
+	w.AddStringAt(time.Now(), key, count)
+}
+
+// Advance expires the slots that fell out of the window ending at t, for
+// quiet periods without adds.
+func (w *WindowedCountMinSketch) Advance(t time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.advance(t)
+}
+
+// EstimateString estimates the count of key within the window.
+func (w *WindowedCountMinSketch) EstimateString(key string) uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sum.EstimateString(key)
+}
+
+// TotalCount returns the count of all keys within the window.
+func (w *WindowedCountMinSketch) TotalCount() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sum.TotalCount()
+}
+
+// Snapshot returns a copy of the window's counts as a plain sketch.
+func (w *WindowedCountMinSketch) Snapshot() *CountMinSketch {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out, _ := NewCountMinSketch(w.sum.width, w.sum.depth)
+	_ = out.Merge(w.sum)
+	return out
+}
+
+func (w *WindowedCountMinSketch) advance(t time.Time) {
+	if w.start.IsZero() {
+		w.start = t.Truncate(w.slotSize)
+		return
+	}
+	steps := int64(t.Sub(w.start) / w.slotSize)
+	if steps <= 0 {
+		return
+	}
+	for i := int64(0); i < min(steps, int64(len(w.slots))); i++ {
+		w.current = (w.current + 1) % len(w.slots)
+		w.expire(w.slots[w.current])
+	}
+	w.start = w.start.Add(time.Duration(steps) * w.slotSize)
+}
+
+// expire subtracts slot from the sum and clears it.
+func (w *WindowedCountMinSketch) expire(slot *CountMinSketch) {
+	if slot.total == 0 {
+		return
+	}
+	for row := range slot.table {
+		for col, v := range slot.table[row] {
+			w.sum.table[row][col] -= v
+		}
+	}
+	w.sum.total -= slot.total
+	slot.Reset()
+}
+
+// WindowedCountMinSketchCollect counts the keys of a finite stream by event
+// time, returning the sketch of the window ending at the last event.
+func WindowedCountMinSketchCollect[A any](width, depth int, window time.Duration, slots int, timeFn func(A) time.Time, keyFn func(A) string) func(iter.Seq[A]) WindowedCountMinSketchResult {
+	return func(seq iter.Seq[A]) WindowedCountMinSketchResult {
+		w, err := NewWindowedCountMinSketch(width, depth, window, slots)
+		if err != nil {
+			return WindowedCountMinSketchResult{Err: err}
+		}
+
+		for v := range seq {
+			w.AddStringAt(timeFn(v), keyFn(v), 1)
+		}
+		return WindowedCountMinSketchResult{Sketch: w}
+	}
+}
+
+// TrackWindowedCounts counts the key of each element into w, at timeFn's
+// time or the current time when timeFn is nil, and passes the element on
+// unchanged. It suits endless follow-mode streams, whose counts are queried
+// from w while the pipeline runs.
+func TrackWindowedCounts[F, A any](w *WindowedCountMinSketch, timeFn func(A) time.Time, keyFn func(A) string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			for v := range seq {
+				if timeFn != nil {
+					w.AddStringAt(timeFn(v), keyFn(v), 1)
+				} else {
+					w.AddString(keyFn(v), 1)
+				}
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package sketch
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/stream"
+)
+
+type hit struct {
+	at  time.Time
+	key string
+}
+
+func TestNewWindowedCountMinSketchValidation(t *testing.T) {
+	if _, err := NewWindowedCountMinSketch(64, 4, 0, 5); err == nil {
+		t.Fatalf("expected error for window=0")
+	}
+	if _, err := NewWindowedCountMinSketch(64, 4, time.Minute, 0); err == nil {
+		t.Fatalf("expected error for slots=0")
+	}
+	if _, err := NewWindowedCountMinSketch(0, 4, time.Minute, 5); err == nil {
+		t.Fatalf("expected error for width=0")
+	}
+}
+
+func TestWindowedCountMinSketchExpiresOldCounts(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	w, err := NewWindowedCountMinSketch(256, 5, 5*time.Minute, 5)
+	if err != nil {
+		t.Fatalf("NewWindowedCountMinSketch() returned error: %v", err)
+	}
+
+	w.AddStringAt(base, "old", 10)
+	w.AddStringAt(base.Add(3*time.Minute), "recent", 4)
+	if w.EstimateString("old") < 10 || w.TotalCount() != 14 {
+		t.Fatalf("EstimateString(old)=%d TotalCount()=%d, expected >= 10 and 14", w.EstimateString("old"), w.TotalCount())
+	}
+
+	w.Advance(base.Add(5 * time.Minute))
+	if w.EstimateString("old") != 0 || w.EstimateString("recent") < 4 || w.TotalCount() != 4 {
+		t.Fatalf("EstimateString(old)=%d EstimateString(recent)=%d TotalCount()=%d, expected 0, >= 4 and 4",
+			w.EstimateString("old"), w.EstimateString("recent"), w.TotalCount())
+	}
+
+	w.Advance(base.Add(time.Hour))
+	if w.TotalCount() != 0 {
+		t.Fatalf("TotalCount()=%d, expected 0 after the window passed", w.TotalCount())
+	}
+}
+
+func TestWindowedCountMinSketchCollectAndTrack(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var hits []hit
+	for i := 0; i < 10; i++ {
+		hits = append(hits, hit{at: base.Add(time.Duration(i) * time.Minute), key: "a"})
+	}
+	hits = append(hits, hit{at: base.Add(9 * time.Minute), key: "b"})
+	timeFn := func(h hit) time.Time { return h.at }
+	keyFn := func(h hit) string { return h.key }
+
+	result := stream.Stream(slices.Values(hits), stream.End(WindowedCountMinSketchCollect(128, 4, 3*time.Minute, 3, timeFn, keyFn)))
+	if result.Err != nil {
+		t.Fatalf("WindowedCountMinSketchCollect() returned error: %v", result.Err)
+	}
+	if got := result.Sketch.EstimateString("a"); got < 3 || got > 4 {
+		t.Fatalf("EstimateString(a)=%d, expected 3 within the last 3 minutes", got)
+	}
+
+	w, _ := NewWindowedCountMinSketch(128, 4, 3*time.Minute, 3)
+	keys := stream.Stream(slices.Values(hits), TrackWindowedCounts(w, timeFn, keyFn, stream.Map(keyFn, stream.End(stream.Collect[string]()))))
+	if len(keys) != len(hits) {
+		t.Fatalf("len(Stream())=%d, expected %d elements passed through", len(keys), len(hits))
+	}
+	if got := w.Snapshot().EstimateString("b"); got != 1 {
+		t.Fatalf("Snapshot().EstimateString(b)=%d, expected 1", got)
+	}
+}
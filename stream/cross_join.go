@@ -0,0 +1,31 @@
+package stream
+
+import (
+	"iter"
+	"slices"
+)
+
+// CrossJoin pairs every element of the stream with every element of other,
+// in stream order and then other's order. other is the small side, such as a
+// rules file: it is read once into memory when the first element arrives,
+// while the stream itself is never buffered. When other is an Input, check
+// its Err after the run.
+func CrossJoin[F, A, B any](other iter.Seq[B], cont func(iter.Seq[Pair[A, B]]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(Pair[A, B]) bool) {
+			var buffered []B
+			loaded := false
+			for a := range seq {
+				if !loaded {
+					buffered = slices.Collect(other)
+					loaded = true
+				}
+				for _, b := range buffered {
+					if !yield(Pair[A, B]{First: a, Second: b}) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCrossJoinPairsEveryElement(t *testing.T) {
+	events := []string{"login", "logout"}
+	rules := []int{1, 2, 3}
+
+	got := Stream(slices.Values(events), CrossJoin(slices.Values(rules), End(Collect[Pair[string, int]]())))
+	expected := []Pair[string, int]{
+		{"login", 1}, {"login", 2}, {"login", 3},
+		{"logout", 1}, {"logout", 2}, {"logout", 3},
+	}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("CrossJoin()=%v, expected %v", got, expected)
+	}
+}
+
+func TestCrossJoinReadsOtherOnce(t *testing.T) {
+	reads := 0
+	other := func(yield func(int) bool) {
+		reads++
+		for _, v := range []int{1, 2} {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+
+	count := Stream(Range(0, 5, 1), CrossJoin(other, Take(7, End(Count[Pair[int, int]]()))))
+	if count != 7 {
+		t.Fatalf("Count()=%d, expected 7", count)
+	}
+	if reads != 1 {
+		t.Fatalf("other read %d times, expected 1", reads)
+	}
+
+	reads = 0
+	empty := Stream(Range(0, 0, 1), CrossJoin(other, End(Count[Pair[int, int]]())))
+	if empty != 0 || reads != 0 {
+		t.Fatalf("empty stream gave %d pairs and %d reads, expected 0 and 0", empty, reads)
+	}
+}
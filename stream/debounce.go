@@ -0,0 +1,112 @@
+package stream
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"time"
+)
+
+// Debounce suppresses bursts: an element is held back until d passes without
+// another element, and only the latest element of each burst is emitted.
+// It suits live sources such as NewFollowLineStream or file-change events,
+// where many elements arrive in quick succession and only the settled state
+// matters. Pending elements are emitted without waiting when the stream ends.
+func Debounce[F, A any](d time.Duration, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return DebounceBy(d, func(A) struct{} { return struct{}{} }, cont)
+}
+
+// DebounceBy is Debounce per key: each key's latest element is emitted after
+// d without another element of that key, e.g. one event per changed file.
+// Elements due at the same time are emitted in the order they arrived.
+//
+// The upstream is read by a separate goroutine so the quiet period can end
+// while it blocks. When the consumer stops early that goroutine exits at the
+// upstream's next element, which for a live source may be after the
+// pipeline returns.
+func DebounceBy[F, A any, K comparable](d time.Duration, keyFn func(A) K, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			in := make(chan A)
+			done := make(chan struct{})
+			var upstreamPanic any
+			go func() {
+				defer close(in)
+				defer func() {
+					upstreamPanic = recover()
+				}()
+				for v := range seq {
+					select {
+					case in <- v:
+					case <-done:
+						return
+					}
+				}
+			}()
+			defer close(done)
+
+			pending := map[K]debounced[A]{}
+			var received uint64
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			for {
+				var next <-chan time.Time
+				if len(pending) > 0 {
+					earliest := time.Time{}
+					for _, p := range pending {
+						if earliest.IsZero() || p.due.Before(earliest) {
+							earliest = p.due
+						}
+					}
+					timer.Reset(time.Until(earliest))
+					next = timer.C
+				}
+
+				select {
+				case v, ok := <-in:
+					if !ok {
+						if upstreamPanic != nil {
+							panic(upstreamPanic)
+						}
+						for _, p := range dueDebounced(pending, time.Time{}, true) {
+							if !yield(p.value) {
+								return
+							}
+						}
+						return
+					}
+					received++
+					pending[keyFn(v)] = debounced[A]{value: v, due: time.Now().Add(d), order: received}
+				case now := <-next:
+					for _, p := range dueDebounced(pending, now, false) {
+						if !yield(p.value) {
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+type debounced[A any] struct {
+	value A
+	due   time.Time
+	order uint64
+}
+
+// dueDebounced removes and returns the pending elements due by now, or all
+// of them, earliest first.
+func dueDebounced[K comparable, A any](pending map[K]debounced[A], now time.Time, all bool) []debounced[A] {
+	var due []debounced[A]
+	for key, p := range pending {
+		if all || !p.due.After(now) {
+			due = append(due, p)
+			delete(pending, key)
+		}
+	}
+	slices.SortFunc(due, func(a, b debounced[A]) int {
+		return cmp.Or(a.due.Compare(b.due), cmp.Compare(a.order, b.order))
+	})
+	return due
+}
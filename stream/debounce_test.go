@@ -0,0 +1,50 @@
+package stream
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// burstSeq yields the elements of each burst back to back and pauses for
+// pause between bursts.
+func burstSeq(pause time.Duration, bursts ...[]string) func(func(string) bool) {
+	return func(yield func(string) bool) {
+		for i, burst := range bursts {
+			if i > 0 {
+				time.Sleep(pause)
+			}
+			for _, v := range burst {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	seq := burstSeq(100*time.Millisecond, []string{"a1", "a2", "a3"}, []string{"b1", "b2"}, []string{"c1"})
+	got := Stream(seq, Debounce(20*time.Millisecond, End(Collect[string]())))
+	if want := []string{"a3", "b2", "c1"}; !slices.Equal(got, want) {
+		t.Fatalf("Debounce() = %v, expected %v", got, want)
+	}
+}
+
+func TestDebounceBy(t *testing.T) {
+	file := func(event string) string { return strings.Split(event, ":")[0] }
+	seq := burstSeq(100*time.Millisecond,
+		[]string{"x:write", "y:write", "x:chmod", "y:rename"},
+		[]string{"x:write", "x:close"},
+	)
+	got := Stream(seq, DebounceBy(20*time.Millisecond, file, End(Collect[string]())))
+	if want := []string{"x:chmod", "y:rename", "x:close"}; !slices.Equal(got, want) {
+		t.Fatalf("DebounceBy() = %v, expected %v", got, want)
+	}
+
+	first := Stream(seq, DebounceBy(20*time.Millisecond, file, Take(1, End(Collect[string]()))))
+	if !slices.Equal(first, []string{"x:chmod"}) {
+		t.Fatalf("DebounceBy() with Take(1) = %v, expected [x:chmod]", first)
+	}
+}
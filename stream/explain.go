@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Plan describes the topology of a pipeline for Explain. Stages are
+// recorded when the pipeline is built, without running it.
+type Plan struct {
+	source   string
+	stages   []StageInfo
+	terminal string
+}
+
+// StageInfo names a stage and describes it.
+type StageInfo struct {
+	Name string
+	// Buffers marks stages that hold every element in memory before
+	// passing any on, such as Sort or GroupBy, or a terminal such as
+	// Collect. Their memory grows with the input.
+	Buffers bool
+	// Meta holds free-form details shown by Explain, e.g. a window size.
+	Meta map[string]string
+}
+
+// NewPlan starts the plan of a pipeline reading from source, a description
+// such as a path pattern.
+func NewPlan(source string) *Plan {
+	return &Plan{source: source}
+}
+
+// Named records the stage info at this point of the pipeline in p and passes
+// elements on unchanged. The operators up to the next Named form the stage.
+//
+// Pipelines are built from the terminal outwards, so each Named is added in
+// front of those built before it; a Plan must describe one pipeline built
+// once.
+func Named[F, A any](p *Plan, info StageInfo, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	p.stages = slices.Insert(p.stages, 0, info)
+	return cont
+}
+
+// NamedEnd is End recording the terminal's name in p.
+func NamedEnd[F any](p *Plan, name string, f F) F {
+	p.terminal = name
+	return f
+}
+
+// Explain describes the pipeline, one line per stage from the source to the
+// terminal, flagging the stages that buffer the whole input.
+func (p *Plan) Explain() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "source: %s\n", p.source)
+	for _, stage := range p.stages {
+		fmt.Fprintf(&b, "  -> %s", stage.Name)
+		for _, key := range slices.Sorted(maps.Keys(stage.Meta)) {
+			fmt.Fprintf(&b, " %s=%s", key, stage.Meta[key])
+		}
+		if stage.Buffers {
+			b.WriteString(" [buffers all elements]")
+		}
+		b.WriteByte('\n')
+	}
+	if p.terminal != "" {
+		fmt.Fprintf(&b, "  => %s\n", p.terminal)
+	}
+	return b.String()
+}
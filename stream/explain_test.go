@@ -0,0 +1,30 @@
+package stream
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlanExplain(t *testing.T) {
+	plan := NewPlan("logs/*.log")
+	pipeline := Named(plan, StageInfo{Name: "parse"}, Map(strings.ToUpper,
+		Named(plan, StageInfo{Name: "errors only", Meta: map[string]string{"level": "ERROR", "field": "msg"}}, Filter(func(s string) bool { return strings.HasPrefix(s, "E") },
+			Named(plan, StageInfo{Name: "sort", Buffers: true}, Sort(cmp.Compare[string],
+				NamedEnd(plan, "collect", Collect[string]())))))))
+
+	want := "source: logs/*.log\n" +
+		"  -> parse\n" +
+		"  -> errors only field=msg level=ERROR\n" +
+		"  -> sort [buffers all elements]\n" +
+		"  => collect\n"
+	if got := plan.Explain(); got != want {
+		t.Fatalf("Explain() = %q, expected %q", got, want)
+	}
+
+	got := Stream(slices.Values([]string{"err b", "info", "err a"}), pipeline)
+	if !slices.Equal(got, []string{"ERR A", "ERR B"}) {
+		t.Fatalf("pipeline = %v, expected [ERR A ERR B]", got)
+	}
+}
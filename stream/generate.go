@@ -0,0 +1,112 @@
+package stream
+
+import (
+	"cmp"
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+)
+
+var errZeroStep = errors.New("step must not be 0")
+
+// Number is the constraint for Range.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Range yields start, start+step, … while the value is before end: below it
+// for a positive step, above it for a negative one. end itself is never
+// yielded, and the range also stops where the next value would overflow N.
+// Float values are computed as start+i*step, so they do not accumulate
+// rounding error. Range panics if step is 0.
+func Range[N Number](start, end, step N) iter.Seq[N] {
+	if step == 0 {
+		panic(errZeroStep)
+	}
+	// Integer division truncates one half to 0.
+	float := N(1)/N(2) != 0
+	return func(yield func(N) bool) {
+		v := start
+		for i := N(1); ; i++ {
+			if (step > 0 && v >= end) || (step < 0 && v <= end) {
+				return
+			}
+			if !yield(v) {
+				return
+			}
+			next := v + step
+			if float {
+				next = start + i*step
+			}
+			// An integer that wrapped around, or a float too large to
+			// change by step, did not move past v.
+			if (step > 0 && next <= v) || (step < 0 && next >= v) {
+				return
+			}
+			v = next
+		}
+	}
+}
+
+// Repeat yields v n times, or forever when n is negative.
+func Repeat[A any](v A, n int) iter.Seq[A] {
+	return func(yield func(A) bool) {
+		for i := 0; n < 0 || i < n; i++ {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate yields seed, next(seed), next(next(seed)), … without end; bound it
+// with Take downstream.
+func Iterate[A any](seed A, next func(A) A) iter.Seq[A] {
+	return func(yield func(A) bool) {
+		for v := seed; ; v = next(v) {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Generate yields the results of calling fn repeatedly, without end. fn is
+// called once per element pulled, so it suits clocks, random data and ID
+// sources.
+func Generate[A any](fn func() A) iter.Seq[A] {
+	return func(yield func(A) bool) {
+		for {
+			if !yield(fn()) {
+				return
+			}
+		}
+	}
+}
+
+// FromMap yields the entries of m as Pairs of key and value, in Go's
+// unspecified map order; sort downstream, or use FromMapSorted, when order
+// matters.
+func FromMap[K comparable, V any](m map[K]V) iter.Seq[Pair[K, V]] {
+	return func(yield func(Pair[K, V]) bool) {
+		for k, v := range m {
+			if !yield(Pair[K, V]{First: k, Second: v}) {
+				return
+			}
+		}
+	}
+}
+
+// FromMapSorted is FromMap in ascending key order.
+func FromMapSorted[K cmp.Ordered, V any](m map[K]V) iter.Seq[Pair[K, V]] {
+	return func(yield func(Pair[K, V]) bool) {
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			if !yield(Pair[K, V]{First: k, Second: m[k]}) {
+				return
+			}
+		}
+	}
+}
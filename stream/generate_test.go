@@ -0,0 +1,91 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	cases := []struct {
+		name             string
+		start, end, step int
+		expected         []int
+	}{
+		{"ascending", 0, 5, 1, []int{0, 1, 2, 3, 4}},
+		{"stepped", 1, 10, 3, []int{1, 4, 7}},
+		{"descending", 5, 0, -2, []int{5, 3, 1}},
+		{"empty", 3, 3, 1, nil},
+		{"wrong direction", 0, 5, -1, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := slices.Collect(Range(tc.start, tc.end, tc.step))
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Range(%d, %d, %d)=%v, expected %v", tc.start, tc.end, tc.step, got, tc.expected)
+			}
+		})
+	}
+
+	floats := slices.Collect(Range(0.0, 1.0, 0.1))
+	if len(floats) != 10 || floats[9] != 0.9 {
+		t.Fatalf("Range(0, 1, 0.1)=%v, expected 10 values ending at 0.9", floats)
+	}
+
+	if got := slices.Collect(Range[uint8](250, 255, 10)); !slices.Equal(got, []uint8{250}) {
+		t.Fatalf("Range[uint8](250, 255, 10)=%v, expected [250]", got)
+	}
+	if got := slices.Collect(Range[int8](120, 127, 5)); !slices.Equal(got, []int8{120, 125}) {
+		t.Fatalf("Range[int8](120, 127, 5)=%v, expected [120 125]", got)
+	}
+	if got := slices.Collect(Range[int8](-120, -128, -7)); !slices.Equal(got, []int8{-120, -127}) {
+		t.Fatalf("Range[int8](-120, -128, -7)=%v, expected [-120 -127]", got)
+	}
+
+	defer func() {
+		if recover() != errZeroStep {
+			t.Fatalf("expected panic with errZeroStep")
+		}
+	}()
+	Range(0, 1, 0)
+}
+
+func TestRepeatIterateGenerate(t *testing.T) {
+	if got := slices.Collect(Repeat("x", 3)); !slices.Equal(got, []string{"x", "x", "x"}) {
+		t.Fatalf("Repeat(x, 3)=%v, expected [x x x]", got)
+	}
+	if got := Stream(Repeat(1, -1), Take(4, End(Count[int]()))); got != 4 {
+		t.Fatalf("Repeat(1, -1) took %d, expected 4", got)
+	}
+
+	powers := Stream(Iterate(1, func(v int) int { return v * 2 }), Take(5, End(Collect[int]())))
+	if !slices.Equal(powers, []int{1, 2, 4, 8, 16}) {
+		t.Fatalf("Iterate()=%v, expected [1 2 4 8 16]", powers)
+	}
+
+	next := 0
+	ids := Stream(Generate(func() int { next++; return next }), Take(3, End(Collect[int]())))
+	if !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Fatalf("Generate()=%v, expected [1 2 3]", ids)
+	}
+	if next != 3 {
+		t.Fatalf("Generate() called fn %d times, expected 3", next)
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	m := map[string]int{"b": 2, "a": 1, "c": 3}
+
+	sorted := slices.Collect(FromMapSorted(m))
+	expected := []Pair[string, int]{{"a", 1}, {"b", 2}, {"c", 3}}
+	if !slices.Equal(sorted, expected) {
+		t.Fatalf("FromMapSorted()=%v, expected %v", sorted, expected)
+	}
+
+	all := slices.SortedFunc(FromMap(m), func(x, y Pair[string, int]) int { return x.Second - y.Second })
+	if !slices.Equal(all, expected) {
+		t.Fatalf("FromMap()=%v, expected entries %v", all, expected)
+	}
+	if got := Stream(FromMap(m), Take(1, End(Count[Pair[string, int]]()))); got != 1 {
+		t.Fatalf("FromMap() with Take(1) counted %d, expected 1", got)
+	}
+}
@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"time"
+)
+
+// LogStageOptions configures LogStageWithOptions.
+type LogStageOptions struct {
+	// Level is the level of the summary and progress records; the zero
+	// value is slog.LevelInfo.
+	Level slog.Level
+	// ErrorLevel is the level of the record for an error reported by Err.
+	// Defaults to slog.LevelError.
+	ErrorLevel slog.Level
+	// Every logs a progress record after every Every elements. Zero logs
+	// only the summary.
+	Every int
+	// Err, if set, is checked once the stage ends, e.g. the Err of the
+	// Input feeding the pipeline.
+	Err func() error
+
+	// now replaces time.Now in tests.
+	now func() time.Time
+}
+
+// LogStage logs a summary record for the stage name through logger once
+// its elements are exhausted or the consumer stops: the element count, when
+// the first and last element passed and whether the stage was stopped
+// early. Elements pass on unchanged.
+func LogStage[F, A any](logger *slog.Logger, name string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return LogStageWithOptions(logger, name, LogStageOptions{}, cont)
+}
+
+// LogStageWithOptions is LogStage with configurable levels, progress records
+// and error reporting.
+func LogStageWithOptions[F, A any](logger *slog.Logger, name string, opts LogStageOptions, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	if opts.ErrorLevel == 0 {
+		opts.ErrorLevel = slog.LevelError
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			ctx := context.Background()
+			var count int64
+			var first, last time.Time
+			stopped := false
+			defer func() {
+				attrs := []slog.Attr{slog.String("stage", name), slog.Int64("count", count)}
+				if count > 0 {
+					attrs = append(attrs, slog.Time("first", first), slog.Time("last", last))
+				}
+				attrs = append(attrs, slog.Bool("stopped", stopped))
+				logger.LogAttrs(ctx, opts.Level, "stage finished", attrs...)
+				if opts.Err == nil {
+					return
+				}
+				if err := opts.Err(); err != nil {
+					logger.LogAttrs(ctx, opts.ErrorLevel, "stage failed", slog.String("stage", name), slog.Any("error", err))
+				}
+			}()
+
+			for v := range seq {
+				last = opts.now()
+				if count == 0 {
+					first = last
+				}
+				count++
+				if opts.Every > 0 && count%int64(opts.Every) == 0 {
+					logger.LogAttrs(ctx, opts.Level, "stage progress", slog.String("stage", name), slog.Int64("count", count))
+				}
+				if !yield(v) {
+					stopped = true
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogStage(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	opts := LogStageOptions{
+		Level: slog.LevelDebug,
+		Every: 2,
+		Err:   func() error { return errors.New("disk gone") },
+		now: func() time.Time {
+			clock = clock.Add(time.Second)
+			return clock
+		},
+	}
+
+	n := Stream(slices.Values([]int{1, 2, 3}), LogStageWithOptions(logger, "parse", opts, End(Count[int]())))
+	if n != 3 {
+		t.Fatalf("Count()=%d, expected 3", n)
+	}
+	want := "level=DEBUG msg=\"stage progress\" stage=parse count=2\n" +
+		"level=DEBUG msg=\"stage finished\" stage=parse count=3 first=2024-05-01T12:00:01.000Z last=2024-05-01T12:00:03.000Z stopped=false\n" +
+		"level=ERROR msg=\"stage failed\" stage=parse error=\"disk gone\"\n"
+	if buf.String() != want {
+		t.Fatalf("log = %q, expected %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	Stream(slices.Values([]int{1, 2, 3}), LogStage(logger, "head", Take(1, End(Count[int]()))))
+	if got := buf.String(); !strings.Contains(got, "stage=head count=1") || !strings.Contains(got, "stopped=true") {
+		t.Fatalf("log = %q, expected one element and stopped=true", got)
+	}
+}
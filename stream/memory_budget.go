@@ -0,0 +1,202 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+var errMemoryBudgetExceeded = errors.New("memory budget exceeded")
+
+// MemoryBudget caps the approximate memory held by the buffering stages of a
+// pipeline: SortWithBudget, GroupByWithBudget and CollectWithBudget. A stage
+// that would take the total past the limit stops reading, passes on nothing
+// more, and records an error returned by Err, so an unexpectedly large input
+// fails the run instead of the process. Like Input.Err, check Err after the
+// run; results produced after an error are incomplete.
+//
+// Sizes are estimates: the size of each element plus the contents of the
+// strings, slices, maps and pointers it holds, without allocator overhead.
+// Memory held by a sort is returned to the budget once its output has been
+// consumed; collected results stay charged, as the caller holds them.
+type MemoryBudget struct {
+	mu    sync.Mutex
+	limit int64
+	used  int64
+	err   error
+}
+
+// MemoryBudgetError reports the stage that exceeded a MemoryBudget.
+type MemoryBudgetError struct {
+	Stage string
+	Limit int64
+	Used  int64
+}
+
+func (e *MemoryBudgetError) Error() string {
+	return fmt.Sprintf("%s: %v: %d bytes held, limit %d", e.Stage, errMemoryBudgetExceeded, e.Used, e.Limit)
+}
+
+func (e *MemoryBudgetError) Unwrap() error {
+	return errMemoryBudgetExceeded
+}
+
+// NewMemoryBudget allows limit bytes across the stages sharing it.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	return &MemoryBudget{limit: limit}
+}
+
+// Used returns the bytes currently charged.
+func (b *MemoryBudget) Used() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Err returns the first budget violation, or nil.
+func (b *MemoryBudget) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// reserve charges n bytes for stage and reports whether they fit. Once the
+// budget has been exceeded every reservation fails.
+func (b *MemoryBudget) reserve(stage string, n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return false
+	}
+	if b.used+n > b.limit {
+		b.err = &MemoryBudgetError{Stage: stage, Limit: b.limit, Used: b.used + n}
+		return false
+	}
+	b.used += n
+	return true
+}
+
+func (b *MemoryBudget) release(n int64) {
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+}
+
+// SortWithBudget is Sort charging the buffered elements to budget. If they
+// do not fit, nothing is passed on.
+func SortWithBudget[F, A any](budget *MemoryBudget, cmp func(A, A) int, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		elements, held, ok := bufferWithBudget(budget, "Sort", seq)
+		defer budget.release(held)
+		if !ok {
+			return cont(func(func(A) bool) {})
+		}
+		slices.SortFunc(elements, cmp)
+		return cont(slices.Values(elements))
+	}
+}
+
+// CollectWithBudget is Collect charging the collected elements to budget.
+// If they do not fit, the elements collected so far are returned.
+func CollectWithBudget[E any](budget *MemoryBudget) func(iter.Seq[E]) []E {
+	return func(seq iter.Seq[E]) []E {
+		elements, _, _ := bufferWithBudget(budget, "Collect", seq)
+		return elements
+	}
+}
+
+// GroupByWithBudget is GroupBy charging the grouped elements and keys to
+// budget. If they do not fit, the groups built so far are returned.
+func GroupByWithBudget[A any, K comparable](budget *MemoryBudget, keyFn func(A) K) func(iter.Seq[A]) map[K][]A {
+	return func(seq iter.Seq[A]) map[K][]A {
+		result := map[K][]A{}
+		for v := range seq {
+			key := keyFn(v)
+			n := approxSize(reflect.ValueOf(&v).Elem())
+			if _, ok := result[key]; !ok {
+				n += approxSize(reflect.ValueOf(&key).Elem())
+			}
+			if !budget.reserve("GroupBy", n) {
+				break
+			}
+			result[key] = append(result[key], v)
+		}
+		return result
+	}
+}
+
+// bufferWithBudget reads seq into a slice while the elements fit in budget
+// and returns the bytes charged and whether all elements fit.
+func bufferWithBudget[A any](budget *MemoryBudget, stage string, seq iter.Seq[A]) ([]A, int64, bool) {
+	elements := []A{}
+	var held int64
+	for v := range seq {
+		n := approxSize(reflect.ValueOf(&v).Elem())
+		if !budget.reserve(stage, n) {
+			return elements, held, false
+		}
+		held += n
+		elements = append(elements, v)
+	}
+	return elements, held, true
+}
+
+// approxSize estimates the memory of v: its own size plus the contents it
+// references. Shared or cyclic references are counted once per path, up to
+// a small depth.
+func approxSize(v reflect.Value) int64 {
+	return int64(v.Type().Size()) + approxIndirect(v, 4)
+}
+
+// approxIndirect estimates the memory v references outside of itself.
+func approxIndirect(v reflect.Value, depth int) int64 {
+	if depth == 0 || !v.IsValid() {
+		return 0
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		n := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := range v.Len() {
+			n += approxIndirect(v.Index(i), depth-1)
+		}
+		return n
+	case reflect.Array:
+		var n int64
+		for i := range v.Len() {
+			n += approxIndirect(v.Index(i), depth-1)
+		}
+		return n
+	case reflect.Struct:
+		var n int64
+		for i := range v.NumField() {
+			n += approxIndirect(v.Field(i), depth-1)
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		entry := int64(v.Type().Key().Size() + v.Type().Elem().Size())
+		n := int64(v.Len()) * entry
+		iter := v.MapRange()
+		for iter.Next() {
+			n += approxIndirect(iter.Key(), depth-1) + approxIndirect(iter.Value(), depth-1)
+		}
+		return n
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + approxIndirect(elem, depth-1)
+	}
+	return 0
+}
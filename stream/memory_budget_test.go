@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"cmp"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApproxSize(t *testing.T) {
+	type record struct {
+		Name string
+		Tags []string
+	}
+	r := record{Name: "abcd", Tags: []string{"xy", "z"}}
+	// 40 for the struct, 4 name bytes, 2*16 for the slice and 3 tag bytes.
+	if got := approxSize(reflect.ValueOf(r)); got != 79 {
+		t.Fatalf("approxSize()=%d, expected 79", got)
+	}
+	if got := approxSize(reflect.ValueOf(int64(1))); got != 8 {
+		t.Fatalf("approxSize()=%d, expected 8", got)
+	}
+}
+
+func TestSortWithBudget(t *testing.T) {
+	words := []string{"delta", "alpha", "charlie", "bravo"}
+
+	budget := NewMemoryBudget(1 << 20)
+	sorted := Stream(slices.Values(words), SortWithBudget(budget, cmp.Compare[string], End(Collect[string]())))
+	if !slices.Equal(sorted, []string{"alpha", "bravo", "charlie", "delta"}) || budget.Err() != nil {
+		t.Fatalf("sorted = %v, Err() = %v, expected sorted words and nil", sorted, budget.Err())
+	}
+	if budget.Used() != 0 {
+		t.Fatalf("Used()=%d after the sort, expected 0", budget.Used())
+	}
+
+	// Each string takes 16 bytes plus its length.
+	small := NewMemoryBudget(50)
+	sorted = Stream(slices.Values(words), SortWithBudget(small, cmp.Compare[string], End(Collect[string]())))
+	var budgetErr *MemoryBudgetError
+	if len(sorted) != 0 || !errors.As(small.Err(), &budgetErr) || budgetErr.Stage != "Sort" {
+		t.Fatalf("sorted = %v, Err() = %v, expected nothing and a Sort budget error", sorted, small.Err())
+	}
+	if !errors.Is(small.Err(), errMemoryBudgetExceeded) || !strings.Contains(small.Err().Error(), "limit 50") {
+		t.Fatalf("Err() = %v, expected the limit in the message", small.Err())
+	}
+}
+
+func TestCollectAndGroupByWithBudget(t *testing.T) {
+	budget := NewMemoryBudget(20)
+	got := Stream(slices.Values([]int64{1, 2, 3, 4}), End(CollectWithBudget[int64](budget)))
+	if !slices.Equal(got, []int64{1, 2}) || budget.Err() == nil || budget.Used() != 16 {
+		t.Fatalf("collected %v with Used()=%d, Err()=%v, expected [1 2], 16 and an error", got, budget.Used(), budget.Err())
+	}
+
+	budget = NewMemoryBudget(1 << 10)
+	groups := Stream(slices.Values([]string{"a1", "b1", "a2"}), End(GroupByWithBudget(budget, func(s string) string { return s[:1] })))
+	if len(groups["a"]) != 2 || len(groups["b"]) != 1 || budget.Err() != nil {
+		t.Fatalf("groups = %v, Err() = %v, expected a: 2, b: 1", groups, budget.Err())
+	}
+	// Three elements of 18 bytes and two keys of 17.
+	if budget.Used() != 88 {
+		t.Fatalf("Used()=%d, expected 88", budget.Used())
+	}
+}
@@ -0,0 +1,185 @@
+package stream
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sourceStage is the stack entry for the code upstream of the first stage.
+const sourceStage = -1
+
+// PipelineMetrics collects per-stage element counts and time for the stages
+// marked with Measure. A stage covers the operators from its Measure to the
+// next one, or to the terminal, and its time excludes later stages, so the
+// slowest stage of a pipeline is the one with the most time.
+//
+// Time is attributed by tracking which stage the goroutine running the
+// pipeline is in, so it is only meaningful for linear pipelines run by one
+// goroutine at a time. Work done by the goroutines of Par operators shows up
+// as waiting time in the stage that consumes their results, and Measure
+// points upstream of a Par operator, which its goroutine pulls, mix up the
+// attribution. Measuring adds a few clock reads and lock operations per
+// element and stage.
+type PipelineMetrics struct {
+	mu     sync.Mutex
+	stages []*stageMetrics
+	byName map[string]int
+	active []int
+	last   time.Time
+	source time.Duration
+
+	// now replaces time.Now in tests.
+	now func() time.Time
+}
+
+type stageMetrics struct {
+	name string
+	in   int64
+	time time.Duration
+}
+
+// StageMetrics reports one stage. Out is the number of elements that
+// reached the next stage, or -1 for the last stage, whose output is not
+// observed.
+type StageMetrics struct {
+	Name string
+	In   int64
+	Out  int64
+	Time time.Duration
+}
+
+func NewPipelineMetrics() *PipelineMetrics {
+	return &PipelineMetrics{byName: make(map[string]int), now: time.Now}
+}
+
+// Measure starts the stage name at this point of the pipeline. Stages are
+// reported in the order they are first run; running the pipeline again, or
+// another one with the same stage names, adds to the same stages.
+func Measure[F, A any](m *PipelineMetrics, name string, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		stage := m.register(name)
+		// Operators between here and the next Measure, including work
+		// they do outside of pulling elements such as sorting, belong
+		// to this stage.
+		m.enter(stage)
+		defer m.leave()
+		return cont(func(yield func(A) bool) {
+			// Pulling from seq runs the code of the stage before.
+			m.enter(stage - 1)
+			defer m.leave()
+			for v := range seq {
+				m.received(stage)
+				m.enter(stage)
+				ok := yield(v)
+				m.leave()
+				if !ok {
+					return
+				}
+			}
+		})
+	}
+}
+
+func (m *PipelineMetrics) register(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stage, ok := m.byName[name]; ok {
+		return stage
+	}
+	m.stages = append(m.stages, &stageMetrics{name: name})
+	m.byName[name] = len(m.stages) - 1
+	return len(m.stages) - 1
+}
+
+func (m *PipelineMetrics) received(stage int) {
+	m.mu.Lock()
+	m.stages[stage].in++
+	m.mu.Unlock()
+}
+
+// enter charges the time since the last switch to the active stage and
+// makes stage active.
+func (m *PipelineMetrics) enter(stage int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.active) == 0 {
+		m.last = m.now()
+	} else {
+		m.charge()
+	}
+	m.active = append(m.active, stage)
+}
+
+// leave charges the time since the last switch to the active stage and
+// returns to the stage active before it.
+func (m *PipelineMetrics) leave() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.charge()
+	m.active = m.active[:len(m.active)-1]
+}
+
+func (m *PipelineMetrics) charge() {
+	now := m.now()
+	elapsed := now.Sub(m.last)
+	m.last = now
+	if stage := m.active[len(m.active)-1]; stage == sourceStage {
+		m.source += elapsed
+	} else {
+		m.stages[stage].time += elapsed
+	}
+}
+
+// Stages returns the metrics of each stage in pipeline order.
+func (m *PipelineMetrics) Stages() []StageMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stages := make([]StageMetrics, len(m.stages))
+	for i, s := range m.stages {
+		stages[i] = StageMetrics{Name: s.name, In: s.in, Out: -1, Time: s.time}
+		if i+1 < len(m.stages) {
+			stages[i].Out = m.stages[i+1].in
+		}
+	}
+	return stages
+}
+
+// SourceTime returns the time spent upstream of the first stage, typically
+// reading and parsing the input.
+func (m *PipelineMetrics) SourceTime() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.source
+}
+
+// String formats the metrics as a table with each stage's share of the
+// total time.
+func (m *PipelineMetrics) String() string {
+	stages := m.Stages()
+	source := m.SourceTime()
+	total := source
+	for _, s := range stages {
+		total += s.Time
+	}
+	share := func(d time.Duration) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(d) / float64(total)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-16s %10s %10s %12s %6s\n", "STAGE", "IN", "OUT", "TIME", "%")
+	fmt.Fprintf(&b, "%-16s %10s %10s %12s %5.1f%%\n", "(source)", "", "", source, share(source))
+	for _, s := range stages {
+		out := "-"
+		if s.Out >= 0 {
+			out = fmt.Sprint(s.Out)
+		}
+		fmt.Fprintf(&b, "%-16s %10d %10s %12s %5.1f%%\n", s.Name, s.In, out, s.Time, share(s.Time))
+	}
+	return b.String()
+}
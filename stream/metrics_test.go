@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMeasureAttributesTimeToStages(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tick := func(d time.Duration) { clock = clock.Add(d) }
+	m := NewPipelineMetrics()
+	m.now = func() time.Time { return clock }
+
+	source := func(yield func(int) bool) {
+		for i := range 10 {
+			tick(2 * time.Millisecond)
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	pipeline := Measure(m, "parse", Map(func(v int) int {
+		tick(time.Millisecond)
+		return v
+	}, Measure(m, "filter", Filter(func(v int) bool {
+		tick(5 * time.Millisecond)
+		return v%2 == 0
+	}, Measure(m, "sink", End(Count[int]()))))))
+
+	if n := Stream(source, pipeline); n != 5 {
+		t.Fatalf("Count()=%d, expected 5", n)
+	}
+	ms := time.Millisecond
+	want := []StageMetrics{
+		{Name: "parse", In: 10, Out: 10, Time: 10 * ms},
+		{Name: "filter", In: 10, Out: 5, Time: 50 * ms},
+		{Name: "sink", In: 5, Out: -1, Time: 0},
+	}
+	if got := m.Stages(); !slices.Equal(got, want) {
+		t.Fatalf("Stages() = %+v, expected %+v", got, want)
+	}
+	if m.SourceTime() != 20*ms {
+		t.Fatalf("SourceTime()=%v, expected 20ms", m.SourceTime())
+	}
+	if report := m.String(); !strings.Contains(report, "filter") || !strings.Contains(report, "62.5%") {
+		t.Fatalf("String() = %q, expected filter at 62.5%%", report)
+	}
+
+	// A second run adds to the same stages.
+	Stream(source, pipeline)
+	if got := m.Stages()[0].In; got != 20 {
+		t.Fatalf("parse In=%d after two runs, expected 20", got)
+	}
+}
+
+func TestMeasureBufferingStage(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewPipelineMetrics()
+	m.now = func() time.Time { return clock }
+	sorted := Stream(slices.Values([]int{3, 1, 2}), Measure(m, "sort", Sort(func(a, b int) int {
+		clock = clock.Add(time.Millisecond)
+		return a - b
+	}, Measure(m, "collect", Map(func(v int) int {
+		clock = clock.Add(10 * time.Millisecond)
+		return v
+	}, End(Collect[int]()))))))
+	if !slices.Equal(sorted, []int{1, 2, 3}) {
+		t.Fatalf("sorted = %v, expected [1 2 3]", sorted)
+	}
+	stages := m.Stages()
+	if stages[0].Time <= 0 || stages[0].Time >= 10*time.Millisecond || stages[1].Time != 30*time.Millisecond {
+		t.Fatalf("Stages() = %+v, expected sort under 10ms and collect 30ms", stages)
+	}
+}
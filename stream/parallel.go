@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"iter"
+	"sync"
+)
+
+// Buffer runs the upstream sequence in its own goroutine and keeps up to n
+// elements queued for downstream, so reading and parsing overlap with the
+// work done by later stages. Element order is preserved.
+// A non-positive n disables buffering and passes the sequence through.
+func Buffer[F, A any](n int, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		if n <= 0 {
+			return cont(seq)
+		}
+
+		return cont(func(yield func(A) bool) {
+			values := make(chan A, n)
+			done := make(chan struct{})
+			var upstreamPanic any
+
+			go func() {
+				defer close(values)
+				defer func() {
+					upstreamPanic = recover()
+				}()
+
+				for v := range seq {
+					select {
+					case values <- v:
+					case <-done:
+						return
+					}
+				}
+			}()
+
+			// Always wait for the producer so the upstream run (including its
+			// deferred error reporting) has finished once this sequence returns.
+			defer func() {
+				close(done)
+				for range values {
+				}
+				if upstreamPanic != nil {
+					panic(upstreamPanic)
+				}
+			}()
+
+			for v := range values {
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// ParChunk groups the stream into chunks of size elements and runs fn on up
+// to workers chunks in parallel. This amortizes per-call overhead for batch
+// work such as bulk lookups. Results are flattened back into the stream in
+// the original chunk order.
+func ParChunk[F, A, B any](size, workers int, fn func([]A) []B, cont func(iter.Seq[B]) F) func(iter.Seq[A]) F {
+	size = max(size, 1)
+	workers = max(workers, 1)
+
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(B) bool) {
+			jobs := make(chan chunkJob[A, B])
+			// pending carries result slots in chunk order and bounds the
+			// number of chunks in flight.
+			pending := make(chan chan chunkResult[B], workers)
+			done := make(chan struct{})
+
+			var wg sync.WaitGroup
+			for range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for job := range jobs {
+						job.out <- runChunk(fn, job.in)
+					}
+				}()
+			}
+
+			var upstreamPanic any
+			go func() {
+				defer close(pending)
+				defer close(jobs)
+				defer func() {
+					upstreamPanic = recover()
+				}()
+
+				chunk := make([]A, 0, size)
+				send := func() bool {
+					out := make(chan chunkResult[B], 1)
+					select {
+					case pending <- out:
+					case <-done:
+						return false
+					}
+					select {
+					case jobs <- chunkJob[A, B]{in: chunk, out: out}:
+					case <-done:
+						return false
+					}
+					chunk = make([]A, 0, size)
+					return true
+				}
+
+				for v := range seq {
+					chunk = append(chunk, v)
+					if len(chunk) == size && !send() {
+						return
+					}
+				}
+				if len(chunk) > 0 {
+					send()
+				}
+			}()
+
+			defer func() {
+				close(done)
+				for range pending {
+				}
+				wg.Wait()
+				if upstreamPanic != nil {
+					panic(upstreamPanic)
+				}
+			}()
+
+			for out := range pending {
+				result := <-out
+				if result.panicVal != nil {
+					panic(result.panicVal)
+				}
+				for _, v := range result.values {
+					if !yield(v) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+type chunkJob[A, B any] struct {
+	in  []A
+	out chan chunkResult[B]
+}
+
+type chunkResult[B any] struct {
+	values   []B
+	panicVal any
+}
+
+func runChunk[A, B any](fn func([]A) []B, in []A) (result chunkResult[B]) {
+	defer func() {
+		if r := recover(); r != nil {
+			result.panicVal = r
+		}
+	}()
+	return chunkResult[B]{values: fn(in)}
+}
@@ -0,0 +1,231 @@
+package stream
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/input"
+)
+
+func TestBuffer(t *testing.T) {
+	t.Run("preserves element order", func(t *testing.T) {
+		data := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+		result := Stream(
+			slices.Values(data),
+			Buffer(3,
+				Map(func(n int) int { return n * 2 },
+					End(Collect[int]()),
+				),
+			),
+		)
+
+		expected := []int{2, 4, 6, 8, 10, 12, 14, 16, 18, 20}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+	})
+
+	t.Run("non-positive size passes through", func(t *testing.T) {
+		data := []int{3, 1, 2}
+
+		result := Stream(
+			slices.Values(data),
+			Buffer(0,
+				End(Collect[int]()),
+			),
+		)
+
+		expected := []int{3, 1, 2}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+	})
+
+	t.Run("stops upstream when downstream stops early", func(t *testing.T) {
+		produced := 0
+		source := func(yield func(int) bool) {
+			for i := 0; i < 1000; i++ {
+				produced++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+
+		result := Stream(
+			source,
+			Buffer(2,
+				Take(3,
+					End(Collect[int]()),
+				),
+			),
+		)
+
+		expected := []int{0, 1, 2}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+		if produced >= 1000 {
+			t.Errorf("produced = %d, expected upstream to stop early", produced)
+		}
+	})
+
+	t.Run("source error is visible after the run", func(t *testing.T) {
+		dir := t.TempDir()
+		fileA := filepath.Join(dir, "a.txt")
+		missing := filepath.Join(dir, "missing.txt")
+		writeTextFile(t, fileA, "a1\na2\n")
+
+		source := input.NewFileLineStream([]string{fileA, missing})
+		got := Stream(source.Seq, Buffer(4, End(Collect[string]())))
+
+		want := []string{"a1", "a2"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Stream() = %v, want %v", got, want)
+		}
+		if err := source.Err(); err == nil {
+			t.Fatal("Err() = nil, want non-nil")
+		}
+	})
+
+	t.Run("re-panics upstream panic in consumer", func(t *testing.T) {
+		source := func(yield func(int) bool) {
+			yield(1)
+			panic("boom")
+		}
+
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recover() = %v, expected boom", r)
+			}
+		}()
+		_ = Stream(source, Buffer(1, End(Collect[int]())))
+		t.Error("expected panic")
+	})
+}
+
+func TestParChunk(t *testing.T) {
+	t.Run("flattens chunk results in order", func(t *testing.T) {
+		data := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+		result := Stream(
+			slices.Values(data),
+			ParChunk(3, 4, func(chunk []int) []int {
+				out := []int{}
+				for _, n := range chunk {
+					if n%2 == 0 {
+						out = append(out, n*10)
+					}
+				}
+				return out
+			},
+				End(Collect[int]()),
+			),
+		)
+
+		expected := []int{20, 40, 60, 80, 100}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+	})
+
+	t.Run("passes whole chunks to fn", func(t *testing.T) {
+		data := []string{"a", "b", "c", "d", "e"}
+
+		result := Stream(
+			slices.Values(data),
+			ParChunk(2, 2, func(chunk []string) []int { return []int{len(chunk)} },
+				End(Collect[int]()),
+			),
+		)
+
+		expected := []int{2, 2, 1}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+	})
+
+	t.Run("runs chunks in parallel", func(t *testing.T) {
+		var arrived sync.WaitGroup
+		arrived.Add(2)
+		released := make(chan struct{})
+		go func() {
+			arrived.Wait()
+			close(released)
+		}()
+
+		data := []int{1, 2}
+		result := Stream(
+			slices.Values(data),
+			ParChunk(1, 2, func(chunk []int) []int {
+				arrived.Done()
+				select {
+				case <-released:
+				case <-time.After(5 * time.Second):
+					t.Error("chunks did not run in parallel")
+				}
+				return chunk
+			},
+				End(Collect[int]()),
+			),
+		)
+
+		expected := []int{1, 2}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+	})
+
+	t.Run("stops upstream when downstream stops early", func(t *testing.T) {
+		produced := 0
+		source := func(yield func(int) bool) {
+			for i := 0; i < 10000; i++ {
+				produced++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+
+		result := Stream(
+			source,
+			ParChunk(10, 2, func(chunk []int) []int { return chunk },
+				Take(15,
+					End(Count[int]()),
+				),
+			),
+		)
+
+		if result != 15 {
+			t.Errorf("Count() = %d, expected 15", result)
+		}
+		if produced >= 10000 {
+			t.Errorf("produced = %d, expected upstream to stop early", produced)
+		}
+	})
+
+	t.Run("re-panics worker panic in consumer", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r != "bad chunk" {
+				t.Errorf("recover() = %v, expected bad chunk", r)
+			}
+		}()
+		_ = Stream(
+			slices.Values([]int{1, 2, 3}),
+			ParChunk(1, 2, func(chunk []int) []int {
+				if chunk[0] == 2 {
+					panic("bad chunk")
+				}
+				return chunk
+			},
+				End(Collect[int]()),
+			),
+		)
+		t.Error("expected panic")
+	})
+}
@@ -0,0 +1,76 @@
+package stream
+
+import "iter"
+
+// Pass is the continuation that hands the stream back unchanged. Ending an
+// operator with it turns the operator into a stage, a plain
+// func(iter.Seq[A]) iter.Seq[B], that Pipe and Compose can chain:
+//
+//	even := Filter(isEven, Pass[int]())
+//	total := Stream(seq, Pipe3(even, Map(square, Pass[int]()), Reduce(0, add)))
+//
+// A stage returns as soon as its stream is built, so operators that do work
+// around the call to their continuation, such as Measure, see none of the
+// downstream work when used as a stage; keep them in nested form.
+func Pass[A any]() func(iter.Seq[A]) iter.Seq[A] {
+	return func(seq iter.Seq[A]) iter.Seq[A] {
+		return seq
+	}
+}
+
+// Compose chains two stages, or a stage and a terminal, into one.
+func Compose[A, B, C any](f func(A) B, g func(B) C) func(A) C {
+	return func(v A) C {
+		return g(f(v))
+	}
+}
+
+// Pipe2 runs a stage and then terminal, the flat form of nesting the
+// stage's operator around End(terminal).
+func Pipe2[A, B, R any](s1 func(iter.Seq[A]) iter.Seq[B], terminal func(iter.Seq[B]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s1(seq))
+	}
+}
+
+// Pipe3 runs 2 stages in order and then terminal.
+func Pipe3[A, B, C, R any](s1 func(iter.Seq[A]) iter.Seq[B], s2 func(iter.Seq[B]) iter.Seq[C], terminal func(iter.Seq[C]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s2(s1(seq)))
+	}
+}
+
+// Pipe4 runs 3 stages in order and then terminal.
+func Pipe4[A, B, C, D, R any](s1 func(iter.Seq[A]) iter.Seq[B], s2 func(iter.Seq[B]) iter.Seq[C], s3 func(iter.Seq[C]) iter.Seq[D], terminal func(iter.Seq[D]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s3(s2(s1(seq))))
+	}
+}
+
+// Pipe5 runs 4 stages in order and then terminal.
+func Pipe5[A, B, C, D, E, R any](s1 func(iter.Seq[A]) iter.Seq[B], s2 func(iter.Seq[B]) iter.Seq[C], s3 func(iter.Seq[C]) iter.Seq[D], s4 func(iter.Seq[D]) iter.Seq[E], terminal func(iter.Seq[E]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s4(s3(s2(s1(seq)))))
+	}
+}
+
+// Pipe6 runs 5 stages in order and then terminal.
+func Pipe6[A, B, C, D, E, F, R any](s1 func(iter.Seq[A]) iter.Seq[B], s2 func(iter.Seq[B]) iter.Seq[C], s3 func(iter.Seq[C]) iter.Seq[D], s4 func(iter.Seq[D]) iter.Seq[E], s5 func(iter.Seq[E]) iter.Seq[F], terminal func(iter.Seq[F]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s5(s4(s3(s2(s1(seq))))))
+	}
+}
+
+// Pipe7 runs 6 stages in order and then terminal.
+func Pipe7[A, B, C, D, E, F, G, R any](s1 func(iter.Seq[A]) iter.Seq[B], s2 func(iter.Seq[B]) iter.Seq[C], s3 func(iter.Seq[C]) iter.Seq[D], s4 func(iter.Seq[D]) iter.Seq[E], s5 func(iter.Seq[E]) iter.Seq[F], s6 func(iter.Seq[F]) iter.Seq[G], terminal func(iter.Seq[G]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s6(s5(s4(s3(s2(s1(seq)))))))
+	}
+}
+
+// Pipe8 runs 7 stages in order and then terminal.
+func Pipe8[A, B, C, D, E, F, G, H, R any](s1 func(iter.Seq[A]) iter.Seq[B], s2 func(iter.Seq[B]) iter.Seq[C], s3 func(iter.Seq[C]) iter.Seq[D], s4 func(iter.Seq[D]) iter.Seq[E], s5 func(iter.Seq[E]) iter.Seq[F], s6 func(iter.Seq[F]) iter.Seq[G], s7 func(iter.Seq[G]) iter.Seq[H], terminal func(iter.Seq[H]) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		return terminal(s7(s6(s5(s4(s3(s2(s1(seq))))))))
+	}
+}
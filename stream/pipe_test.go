@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestPipeMatchesNestedPipeline(t *testing.T) {
+	data := []int{5, 2, 8, 1, 4, 7, 6, 3}
+	isEven := func(v int) bool { return v%2 == 0 }
+	square := func(v int) int { return v * v }
+
+	nested := Stream(slices.Values(data),
+		Filter(isEven,
+			Map(square,
+				Sort(func(a, b int) int { return a - b },
+					End(Collect[int]()),
+				),
+			),
+		),
+	)
+	flat := Stream(slices.Values(data), Pipe4(
+		Filter(isEven, Pass[int]()),
+		Map(square, Pass[int]()),
+		Sort(func(a, b int) int { return a - b }, Pass[int]()),
+		Collect[int](),
+	))
+	if !slices.Equal(flat, nested) {
+		t.Fatalf("Pipe4()=%v, expected %v", flat, nested)
+	}
+}
+
+func TestPipeStopsUpstreamEarly(t *testing.T) {
+	pulled := 0
+	source := func(yield func(int) bool) {
+		for i := range 100 {
+			pulled++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	result := Stream(source, Pipe3(
+		Map(strconv.Itoa, Pass[string]()),
+		Take(3, Pass[string]()),
+		Collect[string](),
+	))
+	if !slices.Equal(result, []string{"0", "1", "2"}) {
+		t.Fatalf("Pipe3()=%v, expected [0 1 2]", result)
+	}
+	if pulled != 3 {
+		t.Fatalf("pulled %d elements, expected 3", pulled)
+	}
+}
+
+func TestComposeBuildsReusableStages(t *testing.T) {
+	double := Map(func(v int) int { return v * 2 }, Pass[int]())
+	positive := Filter(func(v int) bool { return v > 0 }, Pass[int]())
+	stage := Compose(positive, double)
+
+	sum := Stream(slices.Values([]int{-1, 1, 2, -3, 3}), Compose(stage, Reduce(0, func(acc, v int) int { return acc + v })))
+	if sum != 12 {
+		t.Fatalf("sum=%d, expected 12", sum)
+	}
+
+	// A composed stage is itself a stage.
+	count := Stream(slices.Values([]int{-1, 1, 2}), Pipe2(stage, Count[int]()))
+	if count != 2 {
+		t.Fatalf("Count()=%d, expected 2", count)
+	}
+}
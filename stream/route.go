@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"iter"
+)
+
+// RouteResult holds the result of every routed pipeline.
+// Default is the zero value of F when no default pipeline was given.
+type RouteResult[K comparable, F any] struct {
+	Routes  map[K]F
+	Default F
+}
+
+// RouteBy dispatches each element to the pipeline registered for keyFn(element)
+// in a single pass over the source. Elements whose key has no route go to
+// fallback, or are dropped when fallback is nil. Every pipeline runs to
+// completion and contributes a result, even when no element was routed to it.
+// The source is abandoned early once every pipeline has stopped consuming.
+func RouteBy[A any, K comparable, F any](keyFn func(A) K, routes map[K]func(iter.Seq[A]) F, fallback func(iter.Seq[A]) F) func(iter.Seq[A]) RouteResult[K, F] {
+	return func(seq iter.Seq[A]) RouteResult[K, F] {
+		sinks := make(map[K]*pushSink[A, F], len(routes))
+		for key, pipeline := range routes {
+			sinks[key] = newPushSink(pipeline)
+		}
+		var fallbackSink *pushSink[A, F]
+		if fallback != nil {
+			fallbackSink = newPushSink(fallback)
+		}
+		defer func() {
+			for _, sink := range sinks {
+				sink.stop()
+			}
+			if fallbackSink != nil {
+				fallbackSink.stop()
+			}
+		}()
+
+		active := 0
+		for _, sink := range sinks {
+			if sink.active {
+				active++
+			}
+		}
+		if fallbackSink != nil && fallbackSink.active {
+			active++
+		}
+
+		for v := range seq {
+			if active == 0 {
+				break
+			}
+			sink, ok := sinks[keyFn(v)]
+			if !ok {
+				sink = fallbackSink
+			}
+			if sink == nil || !sink.active {
+				continue
+			}
+			if !sink.push(v) {
+				active--
+			}
+		}
+
+		result := RouteResult[K, F]{Routes: make(map[K]F, len(sinks))}
+		for key, sink := range sinks {
+			result.Routes[key] = sink.close()
+		}
+		if fallbackSink != nil {
+			result.Default = fallbackSink.close()
+		}
+		return result
+	}
+}
+
+// pushSink turns a pull-style pipeline into one that accepts pushed elements.
+// The pipeline runs as a coroutine that is resumed once per element.
+type pushSink[A, F any] struct {
+	pending A
+	closed  bool
+	active  bool
+	result  F
+	next    func() (struct{}, bool)
+	stop    func()
+}
+
+func newPushSink[A, F any](pipeline func(iter.Seq[A]) F) *pushSink[A, F] {
+	s := &pushSink[A, F]{}
+	s.next, s.stop = iter.Pull(func(suspend func(struct{}) bool) {
+		s.result = pipeline(func(yield func(A) bool) {
+			for {
+				if s.closed || !suspend(struct{}{}) || s.closed {
+					return
+				}
+				if !yield(s.pending) {
+					return
+				}
+			}
+		})
+	})
+	// Run the pipeline until it asks for its first element.
+	_, s.active = s.next()
+	return s
+}
+
+// push hands v to the pipeline and reports whether it wants more elements.
+func (s *pushSink[A, F]) push(v A) bool {
+	s.pending = v
+	_, s.active = s.next()
+	return s.active
+}
+
+// close signals end of input and returns the pipeline result.
+func (s *pushSink[A, F]) close() F {
+	s.closed = true
+	for s.active {
+		_, s.active = s.next()
+	}
+	return s.result
+}
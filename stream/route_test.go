@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRouteBy(t *testing.T) {
+	level := func(line string) string {
+		level, _, _ := strings.Cut(line, ":")
+		return level
+	}
+
+	t.Run("dispatches elements to pipelines by key", func(t *testing.T) {
+		data := []string{"error:disk", "info:start", "warn:slow", "error:net", "debug:x", "info:stop"}
+
+		result := Stream(
+			slices.Values(data),
+			End(RouteBy(level,
+				map[string]func(iter.Seq[string]) []string{
+					"error": Map(strings.ToUpper, End(Collect[string]())),
+					"warn":  End(Collect[string]()),
+					"info":  Take(1, End(Collect[string]())),
+				},
+				End(Collect[string]()),
+			)),
+		)
+
+		expected := map[string][]string{
+			"error": {"ERROR:DISK", "ERROR:NET"},
+			"warn":  {"warn:slow"},
+			"info":  {"info:start"},
+		}
+		if !reflect.DeepEqual(result.Routes, expected) {
+			t.Errorf("RouteBy().Routes = %v, expected %v", result.Routes, expected)
+		}
+		if !reflect.DeepEqual(result.Default, []string{"debug:x"}) {
+			t.Errorf("RouteBy().Default = %v, expected [debug:x]", result.Default)
+		}
+	})
+
+	t.Run("routes with no elements still produce a result", func(t *testing.T) {
+		data := []string{"info:a", "info:b"}
+
+		result := Stream(
+			slices.Values(data),
+			End(RouteBy(level,
+				map[string]func(iter.Seq[string]) int{
+					"error": End(Count[string]()),
+					"info":  End(Count[string]()),
+				},
+				nil,
+			)),
+		)
+
+		expected := map[string]int{"error": 0, "info": 2}
+		if !reflect.DeepEqual(result.Routes, expected) {
+			t.Errorf("RouteBy().Routes = %v, expected %v", result.Routes, expected)
+		}
+	})
+
+	t.Run("stops the source once every pipeline is done", func(t *testing.T) {
+		pulled := 0
+		source := func(yield func(int) bool) {
+			for i := 0; i < 1000; i++ {
+				pulled++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+
+		result := Stream(
+			source,
+			End(RouteBy(func(n int) bool { return n%2 == 0 },
+				map[bool]func(iter.Seq[int]) []int{
+					true:  Take(2, End(Collect[int]())),
+					false: Take(2, End(Collect[int]())),
+				},
+				nil,
+			)),
+		)
+
+		expected := map[bool][]int{true: {0, 2}, false: {1, 3}}
+		if !reflect.DeepEqual(result.Routes, expected) {
+			t.Errorf("RouteBy().Routes = %v, expected %v", result.Routes, expected)
+		}
+		if pulled >= 1000 {
+			t.Errorf("pulled = %d, expected source to stop early", pulled)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package stream
+
+import "iter"
+
+// Union passes the distinct elements of the stream followed by those of
+// other not already seen, like SQL UNION. Memory grows with the number of
+// distinct elements.
+func Union[A comparable, F any](other iter.Seq[A], cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return UnionBy(other, identity[A], cont)
+}
+
+// UnionBy is Union comparing elements by keyFn; the first element seen for
+// each key is passed.
+func UnionBy[A any, K comparable, F any](other iter.Seq[A], keyFn func(A) K, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			seen := map[K]struct{}{}
+			for _, s := range []iter.Seq[A]{seq, other} {
+				for v := range s {
+					key := keyFn(v)
+					if _, ok := seen[key]; ok {
+						continue
+					}
+					seen[key] = struct{}{}
+					if !yield(v) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+// Intersect passes the distinct elements of the stream that also occur in
+// other, like SQL INTERSECT. other is read into a set first; the stream is
+// not buffered.
+func Intersect[A comparable, F any](other iter.Seq[A], cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return IntersectBy(other, identity[A], cont)
+}
+
+// IntersectBy is Intersect comparing elements by keyFn.
+func IntersectBy[A any, K comparable, F any](other iter.Seq[A], keyFn func(A) K, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return filterByKeys(other, keyFn, true, cont)
+}
+
+// Except passes the distinct elements of the stream that do not occur in
+// other, like SQL EXCEPT. other is read into a set first; the stream is not
+// buffered.
+func Except[A comparable, F any](other iter.Seq[A], cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return ExceptBy(other, identity[A], cont)
+}
+
+// ExceptBy is Except comparing elements by keyFn.
+func ExceptBy[A any, K comparable, F any](other iter.Seq[A], keyFn func(A) K, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return filterByKeys(other, keyFn, false, cont)
+}
+
+func filterByKeys[A any, K comparable, F any](other iter.Seq[A], keyFn func(A) K, keep bool, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			keys := map[K]struct{}{}
+			for v := range other {
+				keys[keyFn(v)] = struct{}{}
+			}
+			seen := map[K]struct{}{}
+			for v := range seq {
+				key := keyFn(v)
+				if _, ok := keys[key]; ok != keep {
+					continue
+				}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func identity[A any](v A) A {
+	return v
+}
@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetOperators(t *testing.T) {
+	left := []int{3, 1, 2, 3, 5}
+	right := []int{5, 4, 3, 4}
+
+	cases := []struct {
+		name     string
+		run      func() []int
+		expected []int
+	}{
+		{"Union", func() []int {
+			return Stream(slices.Values(left), Union(slices.Values(right), End(Collect[int]())))
+		}, []int{3, 1, 2, 5, 4}},
+		{"Intersect", func() []int {
+			return Stream(slices.Values(left), Intersect(slices.Values(right), End(Collect[int]())))
+		}, []int{3, 5}},
+		{"Except", func() []int {
+			return Stream(slices.Values(left), Except(slices.Values(right), End(Collect[int]())))
+		}, []int{1, 2}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.run(); !slices.Equal(got, tc.expected) {
+				t.Fatalf("%s()=%v, expected %v", tc.name, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSetOperatorsByKey(t *testing.T) {
+	type account struct {
+		ID      string
+		Balance int
+	}
+	ledger := []account{{"a", 10}, {"b", 20}, {"c", 30}}
+	bank := []account{{"b", 21}, {"c", 30}, {"d", 40}}
+	byID := func(a account) string { return a.ID }
+
+	missing := Stream(slices.Values(ledger), ExceptBy(slices.Values(bank), byID, End(Collect[account]())))
+	if !slices.Equal(missing, []account{{"a", 10}}) {
+		t.Fatalf("ExceptBy()=%v, expected [{a 10}]", missing)
+	}
+	matched := Stream(slices.Values(ledger), IntersectBy(slices.Values(bank), byID, End(Collect[account]())))
+	if !slices.Equal(matched, []account{{"b", 20}, {"c", 30}}) {
+		t.Fatalf("IntersectBy()=%v, expected [{b 20} {c 30}]", matched)
+	}
+	all := Stream(slices.Values(ledger), UnionBy(slices.Values(bank), byID, End(Collect[account]())))
+	if !slices.Equal(all, []account{{"a", 10}, {"b", 20}, {"c", 30}, {"d", 40}}) {
+		t.Fatalf("UnionBy()=%v, expected ledger accounts then {d 40}", all)
+	}
+}
+
+func TestUnionStopsEarly(t *testing.T) {
+	otherRead := false
+	other := func(yield func(int) bool) {
+		otherRead = true
+		yield(100)
+	}
+	got := Stream(Range(0, 10, 1), Union(other, Take(3, End(Collect[int]()))))
+	if !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatalf("Union() with Take(3)=%v, expected [0 1 2]", got)
+	}
+	if otherRead {
+		t.Fatalf("Union() read other after the stream stopped")
+	}
+}
@@ -0,0 +1,353 @@
+// Package stream builds pipelines over iter.Seq in continuation style:
+// Stream(seq, Op(..., End(Terminal()))).
+package stream
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+)
+
+type AggregateResult[A any] struct {
+	Value A
+	OK    bool
+}
+
+func Stream[F, A any](seqA iter.Seq[A], cont func(iter.Seq[A]) F) F {
+	return cont(seqA)
+}
+
+func End[F any](f F) F {
+	return f
+}
+
+func Sort[F, A any](cmp func(A, A) int, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		elements := []A{}
+		for v := range seq {
+			elements = append(elements, v)
+		}
+		slices.SortFunc(elements, cmp)
+		return cont(slices.Values(elements))
+	}
+}
+
+func Filter[F, A any](fn func(A) bool, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			for v := range seq {
+				if fn(v) {
+					if !yield(v) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+func Map[F, A, B any](fn func(A) B, cont func(iter.Seq[B]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(B) bool) {
+			for v := range seq {
+				if !yield(fn(v)) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func FlatMap[F, A, B any](fn func(A) iter.Seq[B], cont func(iter.Seq[B]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(B) bool) {
+			for v := range seq {
+				for mapped := range fn(v) {
+					if !yield(mapped) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+func Distinct[A comparable, F any](cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			seen := map[A]struct{}{}
+			for v := range seq {
+				if _, ok := seen[v]; ok {
+					continue
+				}
+				seen[v] = struct{}{}
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func Take[A any, F any](n int, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			if n <= 0 {
+				return
+			}
+
+			count := 0
+			for v := range seq {
+				if !yield(v) {
+					return
+				}
+				count++
+				if count >= n {
+					return
+				}
+			}
+		})
+	}
+}
+
+// Page skips the first offset elements and passes the next limit, like
+// OFFSET and LIMIT in SQL. The source is abandoned as soon as the page is
+// full, so previewing a page near the start of a huge file reads only that
+// far. A negative offset is treated as 0; a non-positive limit yields
+// nothing.
+func Page[A any, F any](offset, limit int, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			if limit <= 0 {
+				return
+			}
+
+			skipped, count := 0, 0
+			for v := range seq {
+				if skipped < offset {
+					skipped++
+					continue
+				}
+				if !yield(v) {
+					return
+				}
+				count++
+				if count >= limit {
+					return
+				}
+			}
+		})
+	}
+}
+
+// TeeTo writes format(v) and a newline to w for each element as it passes
+// downstream unchanged, like tee in a shell pipeline. A nil format uses
+// fmt.Sprint. Only elements pulled by the rest of the pipeline are written.
+// Writes are unbuffered so output appears as the stream runs. The first
+// write error is passed to onErr, unless it is nil, and TeeTo then stops
+// writing but keeps passing elements on.
+func TeeTo[F, A any](w io.Writer, format func(A) string, onErr func(error), cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	if format == nil {
+		format = func(v A) string { return fmt.Sprint(v) }
+	}
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			failed := false
+			for v := range seq {
+				if !failed {
+					if _, err := io.WriteString(w, format(v)+"\n"); err != nil {
+						failed = true
+						if onErr != nil {
+							onErr(err)
+						}
+					}
+				}
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func Collect[E any]() func(iter.Seq[E]) []E {
+	return func(seq iter.Seq[E]) []E {
+		result := []E{}
+		for v := range seq {
+			result = append(result, v)
+		}
+		return result
+	}
+}
+
+// CollectInto appends the stream to dst and returns the extended slice, like
+// append. Passing a reused buffer truncated to zero length,
+// CollectInto(buf[:0]), lets hot loops running many small pipelines avoid
+// growing a new slice each time; the result shares dst's backing array
+// while it has room.
+func CollectInto[E any](dst []E) func(iter.Seq[E]) []E {
+	return func(seq iter.Seq[E]) []E {
+		for v := range seq {
+			dst = append(dst, v)
+		}
+		return dst
+	}
+}
+
+// SizeHints presizes the collections built by CollectWithHints and
+// GroupByWithHints, avoiding repeated growth when the result size is known
+// roughly in advance. Hints only affect allocation, never results; zero
+// values keep the defaults.
+type SizeHints struct {
+	// Elements is the expected number of elements.
+	Elements int
+	// Keys is the expected number of distinct keys.
+	Keys int
+}
+
+// CollectWithHints is Collect with its slice presized to hints.Elements.
+func CollectWithHints[E any](hints SizeHints) func(iter.Seq[E]) []E {
+	return func(seq iter.Seq[E]) []E {
+		result := make([]E, 0, max(hints.Elements, 0))
+		for v := range seq {
+			result = append(result, v)
+		}
+		return result
+	}
+}
+
+// CollectChunks returns the stream as an iterator of batches of up to size
+// elements, built lazily as the caller ranges over it, so only one batch is
+// held at a time. The pipeline runs while the iterator is consumed, and
+// stopping early stops it. Each batch is a new slice the caller may keep.
+// A size below 1 is treated as 1.
+func CollectChunks[E any](size int) func(iter.Seq[E]) iter.Seq[[]E] {
+	size = max(size, 1)
+	return func(seq iter.Seq[E]) iter.Seq[[]E] {
+		return func(yield func([]E) bool) {
+			chunk := make([]E, 0, size)
+			for v := range seq {
+				chunk = append(chunk, v)
+				if len(chunk) == size {
+					if !yield(chunk) {
+						return
+					}
+					chunk = make([]E, 0, size)
+				}
+			}
+			if len(chunk) > 0 {
+				yield(chunk)
+			}
+		}
+	}
+}
+
+func Reduce[A, R any](init R, fn func(R, A) R) func(iter.Seq[A]) R {
+	return func(seq iter.Seq[A]) R {
+		result := init
+		for v := range seq {
+			result = fn(result, v)
+		}
+		return result
+	}
+}
+
+func Count[A any]() func(iter.Seq[A]) int {
+	return func(seq iter.Seq[A]) int {
+		count := 0
+		for range seq {
+			count++
+		}
+		return count
+	}
+}
+
+// Drain consumes the stream only for the side effects of the stages before it
+// and returns errFn(), typically the source's Input.Err, once the stream is
+// exhausted. A nil errFn returns nil.
+func Drain[A any](errFn func() error) func(iter.Seq[A]) error {
+	return func(seq iter.Seq[A]) error {
+		for range seq {
+		}
+		if errFn == nil {
+			return nil
+		}
+		return errFn()
+	}
+}
+
+func Any[A any](pred func(A) bool) func(iter.Seq[A]) bool {
+	return func(seq iter.Seq[A]) bool {
+		for v := range seq {
+			if pred(v) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func All[A any](pred func(A) bool) func(iter.Seq[A]) bool {
+	return func(seq iter.Seq[A]) bool {
+		for v := range seq {
+			if !pred(v) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func First[A any]() func(iter.Seq[A]) AggregateResult[A] {
+	return func(seq iter.Seq[A]) AggregateResult[A] {
+		for v := range seq {
+			return AggregateResult[A]{Value: v, OK: true}
+		}
+		return AggregateResult[A]{}
+	}
+}
+
+func Last[A any]() func(iter.Seq[A]) AggregateResult[A] {
+	return func(seq iter.Seq[A]) AggregateResult[A] {
+		var last A
+		ok := false
+		for v := range seq {
+			last = v
+			ok = true
+		}
+		return AggregateResult[A]{Value: last, OK: ok}
+	}
+}
+
+func GroupBy[A any, K comparable](keyFn func(A) K) func(iter.Seq[A]) map[K][]A {
+	return func(seq iter.Seq[A]) map[K][]A {
+		result := map[K][]A{}
+		for v := range seq {
+			key := keyFn(v)
+			result[key] = append(result[key], v)
+		}
+		return result
+	}
+}
+
+// GroupByWithHints is GroupBy with its map presized to hints.Keys and each
+// group presized to an even share of hints.Elements.
+func GroupByWithHints[A any, K comparable](keyFn func(A) K, hints SizeHints) func(iter.Seq[A]) map[K][]A {
+	perKey := 0
+	if hints.Keys > 0 && hints.Elements > 0 {
+		perKey = (hints.Elements + hints.Keys - 1) / hints.Keys
+	}
+	return func(seq iter.Seq[A]) map[K][]A {
+		result := make(map[K][]A, max(hints.Keys, 0))
+		for v := range seq {
+			key := keyFn(v)
+			group, ok := result[key]
+			if !ok && perKey > 0 {
+				group = make([]A, 0, perKey)
+			}
+			result[key] = append(group, v)
+		}
+		return result
+	}
+}
@@ -1,13 +1,42 @@
-package main
+package stream
 
 import (
+	"bytes"
 	"cmp"
+	"errors"
 	"iter"
+	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
+	"strconv"
 	"testing"
+
+	"github.com/donkomura/go-stream/input"
 )
 
+// failingWriter accepts limit bytes and then fails.
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("write failed")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func writeTextFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
 func TestStreamContinuationStyle(t *testing.T) {
 	t.Run("Filter -> Map -> Collect", func(t *testing.T) {
 		data := []int{1, 2, 3, 4, 5, 6}
@@ -117,6 +146,40 @@ func TestStreamContinuationStyle(t *testing.T) {
 		}
 	})
 
+	t.Run("TeeTo -> Filter -> Take -> Collect", func(t *testing.T) {
+		data := []int{1, 2, 3, 4, 5, 6}
+		var audit bytes.Buffer
+
+		result := Stream(
+			slices.Values(data),
+			TeeTo(&audit, func(n int) string { return "saw " + strconv.Itoa(n) }, nil,
+				Filter(func(n int) bool { return n%2 == 0 },
+					Take(2,
+						End(Collect[int]()),
+					),
+				),
+			),
+		)
+
+		expected := []int{2, 4}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Stream() = %v, expected %v", result, expected)
+		}
+		if want := "saw 1\nsaw 2\nsaw 3\nsaw 4\n"; audit.String() != want {
+			t.Errorf("tee output = %q, expected %q", audit.String(), want)
+		}
+
+		var writeErrs []error
+		onErr := func(err error) { writeErrs = append(writeErrs, err) }
+		result = Stream(slices.Values(data), TeeTo(&failingWriter{limit: 4}, nil, onErr, End(Collect[int]())))
+		if !reflect.DeepEqual(result, data) {
+			t.Errorf("Stream() = %v, expected %v after a write error", result, data)
+		}
+		if len(writeErrs) != 1 {
+			t.Errorf("onErr called with %v, expected one write error", writeErrs)
+		}
+	})
+
 	t.Run("Filter only with Collect", func(t *testing.T) {
 		data := []int{1, 2, 3, 4, 5}
 
@@ -271,6 +334,34 @@ func TestAggregateFunctions(t *testing.T) {
 		}
 	})
 
+	t.Run("Drain runs side effects and returns the source error", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "a.txt")
+		writeTextFile(t, path, "x\ny\n")
+		source := input.ParseFiles[string](input.NewFileStream([]string{path, filepath.Join(dir, "missing.txt")}), input.LineParser{})
+
+		seen := []string{}
+		err := Stream(
+			source.Seq,
+			Map(func(s string) string {
+				seen = append(seen, s)
+				return s
+			},
+				End(Drain[string](source.Err)),
+			),
+		)
+
+		if err == nil {
+			t.Errorf("Drain() = nil, expected the missing file error")
+		}
+		if !slices.Equal(seen, []string{"x", "y"}) {
+			t.Errorf("side effects saw %v, expected [x y]", seen)
+		}
+		if err := Stream(slices.Values([]int{1, 2}), End(Drain[int](nil))); err != nil {
+			t.Errorf("Drain(nil) = %v, expected nil", err)
+		}
+	})
+
 	t.Run("Any returns true when one element matches", func(t *testing.T) {
 		data := []int{1, 3, 4, 7}
 
@@ -360,3 +451,129 @@ func TestAggregateFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestPage(t *testing.T) {
+	cases := []struct {
+		name          string
+		offset, limit int
+		expected      []int
+	}{
+		{"first page", 0, 3, []int{0, 1, 2}},
+		{"middle page", 3, 3, []int{3, 4, 5}},
+		{"partial last page", 8, 5, []int{8, 9}},
+		{"past the end", 20, 5, []int{}},
+		{"negative offset", -2, 2, []int{0, 1}},
+		{"zero limit", 0, 0, []int{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Stream(Range(0, 10, 1), Page(tc.offset, tc.limit, End(Collect[int]())))
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("Page(%d, %d) = %v, expected %v", tc.offset, tc.limit, result, tc.expected)
+			}
+		})
+	}
+
+	t.Run("stops the source once the page is full", func(t *testing.T) {
+		pulled := 0
+		source := func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				pulled++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		result := Stream(source, Page(5, 2, End(Collect[int]())))
+		if !reflect.DeepEqual(result, []int{5, 6}) {
+			t.Errorf("Page(5, 2) = %v, expected [5 6]", result)
+		}
+		if pulled != 7 {
+			t.Errorf("pulled %d elements, expected 7", pulled)
+		}
+	})
+}
+
+func TestCollectChunks(t *testing.T) {
+	t.Run("yields batches with a short last one", func(t *testing.T) {
+		chunks := Stream(Range(0, 7, 1), Filter(func(n int) bool { return n != 3 }, End(CollectChunks[int](2))))
+
+		var got [][]int
+		for chunk := range chunks {
+			got = append(got, chunk)
+		}
+		expected := [][]int{{0, 1}, {2, 4}, {5, 6}}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("CollectChunks(2) = %v, expected %v", got, expected)
+		}
+	})
+
+	t.Run("runs lazily and stops with the caller", func(t *testing.T) {
+		pulled := 0
+		source := func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				pulled++
+				if !yield(i) {
+					return
+				}
+			}
+		}
+
+		chunks := Stream(source, End(CollectChunks[int](3)))
+		if pulled != 0 {
+			t.Fatalf("pulled %d elements before ranging, expected 0", pulled)
+		}
+		for chunk := range chunks {
+			if !reflect.DeepEqual(chunk, []int{0, 1, 2}) {
+				t.Errorf("first chunk = %v, expected [0 1 2]", chunk)
+			}
+			break
+		}
+		if pulled != 3 {
+			t.Errorf("pulled %d elements, expected 3", pulled)
+		}
+	})
+
+	t.Run("empty stream yields nothing", func(t *testing.T) {
+		for chunk := range Stream(Range(0, 0, 1), End(CollectChunks[int](0))) {
+			t.Errorf("unexpected chunk %v", chunk)
+		}
+	})
+}
+
+func TestSizeHints(t *testing.T) {
+	collected := Stream(Range(0, 5, 1), End(CollectWithHints[int](SizeHints{Elements: 100})))
+	if !reflect.DeepEqual(collected, []int{0, 1, 2, 3, 4}) || cap(collected) != 100 {
+		t.Errorf("CollectWithHints() = %v (cap %d), expected [0 1 2 3 4] with cap 100", collected, cap(collected))
+	}
+	if empty := Stream(Range(0, 0, 1), End(CollectWithHints[int](SizeHints{}))); empty == nil || len(empty) != 0 {
+		t.Errorf("CollectWithHints() on an empty stream = %#v, expected an empty slice", empty)
+	}
+
+	groups := Stream(Range(0, 10, 1), End(GroupByWithHints(func(n int) bool { return n%2 == 0 }, SizeHints{Elements: 10, Keys: 2})))
+	plain := Stream(Range(0, 10, 1), End(GroupBy(func(n int) bool { return n%2 == 0 })))
+	if !reflect.DeepEqual(groups, plain) {
+		t.Errorf("GroupByWithHints() = %v, expected %v", groups, plain)
+	}
+	if cap(groups[true]) != 5 {
+		t.Errorf("group capacity = %d, expected 5", cap(groups[true]))
+	}
+}
+
+func TestCollectInto(t *testing.T) {
+	buf := make([]int, 0, 8)
+	for _, n := range []int{3, 5, 2} {
+		buf = Stream(Range(0, n, 1), End(CollectInto(buf[:0])))
+		if len(buf) != n || cap(buf) != 8 {
+			t.Fatalf("CollectInto() = %v (cap %d), expected %d elements in the reused buffer", buf, cap(buf), n)
+		}
+	}
+
+	prefixed := Stream(slices.Values([]int{3, 4}), End(CollectInto([]int{1, 2})))
+	if !reflect.DeepEqual(prefixed, []int{1, 2, 3, 4}) {
+		t.Errorf("CollectInto([1 2]) = %v, expected [1 2 3 4]", prefixed)
+	}
+	if got := Stream(Range(0, 0, 1), End(CollectInto[int](nil))); got != nil {
+		t.Errorf("CollectInto(nil) on an empty stream = %v, expected nil", got)
+	}
+}
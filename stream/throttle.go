@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"errors"
+	"iter"
+	"time"
+)
+
+var errInvalidRate = errors.New("rate must be > 0")
+
+// Throttle paces the stream to ratePerSec elements per second with a token
+// bucket: up to burst elements pass at once, after which each element waits
+// for the next token. Place it before a stage that calls a rate-limited API
+// so requests stay within its quota. Waiting blocks the pipeline. A burst
+// below 1 is treated as 1, and Throttle panics if ratePerSec is not > 0.
+func Throttle[F, A any](ratePerSec float64, burst int, cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	return throttle(ratePerSec, burst, time.Now, time.Sleep, cont)
+}
+
+func throttle[F, A any](ratePerSec float64, burst int, now func() time.Time, sleep func(time.Duration), cont func(iter.Seq[A]) F) func(iter.Seq[A]) F {
+	if !(ratePerSec > 0) {
+		panic(errInvalidRate)
+	}
+	capacity := float64(max(burst, 1))
+
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(A) bool) {
+			tokens := capacity
+			last := now()
+			for v := range seq {
+				current := now()
+				tokens = min(capacity, tokens+current.Sub(last).Seconds()*ratePerSec)
+				last = current
+				if tokens < 1 {
+					sleep(time.Duration((1 - tokens) / ratePerSec * float64(time.Second)))
+					last = now()
+					tokens = 1
+				}
+				tokens--
+				if !yield(v) {
+					return
+				}
+			}
+		})
+	}
+}
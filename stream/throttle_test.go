@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	var emitted []time.Duration
+	clock := func() time.Time { return now }
+	sleep := func(d time.Duration) { now = now.Add(d) }
+	record := func(v int) int {
+		emitted = append(emitted, now.Sub(start))
+		return v
+	}
+
+	got := Stream(slices.Values([]int{1, 2, 3, 4, 5}), throttle(10, 2, clock, sleep, Map(record, End(Collect[int]()))))
+	if !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("Throttle() passed %v, expected all elements", got)
+	}
+	// A burst of two, then one element every 100ms.
+	ms := time.Millisecond
+	if want := []time.Duration{0, 0, 100 * ms, 200 * ms, 300 * ms}; !slices.Equal(emitted, want) {
+		t.Fatalf("emission times = %v, expected %v", emitted, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic for ratePerSec=0")
+		}
+	}()
+	Throttle[int, int](0, 1, End(Count[int]()))
+}
+
+func TestThrottleRealClock(t *testing.T) {
+	begin := time.Now()
+	n := Stream(slices.Values([]int{1, 2, 3, 4}), Throttle(100, 1, End(Count[int]())))
+	if elapsed := time.Since(begin); n != 4 || elapsed < 25*time.Millisecond {
+		t.Fatalf("Throttle() passed %d elements in %v, expected 4 in at least 25ms", n, elapsed)
+	}
+}
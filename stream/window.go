@@ -0,0 +1,406 @@
+package stream
+
+import (
+	"container/heap"
+	"iter"
+	"slices"
+	"time"
+)
+
+// WindowCount groups the stream into consecutive non-overlapping windows of
+// n elements, the last one holding the remainder. Each window is a new
+// slice, so it can be kept; pass it through slices.Values to run any
+// terminal on it. n below 1 is treated as 1.
+func WindowCount[F, A any](n int, cont func(iter.Seq[[]A]) F) func(iter.Seq[A]) F {
+	n = max(n, 1)
+
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func([]A) bool) {
+			window := make([]A, 0, n)
+			for v := range seq {
+				window = append(window, v)
+				if len(window) < n {
+					continue
+				}
+				if !yield(window) {
+					return
+				}
+				window = make([]A, 0, n)
+			}
+			if len(window) > 0 {
+				yield(window)
+			}
+		})
+	}
+}
+
+// TimeWindow is a window of elements by event time, holding the elements
+// with Start <= timeFn(v) < End in arrival order. Late is set on the late
+// updates emitted under LateMerge.
+type TimeWindow[A any] struct {
+	Start time.Time
+	End   time.Time
+	Items []A
+	Late  bool
+}
+
+// LatePolicy decides what happens to an element that arrives after a window
+// it belongs to was emitted.
+type LatePolicy int
+
+const (
+	// LateDrop leaves late elements out of the windows already emitted.
+	LateDrop LatePolicy = iota
+	// LateSideOutput passes late elements to WindowOptions.OnLate.
+	LateSideOutput
+	// LateMerge emits, for every window a late element missed, a window with
+	// the same bounds holding only that element and Late set, to be merged
+	// into the earlier result downstream.
+	LateMerge
+)
+
+// WindowOptions configures the event-time window operators.
+type WindowOptions[A any] struct {
+	// MaxOutOfOrder is how far an element's time may lag behind the latest
+	// time seen and still count. The watermark trails the latest time by
+	// this much and a window is emitted once the watermark reaches its end,
+	// so a larger value tolerates more disorder at the cost of holding
+	// windows open longer.
+	MaxOutOfOrder time.Duration
+	// Late handles elements older than the watermark whose windows were
+	// already emitted. Defaults to LateDrop.
+	Late LatePolicy
+	// OnLate receives late elements under LateSideOutput, e.g. to count
+	// or log them.
+	OnLate func(A)
+}
+
+// WindowByTime assigns elements to tumbling windows of the given size by the
+// event time timeFn returns, e.g. the timestamp of a log line, and emits each
+// non-empty window once the stream reaches its end time. Windows are aligned
+// to multiples of size since the zero time, so a size of time.Minute gives
+// UTC minutes. The remaining windows are emitted in time order when the
+// stream ends.
+//
+// The stream is expected in time order: an element whose window has already
+// been emitted is dropped. Use WindowByTimeWithOptions for out-of-order
+// input. A size below 1ns is treated as 1ns.
+func WindowByTime[F, A any](timeFn func(A) time.Time, size time.Duration, cont func(iter.Seq[TimeWindow[A]]) F) func(iter.Seq[A]) F {
+	return WindowByTimeWithOptions(timeFn, size, WindowOptions[A]{}, cont)
+}
+
+// WindowByTimeWithOptions is WindowByTime with a watermark allowing for
+// out-of-order elements and a policy for elements later than that.
+func WindowByTimeWithOptions[F, A any](timeFn func(A) time.Time, size time.Duration, opts WindowOptions[A], cont func(iter.Seq[TimeWindow[A]]) F) func(iter.Seq[A]) F {
+	size = max(size, 1)
+
+	return windowByTime(timeFn, size, size, opts, cont)
+}
+
+// SlidingWindowByTime is WindowByTime with windows of the given size starting
+// every slide, so with a slide shorter than size each element is in several
+// overlapping windows, e.g. a 5 minute window every minute for a moving
+// rate or average. Windows are aligned to multiples of slide since the zero
+// time. A slide longer than size leaves gaps whose elements are in no
+// window. Durations below 1ns are treated as 1ns.
+func SlidingWindowByTime[F, A any](timeFn func(A) time.Time, size, slide time.Duration, cont func(iter.Seq[TimeWindow[A]]) F) func(iter.Seq[A]) F {
+	return SlidingWindowByTimeWithOptions(timeFn, size, slide, WindowOptions[A]{}, cont)
+}
+
+// SlidingWindowByTimeWithOptions is SlidingWindowByTime with a watermark and
+// late policy as in WindowByTimeWithOptions. An element is late if any of its
+// windows was emitted; it still joins those that are open.
+func SlidingWindowByTimeWithOptions[F, A any](timeFn func(A) time.Time, size, slide time.Duration, opts WindowOptions[A], cont func(iter.Seq[TimeWindow[A]]) F) func(iter.Seq[A]) F {
+	return windowByTime(timeFn, max(size, 1), max(slide, 1), opts, cont)
+}
+
+func windowByTime[F, A any](timeFn func(A) time.Time, size, slide time.Duration, opts WindowOptions[A], cont func(iter.Seq[TimeWindow[A]]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(TimeWindow[A]) bool) {
+			w := newTimeWindower[A](size, slide, max(opts.MaxOutOfOrder, 0))
+			for v := range seq {
+				missed := w.add(v, timeFn(v))
+				if len(missed) > 0 {
+					switch opts.Late {
+					case LateSideOutput:
+						if opts.OnLate != nil {
+							opts.OnLate(v)
+						}
+					case LateMerge:
+						for _, window := range missed {
+							if !yield(window) {
+								return
+							}
+						}
+					}
+				}
+				for _, window := range w.closed() {
+					if !yield(window) {
+						return
+					}
+				}
+			}
+			for _, window := range w.flush() {
+				if !yield(window) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// timeWindower assigns elements to windows of size starting every slide and
+// closes them by the watermark, the latest event time seen less the allowed
+// out-of-orderness.
+type timeWindower[A any] struct {
+	size       time.Duration
+	slide      time.Duration
+	outOfOrder time.Duration
+	open       map[int64]*TimeWindow[A]
+	latest     time.Time
+	watermark  time.Time
+}
+
+func newTimeWindower[A any](size, slide, outOfOrder time.Duration) *timeWindower[A] {
+	return &timeWindower[A]{
+		size:       size,
+		slide:      slide,
+		outOfOrder: outOfOrder,
+		open:       make(map[int64]*TimeWindow[A]),
+		watermark:  time.Time{}.Add(-outOfOrder),
+	}
+}
+
+// add adds v to the windows containing t that are still open and returns
+// the already closed windows it missed, as late windows holding only v.
+func (w *timeWindower[A]) add(v A, t time.Time) []TimeWindow[A] {
+	if t.After(w.latest) {
+		w.latest = t
+		w.watermark = t.Add(-w.outOfOrder)
+	}
+	var missed []TimeWindow[A]
+	// The latest window containing t starts at t truncated to the slide;
+	// earlier ones start a slide apart while they still contain t.
+	for start := t.Truncate(w.slide); t.Before(start.Add(w.size)); start = start.Add(-w.slide) {
+		end := start.Add(w.size)
+		if !w.watermark.Before(end) {
+			missed = append(missed, TimeWindow[A]{Start: start, End: end, Items: []A{v}, Late: true})
+			continue
+		}
+		key := start.UnixNano()
+		window, ok := w.open[key]
+		if !ok {
+			window = &TimeWindow[A]{Start: start, End: end}
+			w.open[key] = window
+		}
+		window.Items = append(window.Items, v)
+	}
+	slices.Reverse(missed)
+	return missed
+}
+
+// closed removes and returns the windows that end at or before the
+// watermark, oldest first.
+func (w *timeWindower[A]) closed() []TimeWindow[A] {
+	return w.take(func(window *TimeWindow[A]) bool { return !w.watermark.Before(window.End) })
+}
+
+// flush removes and returns all open windows, oldest first.
+func (w *timeWindower[A]) flush() []TimeWindow[A] {
+	return w.take(func(*TimeWindow[A]) bool { return true })
+}
+
+func (w *timeWindower[A]) take(done func(*TimeWindow[A]) bool) []TimeWindow[A] {
+	var windows []TimeWindow[A]
+	for key, window := range w.open {
+		if done(window) {
+			windows = append(windows, *window)
+			delete(w.open, key)
+		}
+	}
+	slices.SortFunc(windows, func(a, b TimeWindow[A]) int { return a.Start.Compare(b.Start) })
+	return windows
+}
+
+// SessionWindow is a session of one key: elements no more than the gap apart
+// in event time. Start and End are the times of its first and last element.
+type SessionWindow[K comparable, A any] struct {
+	Key   K
+	Start time.Time
+	End   time.Time
+	Items []A
+}
+
+// SessionResult is the aggregate of one session computed by SessionCollect.
+type SessionResult[K comparable, R any] struct {
+	Key   K
+	Start time.Time
+	End   time.Time
+	Count int
+	Value R
+}
+
+// SessionWindows groups elements by keyFn, e.g. a user ID, into sessions
+// that close once the stream's event time passes more than gap beyond
+// their last element. Closed sessions are emitted in the order they close,
+// and the remaining ones in start order when the stream ends.
+//
+// The stream is expected in time order. An element older than the latest
+// time seen joins its key's open session; if that session has already
+// closed it starts a new one.
+func SessionWindows[F, A any, K comparable](keyFn func(A) K, timeFn func(A) time.Time, gap time.Duration, cont func(iter.Seq[SessionWindow[K, A]]) F) func(iter.Seq[A]) F {
+	return func(seq iter.Seq[A]) F {
+		return cont(func(yield func(SessionWindow[K, A]) bool) {
+			s := newSessionizer[K, A](gap)
+			for v := range seq {
+				t := timeFn(v)
+				// Sessions that time out by t close before v can join them.
+				for _, session := range s.advance(t) {
+					if !yield(session) {
+						return
+					}
+				}
+				s.add(keyFn(v), v, t)
+			}
+			for _, session := range s.flush() {
+				if !yield(session) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// SessionCollect sessionizes the stream like SessionWindows and runs agg, any
+// terminal such as Count or TopKCollect, over the elements of each session.
+func SessionCollect[A any, K comparable, R any](keyFn func(A) K, timeFn func(A) time.Time, gap time.Duration, agg func(iter.Seq[A]) R) func(iter.Seq[A]) []SessionResult[K, R] {
+	return SessionWindows(keyFn, timeFn, gap, AggregateSessions(agg, End(Collect[SessionResult[K, R]]())))
+}
+
+// WindowResult is the aggregate of one TimeWindow computed by
+// AggregateTimeWindows.
+type WindowResult[R any] struct {
+	Start time.Time
+	End   time.Time
+	Count int
+	Value R
+	Late  bool
+}
+
+// AggregateWindows runs agg, any terminal such as Count, Reduce or
+// TopKCollect, over each window from WindowCount and passes the results on
+// as a stream.
+func AggregateWindows[F, A, R any](agg func(iter.Seq[A]) R, cont func(iter.Seq[R]) F) func(iter.Seq[[]A]) F {
+	return Map(func(window []A) R { return agg(slices.Values(window)) }, cont)
+}
+
+// AggregateTimeWindows runs agg over each window from WindowByTime or
+// SlidingWindowByTime and passes the results on with the window bounds, e.g.
+// per-minute counts to a sink.
+func AggregateTimeWindows[F, A, R any](agg func(iter.Seq[A]) R, cont func(iter.Seq[WindowResult[R]]) F) func(iter.Seq[TimeWindow[A]]) F {
+	return Map(func(w TimeWindow[A]) WindowResult[R] {
+		return WindowResult[R]{
+			Start: w.Start,
+			End:   w.End,
+			Count: len(w.Items),
+			Value: agg(slices.Values(w.Items)),
+			Late:  w.Late,
+		}
+	}, cont)
+}
+
+// AggregateSessions runs agg over each session from SessionWindows and passes
+// the results on with the session key and bounds.
+func AggregateSessions[F, A any, K comparable, R any](agg func(iter.Seq[A]) R, cont func(iter.Seq[SessionResult[K, R]]) F) func(iter.Seq[SessionWindow[K, A]]) F {
+	return Map(func(s SessionWindow[K, A]) SessionResult[K, R] {
+		return SessionResult[K, R]{
+			Key:   s.Key,
+			Start: s.Start,
+			End:   s.End,
+			Count: len(s.Items),
+			Value: agg(slices.Values(s.Items)),
+		}
+	}, cont)
+}
+
+// sessionizer tracks the open sessions per key. Deadlines are kept in a heap
+// with one entry per element; entries made stale by a later element of the
+// session are skipped when popped.
+type sessionizer[K comparable, A any] struct {
+	gap       time.Duration
+	open      map[K]*SessionWindow[K, A]
+	deadlines sessionHeap[K]
+	watermark time.Time
+}
+
+func newSessionizer[K comparable, A any](gap time.Duration) *sessionizer[K, A] {
+	return &sessionizer[K, A]{gap: gap, open: make(map[K]*SessionWindow[K, A])}
+}
+
+func (s *sessionizer[K, A]) add(key K, v A, t time.Time) {
+	session, ok := s.open[key]
+	if !ok {
+		session = &SessionWindow[K, A]{Key: key, Start: t, End: t}
+		s.open[key] = session
+	}
+	session.Items = append(session.Items, v)
+	if t.Before(session.Start) {
+		session.Start = t
+	}
+	if t.After(session.End) || !ok {
+		session.End = t
+		heap.Push(&s.deadlines, sessionDeadline[K]{key: key, last: t})
+	}
+}
+
+// advance moves the watermark to t if it is later, then removes and returns
+// the sessions whose last element is more than the gap before it.
+func (s *sessionizer[K, A]) advance(t time.Time) []SessionWindow[K, A] {
+	if t.After(s.watermark) {
+		s.watermark = t
+	}
+	var sessions []SessionWindow[K, A]
+	for s.deadlines.Len() > 0 && s.watermark.Sub(s.deadlines.items[0].last) > s.gap {
+		d := heap.Pop(&s.deadlines).(sessionDeadline[K])
+		if session := s.open[d.key]; session != nil && session.End.Equal(d.last) {
+			sessions = append(sessions, *session)
+			delete(s.open, d.key)
+		}
+	}
+	return sessions
+}
+
+// flush removes and returns all open sessions in start order.
+func (s *sessionizer[K, A]) flush() []SessionWindow[K, A] {
+	sessions := make([]SessionWindow[K, A], 0, len(s.open))
+	for _, session := range s.open {
+		sessions = append(sessions, *session)
+	}
+	clear(s.open)
+	s.deadlines.items = nil
+	slices.SortFunc(sessions, func(a, b SessionWindow[K, A]) int { return a.Start.Compare(b.Start) })
+	return sessions
+}
+
+type sessionDeadline[K comparable] struct {
+	key  K
+	last time.Time
+}
+
+type sessionHeap[K comparable] struct {
+	items []sessionDeadline[K]
+}
+
+func (h *sessionHeap[K]) Len() int { return len(h.items) }
+
+func (h *sessionHeap[K]) Less(i, j int) bool { return h.items[i].last.Before(h.items[j].last) }
+
+func (h *sessionHeap[K]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *sessionHeap[K]) Push(x any) { h.items = append(h.items, x.(sessionDeadline[K])) }
+
+func (h *sessionHeap[K]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,210 @@
+package stream
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donkomura/go-stream/input"
+)
+
+func TestWindowCount(t *testing.T) {
+	sums := Stream(slices.Values([]int{1, 2, 3, 4, 5, 6, 7}), WindowCount(3, Map(func(w []int) int {
+		return Stream(slices.Values(w), End(Reduce(0, func(acc, v int) int { return acc + v })))
+	}, End(Collect[int]()))))
+	if want := []int{6, 15, 7}; !slices.Equal(sums, want) {
+		t.Fatalf("window sums = %v, expected %v", sums, want)
+	}
+
+	windows := Stream(slices.Values([]int{1, 2, 3, 4}), WindowCount(2, Take(1, End(Collect[[]int]()))))
+	if len(windows) != 1 || !slices.Equal(windows[0], []int{1, 2}) {
+		t.Fatalf("windows = %v, expected [[1 2]]", windows)
+	}
+
+	singles := Stream(slices.Values([]int{1, 2}), WindowCount(0, End(Count[[]int]())))
+	if singles != 2 {
+		t.Fatalf("Count()=%d, expected 2 windows for n=0", singles)
+	}
+}
+
+type timedEvent struct {
+	At   time.Time
+	Name string
+}
+
+func eventNames(events []timedEvent) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.Name
+	}
+	return names
+}
+
+func TestWindowByTime(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int, name string) timedEvent {
+		return timedEvent{At: base.Add(time.Duration(sec) * time.Second), Name: name}
+	}
+	events := []timedEvent{at(5, "a"), at(30, "b"), at(59, "c"), at(61, "d"), at(20, "late"), at(190, "e")}
+
+	windows := Stream(slices.Values(events), WindowByTime(func(e timedEvent) time.Time { return e.At }, time.Minute, End(Collect[TimeWindow[timedEvent]]())))
+	want := []struct {
+		start time.Time
+		names []string
+	}{
+		{base, []string{"a", "b", "c"}},
+		{base.Add(time.Minute), []string{"d"}},
+		{base.Add(3 * time.Minute), []string{"e"}},
+	}
+	if len(windows) != len(want) {
+		t.Fatalf("got %d windows, expected %d", len(windows), len(want))
+	}
+	for i, w := range want {
+		got := windows[i]
+		if !got.Start.Equal(w.start) || !got.End.Equal(w.start.Add(time.Minute)) || !slices.Equal(eventNames(got.Items), w.names) {
+			t.Fatalf("window %d = %v-%v %v, expected %v %v", i, got.Start, got.End, eventNames(got.Items), w.start, w.names)
+		}
+	}
+}
+
+func TestSlidingWindowByTime(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	values := []int{0, 10, 20, 30, 40, 50}
+	timeFn := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	type avg struct {
+		start int
+		mean  float64
+	}
+	got := Stream(slices.Values(values), SlidingWindowByTime(timeFn, 30*time.Second, 20*time.Second, Map(func(w TimeWindow[int]) avg {
+		sum := Stream(slices.Values(w.Items), End(Reduce(0, func(acc, v int) int { return acc + v })))
+		return avg{start: int(w.Start.Sub(base).Seconds()), mean: float64(sum) / float64(len(w.Items))}
+	}, End(Collect[avg]()))))
+	// Windows start every 20s and span 30s: [-20,10) [0,30) [20,50) [40,70).
+	want := []avg{{-20, 0}, {0, 10}, {20, 30}, {40, 45}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("moving averages = %v, expected %v", got, want)
+	}
+
+	gaps := Stream(slices.Values(values), SlidingWindowByTime(timeFn, 10*time.Second, 30*time.Second, End(Collect[TimeWindow[int]]())))
+	if len(gaps) != 2 || !slices.Equal(gaps[0].Items, []int{0}) || !slices.Equal(gaps[1].Items, []int{30}) {
+		t.Fatalf("hopping windows = %v, expected [0] and [30]", gaps)
+	}
+}
+
+type click struct {
+	User string
+	Min  int
+	Page string
+}
+
+func TestSessionWindows(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeFn := func(c click) time.Time { return base.Add(time.Duration(c.Min) * time.Minute) }
+	userFn := func(c click) string { return c.User }
+	clicks := []click{
+		{"ann", 0, "home"}, {"bob", 5, "home"}, {"ann", 10, "cart"}, {"ann", 35, "pay"},
+		{"bob", 50, "home"}, {"ann", 70, "home"}, {"bob", 75, "cart"},
+	}
+
+	sessions := Stream(slices.Values(clicks), SessionWindows(userFn, timeFn, 30*time.Minute, End(Collect[SessionWindow[string, click]]())))
+	type session struct {
+		user       string
+		start, end int
+		pages      int
+	}
+	var got []session
+	for _, s := range sessions {
+		got = append(got, session{s.Key, int(s.Start.Sub(base).Minutes()), int(s.End.Sub(base).Minutes()), len(s.Items)})
+	}
+	// bob's first session closes at minute 50 and ann's at 70; the rest
+	// are flushed in start order.
+	want := []session{{"bob", 5, 5, 1}, {"ann", 0, 35, 3}, {"bob", 50, 75, 2}, {"ann", 70, 70, 1}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("sessions = %v, expected %v", got, want)
+	}
+
+	results := Stream(slices.Values(clicks), End(SessionCollect(userFn, timeFn, 30*time.Minute, Count[click]())))
+	if len(results) != 4 || results[1].Key != "ann" || results[1].Value != 3 || results[1].Count != 3 {
+		t.Fatalf("SessionCollect() = %v, expected ann's first session with 3 clicks second", results)
+	}
+}
+
+func TestWindowByTimeWithOptions(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int, name string) timedEvent {
+		return timedEvent{At: base.Add(time.Duration(sec) * time.Second), Name: name}
+	}
+	timeFn := func(e timedEvent) time.Time { return e.At }
+	// "b" is 15s out of order, "late" 90s.
+	events := []timedEvent{at(10, "a"), at(65, "c"), at(50, "b"), at(100, "d"), at(20, "late"), at(130, "e")}
+
+	var sideOutput []string
+	opts := WindowOptions[timedEvent]{
+		MaxOutOfOrder: 30 * time.Second,
+		Late:          LateSideOutput,
+		OnLate:        func(e timedEvent) { sideOutput = append(sideOutput, e.Name) },
+	}
+	windows := Stream(slices.Values(events), WindowByTimeWithOptions(timeFn, time.Minute, opts, End(Collect[TimeWindow[timedEvent]]())))
+	if len(windows) != 3 || !slices.Equal(eventNames(windows[0].Items), []string{"a", "b"}) {
+		t.Fatalf("windows = %v, expected [a b] first of 3", windows)
+	}
+	if !slices.Equal(sideOutput, []string{"late"}) {
+		t.Fatalf("side output = %v, expected [late]", sideOutput)
+	}
+
+	opts = WindowOptions[timedEvent]{MaxOutOfOrder: 30 * time.Second, Late: LateMerge}
+	windows = Stream(slices.Values(events), WindowByTimeWithOptions(timeFn, time.Minute, opts, End(Collect[TimeWindow[timedEvent]]())))
+	var got []string
+	for _, w := range windows {
+		got = append(got, fmt.Sprintf("%d%v%v", int(w.Start.Sub(base).Seconds()), eventNames(w.Items), w.Late))
+	}
+	if want := []string{"0[a b]false", "0[late]true", "60[c d]false", "120[e]false"}; !slices.Equal(got, want) {
+		t.Fatalf("windows = %v, expected %v", got, want)
+	}
+
+	// Without a watermark allowance "b" is late and dropped.
+	windows = Stream(slices.Values(events), WindowByTime(timeFn, time.Minute, End(Collect[TimeWindow[timedEvent]]())))
+	if !slices.Equal(eventNames(windows[0].Items), []string{"a"}) {
+		t.Fatalf("first window = %v, expected [a]", eventNames(windows[0].Items))
+	}
+}
+
+func TestSlidingWindowLateMerge(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeFn := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+	opts := WindowOptions[int]{Late: LateMerge}
+	windows := Stream(slices.Values([]int{0, 25, 15}), SlidingWindowByTimeWithOptions(timeFn, 20*time.Second, 10*time.Second, opts, End(Collect[TimeWindow[int]]())))
+	var got []string
+	for _, w := range windows {
+		got = append(got, fmt.Sprintf("%d%v%v", int(w.Start.Sub(base).Seconds()), w.Items, w.Late))
+	}
+	// 15 misses the closed window [0,20) but joins the open [10,30).
+	if want := []string{"-10[0]false", "0[0]false", "0[15]true", "10[25 15]false", "20[25]false"}; !slices.Equal(got, want) {
+		t.Fatalf("windows = %v, expected %v", got, want)
+	}
+}
+
+func TestAggregateWindows(t *testing.T) {
+	maxOf := Reduce(0, func(acc, v int) int { return max(acc, v) })
+	got := Stream(slices.Values([]int{3, 1, 4, 1, 5, 9, 2}), WindowCount(3, AggregateWindows(maxOf, End(Collect[int]()))))
+	if want := []int{4, 9, 2}; !slices.Equal(got, want) {
+		t.Fatalf("window maxima = %v, expected %v", got, want)
+	}
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeFn := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+	var out strings.Builder
+	seconds := []int{1, 2, 30, 61, 62, 125}
+	perMinute := WindowByTime(timeFn, time.Minute, AggregateTimeWindows(Count[int](), Map(func(r WindowResult[int]) string {
+		return fmt.Sprintf("%s %d", r.Start.Format("15:04"), r.Value)
+	}, End(input.WriteLines(&out)))))
+	if err := Stream(slices.Values(seconds), perMinute); err != nil {
+		t.Fatalf("WriteLines() returned error: %v", err)
+	}
+	if want := "12:00 3\n12:01 2\n12:02 1\n"; out.String() != want {
+		t.Fatalf("per-minute counts = %q, expected %q", out.String(), want)
+	}
+}
@@ -0,0 +1,107 @@
+package stream
+
+import "iter"
+
+// Pair is one element of a zipped pair of streams.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Triple is one element of three zipped streams.
+type Triple[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// Zip pairs the elements of a and b by position and ends with the shorter
+// stream. Both streams are read in step, so neither is buffered.
+func Zip[A, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq[Pair[A, B]] {
+	return func(yield func(Pair[A, B]) bool) {
+		nextA, stopA := iter.Pull(a)
+		defer stopA()
+		nextB, stopB := iter.Pull(b)
+		defer stopB()
+
+		for {
+			va, ok := nextA()
+			if !ok {
+				return
+			}
+			vb, ok := nextB()
+			if !ok {
+				return
+			}
+			if !yield(Pair[A, B]{First: va, Second: vb}) {
+				return
+			}
+		}
+	}
+}
+
+// Zip3 is Zip over three streams, such as parallel column files.
+func Zip3[A, B, C any](a iter.Seq[A], b iter.Seq[B], c iter.Seq[C]) iter.Seq[Triple[A, B, C]] {
+	return func(yield func(Triple[A, B, C]) bool) {
+		nextA, stopA := iter.Pull(a)
+		defer stopA()
+		nextB, stopB := iter.Pull(b)
+		defer stopB()
+		nextC, stopC := iter.Pull(c)
+		defer stopC()
+
+		for {
+			va, ok := nextA()
+			if !ok {
+				return
+			}
+			vb, ok := nextB()
+			if !ok {
+				return
+			}
+			vc, ok := nextC()
+			if !ok {
+				return
+			}
+			if !yield(Triple[A, B, C]{First: va, Second: vb, Third: vc}) {
+				return
+			}
+		}
+	}
+}
+
+// ZipLongest is Zip that runs until both streams end, filling the side that
+// ended first with fillA or fillB.
+func ZipLongest[A, B any](a iter.Seq[A], b iter.Seq[B], fillA A, fillB B) iter.Seq[Pair[A, B]] {
+	return func(yield func(Pair[A, B]) bool) {
+		nextA, stopA := iter.Pull(a)
+		defer stopA()
+		nextB, stopB := iter.Pull(b)
+		defer stopB()
+
+		doneA, doneB := false, false
+		for {
+			va, vb := fillA, fillB
+			if !doneA {
+				v, ok := nextA()
+				if ok {
+					va = v
+				}
+				doneA = !ok
+			}
+			if !doneB {
+				v, ok := nextB()
+				if ok {
+					vb = v
+				}
+				doneB = !ok
+			}
+			if doneA && doneB {
+				return
+			}
+			if !yield(Pair[A, B]{First: va, Second: vb}) {
+				return
+			}
+		}
+	}
+}
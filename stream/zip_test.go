@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestZipEndsWithShorterStream(t *testing.T) {
+	got := slices.Collect(Zip(slices.Values([]string{"a", "b", "c"}), Range(1, 100, 1)))
+	expected := []Pair[string, int]{{"a", 1}, {"b", 2}, {"c", 3}}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("Zip()=%v, expected %v", got, expected)
+	}
+}
+
+func TestZip3AlignsColumns(t *testing.T) {
+	ids := slices.Values([]int{1, 2, 3})
+	names := slices.Values([]string{"ann", "bob", "cy"})
+	scores := slices.Values([]float64{9.5, 7})
+
+	got := slices.Collect(Zip3(ids, names, scores))
+	expected := []Triple[int, string, float64]{{1, "ann", 9.5}, {2, "bob", 7}}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("Zip3()=%v, expected %v", got, expected)
+	}
+
+	first := Stream(Zip3(ids, names, Repeat(0.0, -1)), Take(1, End(Collect[Triple[int, string, float64]]())))
+	if len(first) != 1 || first[0].Second != "ann" {
+		t.Fatalf("Zip3() with Take(1)=%v, expected one triple for ann", first)
+	}
+}
+
+func TestZipLongestFillsShorterSide(t *testing.T) {
+	got := slices.Collect(ZipLongest(slices.Values([]string{"a"}), slices.Values([]int{1, 2, 3}), "-", 0))
+	expected := []Pair[string, int]{{"a", 1}, {"-", 2}, {"-", 3}}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("ZipLongest()=%v, expected %v", got, expected)
+	}
+
+	got = slices.Collect(ZipLongest(slices.Values([]string{"a", "b"}), slices.Values([]int{}), "-", -1))
+	expected = []Pair[string, int]{{"a", -1}, {"b", -1}}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("ZipLongest()=%v, expected %v", got, expected)
+	}
+}